@@ -0,0 +1,256 @@
+// Package crn provides a parser for interacting with IBM Cloud Resource Names.
+package crn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	crnDelimiter = ":"
+	crnSections  = 10
+	crnPrefix    = "crn:"
+
+	// zero-indexed
+	sectionVersion         = 1
+	sectionCName           = 2
+	sectionCType           = 3
+	sectionServiceName     = 4
+	sectionLocation        = 5
+	sectionScope           = 6
+	sectionServiceInstance = 7
+	sectionResourceType    = 8
+	sectionResource        = 9
+
+	// errors
+	invalidPrefix   = "crn: invalid prefix"
+	invalidSections = "crn: not enough sections"
+	invalidRootKey  = "crn: not a Key Protect or Hyper Protect Crypto Services root key"
+	invalidInstance = "crn: invalid service instance ID"
+)
+
+// Version1 is the only version of the CRN format.
+const Version1 = "v1"
+
+// ScopeType identifies the kind of owner in the scope of a CRN.
+type ScopeType string
+
+// Enumeration of CRN scope types.
+const (
+	ScopeTypeAccount      ScopeType = "a"
+	ScopeTypeOrganization ScopeType = "o"
+	ScopeTypeSpace        ScopeType = "s"
+	ScopeTypeProject      ScopeType = "p"
+)
+
+// Service names of the key management services whose keys can be used as
+// SSE-KP root keys.
+const (
+	ServiceKeyProtect = "kms"
+	ServiceHPCS       = "hs-crypto"
+)
+
+// CRN captures the individual fields of an IBM Cloud Resource Name.
+// See https://cloud.ibm.com/docs/account?topic=account-crn for more information.
+type CRN struct {
+	// The version of the CRN format, currently always "v1".
+	Version string
+
+	// The cloud instance the resource is in, such as "bluemix" for the public
+	// cloud.
+	CName string
+
+	// The type of the cloud instance, such as "public" or "dedicated".
+	CType string
+
+	// The name of the service offering, such as "cloud-object-storage" or
+	// "kms".
+	ServiceName string
+
+	// The region, zone or data center of the resource, or "global" for
+	// resources that are not bound to a location.
+	Location string
+
+	// The owner of the resource, such as "a/<account ID>". Empty for resources
+	// that are not scoped to an account.
+	Scope string
+
+	// The identifier of the service instance the resource belongs to, usually
+	// a GUID.
+	ServiceInstance string
+
+	// The type of the resource within the service instance, such as "key" or
+	// "bucket". Empty if the CRN identifies the service instance itself.
+	ResourceType string
+
+	// The identifier of the resource within the service instance. Empty if the
+	// CRN identifies the service instance itself.
+	Resource string
+}
+
+// Parse parses a CRN into its constituent parts. Parse only checks the
+// prefix and the number of sections, use Validate to check the fields.
+//
+// Some example CRNs:
+// crn:v1:bluemix:public:cloud-object-storage:global:a/59bcbfa6ea2f006b4ed7094c1a08dcdd:1a0ec336-f391-4091-a6fb-5e084a4c56f4::
+// crn:v1:bluemix:public:kms:us-south:a/59bcbfa6ea2f006b4ed7094c1a08dcdd:a2a5b5a8-4b12-4d9a-9fd4-6f3b1a4e5a11:key:02fd6835-6001-4482-a892-13bd2085f75d
+func Parse(crn string) (CRN, error) {
+	if !strings.HasPrefix(crn, crnPrefix) {
+		return CRN{}, errors.New(invalidPrefix)
+	}
+	sections := strings.SplitN(crn, crnDelimiter, crnSections)
+	if len(sections) != crnSections {
+		return CRN{}, errors.New(invalidSections)
+	}
+	return CRN{
+		Version:         sections[sectionVersion],
+		CName:           sections[sectionCName],
+		CType:           sections[sectionCType],
+		ServiceName:     sections[sectionServiceName],
+		Location:        sections[sectionLocation],
+		Scope:           sections[sectionScope],
+		ServiceInstance: sections[sectionServiceInstance],
+		ResourceType:    sections[sectionResourceType],
+		Resource:        sections[sectionResource],
+	}, nil
+}
+
+// IsCRN returns whether the given string is a crn
+// by looking for whether the string starts with crn:
+func IsCRN(crn string) bool {
+	return strings.HasPrefix(crn, crnPrefix) && strings.Count(crn, crnDelimiter) >= crnSections-1
+}
+
+// String returns the canonical representation of the CRN
+func (crn CRN) String() string {
+	return crnPrefix +
+		crn.Version + crnDelimiter +
+		crn.CName + crnDelimiter +
+		crn.CType + crnDelimiter +
+		crn.ServiceName + crnDelimiter +
+		crn.Location + crnDelimiter +
+		crn.Scope + crnDelimiter +
+		crn.ServiceInstance + crnDelimiter +
+		crn.ResourceType + crnDelimiter +
+		crn.Resource
+}
+
+// ScopeType returns the type of the owner in the scope of the CRN, such as
+// ScopeTypeAccount, or an empty string if the CRN has no scope.
+func (crn CRN) ScopeType() ScopeType {
+	if i := strings.Index(crn.Scope, "/"); i > 0 {
+		return ScopeType(crn.Scope[:i])
+	}
+	return ""
+}
+
+// ScopeID returns the identifier of the owner in the scope of the CRN, such
+// as the account ID, or an empty string if the CRN has no scope.
+func (crn CRN) ScopeID() string {
+	if i := strings.Index(crn.Scope, "/"); i > 0 {
+		return crn.Scope[i+1:]
+	}
+	return ""
+}
+
+// AccountID returns the ID of the account owning the resource, or an empty
+// string if the CRN is not scoped to an account.
+func (crn CRN) AccountID() string {
+	if crn.ScopeType() != ScopeTypeAccount {
+		return ""
+	}
+	return crn.ScopeID()
+}
+
+// Validate returns an error if a field of the CRN is missing or malformed.
+func (crn CRN) Validate() error {
+	if crn.Version != Version1 {
+		return fmt.Errorf("crn: unsupported version %q", crn.Version)
+	}
+	if len(crn.CName) == 0 {
+		return errors.New("crn: missing cname")
+	}
+	if len(crn.CType) == 0 {
+		return errors.New("crn: missing ctype")
+	}
+	if len(crn.ServiceName) == 0 {
+		return errors.New("crn: missing service name")
+	}
+	if len(crn.Location) == 0 {
+		return errors.New("crn: missing location")
+	}
+	if len(crn.Scope) != 0 {
+		switch crn.ScopeType() {
+		case ScopeTypeAccount, ScopeTypeOrganization, ScopeTypeSpace, ScopeTypeProject:
+		default:
+			return fmt.Errorf("crn: invalid scope %q", crn.Scope)
+		}
+		if len(crn.ScopeID()) == 0 {
+			return fmt.Errorf("crn: invalid scope %q", crn.Scope)
+		}
+	}
+	if len(crn.ResourceType) != 0 && len(crn.Resource) == 0 {
+		return errors.New("crn: missing resource")
+	}
+	for _, field := range []string{crn.Version, crn.CName, crn.CType, crn.ServiceName, crn.Location, crn.Scope, crn.ServiceInstance, crn.ResourceType} {
+		if strings.ContainsAny(field, " \t\r\n") {
+			return fmt.Errorf("crn: field %q contains whitespace", field)
+		}
+	}
+	return nil
+}
+
+// ServiceInstanceID normalizes a service instance identifier given either as
+// the CRN of the instance, or of one of its resources, or as a bare GUID, and
+// returns the GUID of the instance.
+//
+// For example, both of the following return
+// "1a0ec336-f391-4091-a6fb-5e084a4c56f4":
+//
+//	crn.ServiceInstanceID("crn:v1:bluemix:public:cloud-object-storage:global:a/59bcbfa6ea2f006b4ed7094c1a08dcdd:1a0ec336-f391-4091-a6fb-5e084a4c56f4::")
+//	crn.ServiceInstanceID("1a0ec336-f391-4091-a6fb-5e084a4c56f4")
+func ServiceInstanceID(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if strings.HasPrefix(id, crnPrefix) {
+		v, err := Parse(id)
+		if err != nil {
+			return "", err
+		}
+		if err := v.Validate(); err != nil {
+			return "", err
+		}
+		id = v.ServiceInstance
+	}
+	if len(id) == 0 || strings.ContainsAny(id, ": \t\r\n") {
+		return "", errors.New(invalidInstance)
+	}
+	return id, nil
+}
+
+// IsRootKey returns whether the CRN identifies a key of a Key Protect or
+// Hyper Protect Crypto Services instance, which can be used as the root key
+// of an SSE-KP bucket.
+func (crn CRN) IsRootKey() bool {
+	return (crn.ServiceName == ServiceKeyProtect || crn.ServiceName == ServiceHPCS) &&
+		len(crn.ServiceInstance) != 0 &&
+		crn.ResourceType == "key" &&
+		len(crn.Resource) != 0
+}
+
+// ParseRootKey parses the CRN of an SSE-KP root key, returning an error if
+// it is not a valid CRN or does not identify a Key Protect or Hyper Protect
+// Crypto Services key.
+func ParseRootKey(crn string) (CRN, error) {
+	v, err := Parse(crn)
+	if err != nil {
+		return CRN{}, err
+	}
+	if err := v.Validate(); err != nil {
+		return CRN{}, err
+	}
+	if !v.IsRootKey() {
+		return CRN{}, errors.New(invalidRootKey)
+	}
+	return v, nil
+}
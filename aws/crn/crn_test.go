@@ -0,0 +1,235 @@
+package crn
+
+import (
+	"errors"
+	"testing"
+)
+
+const testRootKey = "crn:v1:bluemix:public:kms:us-south:a/59bcbfa6ea2f006b4ed7094c1a08dcdd:a2a5b5a8-4b12-4d9a-9fd4-6f3b1a4e5a11:key:02fd6835-6001-4482-a892-13bd2085f75d"
+
+func TestParseCRN(t *testing.T) {
+	cases := []struct {
+		input string
+		crn   CRN
+		err   error
+	}{
+		{
+			input: "invalid",
+			err:   errors.New(invalidPrefix),
+		},
+		{
+			input: "crn:v1:bluemix:public:kms",
+			err:   errors.New(invalidSections),
+		},
+		{
+			input: testRootKey,
+			crn: CRN{
+				Version:         "v1",
+				CName:           "bluemix",
+				CType:           "public",
+				ServiceName:     "kms",
+				Location:        "us-south",
+				Scope:           "a/59bcbfa6ea2f006b4ed7094c1a08dcdd",
+				ServiceInstance: "a2a5b5a8-4b12-4d9a-9fd4-6f3b1a4e5a11",
+				ResourceType:    "key",
+				Resource:        "02fd6835-6001-4482-a892-13bd2085f75d",
+			},
+		},
+		{
+			input: "crn:v1:bluemix:public:cloud-object-storage:global:a/59bcbfa6ea2f006b4ed7094c1a08dcdd:1a0ec336-f391-4091-a6fb-5e084a4c56f4::",
+			crn: CRN{
+				Version:         "v1",
+				CName:           "bluemix",
+				CType:           "public",
+				ServiceName:     "cloud-object-storage",
+				Location:        "global",
+				Scope:           "a/59bcbfa6ea2f006b4ed7094c1a08dcdd",
+				ServiceInstance: "1a0ec336-f391-4091-a6fb-5e084a4c56f4",
+			},
+		},
+		{
+			input: "crn:v1:bluemix:public:cloud-object-storage:global:a/acct:instance:object:bucket/key:with:colons",
+			crn: CRN{
+				Version:         "v1",
+				CName:           "bluemix",
+				CType:           "public",
+				ServiceName:     "cloud-object-storage",
+				Location:        "global",
+				Scope:           "a/acct",
+				ServiceInstance: "instance",
+				ResourceType:    "object",
+				Resource:        "bucket/key:with:colons",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			spec, err := Parse(tc.input)
+			if tc.crn != spec {
+				t.Errorf("Expected %q to parse as %v, but got %v", tc.input, tc.crn, spec)
+			}
+			if err == nil && tc.err != nil {
+				t.Errorf("Expected err to be %v, but got nil", tc.err)
+			} else if err != nil && tc.err == nil {
+				t.Errorf("Expected err to be nil, but got %v", err)
+			} else if err != nil && tc.err != nil && err.Error() != tc.err.Error() {
+				t.Errorf("Expected err to be %v, but got %v", tc.err, err)
+			}
+			if err == nil && spec.String() != tc.input {
+				t.Errorf("Expected %q to round trip, but got %q", tc.input, spec.String())
+			}
+		})
+	}
+}
+
+func TestIsCRN(t *testing.T) {
+	cases := map[string]struct {
+		In     string
+		Expect bool
+	}{
+		"valid crn":    {In: testRootKey, Expect: true},
+		"instance crn": {In: "crn:v1:bluemix:public:cloud-object-storage:global:a/acct:instance::", Expect: true},
+		"invalid crn":  {In: "crn:v1:bluemix:public:kms"},
+		"guid":         {In: "a2a5b5a8-4b12-4d9a-9fd4-6f3b1a4e5a11"},
+		"arn":          {In: "arn:aws:s3:::bucket"},
+		"empty":        {In: ""},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if e, a := c.Expect, IsCRN(c.In); e != a {
+				t.Errorf("expect %v, got %v", e, a)
+			}
+		})
+	}
+}
+
+func TestParseRootKey(t *testing.T) {
+	cases := map[string]struct {
+		In        string
+		ExpectErr string
+	}{
+		"key protect": {In: testRootKey},
+		"hpcs":        {In: "crn:v1:bluemix:public:hs-crypto:us-east:a/acct:instance:key:key-id"},
+		"not a crn":   {In: "02fd6835-6001-4482-a892-13bd2085f75d", ExpectErr: invalidPrefix},
+		"cos instance": {
+			In:        "crn:v1:bluemix:public:cloud-object-storage:global:a/acct:instance::",
+			ExpectErr: invalidRootKey,
+		},
+		"kms instance": {
+			In:        "crn:v1:bluemix:public:kms:us-south:a/acct:instance::",
+			ExpectErr: invalidRootKey,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			v, err := ParseRootKey(c.In)
+			if len(c.ExpectErr) != 0 {
+				if err == nil || err.Error() != c.ExpectErr {
+					t.Fatalf("expect error %q, got %v", c.ExpectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !v.IsRootKey() {
+				t.Errorf("expect root key")
+			}
+		})
+	}
+}
+
+func TestCRN_Validate(t *testing.T) {
+	cases := map[string]struct {
+		In        string
+		ExpectErr bool
+	}{
+		"root key":         {In: testRootKey},
+		"instance":         {In: "crn:v1:bluemix:public:cloud-object-storage:global:a/acct:instance::"},
+		"no scope":         {In: "crn:v1:bluemix:public:iam-identity:global::::"},
+		"unknown version":  {In: "crn:v2:bluemix:public:kms:us-south:a/acct:instance::", ExpectErr: true},
+		"missing cname":    {In: "crn:v1::public:kms:us-south:a/acct:instance::", ExpectErr: true},
+		"missing service":  {In: "crn:v1:bluemix:public::us-south:a/acct:instance::", ExpectErr: true},
+		"missing location": {In: "crn:v1:bluemix:public:kms::a/acct:instance::", ExpectErr: true},
+		"invalid scope":    {In: "crn:v1:bluemix:public:kms:us-south:acct:instance::", ExpectErr: true},
+		"empty scope id":   {In: "crn:v1:bluemix:public:kms:us-south:a/:instance::", ExpectErr: true},
+		"missing resource": {In: "crn:v1:bluemix:public:kms:us-south:a/acct:instance:key:", ExpectErr: true},
+		"whitespace":       {In: "crn:v1:bluemix:public:kms:us south:a/acct:instance::", ExpectErr: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			v, err := Parse(c.In)
+			if err != nil {
+				t.Fatalf("expect no parse error, got %v", err)
+			}
+			err = v.Validate()
+			if c.ExpectErr && err == nil {
+				t.Errorf("expect error")
+			} else if !c.ExpectErr && err != nil {
+				t.Errorf("expect no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCRN_Scope(t *testing.T) {
+	v, err := Parse(testRootKey)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := ScopeTypeAccount, v.ScopeType(); e != a {
+		t.Errorf("expect scope type %v, got %v", e, a)
+	}
+	if e, a := "59bcbfa6ea2f006b4ed7094c1a08dcdd", v.AccountID(); e != a {
+		t.Errorf("expect account %v, got %v", e, a)
+	}
+
+	v.Scope = "o/org"
+	if e, a := "org", v.ScopeID(); e != a {
+		t.Errorf("expect scope ID %v, got %v", e, a)
+	}
+	if a := v.AccountID(); len(a) != 0 {
+		t.Errorf("expect no account, got %v", a)
+	}
+}
+
+func TestServiceInstanceID(t *testing.T) {
+	const guid = "1a0ec336-f391-4091-a6fb-5e084a4c56f4"
+
+	cases := map[string]struct {
+		In        string
+		Expect    string
+		ExpectErr bool
+	}{
+		"guid":         {In: guid, Expect: guid},
+		"padded guid":  {In: " " + guid + "\n", Expect: guid},
+		"instance crn": {In: "crn:v1:bluemix:public:cloud-object-storage:global:a/acct:" + guid + "::", Expect: guid},
+		"bucket crn":   {In: "crn:v1:bluemix:public:cloud-object-storage:global:a/acct:" + guid + ":bucket:b", Expect: guid},
+		"empty":        {ExpectErr: true},
+		"short crn":    {In: "crn:v1:bluemix:public", ExpectErr: true},
+		"invalid crn":  {In: "crn:v1:bluemix:public:cloud-object-storage:global:acct:" + guid + "::", ExpectErr: true},
+		"no instance":  {In: "crn:v1:bluemix:public:cloud-object-storage:global:a/acct:::", ExpectErr: true},
+		"not a crn":    {In: "arn:aws:s3:::bucket", ExpectErr: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ServiceInstanceID(c.In)
+			if c.ExpectErr {
+				if err == nil {
+					t.Fatalf("expect error, got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if e, a := c.Expect, actual; e != a {
+				t.Errorf("expect %v, got %v", e, a)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/crn"
 	"github.com/aws/smithy-go/logging"
 )
 
@@ -45,14 +46,21 @@ func (s *IBMCOSSigner) SignHTTP(
 ) error {
 	token := credentials.Token.AccessToken
 	tokenType := credentials.Token.TokenType
-	serviceInstanceID := r.Header.Get("ibm-service-instance-id")
 
-	if serviceInstanceID == "" && credentials.ServiceInstanceID != "" {
+	if r.Header.Get("ibm-service-instance-id") == "" && credentials.ServiceInstanceID != "" {
 		// Log the Service Instance ID
 		//if s.logger != nil {
 		//	s.logger.Logf(logging.Debug, "Setting Service Instance ID: %s", credentials.ServiceInstanceID)
 		//}
-		r.Header.Set("ibm-service-instance-id", credentials.ServiceInstanceID)
+
+		// The service instance of the request is normalised when it is
+		// serialized, the one of the credentials may still be given as a
+		// CRN or as a bare GUID, send the GUID either way
+		id, err := crn.ServiceInstanceID(credentials.ServiceInstanceID)
+		if err != nil {
+			return fmt.Errorf("invalid service instance ID %q: %w", credentials.ServiceInstanceID, err)
+		}
+		r.Header.Set("ibm-service-instance-id", id)
 	}
 
 	if token == "" {
@@ -0,0 +1,332 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"strconv"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+// dataKeySize is the size of the AES-256 data keys
+const dataKeySize = 32
+
+// DefaultInstructionFileSuffix is the default suffix of the key of the
+// instruction file of an object
+const DefaultInstructionFileSuffix = ".instruction"
+
+// EnvelopeLocation is where the envelope of an object is stored
+type EnvelopeLocation string
+
+// Enum values for EnvelopeLocation
+const (
+	// The envelope is stored in the metadata of the object
+	EnvelopeMetadata EnvelopeLocation = "metadata"
+
+	// The envelope is stored in an instruction file, an object whose key is
+	// the key of the object followed by Options.InstructionFileSuffix
+	EnvelopeInstructionFile = "instruction-file"
+)
+
+// S3APIClient is an S3 client the encryption client reads and writes
+// objects with
+type S3APIClient interface {
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(context.Context, *s3.HeadObjectInput, ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+}
+
+// Options configures the encryption client
+type Options struct {
+	// The KeyWrapper the data keys of the objects are wrapped and unwrapped
+	// with. (Required)
+	KeyWrapper KeyWrapper
+
+	// The encryption context recorded in the material description of the
+	// objects encrypted. Key wrappers may bind the data keys to it, as the
+	// KeyProtectKeyWrapper does.
+	EncryptionContext map[string]string
+
+	// Where the envelopes of the objects encrypted are stored, defaults to
+	// EnvelopeMetadata. Envelopes are read from either location.
+	EnvelopeLocation EnvelopeLocation
+
+	// The suffix of the key of instruction files, defaults to
+	// DefaultInstructionFileSuffix
+	InstructionFileSuffix string
+
+	// The options of the requests of the S3 client
+	ClientOptions []func(*s3.Options)
+
+	// The options of the transfers of a TransferClient
+	TransferOptions []func(*transfermanager.Options)
+}
+
+// Copy returns a copy of the options
+func (o Options) Copy() Options {
+	to := o
+	to.EncryptionContext = maps.Clone(o.EncryptionContext)
+	to.ClientOptions = append([]func(*s3.Options){}, o.ClientOptions...)
+	to.TransferOptions = append([]func(*transfermanager.Options){}, o.TransferOptions...)
+	return to
+}
+
+func resolveOptions(o *Options) {
+	if o.EnvelopeLocation == "" {
+		o.EnvelopeLocation = EnvelopeMetadata
+	}
+	if o.InstructionFileSuffix == "" {
+		o.InstructionFileSuffix = DefaultInstructionFileSuffix
+	}
+}
+
+// Client encrypts objects before they are written and decrypts them once
+// read, with AES-GCM envelope encryption. It is safe to call Client methods
+// concurrently across goroutines.
+type Client struct {
+	client  S3APIClient
+	options Options
+}
+
+// New returns an encryption client of the objects read and written with
+// client. Provide more functional options to further configure the Client
+func New(client S3APIClient, opts Options, optFns ...func(*Options)) *Client {
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	resolveOptions(&opts)
+
+	return &Client{
+		client:  client,
+		options: opts,
+	}
+}
+
+// operationOptions returns the options of an operation
+func (c *Client) operationOptions(optFns []func(*Options)) (Options, error) {
+	o := c.options.Copy()
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	resolveOptions(&o)
+
+	if o.KeyWrapper == nil {
+		return o, fmt.Errorf("key wrapper is required")
+	}
+	switch o.EnvelopeLocation {
+	case EnvelopeMetadata, EnvelopeInstructionFile:
+	default:
+		return o, fmt.Errorf("unknown envelope location %q", o.EnvelopeLocation)
+	}
+	return o, nil
+}
+
+// newEnvelope generates a data key and returns it along with the envelope of
+// an object of size plaintext bytes, -1 if unknown
+func newEnvelope(ctx context.Context, o Options, size int64) ([]byte, *Envelope, error) {
+	key := make([]byte, dataKeySize)
+	iv := make([]byte, gcmIVSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err := o.KeyWrapper.WrapKey(ctx, key, o.EncryptionContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, &Envelope{
+		Version:          EnvelopeVersion,
+		WrappedKey:       *wrapped,
+		IV:               iv,
+		ContentAlgorithm: ContentAlgorithm,
+		TagLength:        gcmTagSize * 8,
+		ContentLength:    size,
+	}, nil
+}
+
+// envelopeMetadata returns metadata with the envelope added if it is stored
+// in the metadata
+func envelopeMetadata(o Options, e *Envelope, metadata map[string]string) (map[string]string, error) {
+	if o.EnvelopeLocation != EnvelopeMetadata {
+		return metadata, nil
+	}
+	envelope, err := e.encode()
+	if err != nil {
+		return nil, err
+	}
+	m := maps.Clone(metadata)
+	if m == nil {
+		m = map[string]string{}
+	}
+	maps.Copy(m, envelope)
+	return m, nil
+}
+
+// putInstructionFile writes the envelope of an object to its instruction
+// file, if it is stored there
+func (c *Client) putInstructionFile(ctx context.Context, o Options, e *Envelope, bucket, key, expectedBucketOwner string) error {
+	if o.EnvelopeLocation != EnvelopeInstructionFile {
+		return nil
+	}
+	b, err := encodeInstructionFile(e)
+	if err != nil {
+		return err
+	}
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key + o.InstructionFileSuffix),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+	}
+	if expectedBucketOwner != "" {
+		input.ExpectedBucketOwner = aws.String(expectedBucketOwner)
+	}
+	if _, err := c.client.PutObject(ctx, input, o.ClientOptions...); err != nil {
+		return fmt.Errorf("unable to write instruction file: %w", err)
+	}
+	return nil
+}
+
+// dataKey returns the envelope and data key of an object, whose envelope is
+// either in its metadata or its instruction file.
+func (c *Client) dataKey(ctx context.Context, o Options, bucket, key string, metadata map[string]string) ([]byte, *Envelope, error) {
+	var e *Envelope
+	var err error
+	if hasEnvelope(metadata) {
+		e, err = decodeEnvelope(metadata)
+	} else {
+		e, err = c.instructionFile(ctx, o, bucket, key)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataKey, err := o.KeyWrapper.UnwrapKey(ctx, &e.WrappedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(dataKey) != dataKeySize {
+		return nil, nil, fmt.Errorf("invalid data key size %d", len(dataKey))
+	}
+	return dataKey, e, nil
+}
+
+func (c *Client) instructionFile(ctx context.Context, o Options, bucket, key string) (*Envelope, error) {
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key + o.InstructionFileSuffix),
+	}, o.ClientOptions...)
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrNoEnvelope
+		}
+		return nil, fmt.Errorf("unable to read instruction file: %w", err)
+	}
+	defer out.Body.Close()
+
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read instruction file: %w", err)
+	}
+	return decodeInstructionFile(b)
+}
+
+var rangeRegex = regexp.MustCompile(`^bytes=(\d*)-(\d*)$`)
+
+// plaintextRange is a range of the plaintext of an object, whose end is -1
+// if it is open, and whose start is -1 if it is a suffix range of the last
+// suffix bytes
+type plaintextRange struct {
+	start, end int64
+	suffix     int64
+}
+
+func parseRange(v string) (plaintextRange, error) {
+	m := rangeRegex.FindStringSubmatch(v)
+	if m == nil || (m[1] == "" && m[2] == "") {
+		return plaintextRange{}, fmt.Errorf("unsupported range %q", v)
+	}
+	if m[1] == "" {
+		n, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil || n == 0 {
+			return plaintextRange{}, fmt.Errorf("unsupported range %q", v)
+		}
+		return plaintextRange{start: -1, end: -1, suffix: n}, nil
+	}
+
+	r := plaintextRange{end: -1}
+	var err error
+	if r.start, err = strconv.ParseInt(m[1], 10, 64); err != nil {
+		return r, fmt.Errorf("unsupported range %q", v)
+	}
+	if m[2] != "" {
+		if r.end, err = strconv.ParseInt(m[2], 10, 64); err != nil || r.end < r.start {
+			return r, fmt.Errorf("unsupported range %q", v)
+		}
+	}
+	return r, nil
+}
+
+// resolveSuffix resolves a suffix range of the plaintext of an object
+// whose ciphertext is size bytes
+func (r *plaintextRange) resolveSuffix(size int64) {
+	if r.start >= 0 {
+		return
+	}
+	plaintext := size - gcmTagSize
+	r.start, r.end = max(plaintext-r.suffix, 0), plaintext-1
+}
+
+// ciphertextRange returns the range of the ciphertext of the object
+// requested to decrypt the range, which starts at a block boundary
+func (r plaintextRange) ciphertextRange() string {
+	start := r.start - r.start%gcmBlockSize
+	if r.end < 0 {
+		return fmt.Sprintf("bytes=%d-", start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", start, r.end)
+}
+
+// resolve returns the range of the plaintext of an object, whose ciphertext
+// is size bytes, which is decrypted
+func (r plaintextRange) resolve(size int64) (start, end int64, err error) {
+	plaintext := size - gcmTagSize
+	if r.start >= plaintext {
+		return 0, 0, fmt.Errorf("range start %d beyond the object size %d", r.start, plaintext)
+	}
+	end = plaintext - 1
+	if r.end >= 0 {
+		end = min(r.end, end)
+	}
+	return r.start, end, nil
+}
+
+var contentRangeSizeRegex = regexp.MustCompile(`/(\d+)$`)
+
+// contentRangeSize returns the size of the object of a content range
+func contentRangeSize(v string) (int64, error) {
+	m := contentRangeSizeRegex.FindStringSubmatch(v)
+	if m == nil {
+		return 0, fmt.Errorf("invalid content range %q", v)
+	}
+	return strconv.ParseInt(m[1], 10, 64)
+}
+
+// readCloser is a reader closing another
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,406 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+type memoryObject struct {
+	body     []byte
+	metadata map[string]string
+	etag     string
+}
+
+type memoryUpload struct {
+	key      string
+	metadata map[string]string
+	parts    map[int32][]byte
+}
+
+// memoryClient is an S3 client storing objects in memory, the operations
+// not used by the encryption and transfer manager clients panic.
+type memoryClient struct {
+	transfermanager.S3APIClient
+
+	mu      sync.Mutex
+	objects map[string]*memoryObject
+	uploads map[string]*memoryUpload
+	n       int
+}
+
+func newMemoryClient() *memoryClient {
+	return &memoryClient{
+		objects: map[string]*memoryObject{},
+		uploads: map[string]*memoryUpload{},
+	}
+}
+
+func (c *memoryClient) put(key string, body []byte, metadata map[string]string) string {
+	c.n++
+	etag := fmt.Sprintf("%q", strconv.Itoa(c.n))
+	c.objects[key] = &memoryObject{body: body, metadata: maps.Clone(metadata), etag: etag}
+	return etag
+}
+
+func (c *memoryClient) object(key string) (*memoryObject, error) {
+	o, ok := c.objects[key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{Message: aws.String("no such key " + key)}
+	}
+	return o, nil
+}
+
+func (c *memoryClient) PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	var body []byte
+	if input.Body != nil {
+		var err error
+		if body, err = io.ReadAll(input.Body); err != nil {
+			return nil, err
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &s3.PutObjectOutput{ETag: aws.String(c.put(aws.ToString(input.Key), body, input.Metadata))}, nil
+}
+
+func (c *memoryClient) CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+	id := strconv.Itoa(c.n)
+	c.uploads[id] = &memoryUpload{
+		key:      aws.ToString(input.Key),
+		metadata: maps.Clone(input.Metadata),
+		parts:    map[int32][]byte{},
+	}
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(id)}, nil
+}
+
+func (c *memoryClient) UploadPart(ctx context.Context, input *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	body, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uploads[aws.ToString(input.UploadId)].parts[aws.ToInt32(input.PartNumber)] = body
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("%q", strconv.Itoa(int(aws.ToInt32(input.PartNumber)))))}, nil
+}
+
+func (c *memoryClient) CompleteMultipartUpload(ctx context.Context, input *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.uploads[aws.ToString(input.UploadId)]
+	delete(c.uploads, aws.ToString(input.UploadId))
+	var body []byte
+	for _, p := range input.MultipartUpload.Parts {
+		body = append(body, u.parts[aws.ToInt32(p.PartNumber)]...)
+	}
+	return &s3.CompleteMultipartUploadOutput{ETag: aws.String(c.put(u.key, body, u.metadata))}, nil
+}
+
+func (c *memoryClient) AbortMultipartUpload(ctx context.Context, input *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.uploads, aws.ToString(input.UploadId))
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (c *memoryClient) HeadObject(ctx context.Context, input *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	o, err := c.object(aws.ToString(input.Key))
+	if err != nil {
+		return nil, err
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(o.body))),
+		ETag:          aws.String(o.etag),
+		Metadata:      maps.Clone(o.metadata),
+	}, nil
+}
+
+func (c *memoryClient) GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	o, err := c.object(aws.ToString(input.Key))
+	if err != nil {
+		return nil, err
+	}
+	if v := aws.ToString(input.IfMatch); v != "" && v != o.etag {
+		return nil, fmt.Errorf("precondition failed")
+	}
+
+	out := &s3.GetObjectOutput{
+		ETag:     aws.String(o.etag),
+		Metadata: maps.Clone(o.metadata),
+	}
+	body := o.body
+	if input.Range != nil {
+		size := int64(len(body))
+		v := strings.TrimPrefix(aws.ToString(input.Range), "bytes=")
+		first, last, _ := strings.Cut(v, "-")
+		start, end := int64(0), size-1
+		if first == "" {
+			n, _ := strconv.ParseInt(last, 10, 64)
+			start = max(size-n, 0)
+		} else {
+			start, _ = strconv.ParseInt(first, 10, 64)
+			if last != "" {
+				end, _ = strconv.ParseInt(last, 10, 64)
+				end = min(end, size-1)
+			}
+		}
+		if start >= size {
+			return nil, fmt.Errorf("invalid range %s", aws.ToString(input.Range))
+		}
+		body = body[start : end+1]
+		out.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	}
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = aws.Int64(int64(len(body)))
+	return out, nil
+}
+
+func newTestClient(t *testing.T, optFns ...func(*Options)) (*Client, *memoryClient) {
+	t.Helper()
+	keyring, err := NewLocalKeyring("key", testData(32))
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	s3Client := newMemoryClient()
+	return New(s3Client, Options{KeyWrapper: keyring}, optFns...), s3Client
+}
+
+func getObject(t *testing.T, c *Client, key, rng string) ([]byte, *s3.GetObjectOutput, error) {
+	t.Helper()
+	input := &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String(key)}
+	if rng != "" {
+		input.Range = aws.String(rng)
+	}
+	out, err := c.GetObject(context.Background(), input)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer out.Body.Close()
+	b, err := io.ReadAll(out.Body)
+	return b, out, err
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	cases := map[string]struct {
+		location EnvelopeLocation
+		size     int
+	}{
+		"metadata":         {location: EnvelopeMetadata, size: 1000},
+		"instruction file": {location: EnvelopeInstructionFile, size: 1000},
+		"empty":            {location: EnvelopeMetadata},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, s3Client := newTestClient(t, func(o *Options) {
+				o.EnvelopeLocation = tt.location
+				o.EncryptionContext = map[string]string{"purpose": "test"}
+			})
+			plaintext := testData(tt.size)
+			_, err := c.PutObject(context.Background(), &s3.PutObjectInput{
+				Bucket:   aws.String("bucket"),
+				Key:      aws.String("key"),
+				Body:     bytes.NewReader(plaintext),
+				Metadata: map[string]string{"color": "blue"},
+			})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+
+			stored := s3Client.objects["key"]
+			if bytes.Contains(stored.body, plaintext) && tt.size > 0 {
+				t.Error("expect the object to be encrypted")
+			}
+			if e, a := tt.size+gcmTagSize, len(stored.body); e != a {
+				t.Errorf("expect ciphertext size %v, got %v", e, a)
+			}
+			if e, a := "blue", stored.metadata["color"]; e != a {
+				t.Errorf("expect metadata %v, got %v", e, a)
+			}
+			_, instructionFile := s3Client.objects["key"+DefaultInstructionFileSuffix]
+			if e, a := tt.location == EnvelopeInstructionFile, instructionFile; e != a {
+				t.Errorf("expect instruction file %v, got %v", e, a)
+			}
+			if e, a := tt.location == EnvelopeMetadata, hasEnvelope(stored.metadata); e != a {
+				t.Errorf("expect envelope in metadata %v, got %v", e, a)
+			}
+
+			b, out, err := getObject(t, c, "key", "")
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !bytes.Equal(plaintext, b) {
+				t.Error("expect the object to be decrypted")
+			}
+			if e, a := int64(tt.size), aws.ToInt64(out.ContentLength); e != a {
+				t.Errorf("expect content length %v, got %v", e, a)
+			}
+		})
+	}
+}
+
+func TestClientGetObjectRange(t *testing.T) {
+	c, _ := newTestClient(t)
+	plaintext := testData(100)
+	_, err := c.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+		Body:   bytes.NewReader(plaintext),
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	cases := map[string]struct {
+		rng          string
+		start, end   int
+		contentRange string
+	}{
+		"closed":            {rng: "bytes=10-40", start: 10, end: 40, contentRange: "bytes 10-40/100"},
+		"aligned":           {rng: "bytes=16-31", start: 16, end: 31, contentRange: "bytes 16-31/100"},
+		"open":              {rng: "bytes=90-", start: 90, end: 99, contentRange: "bytes 90-99/100"},
+		"beyond the end":    {rng: "bytes=95-200", start: 95, end: 99, contentRange: "bytes 95-99/100"},
+		"suffix":            {rng: "bytes=-5", start: 95, end: 99, contentRange: "bytes 95-99/100"},
+		"suffix beyond all": {rng: "bytes=-500", start: 0, end: 99, contentRange: "bytes 0-99/100"},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			b, out, err := getObject(t, c, "key", tt.rng)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !bytes.Equal(plaintext[tt.start:tt.end+1], b) {
+				t.Error("expect the range to be decrypted")
+			}
+			if e, a := int64(tt.end-tt.start+1), aws.ToInt64(out.ContentLength); e != a {
+				t.Errorf("expect content length %v, got %v", e, a)
+			}
+			if e, a := tt.contentRange, aws.ToString(out.ContentRange); e != a {
+				t.Errorf("expect content range %v, got %v", e, a)
+			}
+		})
+	}
+
+	if _, _, err := getObject(t, c, "key", "bytes=100-"); err == nil {
+		t.Error("expect error for a range beyond the object")
+	}
+}
+
+func TestClientGetObjectErrors(t *testing.T) {
+	c, s3Client := newTestClient(t)
+	ctx := context.Background()
+	_, err := c.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+		Body:   bytes.NewReader(testData(100)),
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	s3Client.put("plain", testData(10), nil)
+	if _, _, err := getObject(t, c, "plain", ""); !errors.Is(err, ErrNoEnvelope) {
+		t.Errorf("expect ErrNoEnvelope, got %v", err)
+	}
+
+	s3Client.objects["key"].body[3] ^= 1
+	if _, _, err := getObject(t, c, "key", ""); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expect ErrAuthentication, got %v", err)
+	}
+
+	other, err := NewLocalKeyring("other", testData(32))
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	_, err = c.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")}, func(o *Options) {
+		o.KeyWrapper = other
+	})
+	if err == nil {
+		t.Error("expect error unwrapping the data key with another keyring")
+	}
+
+	_, err = c.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key"), PartNumber: aws.Int32(1)})
+	if err == nil {
+		t.Error("expect error for a part number")
+	}
+	_, err = New(s3Client, Options{}).GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")})
+	if err == nil {
+		t.Error("expect error without key wrapper")
+	}
+}
+
+func TestEnvelopeEncoding(t *testing.T) {
+	e := &Envelope{
+		Version: EnvelopeVersion,
+		WrappedKey: WrappedKey{
+			Algorithm:           KeyringWrapAlgorithm,
+			Ciphertext:          testData(48),
+			MaterialDescription: map[string]string{"purpose": "test"},
+		},
+		IV:               testData(gcmIVSize),
+		ContentAlgorithm: ContentAlgorithm,
+		TagLength:        128,
+		ContentLength:    1000,
+	}
+
+	m, err := e.encode()
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	for k, v := range map[string]string{
+		metaContentAlgorithm:    "AES/GCM/NoPadding",
+		metaTagLength:           "128",
+		metaMaterialDescription: `{"purpose":"test"}`,
+		metaContentLength:       "1000",
+	} {
+		if m[k] != v {
+			t.Errorf("expect %s %v, got %v", k, v, m[k])
+		}
+	}
+
+	b, err := encodeInstructionFile(e)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	decoded, err := decodeInstructionFile(b)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if !bytes.Equal(e.IV, decoded.IV) || !bytes.Equal(e.WrappedKey.Ciphertext, decoded.WrappedKey.Ciphertext) ||
+		!maps.Equal(e.WrappedKey.MaterialDescription, decoded.WrappedKey.MaterialDescription) ||
+		e.ContentLength != decoded.ContentLength || e.WrappedKey.Algorithm != decoded.WrappedKey.Algorithm {
+		t.Errorf("expect envelope %+v, got %+v", e, decoded)
+	}
+
+	if _, err := decodeEnvelope(map[string]string{metaKeyV1: "key"}); err == nil || errors.Is(err, ErrNoEnvelope) {
+		t.Errorf("expect unsupported version error, got %v", err)
+	}
+	if _, err := decodeEnvelope(map[string]string{}); !errors.Is(err, ErrNoEnvelope) {
+		t.Errorf("expect ErrNoEnvelope, got %v", err)
+	}
+	m[metaContentAlgorithm] = "AES/CBC/PKCS5Padding"
+	if _, err := decodeEnvelope(m); err == nil {
+		t.Error("expect error for an unsupported content algorithm")
+	}
+}
@@ -0,0 +1,123 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// GetObject reads an object and decrypts its body as it is read. The
+// plaintext is authenticated once the body is read to its end: rather than
+// io.EOF, reading the end of the body returns ErrAuthentication if the
+// object or its envelope was modified.
+//
+// If the input has a Range, only the blocks of the ciphertext covering the
+// range are read and decrypted. A range is not authenticated, as the
+// authentication tag covers the whole object.
+func (c *Client) GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*Options)) (*s3.GetObjectOutput, error) {
+	o, err := c.operationOptions(optFns)
+	if err != nil {
+		return nil, err
+	}
+	if input.PartNumber != nil {
+		return nil, fmt.Errorf("part number is not supported for encrypted objects")
+	}
+	if input.Range != nil {
+		return c.getObjectRange(ctx, o, input)
+	}
+
+	out, err := c.client.GetObject(ctx, input, o.ClientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	key, e, err := c.dataKey(ctx, o, aws.ToString(input.Bucket), aws.ToString(input.Key), out.Metadata)
+	if err != nil {
+		out.Body.Close()
+		return nil, err
+	}
+	r, err := newDecryptReader(out.Body, key, e.IV)
+	if err != nil {
+		out.Body.Close()
+		return nil, err
+	}
+
+	out.Body = readCloser{Reader: r, Closer: out.Body}
+	if out.ContentLength != nil {
+		out.ContentLength = aws.Int64(max(*out.ContentLength-gcmTagSize, 0))
+	}
+	clearChecksums(out)
+	return out, nil
+}
+
+func (c *Client) getObjectRange(ctx context.Context, o Options, input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	rng, err := parseRange(aws.ToString(input.Range))
+	if err != nil {
+		return nil, err
+	}
+	if rng.start < 0 {
+		head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket:               input.Bucket,
+			Key:                  input.Key,
+			VersionId:            input.VersionId,
+			IfMatch:              input.IfMatch,
+			ExpectedBucketOwner:  input.ExpectedBucketOwner,
+			RequestPayer:         input.RequestPayer,
+			SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+			SSECustomerKey:       input.SSECustomerKey,
+			SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+		}, o.ClientOptions...)
+		if err != nil {
+			return nil, err
+		}
+		rng.resolveSuffix(aws.ToInt64(head.ContentLength))
+	}
+
+	in := *input
+	in.Range = aws.String(rng.ciphertextRange())
+	out, err := c.client.GetObject(ctx, &in, o.ClientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.decryptRange(ctx, o, input, out, rng)
+	if err != nil {
+		out.Body.Close()
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *Client) decryptRange(ctx context.Context, o Options, input *s3.GetObjectInput, out *s3.GetObjectOutput, rng plaintextRange) (*s3.GetObjectOutput, error) {
+	size, err := contentRangeSize(aws.ToString(out.ContentRange))
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rng.resolve(size)
+	if err != nil {
+		return nil, err
+	}
+	key, e, err := c.dataKey(ctx, o, aws.ToString(input.Bucket), aws.ToString(input.Key), out.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRangeDecryptReader(out.Body, key, e.IV, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Body = readCloser{Reader: r, Closer: out.Body}
+	out.ContentLength = aws.Int64(end - start + 1)
+	out.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, size-gcmTagSize))
+	clearChecksums(out)
+	return out, nil
+}
+
+// clearChecksums clears the checksums of the ciphertext from the output
+func clearChecksums(out *s3.GetObjectOutput) {
+	out.ChecksumCRC32 = nil
+	out.ChecksumCRC32C = nil
+	out.ChecksumCRC64NVME = nil
+	out.ChecksumSHA1 = nil
+	out.ChecksumSHA256 = nil
+}
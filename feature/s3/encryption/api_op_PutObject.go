@@ -0,0 +1,67 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// PutObject encrypts the body of an object and writes it with a single
+// PutObject request. The body is encrypted in memory, objects larger than a
+// few MiB should be written with a TransferClient instead.
+//
+// The envelope of the object is added to its metadata, or written to its
+// instruction file once the object is written. The checksums and Content-MD5
+// of the input, which would be those of the plaintext, are ignored.
+func (c *Client) PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*Options)) (*s3.PutObjectOutput, error) {
+	o, err := c.operationOptions(optFns)
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext []byte
+	if input.Body != nil {
+		if plaintext, err = io.ReadAll(input.Body); err != nil {
+			return nil, fmt.Errorf("unable to read body: %w", err)
+		}
+	}
+	key, e, err := newEnvelope(ctx, o, int64(len(plaintext)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate data key: %w", err)
+	}
+	r, err := newEncryptReader(bytes.NewReader(plaintext), key, e.IV)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.Body = bytes.NewReader(ciphertext)
+	in.ContentLength = aws.Int64(int64(len(ciphertext)))
+	in.ContentMD5 = nil
+	in.ChecksumCRC32 = nil
+	in.ChecksumCRC32C = nil
+	in.ChecksumCRC64NVME = nil
+	in.ChecksumSHA1 = nil
+	in.ChecksumSHA256 = nil
+	if in.Metadata, err = envelopeMetadata(o, e, input.Metadata); err != nil {
+		return nil, err
+	}
+
+	out, err := c.client.PutObject(ctx, &in, o.ClientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	err = c.putInstructionFile(ctx, o, e, aws.ToString(input.Bucket), aws.ToString(input.Key), aws.ToString(input.ExpectedBucketOwner))
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,40 @@
+// Package encryption provides a client encrypting Cloud Object Storage
+// objects before they are written, and decrypting them once read, with
+// envelope encryption.
+//
+// Every object is encrypted with its own 256-bit data key, with AES-GCM. The
+// data key is wrapped by a KeyWrapper, e.g. with a root key of Key Protect or
+// Hyper Protect Crypto Services, and stored wrapped in the envelope of the
+// object along with the parameters of the encryption. The envelope is stored
+// in the metadata of the object, or in an instruction file next to it, and
+// has the format of the v2 Amazon S3 Encryption Clients.
+//
+//	kp := keyprotect.NewFromConfig(cfg, func(o *keyprotect.Options) {
+//		o.Region = "us-south"
+//		o.InstanceID = "2ac04c5b-4f7d-4c3a-b2cb-1c9a6d4a5e8d"
+//	})
+//	client := encryption.New(s3.NewFromConfig(cfg), encryption.Options{
+//		KeyWrapper:        encryption.NewKeyProtectKeyWrapper(kp, rootKeyID),
+//		EncryptionContext: map[string]string{"department": "finance"},
+//	})
+//
+//	_, err := client.PutObject(ctx, &s3.PutObjectInput{
+//		Bucket: aws.String("bucket"),
+//		Key:    aws.String("report.csv"),
+//		Body:   body,
+//	})
+//
+// The Client encrypts objects in memory. Larger objects are encrypted and
+// decrypted as they are streamed by a TransferClient, which uploads and
+// downloads them with a transfer manager client:
+//
+//	tc := client.TransferManager(transfermanager.New(s3.NewFromConfig(cfg), transfermanager.Options{}))
+//	out, err := tc.GetObject(ctx, &transfermanager.GetObjectInput{
+//		Bucket: "bucket",
+//		Key:    "backup.tar",
+//	})
+//
+// The plaintext of an object is authenticated once its body is read to its
+// end, which returns ErrAuthentication rather than io.EOF if the object was
+// modified. Ranges of objects are decrypted without being authenticated.
+package encryption
@@ -0,0 +1,157 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// EnvelopeVersion is the version of the envelope format written by the
+// client. Version 2 is the format of the v2 Amazon S3 Encryption Clients, so
+// that objects can be decrypted by them given a compatible key wrapper, and
+// the other way around.
+const EnvelopeVersion = 2
+
+// ContentAlgorithm is the algorithm the content of the objects is encrypted
+// with
+const ContentAlgorithm = "AES/GCM/NoPadding"
+
+// The keys of the envelope, in the object metadata or the instruction file.
+const (
+	metaKeyV2               = "x-amz-key-v2"
+	metaKeyV1               = "x-amz-key"
+	metaIV                  = "x-amz-iv"
+	metaMaterialDescription = "x-amz-matdesc"
+	metaWrapAlgorithm       = "x-amz-wrap-alg"
+	metaContentAlgorithm    = "x-amz-cek-alg"
+	metaTagLength           = "x-amz-tag-len"
+	metaContentLength       = "x-amz-unencrypted-content-length"
+)
+
+// ErrNoEnvelope is returned when an object has no envelope in its metadata
+// nor an instruction file, e.g. because it is not encrypted.
+var ErrNoEnvelope = errors.New("object has no encryption envelope")
+
+// Envelope holds what is needed to decrypt an object besides the key
+// wrapper: the wrapped data key and the parameters of the content
+// encryption.
+type Envelope struct {
+	// The version of the envelope format
+	Version int
+
+	// The data key, wrapped by the key wrapper
+	WrappedKey WrappedKey
+
+	// The IV the content is encrypted with
+	IV []byte
+
+	// The algorithm the content is encrypted with, ContentAlgorithm
+	ContentAlgorithm string
+
+	// The size in bits of the authentication tag appended to the content
+	TagLength int
+
+	// The size of the plaintext, -1 if unknown when the object was
+	// encrypted
+	ContentLength int64
+}
+
+// encode returns the envelope as metadata, or instruction file, entries
+func (e *Envelope) encode() (map[string]string, error) {
+	matdesc, err := json.Marshal(e.WrappedKey.MaterialDescription)
+	if err != nil {
+		return nil, err
+	}
+	if e.WrappedKey.MaterialDescription == nil {
+		matdesc = []byte("{}")
+	}
+	m := map[string]string{
+		metaKeyV2:               base64.StdEncoding.EncodeToString(e.WrappedKey.Ciphertext),
+		metaIV:                  base64.StdEncoding.EncodeToString(e.IV),
+		metaMaterialDescription: string(matdesc),
+		metaWrapAlgorithm:       e.WrappedKey.Algorithm,
+		metaContentAlgorithm:    e.ContentAlgorithm,
+		metaTagLength:           strconv.Itoa(e.TagLength),
+	}
+	if e.ContentLength >= 0 {
+		m[metaContentLength] = strconv.FormatInt(e.ContentLength, 10)
+	}
+	return m, nil
+}
+
+// hasEnvelope returns whether metadata holds an envelope, of any version
+func hasEnvelope(m map[string]string) bool {
+	_, v2 := m[metaKeyV2]
+	_, v1 := m[metaKeyV1]
+	return v2 || v1
+}
+
+// decodeEnvelope returns the envelope of metadata, or instruction file,
+// entries
+func decodeEnvelope(m map[string]string) (*Envelope, error) {
+	key, ok := m[metaKeyV2]
+	if !ok {
+		if _, ok := m[metaKeyV1]; ok {
+			return nil, fmt.Errorf("unsupported envelope version 1")
+		}
+		return nil, ErrNoEnvelope
+	}
+
+	e := &Envelope{
+		Version:          EnvelopeVersion,
+		ContentAlgorithm: m[metaContentAlgorithm],
+		ContentLength:    -1,
+	}
+	if e.ContentAlgorithm != ContentAlgorithm {
+		return nil, fmt.Errorf("unsupported content algorithm %q", e.ContentAlgorithm)
+	}
+
+	var err error
+	if e.WrappedKey.Ciphertext, err = base64.StdEncoding.DecodeString(key); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", metaKeyV2, err)
+	}
+	if e.IV, err = base64.StdEncoding.DecodeString(m[metaIV]); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", metaIV, err)
+	}
+	if len(e.IV) != gcmIVSize {
+		return nil, fmt.Errorf("invalid %s size %d", metaIV, len(e.IV))
+	}
+	if e.TagLength, err = strconv.Atoi(m[metaTagLength]); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", metaTagLength, err)
+	}
+	if e.TagLength != gcmTagSize*8 {
+		return nil, fmt.Errorf("unsupported %s %d", metaTagLength, e.TagLength)
+	}
+	if v, ok := m[metaContentLength]; ok {
+		if e.ContentLength, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", metaContentLength, err)
+		}
+	}
+	if v := m[metaMaterialDescription]; v != "" {
+		if err := json.Unmarshal([]byte(v), &e.WrappedKey.MaterialDescription); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", metaMaterialDescription, err)
+		}
+	}
+	e.WrappedKey.Algorithm = m[metaWrapAlgorithm]
+	return e, nil
+}
+
+// encodeInstructionFile returns the content of the instruction file of the
+// envelope, a JSON object of its entries
+func encodeInstructionFile(e *Envelope) ([]byte, error) {
+	m, err := e.encode()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+func decodeInstructionFile(b []byte) (*Envelope, error) {
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("invalid instruction file: %w", err)
+	}
+	return decodeEnvelope(m)
+}
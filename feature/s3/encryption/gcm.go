@@ -0,0 +1,391 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	gcmBlockSize = 16
+	gcmIVSize    = 12
+	gcmTagSize   = 16
+
+	// gcmMaxPlaintextSize is the largest plaintext AES-GCM can encrypt with a
+	// 96-bit IV, 2^32-2 blocks
+	gcmMaxPlaintextSize = (1<<32 - 2) * gcmBlockSize
+)
+
+// ErrAuthentication is returned when reading the end of an object whose
+// content does not match its authentication tag, because either the object or
+// its envelope was modified.
+var ErrAuthentication = errors.New("object failed authentication")
+
+// The content is encrypted with AES-GCM, without additional authenticated
+// data, so that it can be decrypted by any AES-GCM implementation given the
+// data key and IV of the envelope. The standard library only implements
+// AES-GCM over buffers held in memory, so the content is encrypted and
+// decrypted as a stream with AES-CTR and a GHASH computed alongside, as
+// specified by NIST SP 800-38D.
+
+// gcmFieldElement is an element of GF(2^128), in the bit order of GCM: the
+// first bit of low is the coefficient of x^0.
+type gcmFieldElement struct {
+	low, high uint64
+}
+
+// ghash computes the GHASH of a stream of ciphertext, with a 4-bit table of
+// the multiples of the hash key.
+type ghash struct {
+	productTable [16]gcmFieldElement
+	y            gcmFieldElement
+
+	// the pending bytes of an incomplete block, and the number of bytes
+	// hashed
+	buf    [gcmBlockSize]byte
+	n      int
+	length uint64
+}
+
+func newGHash(h []byte) *ghash {
+	g := &ghash{}
+	x := gcmFieldElement{
+		low:  binary.BigEndian.Uint64(h[:8]),
+		high: binary.BigEndian.Uint64(h[8:]),
+	}
+	g.productTable[reverseBits(1)] = x
+	for i := 2; i < 16; i += 2 {
+		g.productTable[reverseBits(i)] = gcmDouble(g.productTable[reverseBits(i/2)])
+		g.productTable[reverseBits(i+1)] = gcmAdd(g.productTable[reverseBits(i)], x)
+	}
+	return g
+}
+
+// reverseBits reverses the order of the 4 bits of i
+func reverseBits(i int) int {
+	i = ((i << 2) & 0xc) | ((i >> 2) & 0x3)
+	i = ((i << 1) & 0xa) | ((i >> 1) & 0x5)
+	return i
+}
+
+func gcmAdd(x, y gcmFieldElement) gcmFieldElement {
+	return gcmFieldElement{low: x.low ^ y.low, high: x.high ^ y.high}
+}
+
+// gcmDouble returns x multiplied by x^1
+func gcmDouble(x gcmFieldElement) gcmFieldElement {
+	msbSet := x.high&1 == 1
+	double := gcmFieldElement{
+		high: x.high>>1 | x.low<<63,
+		low:  x.low >> 1,
+	}
+	if msbSet {
+		double.low ^= 0xe100000000000000
+	}
+	return double
+}
+
+// gcmReductionTable holds the reductions of the 4 bits shifted out of an
+// element by the multiplication
+var gcmReductionTable = [16]uint16{
+	0x0000, 0x1c20, 0x3840, 0x2460, 0x7080, 0x6ca0, 0x48c0, 0x54e0,
+	0xe100, 0xfd20, 0xd940, 0xc560, 0x9180, 0x8da0, 0xa9c0, 0xb5e0,
+}
+
+// mul sets y to y*H
+func (g *ghash) mul(y *gcmFieldElement) {
+	var z gcmFieldElement
+	for i := 0; i < 2; i++ {
+		word := y.high
+		if i == 1 {
+			word = y.low
+		}
+		for j := 0; j < 64; j += 4 {
+			msw := z.high & 0xf
+			z.high >>= 4
+			z.high |= z.low << 60
+			z.low >>= 4
+			z.low ^= uint64(gcmReductionTable[msw]) << 48
+
+			t := g.productTable[word&0xf]
+			z.low ^= t.low
+			z.high ^= t.high
+			word >>= 4
+		}
+	}
+	*y = z
+}
+
+func (g *ghash) block(b []byte) {
+	g.y.low ^= binary.BigEndian.Uint64(b)
+	g.y.high ^= binary.BigEndian.Uint64(b[8:])
+	g.mul(&g.y)
+}
+
+// Write hashes p
+func (g *ghash) Write(p []byte) {
+	g.length += uint64(len(p))
+	if g.n > 0 {
+		c := copy(g.buf[g.n:], p)
+		g.n += c
+		p = p[c:]
+		if g.n < gcmBlockSize {
+			return
+		}
+		g.block(g.buf[:])
+		g.n = 0
+	}
+	for len(p) >= gcmBlockSize {
+		g.block(p[:gcmBlockSize])
+		p = p[gcmBlockSize:]
+	}
+	g.n = copy(g.buf[:], p)
+}
+
+// Sum returns the tag of the hashed ciphertext, masked with tagMask
+func (g *ghash) Sum(tagMask []byte) []byte {
+	if g.n > 0 {
+		clear(g.buf[g.n:])
+		g.block(g.buf[:])
+		g.n = 0
+	}
+	// the lengths in bits of the additional data, which is empty, and of the
+	// ciphertext
+	g.y.high ^= g.length * 8
+	g.mul(&g.y)
+
+	tag := make([]byte, gcmTagSize)
+	binary.BigEndian.PutUint64(tag, g.y.low)
+	binary.BigEndian.PutUint64(tag[8:], g.y.high)
+	subtle.XORBytes(tag, tag, tagMask)
+	return tag
+}
+
+// gcmStream holds the keystream and GHASH of an AES-GCM encrypted content
+type gcmStream struct {
+	ctr     cipher.Stream
+	hash    *ghash
+	tagMask []byte
+}
+
+// newGCMStream returns the stream of a content encrypted with key and iv,
+// starting at block, which must be 0 to compute the tag of the content.
+func newGCMStream(key, iv []byte, block int64) (*gcmStream, error) {
+	if len(iv) != gcmIVSize {
+		return nil, fmt.Errorf("invalid IV size %d, expect %d", len(iv), gcmIVSize)
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var h, j0 [gcmBlockSize]byte
+	c.Encrypt(h[:], h[:])
+	copy(j0[:], iv)
+	binary.BigEndian.PutUint32(j0[gcmIVSize:], 1)
+	tagMask := make([]byte, gcmBlockSize)
+	c.Encrypt(tagMask, j0[:])
+
+	// the content is encrypted from the counter following J0. The content
+	// is short enough for the 32-bit counter not to wrap.
+	counter := j0
+	binary.BigEndian.PutUint32(counter[gcmIVSize:], uint32(2+block))
+	return &gcmStream{
+		ctr:     cipher.NewCTR(c, counter[:]),
+		hash:    newGHash(h[:]),
+		tagMask: tagMask,
+	}, nil
+}
+
+// encryptReader encrypts a plaintext, appending the tag to the ciphertext
+type encryptReader struct {
+	src    io.Reader
+	stream *gcmStream
+	n      int64
+
+	// the tag, once the plaintext is read
+	tag []byte
+	err error
+}
+
+func newEncryptReader(src io.Reader, key, iv []byte) (*encryptReader, error) {
+	stream, err := newGCMStream(key, iv, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptReader{src: src, stream: stream}, nil
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	if r.tag != nil {
+		if len(r.tag) == 0 {
+			return 0, io.EOF
+		}
+		n := copy(p, r.tag)
+		r.tag = r.tag[n:]
+		return n, nil
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.src.Read(p)
+	if n > 0 {
+		r.n += int64(n)
+		if r.n > gcmMaxPlaintextSize {
+			r.err = fmt.Errorf("plaintext exceeds the maximum size of %d bytes", int64(gcmMaxPlaintextSize))
+			return 0, r.err
+		}
+		r.stream.ctr.XORKeyStream(p[:n], p[:n])
+		r.stream.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		r.tag = r.stream.hash.Sum(r.stream.tagMask)
+		err = nil
+	} else if err != nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// decryptReader decrypts a ciphertext followed by its tag, returning
+// ErrAuthentication rather than io.EOF if the tag does not match. The
+// plaintext read before io.EOF is returned is not yet authenticated.
+type decryptReader struct {
+	src    io.Reader
+	stream *gcmStream
+
+	// holds back the last bytes read, which may be the tag
+	buf  []byte
+	tail int
+	err  error
+}
+
+func newDecryptReader(src io.Reader, key, iv []byte) (*decryptReader, error) {
+	stream, err := newGCMStream(key, iv, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{src: src, stream: stream}, nil
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for {
+		if cap(r.buf) < len(p)+gcmTagSize {
+			buf := make([]byte, len(p)+gcmTagSize)
+			copy(buf, r.buf[:r.tail])
+			r.buf = buf
+		}
+		r.buf = r.buf[:len(p)+gcmTagSize]
+
+		n, err := r.src.Read(r.buf[r.tail:])
+		r.tail += n
+		if err == io.EOF {
+			return r.finish(p)
+		}
+		if err != nil {
+			r.err = err
+			return 0, err
+		}
+
+		// returns the bytes which cannot be the tag
+		if avail := r.tail - gcmTagSize; avail > 0 {
+			n := copy(p, r.buf[:avail])
+			r.decrypt(p[:n])
+			r.tail = copy(r.buf, r.buf[n:r.tail])
+			return n, nil
+		}
+	}
+}
+
+// finish decrypts the remaining ciphertext and authenticates the content
+func (r *decryptReader) finish(p []byte) (int, error) {
+	if r.tail < gcmTagSize {
+		r.err = fmt.Errorf("%w: ciphertext too short", ErrAuthentication)
+		return 0, r.err
+	}
+	n := copy(p, r.buf[:r.tail-gcmTagSize])
+	r.decrypt(p[:n])
+	if n < r.tail-gcmTagSize {
+		r.tail = copy(r.buf, r.buf[n:r.tail])
+		return n, nil
+	}
+
+	tag := r.stream.hash.Sum(r.stream.tagMask)
+	if subtle.ConstantTimeCompare(tag, r.buf[n:r.tail]) != 1 {
+		r.err = ErrAuthentication
+	} else {
+		r.err = io.EOF
+	}
+	return n, r.err
+}
+
+func (r *decryptReader) decrypt(b []byte) {
+	r.stream.hash.Write(b)
+	r.stream.ctr.XORKeyStream(b, b)
+}
+
+// rangeDecryptReader decrypts the ciphertext of a range of the content,
+// starting at a block boundary, discarding skip bytes at its start and
+// returning at most n bytes. The range is not authenticated.
+type rangeDecryptReader struct {
+	src  io.Reader
+	ctr  cipher.Stream
+	skip int64
+	n    int64
+}
+
+func newRangeDecryptReader(src io.Reader, key, iv []byte, start, end int64) (*rangeDecryptReader, error) {
+	block := start / gcmBlockSize
+	stream, err := newGCMStream(key, iv, block)
+	if err != nil {
+		return nil, err
+	}
+	return &rangeDecryptReader{
+		src:  src,
+		ctr:  stream.ctr,
+		skip: start - block*gcmBlockSize,
+		n:    end - start + 1,
+	}, nil
+}
+
+func (r *rangeDecryptReader) Read(p []byte) (int, error) {
+	for r.skip > 0 {
+		var buf [gcmBlockSize]byte
+		n, err := r.src.Read(buf[:r.skip])
+		r.ctr.XORKeyStream(buf[:n], buf[:n])
+		r.skip -= int64(n)
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	if r.n <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+	n, err := r.src.Read(p)
+	r.ctr.XORKeyStream(p[:n], p[:n])
+	r.n -= int64(n)
+	if err == io.EOF && r.n > 0 {
+		err = io.ErrUnexpectedEOF
+	} else if err == nil && r.n == 0 {
+		err = io.EOF
+	}
+	return n, err
+}
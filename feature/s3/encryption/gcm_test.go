@@ -0,0 +1,126 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func testData(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i*7 + i/251)
+	}
+	return b
+}
+
+// sealGCM encrypts plaintext with the AES-GCM implementation of the standard
+// library
+func sealGCM(t *testing.T, key, iv, plaintext []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	return aead.Seal(nil, iv, plaintext, nil)
+}
+
+func TestEncryptReader(t *testing.T) {
+	key, iv := testData(32), testData(12)
+	for _, size := range []int{0, 1, 15, 16, 17, 1000, 100 * 1024} {
+		for name, wrap := range map[string]func(io.Reader) io.Reader{
+			"full":     func(r io.Reader) io.Reader { return r },
+			"one byte": iotest.OneByteReader,
+			"half":     iotest.HalfReader,
+		} {
+			t.Run(fmt.Sprintf("%d %s", size, name), func(t *testing.T) {
+				plaintext := testData(size)
+				r, err := newEncryptReader(wrap(bytes.NewReader(plaintext)), key, iv)
+				if err != nil {
+					t.Fatalf("expect no error, got %v", err)
+				}
+				ciphertext, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("expect no error, got %v", err)
+				}
+				if expect := sealGCM(t, key, iv, plaintext); !bytes.Equal(expect, ciphertext) {
+					t.Error("expect the ciphertext of AES-GCM")
+				}
+			})
+		}
+	}
+}
+
+func TestDecryptReader(t *testing.T) {
+	key, iv := testData(32), testData(12)
+	for _, size := range []int{0, 1, 16, 33, 100 * 1024} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			plaintext := testData(size)
+			ciphertext := sealGCM(t, key, iv, plaintext)
+
+			r, err := newDecryptReader(iotest.HalfReader(bytes.NewReader(ciphertext)), key, iv)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !bytes.Equal(plaintext, b) {
+				t.Error("expect the plaintext to be decrypted")
+			}
+
+			tampered := bytes.Clone(ciphertext)
+			tampered[len(tampered)/2] ^= 1
+			r, _ = newDecryptReader(bytes.NewReader(tampered), key, iv)
+			if _, err := io.ReadAll(r); !errors.Is(err, ErrAuthentication) {
+				t.Errorf("expect ErrAuthentication, got %v", err)
+			}
+		})
+	}
+
+	r, _ := newDecryptReader(bytes.NewReader(make([]byte, gcmTagSize-1)), key, iv)
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expect ErrAuthentication for a truncated ciphertext, got %v", err)
+	}
+}
+
+func TestRangeDecryptReader(t *testing.T) {
+	key, iv := testData(32), testData(12)
+	plaintext := testData(100)
+	ciphertext := sealGCM(t, key, iv, plaintext)
+
+	for start := int64(0); start < 100; start += 7 {
+		for _, end := range []int64{start, start + 15, start + 16, 99} {
+			if end > 99 {
+				continue
+			}
+			aligned := start - start%gcmBlockSize
+			r, err := newRangeDecryptReader(bytes.NewReader(ciphertext[aligned:end+1]), key, iv, start, end)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			b, err := io.ReadAll(iotest.OneByteReader(r))
+			if err != nil {
+				t.Fatalf("%d-%d: expect no error, got %v", start, end, err)
+			}
+			if !bytes.Equal(plaintext[start:end+1], b) {
+				t.Errorf("%d-%d: expect the range to be decrypted", start, end)
+			}
+		}
+	}
+
+	r, _ := newRangeDecryptReader(bytes.NewReader(ciphertext[:10]), key, iv, 0, 20)
+	if _, err := io.ReadAll(r); err != io.ErrUnexpectedEOF {
+		t.Errorf("expect %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
@@ -0,0 +1,152 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// KeyWrapper wraps the data keys the objects are encrypted with, e.g. with a
+// root key of a key management service.
+type KeyWrapper interface {
+	// WrapKey wraps a data key. The material description holds the
+	// encryption context of the object, to which the wrapper may add
+	// entries identifying its key, and which it may bind the wrapped key to.
+	WrapKey(ctx context.Context, key []byte, materialDescription map[string]string) (*WrappedKey, error)
+
+	// UnwrapKey returns the data key of a wrapped key
+	UnwrapKey(ctx context.Context, key *WrappedKey) ([]byte, error)
+}
+
+// WrappedKey is a data key wrapped by a KeyWrapper, stored in the envelope of
+// an object.
+type WrappedKey struct {
+	// The algorithm the key is wrapped with, identifying the KeyWrapper
+	// which unwraps it
+	Algorithm string
+
+	// The wrapped key
+	Ciphertext []byte
+
+	// The encryption context of the object and the entries the KeyWrapper
+	// needs to unwrap the key
+	MaterialDescription map[string]string
+}
+
+// KeyringWrapAlgorithm is the wrap algorithm of the data keys wrapped by a
+// LocalKeyring
+const KeyringWrapAlgorithm = "AES/GCM"
+
+// keyringKeyIDKey is the material description entry holding the ID of the
+// key of a LocalKeyring which wrapped a data key
+const keyringKeyIDKey = "keyring-key-id"
+
+// LocalKeyring is a KeyWrapper wrapping data keys with 256-bit AES keys held
+// in memory. Data keys are wrapped with the current key, and unwrapped with
+// the key they were wrapped with, so that the current key can be rotated
+// while the older ones remain to decrypt existing objects.
+//
+// The wrapped keys are the AES-GCM ciphertext of the data key, prefixed with
+// its IV and authenticated with the content algorithm.
+type LocalKeyring struct {
+	mu      sync.RWMutex
+	keys    map[string]cipher.AEAD
+	current string
+}
+
+// NewLocalKeyring returns a LocalKeyring wrapping data keys with key, whose
+// ID is recorded in the envelopes of the objects.
+func NewLocalKeyring(id string, key []byte) (*LocalKeyring, error) {
+	k := &LocalKeyring{keys: map[string]cipher.AEAD{}}
+	if err := k.AddKey(id, key); err != nil {
+		return nil, err
+	}
+	k.current = id
+	return k, nil
+}
+
+// AddKey adds a key the keyring unwraps data keys with
+func (k *LocalKeyring) AddKey(id string, key []byte) error {
+	if id == "" {
+		return fmt.Errorf("key ID is required")
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = aead
+	return nil
+}
+
+// SetCurrentKey sets the key, previously added, that data keys are wrapped
+// with
+func (k *LocalKeyring) SetCurrentKey(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("unknown key %q", id)
+	}
+	k.current = id
+	return nil
+}
+
+// WrapKey wraps a data key with the current key
+func (k *LocalKeyring) WrapKey(ctx context.Context, key []byte, materialDescription map[string]string) (*WrappedKey, error) {
+	k.mu.RLock()
+	id, aead := k.current, k.keys[k.current]
+	k.mu.RUnlock()
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	desc := maps.Clone(materialDescription)
+	if desc == nil {
+		desc = map[string]string{}
+	}
+	desc[keyringKeyIDKey] = id
+	return &WrappedKey{
+		Algorithm:           KeyringWrapAlgorithm,
+		Ciphertext:          aead.Seal(nonce, nonce, key, []byte(ContentAlgorithm)),
+		MaterialDescription: desc,
+	}, nil
+}
+
+// UnwrapKey unwraps a data key with the key it was wrapped with
+func (k *LocalKeyring) UnwrapKey(ctx context.Context, key *WrappedKey) ([]byte, error) {
+	if key.Algorithm != KeyringWrapAlgorithm {
+		return nil, fmt.Errorf("unsupported wrap algorithm %q", key.Algorithm)
+	}
+	id := key.MaterialDescription[keyringKeyIDKey]
+
+	k.mu.RLock()
+	aead, ok := k.keys[id]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", id)
+	}
+
+	if len(key.Ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+	nonce, ciphertext := key.Ciphertext[:aead.NonceSize()], key.Ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(ContentAlgorithm))
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap key with %q: %w", id, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,138 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect"
+)
+
+func TestLocalKeyring(t *testing.T) {
+	ctx := context.Background()
+	k, err := NewLocalKeyring("key-1", testData(32))
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	dataKey := testData(dataKeySize)
+	first, err := k.WrapKey(ctx, dataKey, map[string]string{"purpose": "test"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := map[string]string{"purpose": "test", keyringKeyIDKey: "key-1"}, first.MaterialDescription; !reflect.DeepEqual(e, a) {
+		t.Errorf("expect material description %v, got %v", e, a)
+	}
+
+	if err := k.AddKey("key-2", bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := k.SetCurrentKey("key-2"); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	second, err := k.WrapKey(ctx, dataKey, nil)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := "key-2", second.MaterialDescription[keyringKeyIDKey]; e != a {
+		t.Errorf("expect key %v, got %v", e, a)
+	}
+
+	for _, wrapped := range []*WrappedKey{first, second} {
+		b, err := k.UnwrapKey(ctx, wrapped)
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if !bytes.Equal(dataKey, b) {
+			t.Error("expect the data key to be unwrapped")
+		}
+	}
+
+	other, _ := NewLocalKeyring("key-3", testData(32))
+	if _, err := other.UnwrapKey(ctx, first); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Errorf("expect unknown key error, got %v", err)
+	}
+	if err := k.SetCurrentKey("key-3"); err == nil {
+		t.Error("expect error setting an unknown key")
+	}
+	if _, err := NewLocalKeyring("key", testData(16)); err == nil {
+		t.Error("expect error for a 16 bytes key")
+	}
+
+	tampered := *first
+	tampered.Ciphertext = bytes.Clone(first.Ciphertext)
+	tampered.Ciphertext[len(tampered.Ciphertext)-1] ^= 1
+	if _, err := k.UnwrapKey(ctx, &tampered); err == nil {
+		t.Error("expect error unwrapping a tampered key")
+	}
+}
+
+type mockKeyProtectClient struct {
+	wrapInput   *keyprotect.WrapKeyInput
+	unwrapInput *keyprotect.UnwrapKeyInput
+	plaintext   []byte
+}
+
+func (c *mockKeyProtectClient) WrapKey(ctx context.Context, input *keyprotect.WrapKeyInput, optFns ...func(*keyprotect.Options)) (*keyprotect.WrapKeyOutput, error) {
+	c.wrapInput = input
+	c.plaintext = input.Plaintext
+	return &keyprotect.WrapKeyOutput{
+		Ciphertext: aws.String(base64.StdEncoding.EncodeToString([]byte("wrapped"))),
+	}, nil
+}
+
+func (c *mockKeyProtectClient) UnwrapKey(ctx context.Context, input *keyprotect.UnwrapKeyInput, optFns ...func(*keyprotect.Options)) (*keyprotect.UnwrapKeyOutput, error) {
+	c.unwrapInput = input
+	return &keyprotect.UnwrapKeyOutput{Plaintext: c.plaintext}, nil
+}
+
+func TestKeyProtectKeyWrapper(t *testing.T) {
+	ctx := context.Background()
+	client := &mockKeyProtectClient{}
+	w := NewKeyProtectKeyWrapper(client, "root-key")
+
+	dataKey := testData(dataKeySize)
+	wrapped, err := w.WrapKey(ctx, dataKey, map[string]string{"department": "finance"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := "root-key", aws.ToString(client.wrapInput.ID); e != a {
+		t.Errorf("expect root key %v, got %v", e, a)
+	}
+	aad := []string{"department=finance", "keyprotect-root-key-id=root-key"}
+	if e, a := aad, client.wrapInput.AAD; !reflect.DeepEqual(e, a) {
+		t.Errorf("expect AAD %v, got %v", e, a)
+	}
+	if e, a := "wrapped", string(wrapped.Ciphertext); e != a {
+		t.Errorf("expect ciphertext %v, got %v", e, a)
+	}
+	if e, a := KeyProtectWrapAlgorithm, wrapped.Algorithm; e != a {
+		t.Errorf("expect algorithm %v, got %v", e, a)
+	}
+
+	// the key is unwrapped with the root key it was wrapped with
+	b, err := NewKeyProtectKeyWrapper(client, "other-root-key").UnwrapKey(ctx, wrapped)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if !bytes.Equal(dataKey, b) {
+		t.Error("expect the data key to be unwrapped")
+	}
+	if e, a := "root-key", aws.ToString(client.unwrapInput.ID); e != a {
+		t.Errorf("expect root key %v, got %v", e, a)
+	}
+	if e, a := aad, client.unwrapInput.AAD; !reflect.DeepEqual(e, a) {
+		t.Errorf("expect AAD %v, got %v", e, a)
+	}
+	if e, a := base64.StdEncoding.EncodeToString([]byte("wrapped")), aws.ToString(client.unwrapInput.Ciphertext); e != a {
+		t.Errorf("expect ciphertext %v, got %v", e, a)
+	}
+
+	if _, err := w.UnwrapKey(ctx, &WrappedKey{Algorithm: KeyringWrapAlgorithm}); err == nil {
+		t.Error("expect error for another wrap algorithm")
+	}
+}
@@ -0,0 +1,108 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect"
+)
+
+// KeyProtectWrapAlgorithm is the wrap algorithm of the data keys wrapped by a
+// KeyProtectKeyWrapper
+const KeyProtectWrapAlgorithm = "keyprotect+context"
+
+// keyProtectRootKeyIDKey is the material description entry holding the ID of
+// the root key which wrapped a data key
+const keyProtectRootKeyIDKey = "keyprotect-root-key-id"
+
+// KeyProtectAPIClient is a Key Protect client which can wrap and unwrap data
+// keys
+type KeyProtectAPIClient interface {
+	WrapKey(context.Context, *keyprotect.WrapKeyInput, ...func(*keyprotect.Options)) (*keyprotect.WrapKeyOutput, error)
+	UnwrapKey(context.Context, *keyprotect.UnwrapKeyInput, ...func(*keyprotect.Options)) (*keyprotect.UnwrapKeyOutput, error)
+}
+
+// KeyProtectKeyWrapper is a KeyWrapper wrapping data keys with a root key of
+// Key Protect or Hyper Protect Crypto Services. The data keys are bound to
+// the material description of the object, which is passed as additional
+// authenticated data, so that they are unwrapped only along with the
+// encryption context they were wrapped with.
+//
+// Data keys are unwrapped with the root key they were wrapped with, which is
+// recorded in the material description, so that the wrapper can be switched
+// to another root key.
+type KeyProtectKeyWrapper struct {
+	client    KeyProtectAPIClient
+	rootKeyID string
+}
+
+// NewKeyProtectKeyWrapper returns a KeyProtectKeyWrapper wrapping data keys
+// with the root key of ID rootKeyID.
+func NewKeyProtectKeyWrapper(client KeyProtectAPIClient, rootKeyID string) *KeyProtectKeyWrapper {
+	return &KeyProtectKeyWrapper{client: client, rootKeyID: rootKeyID}
+}
+
+// WrapKey wraps a data key with the root key
+func (w *KeyProtectKeyWrapper) WrapKey(ctx context.Context, key []byte, materialDescription map[string]string) (*WrappedKey, error) {
+	if w.rootKeyID == "" {
+		return nil, fmt.Errorf("root key ID is required")
+	}
+	desc := maps.Clone(materialDescription)
+	if desc == nil {
+		desc = map[string]string{}
+	}
+	desc[keyProtectRootKeyIDKey] = w.rootKeyID
+
+	out, err := w.client.WrapKey(ctx, &keyprotect.WrapKeyInput{
+		ID:        aws.String(w.rootKeyID),
+		Plaintext: key,
+		AAD:       keyProtectAAD(desc),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to wrap key with %s: %w", w.rootKeyID, err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(aws.ToString(out.Ciphertext))
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped key: %w", err)
+	}
+	return &WrappedKey{
+		Algorithm:           KeyProtectWrapAlgorithm,
+		Ciphertext:          ciphertext,
+		MaterialDescription: desc,
+	}, nil
+}
+
+// UnwrapKey unwraps a data key with the root key it was wrapped with
+func (w *KeyProtectKeyWrapper) UnwrapKey(ctx context.Context, key *WrappedKey) ([]byte, error) {
+	if key.Algorithm != KeyProtectWrapAlgorithm {
+		return nil, fmt.Errorf("unsupported wrap algorithm %q", key.Algorithm)
+	}
+	rootKeyID := key.MaterialDescription[keyProtectRootKeyIDKey]
+	if rootKeyID == "" {
+		return nil, fmt.Errorf("material description has no %s", keyProtectRootKeyIDKey)
+	}
+
+	out, err := w.client.UnwrapKey(ctx, &keyprotect.UnwrapKeyInput{
+		ID:         aws.String(rootKeyID),
+		Ciphertext: aws.String(base64.StdEncoding.EncodeToString(key.Ciphertext)),
+		AAD:        keyProtectAAD(key.MaterialDescription),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap key with %s: %w", rootKeyID, err)
+	}
+	return out.Plaintext, nil
+}
+
+// keyProtectAAD returns the additional authenticated data of a material
+// description, its entries sorted by key
+func keyProtectAAD(desc map[string]string) []string {
+	aad := make([]string, 0, len(desc))
+	for _, k := range slices.Sorted(maps.Keys(desc)) {
+		aad = append(aad, k+"="+desc[k])
+	}
+	return aad
+}
@@ -0,0 +1,211 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager"
+	tmtypes "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// TransferClient encrypts the objects uploaded and decrypts the objects
+// downloaded with a transfer manager client. Objects are encrypted and
+// decrypted as they are streamed, so that their size is not bounded by
+// memory, and have the same format as those of the Client, so that either
+// client can read the objects of the other.
+type TransferClient struct {
+	client *Client
+	tm     *transfermanager.Client
+}
+
+// TransferManager returns a TransferClient encrypting the transfers of tm
+// with the options of the client. The client writes and reads the instruction
+// files.
+func (c *Client) TransferManager(tm *transfermanager.Client) *TransferClient {
+	return &TransferClient{client: c, tm: tm}
+}
+
+// PutObject encrypts the body of an object as it is uploaded by the transfer
+// manager. The upload is multipart if the size of the body is unknown or
+// above the multipart upload threshold of the transfer manager.
+//
+// The envelope of the object is added to its metadata, or written to its
+// instruction file once the object is uploaded. Uploads with a
+// CheckpointStore cannot be resumed once encrypted, and are not supported.
+func (c *TransferClient) PutObject(ctx context.Context, input *transfermanager.PutObjectInput, optFns ...func(*Options)) (*transfermanager.PutObjectOutput, error) {
+	o, err := c.client.operationOptions(optFns)
+	if err != nil {
+		return nil, err
+	}
+	if input.CheckpointStore != nil {
+		return nil, fmt.Errorf("checkpoint store is not supported for encrypted uploads")
+	}
+
+	size, err := plaintextSize(input)
+	if err != nil {
+		return nil, err
+	}
+	key, e, err := newEnvelope(ctx, o, size)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate data key: %w", err)
+	}
+	var body io.Reader = eofReader{}
+	if input.Body != nil {
+		body = input.Body
+	}
+	r, err := newEncryptReader(body, key, e.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.Body = r
+	in.ContentLength = 0
+	if size >= 0 {
+		in.ContentLength = size + gcmTagSize
+	}
+	if in.Metadata, err = envelopeMetadata(o, e, input.Metadata); err != nil {
+		return nil, err
+	}
+
+	out, err := c.tm.PutObject(ctx, &in, o.TransferOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.client.putInstructionFile(ctx, o, e, input.Bucket, input.Key, input.ExpectedBucketOwner); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// plaintextSize returns the size of the body of an upload, -1 if unknown
+func plaintextSize(input *transfermanager.PutObjectInput) (int64, error) {
+	switch r := input.Body.(type) {
+	case nil:
+		return 0, nil
+	case io.Seeker:
+		return tmtypes.SeekerLen(r)
+	}
+	if input.ContentLength > 0 {
+		return input.ContentLength, nil
+	}
+	return -1, nil
+}
+
+// eofReader is the empty body of an upload without body
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+// GetObject downloads an object with the transfer manager and decrypts its
+// body as it is read. As with Client.GetObject, the plaintext is
+// authenticated once the body is read to its end, and a Range is decrypted
+// from the blocks covering it without being authenticated.
+func (c *TransferClient) GetObject(ctx context.Context, input *transfermanager.GetObjectInput, optFns ...func(*Options)) (*transfermanager.GetObjectOutput, error) {
+	o, err := c.client.operationOptions(optFns)
+	if err != nil {
+		return nil, err
+	}
+	if input.PartNumber != 0 {
+		return nil, fmt.Errorf("part number is not supported for encrypted objects")
+	}
+
+	if input.Range == "" {
+		out, err := c.tm.GetObject(ctx, input, o.TransferOptions...)
+		if err != nil {
+			return nil, err
+		}
+		key, e, err := c.client.dataKey(ctx, o, input.Bucket, input.Key, out.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if out.Body, err = newDecryptReader(out.Body, key, e.IV); err != nil {
+			return nil, err
+		}
+		out.ContentLength = max(out.ContentLength-gcmTagSize, 0)
+		size, err := contentRangeSize(out.ContentRange)
+		out.ContentRange = ""
+		// the range of an empty plaintext cannot be expressed
+		if err == nil && size > gcmTagSize {
+			out.ContentRange = fmt.Sprintf("bytes 0-%d/%d", size-gcmTagSize-1, size-gcmTagSize)
+		}
+		clearTransferChecksums(out)
+		return out, nil
+	}
+
+	// the transfer manager downloads closed ranges within the object, the
+	// range of the plaintext is resolved from the size of the object first
+	rng, err := parseRange(input.Range)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.client.client.HeadObject(ctx, headObjectInput(input), o.ClientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	size := aws.ToInt64(head.ContentLength)
+	rng.resolveSuffix(size)
+	start, end, err := rng.resolve(size)
+	if err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.Range = plaintextRange{start: start, end: end}.ciphertextRange()
+	if in.VersionID == "" && in.IfMatch == "" {
+		in.IfMatch = aws.ToString(head.ETag)
+	}
+	out, err := c.tm.GetObject(ctx, &in, o.TransferOptions...)
+	if err != nil {
+		return nil, err
+	}
+	key, e, err := c.client.dataKey(ctx, o, input.Bucket, input.Key, out.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if out.Body, err = newRangeDecryptReader(out.Body, key, e.IV, start, end); err != nil {
+		return nil, err
+	}
+	out.ContentLength = end - start + 1
+	out.ContentRange = fmt.Sprintf("bytes %d-%d/%d", start, end, size-gcmTagSize)
+	clearTransferChecksums(out)
+	return out, nil
+}
+
+// clearTransferChecksums clears the checksums of the ciphertext from the
+// output
+func clearTransferChecksums(out *transfermanager.GetObjectOutput) {
+	out.ChecksumCRC32, out.ChecksumCRC32C, out.ChecksumSHA1, out.ChecksumSHA256 = "", "", "", ""
+}
+
+// headObjectInput returns the HeadObject request of the object of a
+// download
+func headObjectInput(input *transfermanager.GetObjectInput) *s3.HeadObjectInput {
+	in := &s3.HeadObjectInput{
+		Bucket: aws.String(input.Bucket),
+		Key:    aws.String(input.Key),
+	}
+	if input.VersionID != "" {
+		in.VersionId = aws.String(input.VersionID)
+	}
+	if input.ExpectedBucketOwner != "" {
+		in.ExpectedBucketOwner = aws.String(input.ExpectedBucketOwner)
+	}
+	algorithm, key, keyMD5 := input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5
+	if k := input.CustomerKey; k != nil {
+		algorithm, key, keyMD5 = k.Algorithm(), k.Key(), k.KeyMD5()
+	}
+	if key != "" {
+		in.SSECustomerAlgorithm = aws.String(algorithm)
+		in.SSECustomerKey = aws.String(key)
+	}
+	if keyMD5 != "" {
+		in.SSECustomerKeyMD5 = aws.String(keyMD5)
+	}
+	return in
+}
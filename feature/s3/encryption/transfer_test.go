@@ -0,0 +1,135 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+)
+
+func TestTransferClient(t *testing.T) {
+	const partSize = 8 * 1024 * 1024
+	plaintext := testData(partSize + 1000)
+
+	cases := map[string]struct {
+		size     int
+		body     func([]byte) io.Reader
+		location EnvelopeLocation
+	}{
+		"empty": {
+			size:     0,
+			body:     func(b []byte) io.Reader { return bytes.NewReader(b) },
+			location: EnvelopeMetadata,
+		},
+		"single part": {
+			size:     1000,
+			body:     func(b []byte) io.Reader { return bytes.NewReader(b) },
+			location: EnvelopeMetadata,
+		},
+		"multipart unknown size": {
+			size:     len(plaintext),
+			body:     func(b []byte) io.Reader { return io.MultiReader(bytes.NewReader(b)) },
+			location: EnvelopeMetadata,
+		},
+		"multipart instruction file": {
+			size:     len(plaintext),
+			body:     func(b []byte) io.Reader { return bytes.NewReader(b) },
+			location: EnvelopeInstructionFile,
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c, s3Client := newTestClient(t, func(o *Options) {
+				o.EnvelopeLocation = tt.location
+			})
+			tm := transfermanager.New(s3Client, transfermanager.Options{
+				PartSizeBytes:             partSize,
+				MultipartUploadThreshold:  partSize,
+				GetObjectType:             types.GetObjectRanges,
+				DisableChecksumValidation: true,
+			})
+			tc := c.TransferManager(tm)
+
+			expect := plaintext[:tt.size]
+			_, err := tc.PutObject(ctx, &transfermanager.PutObjectInput{
+				Bucket: "bucket",
+				Key:    "key",
+				Body:   tt.body(expect),
+			})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if e, a := len(expect)+gcmTagSize, len(s3Client.objects["key"].body); e != a {
+				t.Errorf("expect ciphertext size %v, got %v", e, a)
+			}
+
+			out, err := tc.GetObject(ctx, &transfermanager.GetObjectInput{Bucket: "bucket", Key: "key"})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			b, err := io.ReadAll(out.Body)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !bytes.Equal(expect, b) {
+				t.Error("expect the object to be decrypted")
+			}
+			if e, a := int64(len(expect)), out.ContentLength; e != a {
+				t.Errorf("expect content length %v, got %v", e, a)
+			}
+			var contentRange string
+			if len(expect) > 0 {
+				contentRange = fmt.Sprintf("bytes 0-%d/%d", len(expect)-1, len(expect))
+			}
+			if e, a := contentRange, out.ContentRange; e != a {
+				t.Errorf("expect content range %q, got %q", e, a)
+			}
+			if len(expect) == 0 {
+				return
+			}
+
+			out, err = tc.GetObject(ctx, &transfermanager.GetObjectInput{Bucket: "bucket", Key: "key", Range: "bytes=-100"})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if b, err = io.ReadAll(out.Body); err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !bytes.Equal(expect[len(expect)-100:], b) {
+				t.Error("expect the range to be decrypted")
+			}
+			if e, a := fmt.Sprintf("bytes %d-%d/%d", len(expect)-100, len(expect)-1, len(expect)), out.ContentRange; e != a {
+				t.Errorf("expect content range %q, got %q", e, a)
+			}
+
+			// objects uploaded by the transfer client are read by the client
+			b, _, err = getObject(t, c, "key", "")
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !bytes.Equal(expect, b) {
+				t.Error("expect the object to be decrypted by the client")
+			}
+		})
+	}
+}
+
+func TestTransferClientCheckpointStore(t *testing.T) {
+	c, s3Client := newTestClient(t)
+	tc := c.TransferManager(transfermanager.New(s3Client, transfermanager.Options{}))
+	_, err := tc.PutObject(context.Background(), &transfermanager.PutObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		Body:            bytes.NewReader(testData(10)),
+		CheckpointStore: transfermanager.NewFileCheckpointStore(t.TempDir()),
+	})
+	if err == nil {
+		t.Error("expect error for a checkpoint store")
+	}
+}
@@ -0,0 +1,78 @@
+package inventory
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/manager"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// S3APIClient is an S3 client the inventory client lists objects and object
+// versions with
+type S3APIClient interface {
+	manager.ListObjectsV2APIClient
+	ListObjectVersions(context.Context, *s3.ListObjectVersionsInput, ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error)
+}
+
+// Options configures the inventory client
+type Options struct {
+	// The maximum number of ListObjectsV2 requests in flight when crawling the
+	// current objects of a bucket, defaults to manager.DefaultListConcurrency.
+	// The versions of objects are listed with sequential requests.
+	Concurrency int
+
+	// The number of partitions the keys are split into at most when crawling
+	// the current objects of a bucket, defaults to
+	// manager.DefaultListMaxPartitions
+	MaxPartitions int
+
+	// The options of the requests of the S3 client
+	ClientOptions []func(*s3.Options)
+}
+
+// Copy returns a copy of the options
+func (o Options) Copy() Options {
+	to := o
+	to.ClientOptions = append([]func(*s3.Options){}, o.ClientOptions...)
+	return to
+}
+
+func resolveOptions(o *Options) {
+	if o.Concurrency <= 0 {
+		o.Concurrency = manager.DefaultListConcurrency
+	}
+	if o.MaxPartitions <= 0 {
+		o.MaxPartitions = manager.DefaultListMaxPartitions
+	}
+}
+
+// Client crawls buckets into snapshots of their objects. It is safe to call
+// Client methods concurrently across goroutines.
+type Client struct {
+	client  S3APIClient
+	options Options
+}
+
+// New returns an inventory client of the buckets listed with client. Provide
+// more functional options to further configure the Client
+func New(client S3APIClient, opts Options, optFns ...func(*Options)) *Client {
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	resolveOptions(&opts)
+
+	return &Client{
+		client:  client,
+		options: opts,
+	}
+}
+
+// operationOptions returns the options of an operation
+func (c *Client) operationOptions(optFns []func(*Options)) Options {
+	o := c.options.Copy()
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	resolveOptions(&o)
+	return o
+}
@@ -0,0 +1,175 @@
+package inventory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/manager"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// CrawlInput represents a request to the Crawl() call
+type CrawlInput struct {
+	// Bucket to crawl. (Required)
+	Bucket string
+
+	// Prefix of the keys to crawl, the whole bucket if empty
+	Prefix string
+
+	// Whether to crawl every version of the objects, including delete
+	// markers, rather than their current version
+	Versions bool
+
+	// Directory to write the snapshot to, which is created if it does not
+	// exist. It must not hold a snapshot already. (Required)
+	Path string
+}
+
+// CrawlOutput represents a response from the Crawl() call
+type CrawlOutput struct {
+	// Manifest of the snapshot written
+	Manifest *Manifest
+}
+
+// Crawl lists the objects of a bucket, or of a key prefix, and writes them
+// as a snapshot to a local directory, sorted by key. The current objects are
+// listed with concurrent ListObjectsV2 requests over partitions of the keys,
+// the versions of objects with sequential ListObjectVersions requests.
+//
+// The manifest of the snapshot is written once every object has been
+// listed, so that a failed crawl leaves no snapshot behind.
+//
+// Additional functional options can be provided to configure the individual
+// crawl. These options are copies of the original Options instance, the client of which Crawl is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) Crawl(ctx context.Context, input *CrawlInput, opts ...func(*Options)) (*CrawlOutput, error) {
+	if input.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if input.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	o := c.operationOptions(opts)
+
+	w, err := newSnapshotWriter(input.Path, Manifest{
+		Bucket:    input.Bucket,
+		Prefix:    input.Prefix,
+		Versions:  input.Versions,
+		StartedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Versions {
+		err = c.crawlVersions(ctx, input, o, w)
+	} else {
+		err = c.crawlObjects(ctx, input, o, w)
+	}
+	if err != nil {
+		w.abort()
+		return nil, fmt.Errorf("failed to crawl bucket %s: %w", input.Bucket, err)
+	}
+
+	manifest, err := w.commit(time.Now().UTC())
+	if err != nil {
+		w.abort()
+		return nil, err
+	}
+	return &CrawlOutput{Manifest: manifest}, nil
+}
+
+// crawlObjects writes the current objects, merged in order of their keys
+// from a parallel listing
+func (c *Client) crawlObjects(ctx context.Context, input *CrawlInput, o Options, w *snapshotWriter) error {
+	lister := manager.NewLister(c.client, func(l *manager.Lister) {
+		l.Concurrency = o.Concurrency
+		l.MaxPartitions = o.MaxPartitions
+		l.ClientOptions = o.ClientOptions
+	})
+	stream, err := lister.List(ctx, &manager.ListInput{
+		Bucket: aws.String(input.Bucket),
+		Prefix: aws.String(input.Prefix),
+	})
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+		obj := stream.Object()
+		if err := w.write(Entry{
+			Key:          aws.ToString(obj.Key),
+			Size:         aws.ToInt64(obj.Size),
+			ETag:         aws.ToString(obj.ETag),
+			LastModified: aws.ToTime(obj.LastModified).UTC(),
+			StorageClass: string(obj.StorageClass),
+		}); err != nil {
+			return err
+		}
+	}
+	return stream.Err()
+}
+
+// crawlVersions writes the versions and delete markers of the objects,
+// newest first for each key
+func (c *Client) crawlVersions(ctx context.Context, input *CrawlInput, o Options, w *snapshotWriter) error {
+	params := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(input.Bucket),
+		Prefix: aws.String(input.Prefix),
+	}
+	for {
+		out, err := c.client.ListObjectVersions(ctx, params, o.ClientOptions...)
+		if err != nil {
+			return err
+		}
+
+		entries := make([]Entry, 0, len(out.Versions)+len(out.DeleteMarkers))
+		for _, v := range out.Versions {
+			entries = append(entries, Entry{
+				Key:          aws.ToString(v.Key),
+				VersionID:    aws.ToString(v.VersionId),
+				IsLatest:     aws.ToBool(v.IsLatest),
+				Size:         aws.ToInt64(v.Size),
+				ETag:         aws.ToString(v.ETag),
+				LastModified: aws.ToTime(v.LastModified).UTC(),
+				StorageClass: string(v.StorageClass),
+			})
+		}
+		for _, m := range out.DeleteMarkers {
+			entries = append(entries, Entry{
+				Key:          aws.ToString(m.Key),
+				VersionID:    aws.ToString(m.VersionId),
+				IsLatest:     aws.ToBool(m.IsLatest),
+				DeleteMarker: true,
+				LastModified: aws.ToTime(m.LastModified).UTC(),
+			})
+		}
+		// versions and delete markers are listed apart, each by key and
+		// then newest first
+		slices.SortStableFunc(entries, func(a, b Entry) int {
+			if n := cmp.Compare(a.Key, b.Key); n != 0 {
+				return n
+			}
+			return b.LastModified.Compare(a.LastModified)
+		})
+		for _, e := range entries {
+			if err := w.write(e); err != nil {
+				return err
+			}
+		}
+
+		if !aws.ToBool(out.IsTruncated) {
+			return nil
+		}
+		if out.NextKeyMarker == nil && out.NextVersionIdMarker == nil {
+			return fmt.Errorf("truncated ListObjectVersions response without a next marker")
+		}
+		params.KeyMarker = out.NextKeyMarker
+		params.VersionIdMarker = out.NextVersionIdMarker
+	}
+}
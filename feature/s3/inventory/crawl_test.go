@@ -0,0 +1,307 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+var testTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type mockVersion struct {
+	key          string
+	versionID    string
+	latest       bool
+	deleteMarker bool
+	size         int64
+	etag         string
+	modified     time.Time
+}
+
+// mockBucketClient lists the objects and versions of an in-memory bucket,
+// pageSize at a time. Continuation tokens and key markers are indexes.
+type mockBucketClient struct {
+	mu       sync.Mutex
+	objects  map[string]mockVersion
+	versions []mockVersion
+	pageSize int
+	failFn   func() error
+	requests int
+}
+
+func newMockBucketClient(pageSize int, keys ...string) *mockBucketClient {
+	c := &mockBucketClient{objects: map[string]mockVersion{}, pageSize: pageSize}
+	for i, key := range keys {
+		c.objects[key] = mockVersion{key: key, size: int64(i + 1), etag: fmt.Sprintf("%q", key), modified: testTime}
+	}
+	return c
+}
+
+func (c *mockBucketClient) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	if c.failFn != nil && in.Delimiter == nil {
+		if err := c.failFn(); err != nil {
+			return nil, err
+		}
+	}
+
+	prefix, delimiter := aws.ToString(in.Prefix), aws.ToString(in.Delimiter)
+	keys := make([]string, 0, len(c.objects))
+	for key := range c.objects {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	out := &s3.ListObjectsV2Output{}
+	seen := map[string]bool{}
+	var items int
+	start, _ := strconv.Atoi(aws.ToString(in.ContinuationToken))
+	for i, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= aws.ToString(in.StartAfter) || i < start {
+			continue
+		}
+		if items == c.pageSize {
+			out.IsTruncated = aws.Bool(true)
+			out.NextContinuationToken = aws.String(strconv.Itoa(i))
+			break
+		}
+		if delimiter != "" {
+			if n := strings.Index(key[len(prefix):], delimiter); n >= 0 {
+				p := key[:len(prefix)+n+len(delimiter)]
+				if !seen[p] {
+					seen[p] = true
+					items++
+					out.CommonPrefixes = append(out.CommonPrefixes, types.CommonPrefix{Prefix: aws.String(p)})
+				}
+				continue
+			}
+		}
+		o := c.objects[key]
+		items++
+		out.Contents = append(out.Contents, types.Object{
+			Key:          aws.String(o.key),
+			Size:         aws.Int64(o.size),
+			ETag:         aws.String(o.etag),
+			LastModified: aws.Time(o.modified),
+			StorageClass: types.ObjectStorageClassStandard,
+		})
+	}
+	return out, nil
+}
+
+func (c *mockBucketClient) ListObjectVersions(ctx context.Context, in *s3.ListObjectVersionsInput, _ ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+
+	out := &s3.ListObjectVersionsOutput{}
+	start, _ := strconv.Atoi(aws.ToString(in.KeyMarker))
+	var items int
+	for i := start; i < len(c.versions); i++ {
+		v := c.versions[i]
+		if !strings.HasPrefix(v.key, aws.ToString(in.Prefix)) {
+			continue
+		}
+		if items == c.pageSize {
+			out.IsTruncated = aws.Bool(true)
+			out.NextKeyMarker = aws.String(strconv.Itoa(i))
+			out.NextVersionIdMarker = aws.String(v.versionID)
+			break
+		}
+		items++
+		if v.deleteMarker {
+			out.DeleteMarkers = append(out.DeleteMarkers, types.DeleteMarkerEntry{
+				Key:          aws.String(v.key),
+				VersionId:    aws.String(v.versionID),
+				IsLatest:     aws.Bool(v.latest),
+				LastModified: aws.Time(v.modified),
+			})
+			continue
+		}
+		out.Versions = append(out.Versions, types.ObjectVersion{
+			Key:          aws.String(v.key),
+			VersionId:    aws.String(v.versionID),
+			IsLatest:     aws.Bool(v.latest),
+			Size:         aws.Int64(v.size),
+			ETag:         aws.String(v.etag),
+			LastModified: aws.Time(v.modified),
+		})
+	}
+	return out, nil
+}
+
+// readEntries returns the entries of the snapshot in dir
+func readEntries(t *testing.T, dir string) []Entry {
+	t.Helper()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	r, err := s.Entries()
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer r.Close()
+	var entries []Entry
+	for r.Next() {
+		entries = append(entries, r.Entry())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	return entries
+}
+
+func TestCrawl(t *testing.T) {
+	keys := []string{"a.txt", "docs/1.txt", "docs/2.txt", "docs/sub/3.txt", "img/1.png", "img/2.png", "z.txt"}
+	cases := map[string]struct {
+		prefix string
+		expect []string
+	}{
+		"bucket": {expect: keys},
+		"prefix": {prefix: "docs/", expect: keys[1:4]},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s3Client := newMockBucketClient(2, keys...)
+			dir := filepath.Join(t.TempDir(), "snapshot")
+			out, err := New(s3Client, Options{}).Crawl(context.Background(), &CrawlInput{
+				Bucket: "bucket",
+				Prefix: c.prefix,
+				Path:   dir,
+			})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+
+			entries := readEntries(t, dir)
+			var listed []string
+			var size int64
+			for _, e := range entries {
+				listed = append(listed, e.Key)
+				size += e.Size
+				if e.ETag != fmt.Sprintf("%q", e.Key) || !e.LastModified.Equal(testTime) || e.StorageClass != "STANDARD" {
+					t.Errorf("%s: unexpected entry %+v", e.Key, e)
+				}
+			}
+			if !slices.Equal(c.expect, listed) {
+				t.Errorf("expect keys %v, got %v", c.expect, listed)
+			}
+
+			m := out.Manifest
+			if e, a := int64(len(c.expect)), m.Entries; e != a {
+				t.Errorf("expect %d entries, got %d", e, a)
+			}
+			if e, a := size, m.TotalBytes; e != a {
+				t.Errorf("expect %d bytes, got %d", e, a)
+			}
+			if e, a := c.prefix, m.Prefix; e != a {
+				t.Errorf("expect prefix %q, got %q", e, a)
+			}
+			if m.StartedAt.IsZero() || m.CompletedAt.Before(m.StartedAt) {
+				t.Errorf("unexpected crawl times %v, %v", m.StartedAt, m.CompletedAt)
+			}
+		})
+	}
+}
+
+func TestCrawlVersions(t *testing.T) {
+	s3Client := newMockBucketClient(2)
+	s3Client.versions = []mockVersion{
+		{key: "a.txt", versionID: "a2", latest: true, size: 2, etag: `"a2"`, modified: testTime.Add(time.Hour)},
+		{key: "a.txt", versionID: "a1", size: 1, etag: `"a1"`, modified: testTime},
+		{key: "b.txt", versionID: "b2", latest: true, deleteMarker: true, modified: testTime.Add(time.Hour)},
+		{key: "b.txt", versionID: "b1", size: 3, etag: `"b1"`, modified: testTime},
+		{key: "c.txt", versionID: "c1", latest: true, size: 4, etag: `"c1"`, modified: testTime},
+	}
+	dir := t.TempDir()
+	out, err := New(s3Client, Options{}).Crawl(context.Background(), &CrawlInput{
+		Bucket:   "bucket",
+		Versions: true,
+		Path:     dir,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 3, s3Client.requests; e != a {
+		t.Errorf("expect %d requests, got %d", e, a)
+	}
+
+	entries := readEntries(t, dir)
+	if e, a := len(s3Client.versions), len(entries); e != a {
+		t.Fatalf("expect %d entries, got %d", e, a)
+	}
+	for i, v := range s3Client.versions {
+		e := entries[i]
+		if v.key != e.Key || v.versionID != e.VersionID || v.latest != e.IsLatest || v.deleteMarker != e.DeleteMarker || v.size != e.Size {
+			t.Errorf("expect entry %d to be %+v, got %+v", i, v, e)
+		}
+	}
+	if !out.Manifest.Versions {
+		t.Error("expect a snapshot of versions")
+	}
+	if e, a := int64(10), out.Manifest.TotalBytes; e != a {
+		t.Errorf("expect %d bytes, got %d", e, a)
+	}
+}
+
+func TestCrawlErrors(t *testing.T) {
+	t.Run("list error", func(t *testing.T) {
+		s3Client := newMockBucketClient(2, "a", "b", "c", "d")
+		s3Client.failFn = func() error {
+			if s3Client.requests > 2 {
+				return errors.New("list failed")
+			}
+			return nil
+		}
+		dir := t.TempDir()
+		_, err := New(s3Client, Options{}).Crawl(context.Background(), &CrawlInput{Bucket: "bucket", Path: dir})
+		if err == nil {
+			t.Fatal("expect error")
+		}
+		if files, _ := os.ReadDir(dir); len(files) != 0 {
+			t.Errorf("expect no files left, got %d", len(files))
+		}
+		if _, err := Open(dir); err == nil {
+			t.Error("expect no snapshot")
+		}
+	})
+
+	t.Run("snapshot exists", func(t *testing.T) {
+		s3Client := newMockBucketClient(2, "a")
+		dir := t.TempDir()
+		client := New(s3Client, Options{})
+		if _, err := client.Crawl(context.Background(), &CrawlInput{Bucket: "bucket", Path: dir}); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		_, err := client.Crawl(context.Background(), &CrawlInput{Bucket: "bucket", Path: dir})
+		if !errors.Is(err, ErrSnapshotExists) {
+			t.Errorf("expect ErrSnapshotExists, got %v", err)
+		}
+	})
+
+	t.Run("required", func(t *testing.T) {
+		client := New(newMockBucketClient(2), Options{})
+		if _, err := client.Crawl(context.Background(), &CrawlInput{Path: t.TempDir()}); err == nil {
+			t.Error("expect error without bucket")
+		}
+		if _, err := client.Crawl(context.Background(), &CrawlInput{Bucket: "bucket"}); err == nil {
+			t.Error("expect error without path")
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package inventory
+
+import (
+	"fmt"
+)
+
+// ChangeType is the type of a change between two snapshots
+type ChangeType string
+
+// Enum values for ChangeType
+const (
+	// The object is in the new snapshot only
+	ChangeAdded ChangeType = "added"
+
+	// The object is in the old snapshot only
+	ChangeRemoved ChangeType = "removed"
+
+	// The object is in both snapshots, with another ETag, size or last
+	// modification time
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is an object, or a version of an object, that changed between two
+// snapshots
+type Change struct {
+	// Type of the change
+	Type ChangeType `json:"type"`
+
+	// Key of the object
+	Key string `json:"key"`
+
+	// Version ID of the object, in snapshots of versions
+	VersionID string `json:"versionId,omitempty"`
+
+	// Entry of the old snapshot, nil if the object was added
+	Old *Entry `json:"old,omitempty"`
+
+	// Entry of the new snapshot, nil if the object was removed
+	New *Entry `json:"new,omitempty"`
+}
+
+// DiffOutput counts the changes between two snapshots
+type DiffOutput struct {
+	// Number of objects added
+	Added int64
+
+	// Number of objects removed
+	Removed int64
+
+	// Number of objects modified
+	Modified int64
+
+	// Number of objects unchanged
+	Unchanged int64
+}
+
+// Diff compares the snapshots old and new, and calls fn with each object
+// added, removed or modified, in order of their keys. The objects of
+// snapshots of versions are compared version by version, and a version
+// which only stopped being the latest is not modified.
+//
+// The snapshots are read side by side, so that the memory used does not
+// grow with their size. The comparison stops at the first error returned by
+// fn, which Diff returns, or at the first error reading the snapshots, which
+// is returned before the entries of the last key read are compared.
+func Diff(old, new *Snapshot, fn func(Change) error) (*DiffOutput, error) {
+	if old.Manifest.Versions != new.Manifest.Versions {
+		return nil, fmt.Errorf("cannot compare a snapshot of versions with a snapshot of current objects")
+	}
+
+	or, err := old.Entries()
+	if err != nil {
+		return nil, err
+	}
+	defer or.Close()
+	nr, err := new.Entries()
+	if err != nil {
+		return nil, err
+	}
+	defer nr.Close()
+
+	d := &differ{fn: fn, out: &DiffOutput{}}
+	og, ng := &keyGroups{r: or}, &keyGroups{r: nr}
+	oldEntries, err := og.next()
+	if err != nil {
+		return d.out, fmt.Errorf("failed to read old snapshot: %w", err)
+	}
+	newEntries, err := ng.next()
+	if err != nil {
+		return d.out, fmt.Errorf("failed to read new snapshot: %w", err)
+	}
+	for oldEntries != nil || newEntries != nil {
+		advanceOld, advanceNew := true, true
+		switch {
+		case newEntries == nil || oldEntries != nil && oldEntries[0].Key < newEntries[0].Key:
+			advanceNew = false
+			err = d.compare(oldEntries, nil)
+		case oldEntries == nil || newEntries[0].Key < oldEntries[0].Key:
+			advanceOld = false
+			err = d.compare(nil, newEntries)
+		default:
+			err = d.compare(oldEntries, newEntries)
+		}
+		if err != nil {
+			return d.out, err
+		}
+		if advanceOld {
+			if oldEntries, err = og.next(); err != nil {
+				return d.out, fmt.Errorf("failed to read old snapshot: %w", err)
+			}
+		}
+		if advanceNew {
+			if newEntries, err = ng.next(); err != nil {
+				return d.out, fmt.Errorf("failed to read new snapshot: %w", err)
+			}
+		}
+	}
+	return d.out, nil
+}
+
+// differ reports the changes between the entries of a key
+type differ struct {
+	fn  func(Change) error
+	out *DiffOutput
+}
+
+// compare reports the changes between the old and new entries of a key,
+// matched by version ID
+func (d *differ) compare(old, new []Entry) error {
+	versions := make(map[string]int, len(old))
+	for i := range old {
+		versions[old[i].VersionID] = i
+	}
+	matched := make([]bool, len(old))
+	for i := range new {
+		n := &new[i]
+		j, ok := versions[n.VersionID]
+		if !ok || matched[j] {
+			d.out.Added++
+			if err := d.fn(Change{Type: ChangeAdded, Key: n.Key, VersionID: n.VersionID, New: n}); err != nil {
+				return err
+			}
+			continue
+		}
+		matched[j] = true
+		o := &old[j]
+		if !modified(o, n) {
+			d.out.Unchanged++
+			continue
+		}
+		d.out.Modified++
+		if err := d.fn(Change{Type: ChangeModified, Key: n.Key, VersionID: n.VersionID, Old: o, New: n}); err != nil {
+			return err
+		}
+	}
+	for i := range old {
+		if matched[i] {
+			continue
+		}
+		o := &old[i]
+		d.out.Removed++
+		if err := d.fn(Change{Type: ChangeRemoved, Key: o.Key, VersionID: o.VersionID, Old: o}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modified returns whether an object differs between two snapshots
+func modified(old, new *Entry) bool {
+	return old.ETag != new.ETag ||
+		old.Size != new.Size ||
+		old.DeleteMarker != new.DeleteMarker ||
+		!old.LastModified.Equal(new.LastModified)
+}
+
+// keyGroups groups the entries of a reader by key
+type keyGroups struct {
+	r       *EntryReader
+	started bool
+	pending *Entry
+}
+
+// next returns the entries of the next key, nil once the reader is
+// exhausted. The error of the reader is returned along with the last
+// entries, before they are compared.
+func (g *keyGroups) next() ([]Entry, error) {
+	if !g.started {
+		g.started = true
+		g.read()
+	}
+	if g.pending == nil {
+		return nil, g.r.Err()
+	}
+	entries := []Entry{*g.pending}
+	for g.read(); g.pending != nil && g.pending.Key == entries[0].Key; g.read() {
+		entries = append(entries, *g.pending)
+	}
+	if g.pending == nil {
+		if err := g.r.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// read reads the next entry into pending, nil once the reader is exhausted
+func (g *keyGroups) read() {
+	g.pending = nil
+	if g.r.Next() {
+		e := g.r.Entry()
+		g.pending = &e
+	}
+}
@@ -0,0 +1,147 @@
+package inventory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeSnapshot writes a snapshot of the entries to a temporary directory
+func writeSnapshot(t *testing.T, versions bool, entries ...Entry) *Snapshot {
+	t.Helper()
+	dir := t.TempDir()
+	w, err := newSnapshotWriter(dir, Manifest{Bucket: "bucket", Versions: versions, StartedAt: testTime})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	for _, e := range entries {
+		if err := w.write(e); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+	if _, err := w.commit(testTime); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	later := testTime.Add(time.Hour)
+	old := writeSnapshot(t, false,
+		Entry{Key: "a", Size: 1, ETag: "1", LastModified: testTime},
+		Entry{Key: "b", Size: 1, ETag: "1", LastModified: testTime},
+		Entry{Key: "c", Size: 1, ETag: "1", LastModified: testTime},
+		Entry{Key: "d", Size: 1, ETag: "1", LastModified: testTime},
+		Entry{Key: "e", Size: 1, ETag: "1", LastModified: testTime},
+	)
+	new := writeSnapshot(t, false,
+		Entry{Key: "0", Size: 1, ETag: "1", LastModified: testTime},
+		Entry{Key: "a", Size: 1, ETag: "1", LastModified: testTime},
+		Entry{Key: "b", Size: 1, ETag: "2", LastModified: testTime},
+		Entry{Key: "c", Size: 2, ETag: "1", LastModified: testTime},
+		Entry{Key: "d", Size: 1, ETag: "1", LastModified: later},
+		Entry{Key: "f", Size: 1, ETag: "1", LastModified: testTime},
+	)
+
+	var changes []string
+	out, err := Diff(old, new, func(c Change) error {
+		changes = append(changes, string(c.Type)+" "+c.Key)
+		if (c.Old == nil) != (c.Type == ChangeAdded) || (c.New == nil) != (c.Type == ChangeRemoved) {
+			t.Errorf("%s: unexpected entries of change %+v", c.Key, c)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	expect := []string{"added 0", "modified b", "modified c", "modified d", "removed e", "added f"}
+	if !reflect.DeepEqual(expect, changes) {
+		t.Errorf("expect changes %v, got %v", expect, changes)
+	}
+	if e, a := (DiffOutput{Added: 2, Removed: 1, Modified: 3, Unchanged: 1}), *out; e != a {
+		t.Errorf("expect %+v, got %+v", e, a)
+	}
+}
+
+func TestDiffVersions(t *testing.T) {
+	later := testTime.Add(time.Hour)
+	old := writeSnapshot(t, true,
+		Entry{Key: "a", VersionID: "a1", IsLatest: true, Size: 1, ETag: "1", LastModified: testTime},
+		Entry{Key: "b", VersionID: "b1", IsLatest: true, Size: 1, ETag: "1", LastModified: testTime},
+	)
+	new := writeSnapshot(t, true,
+		Entry{Key: "a", VersionID: "a2", IsLatest: true, Size: 2, ETag: "2", LastModified: later},
+		Entry{Key: "a", VersionID: "a1", Size: 1, ETag: "1", LastModified: testTime},
+		Entry{Key: "b", VersionID: "b2", IsLatest: true, DeleteMarker: true, LastModified: later},
+	)
+
+	var changes []string
+	out, err := Diff(old, new, func(c Change) error {
+		changes = append(changes, string(c.Type)+" "+c.Key+" "+c.VersionID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	expect := []string{"added a a2", "added b b2", "removed b b1"}
+	if !reflect.DeepEqual(expect, changes) {
+		t.Errorf("expect changes %v, got %v", expect, changes)
+	}
+	if e, a := int64(1), out.Unchanged; e != a {
+		t.Errorf("expect %d unchanged, got %d", e, a)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	entry := Entry{Key: "a", Size: 1, LastModified: testTime}
+
+	t.Run("versions mismatch", func(t *testing.T) {
+		if _, err := Diff(writeSnapshot(t, false), writeSnapshot(t, true), nil); err == nil {
+			t.Error("expect error")
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		expect := errors.New("callback failed")
+		_, err := Diff(writeSnapshot(t, false), writeSnapshot(t, false, entry), func(Change) error {
+			return expect
+		})
+		if !errors.Is(err, expect) {
+			t.Errorf("expect callback error, got %v", err)
+		}
+	})
+
+	t.Run("corrupt snapshot", func(t *testing.T) {
+		old := writeSnapshot(t, false, entry)
+		new := writeSnapshot(t, false)
+		// a truncated snapshot must not report its missing entries removed
+		path := filepath.Join(new.dir, EntriesFile)
+		other := writeSnapshot(t, false, Entry{Key: "b", LastModified: testTime})
+		b, err := os.ReadFile(filepath.Join(other.dir, EntriesFile))
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+
+		var changes int
+		_, err = Diff(old, new, func(Change) error {
+			changes++
+			return nil
+		})
+		if err == nil {
+			t.Fatal("expect error")
+		}
+		if e, a := 0, changes; e != a {
+			t.Errorf("expect %d changes, got %d", e, a)
+		}
+	})
+}
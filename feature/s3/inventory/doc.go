@@ -0,0 +1,33 @@
+// Package inventory provides snapshots of the objects of Cloud Object Storage
+// buckets, and the changes between them, e.g. to find the objects to scan or
+// synchronize since a point in time.
+//
+// A Client crawls a bucket, or a key prefix, into a snapshot directory on
+// local disk. The current objects are listed in parallel with a
+// manager.Lister, and every version of the objects with
+// CrawlInput.Versions:
+//
+//	client := inventory.New(s3.NewFromConfig(cfg), inventory.Options{})
+//	out, err := client.Crawl(ctx, &inventory.CrawlInput{
+//		Bucket: "bucket",
+//		Prefix: "reports/",
+//		Path:   "snapshots/2024-05-01",
+//	})
+//
+// A snapshot holds its entries as gzip-compressed JSON lines sorted by key,
+// one line per object or version with its size, ETag and last modification
+// time, and a manifest.json recording the crawl and the checksum of the
+// entries. The manifest is written last, so that a directory holds a snapshot
+// only once its crawl is complete.
+//
+// Diff compares two snapshots, read side by side, and reports the objects
+// added, removed and modified in between by their ETag, size and last
+// modification time:
+//
+//	old, err := inventory.Open("snapshots/2024-04-01")
+//	new, err := inventory.Open("snapshots/2024-05-01")
+//	enc := json.NewEncoder(os.Stdout)
+//	out, err := inventory.Diff(old, new, func(c inventory.Change) error {
+//		return enc.Encode(c)
+//	})
+package inventory
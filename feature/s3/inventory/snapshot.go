@@ -0,0 +1,306 @@
+package inventory
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FormatVersion is the version of the snapshot format written by the client
+const FormatVersion = 1
+
+const (
+	// ManifestFile is the name of the manifest file in a snapshot directory
+	ManifestFile = "manifest.json"
+
+	// EntriesFile is the name of the entries file in a snapshot directory
+	EntriesFile = "entries.jsonl.gz"
+)
+
+// ErrSnapshotExists is returned when a snapshot is written to a directory
+// holding a snapshot already
+var ErrSnapshotExists = errors.New("snapshot already exists")
+
+// Entry is an object, or a version of an object, of a snapshot
+type Entry struct {
+	// Key of the object
+	Key string `json:"key"`
+
+	// Version ID of the object, in snapshots of versions
+	VersionID string `json:"versionId,omitempty"`
+
+	// Whether the version is the current version of the object, in
+	// snapshots of versions
+	IsLatest bool `json:"latest,omitempty"`
+
+	// Whether the version is a delete marker, in snapshots of versions
+	DeleteMarker bool `json:"deleteMarker,omitempty"`
+
+	// Size of the object in bytes
+	Size int64 `json:"size"`
+
+	// ETag of the object
+	ETag string `json:"etag,omitempty"`
+
+	// Last modification time of the object
+	LastModified time.Time `json:"lastModified"`
+
+	// Storage class of the object
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// Manifest describes a snapshot. It is written once its entries are
+// complete, so that a directory without a manifest holds no snapshot.
+type Manifest struct {
+	// Version of the snapshot format
+	FormatVersion int `json:"formatVersion"`
+
+	// Bucket crawled
+	Bucket string `json:"bucket"`
+
+	// Prefix of the keys crawled
+	Prefix string `json:"prefix,omitempty"`
+
+	// Whether the snapshot holds every version of the objects rather than
+	// their current version
+	Versions bool `json:"versions,omitempty"`
+
+	// Time the crawl started at
+	StartedAt time.Time `json:"startedAt"`
+
+	// Time the crawl completed at
+	CompletedAt time.Time `json:"completedAt"`
+
+	// Number of entries of the snapshot
+	Entries int64 `json:"entries"`
+
+	// Total size of the entries in bytes
+	TotalBytes int64 `json:"totalBytes"`
+
+	// Name of the entries file in the snapshot directory
+	EntriesFile string `json:"entriesFile"`
+
+	// Hex-encoded SHA-256 checksum of the entries file
+	EntriesSHA256 string `json:"entriesSha256"`
+}
+
+// snapshotWriter writes the entries file of a snapshot, and its manifest
+// once complete
+type snapshotWriter struct {
+	dir      string
+	manifest Manifest
+
+	f       *os.File
+	h       hash.Hash
+	gz      *gzip.Writer
+	enc     *json.Encoder
+	lastKey string
+}
+
+// newSnapshotWriter creates the snapshot directory dir and a temporary
+// entries file within it
+func newSnapshotWriter(dir string, manifest Manifest) (*snapshotWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+		return nil, fmt.Errorf("%s: %w", dir, ErrSnapshotExists)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(dir, "."+EntriesFile+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	w := &snapshotWriter{dir: dir, manifest: manifest, f: f, h: sha256.New()}
+	w.manifest.FormatVersion = FormatVersion
+	w.manifest.EntriesFile = EntriesFile
+	w.gz = gzip.NewWriter(io.MultiWriter(f, w.h))
+	w.enc = json.NewEncoder(w.gz)
+	return w, nil
+}
+
+// write appends e to the entries, which must be sorted by key
+func (w *snapshotWriter) write(e Entry) error {
+	if w.manifest.Entries > 0 && e.Key < w.lastKey {
+		return fmt.Errorf("entry %s out of order, after %s", e.Key, w.lastKey)
+	}
+	if err := w.enc.Encode(e); err != nil {
+		return err
+	}
+	w.lastKey = e.Key
+	w.manifest.Entries++
+	if !e.DeleteMarker {
+		w.manifest.TotalBytes += e.Size
+	}
+	return nil
+}
+
+// commit renames the entries file and writes the manifest of the snapshot
+func (w *snapshotWriter) commit(completedAt time.Time) (*Manifest, error) {
+	if err := w.gz.Close(); err != nil {
+		return nil, err
+	}
+	if err := w.f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(w.f.Name(), filepath.Join(w.dir, EntriesFile)); err != nil {
+		return nil, err
+	}
+	w.manifest.CompletedAt = completedAt
+	w.manifest.EntriesSHA256 = hex.EncodeToString(w.h.Sum(nil))
+
+	b, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	tmp := filepath.Join(w.dir, "."+ManifestFile+".tmp")
+	if err := os.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, filepath.Join(w.dir, ManifestFile)); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	manifest := w.manifest
+	return &manifest, nil
+}
+
+// abort removes the temporary entries file
+func (w *snapshotWriter) abort() {
+	w.f.Close()
+	os.Remove(w.f.Name())
+}
+
+// Snapshot is a snapshot of the objects of a bucket, as written by
+// Client.Crawl
+type Snapshot struct {
+	// Manifest of the snapshot
+	Manifest Manifest
+
+	dir string
+}
+
+// Open opens the snapshot in the directory dir, reading its manifest. It
+// fails if the directory holds no complete snapshot.
+func Open(dir string) (*Snapshot, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{dir: dir}
+	if err := json.Unmarshal(b, &s.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to read manifest of snapshot %s: %w", dir, err)
+	}
+	if s.Manifest.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("snapshot %s has unsupported format version %d", dir, s.Manifest.FormatVersion)
+	}
+	if s.Manifest.EntriesFile == "" || filepath.Base(s.Manifest.EntriesFile) != s.Manifest.EntriesFile {
+		return nil, fmt.Errorf("snapshot %s has invalid entries file %q", dir, s.Manifest.EntriesFile)
+	}
+	return s, nil
+}
+
+// Entries returns a reader of the entries of the snapshot, sorted by key.
+// The entries file is checked against the manifest once read to its end.
+func (s *Snapshot) Entries() (*EntryReader, error) {
+	f, err := os.Open(filepath.Join(s.dir, s.Manifest.EntriesFile))
+	if err != nil {
+		return nil, err
+	}
+	r := &EntryReader{manifest: s.Manifest, f: f, h: sha256.New()}
+	r.src = io.TeeReader(f, r.h)
+	r.gz, err = gzip.NewReader(r.src)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read entries of snapshot %s: %w", s.dir, err)
+	}
+	r.dec = json.NewDecoder(r.gz)
+	return r, nil
+}
+
+// EntryReader reads the entries of a snapshot. It is returned by
+// Snapshot.Entries, and is not safe for concurrent use.
+type EntryReader struct {
+	manifest Manifest
+	f        *os.File
+	h        hash.Hash
+	src      io.Reader
+	gz       *gzip.Reader
+	dec      *json.Decoder
+
+	entry Entry
+	read  int64
+	err   error
+}
+
+// Next advances the reader to the next entry, which is then returned by
+// Entry. It returns false once the entries are exhausted or failed, see
+// Err.
+func (r *EntryReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	var e Entry
+	if err := r.dec.Decode(&e); err == io.EOF {
+		r.err = r.verify()
+		if r.err == nil {
+			r.err = io.EOF
+		}
+		return false
+	} else if err != nil {
+		r.err = fmt.Errorf("failed to read entry %d: %w", r.read+1, err)
+		return false
+	}
+	if r.read > 0 && e.Key < r.entry.Key {
+		r.err = fmt.Errorf("entry %s out of order, after %s", e.Key, r.entry.Key)
+		return false
+	}
+	r.entry = e
+	r.read++
+	return true
+}
+
+// verify checks the entries read against the manifest
+func (r *EntryReader) verify() error {
+	// drain the trailer of the gzip stream into the checksum
+	if _, err := io.Copy(io.Discard, r.src); err != nil {
+		return err
+	}
+	if r.read != r.manifest.Entries {
+		return fmt.Errorf("snapshot has %d entries, manifest records %d", r.read, r.manifest.Entries)
+	}
+	if sum := hex.EncodeToString(r.h.Sum(nil)); sum != r.manifest.EntriesSHA256 {
+		return fmt.Errorf("entries file checksum %s does not match manifest checksum %s", sum, r.manifest.EntriesSHA256)
+	}
+	return nil
+}
+
+// Entry returns the current entry of the reader.
+func (r *EntryReader) Entry() Entry {
+	return r.entry
+}
+
+// Err returns the error which stopped the reader, if any.
+func (r *EntryReader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// Close closes the entries file.
+func (r *EntryReader) Close() error {
+	r.gz.Close()
+	return r.f.Close()
+}
@@ -0,0 +1,104 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Key: "a", VersionID: "2", IsLatest: true, Size: 10, ETag: `"x"`, LastModified: testTime, StorageClass: "STANDARD"},
+		{Key: "a", VersionID: "1", Size: 5, ETag: `"y"`, LastModified: testTime},
+		{Key: "b", VersionID: "3", IsLatest: true, DeleteMarker: true, LastModified: testTime},
+	}
+	s := writeSnapshot(t, true, entries...)
+
+	if e, a := int64(3), s.Manifest.Entries; e != a {
+		t.Errorf("expect %d entries, got %d", e, a)
+	}
+	if e, a := int64(15), s.Manifest.TotalBytes; e != a {
+		t.Errorf("expect %d bytes, got %d", e, a)
+	}
+	if e, a := FormatVersion, s.Manifest.FormatVersion; e != a {
+		t.Errorf("expect format version %d, got %d", e, a)
+	}
+	if got := readEntries(t, s.dir); !reflect.DeepEqual(entries, got) {
+		t.Errorf("expect entries %+v, got %+v", entries, got)
+	}
+}
+
+func TestSnapshotWriterOrder(t *testing.T) {
+	w, err := newSnapshotWriter(t.TempDir(), Manifest{Bucket: "bucket"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer w.abort()
+	if err := w.write(Entry{Key: "b"}); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := w.write(Entry{Key: "a"}); err == nil {
+		t.Error("expect error for an entry out of order")
+	}
+}
+
+func TestSnapshotVerify(t *testing.T) {
+	cases := map[string]func(t *testing.T, s *Snapshot){
+		"checksum": func(t *testing.T, s *Snapshot) {
+			s.Manifest.EntriesSHA256 = strings.Repeat("0", 64)
+		},
+		"entries count": func(t *testing.T, s *Snapshot) {
+			s.Manifest.Entries++
+		},
+		"truncated": func(t *testing.T, s *Snapshot) {
+			path := filepath.Join(s.dir, EntriesFile)
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if err := os.WriteFile(path, b[:len(b)-4], 0644); err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+		},
+	}
+
+	for name, corrupt := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := writeSnapshot(t, false, Entry{Key: "a", Size: 1, LastModified: testTime})
+			corrupt(t, s)
+			r, err := s.Entries()
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			defer r.Close()
+			for r.Next() {
+			}
+			if r.Err() == nil {
+				t.Error("expect error")
+			}
+		})
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Error("expect error for a directory without a manifest")
+	}
+
+	s := writeSnapshot(t, false)
+	path := filepath.Join(s.dir, ManifestFile)
+	if err := os.WriteFile(path, []byte(`{"formatVersion":2,"entriesFile":"entries.jsonl.gz"}`), 0644); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if _, err := Open(s.dir); err == nil {
+		t.Error("expect error for an unsupported format version")
+	}
+	if err := os.WriteFile(path, []byte(`{"formatVersion":1,"entriesFile":"../entries.jsonl.gz"}`), 0644); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if _, err := Open(s.dir); err == nil {
+		t.Error("expect error for an entries file outside the snapshot")
+	}
+}
@@ -0,0 +1,366 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/crn"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/logging"
+)
+
+// DefaultEncryptionAuditConcurrency is the default number of goroutines to
+// spin up when using Audit().
+const DefaultEncryptionAuditConcurrency = 10
+
+// ListBucketsAPIClient is an S3 API client that can invoke the ListBuckets operation.
+type ListBucketsAPIClient interface {
+	ListBuckets(context.Context, *s3.ListBucketsInput, ...func(*s3.Options)) (*s3.ListBucketsOutput, error)
+}
+
+// EncryptionAuditAPIClient is an S3 API client that can invoke the
+// ListBuckets and HeadBucket operations.
+type EncryptionAuditAPIClient interface {
+	ListBucketsAPIClient
+	HeadBucketAPIClient
+}
+
+// BucketEncryptionReport describes the SSE-KP configuration of a bucket.
+type BucketEncryptionReport struct {
+	// The name of the bucket.
+	Bucket string
+
+	// Whether the bucket is encrypted with a Key Protect or Hyper Protect
+	// Crypto Services root key.
+	SSEKPEnabled bool
+
+	// The CRN of the root key, as returned by HeadBucket. Empty if SSE-KP is
+	// not enabled.
+	RootKeyCRN string
+
+	// The parsed root key CRN. The zero value if RootKeyCRN is empty or not a
+	// valid root key CRN.
+	RootKey crn.CRN
+
+	// Whether the root key is in the auditor's AllowedRootKeys. Always true
+	// if no allowed list is configured and SSE-KP is enabled.
+	RootKeyAllowed bool
+
+	// The error which prevented the bucket from being checked, if any.
+	Err error
+}
+
+// Compliant returns whether the bucket satisfies the audit policy: it could
+// be checked, SSE-KP is enabled and its root key is allowed.
+func (r BucketEncryptionReport) Compliant() bool {
+	return r.Err == nil && r.SSEKPEnabled && r.RootKeyAllowed
+}
+
+// reason describes why the bucket is not compliant.
+func (r BucketEncryptionReport) reason() string {
+	switch {
+	case r.Err != nil:
+		return r.Err.Error()
+	case !r.SSEKPEnabled:
+		return "SSE-KP is not enabled"
+	case r.RootKey == (crn.CRN{}):
+		return fmt.Sprintf("root key %q is not a valid root key CRN", r.RootKeyCRN)
+	default:
+		return fmt.Sprintf("root key %s is not allowed", r.RootKeyCRN)
+	}
+}
+
+// EncryptionPolicyError is returned by EncryptionAuditOutput.Err when one or
+// more buckets do not satisfy the audit policy.
+type EncryptionPolicyError struct {
+	// The reports of the non-compliant buckets.
+	Violations []BucketEncryptionReport
+}
+
+func (e *EncryptionPolicyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d bucket(s) violate the encryption policy", len(e.Violations))
+	for _, v := range e.Violations {
+		fmt.Fprintf(&b, "\n\t%s: %s", v.Bucket, v.reason())
+	}
+	return b.String()
+}
+
+// EncryptionAuditInput provides the parameters for an encryption audit.
+type EncryptionAuditInput struct {
+	// The buckets to audit. If empty, every bucket returned by ListBuckets
+	// is audited.
+	Buckets []string
+
+	// Only audit listed buckets whose name starts with this prefix. Ignored
+	// if Buckets is set.
+	Prefix *string
+}
+
+// EncryptionAuditOutput represents a response from the Audit() call.
+type EncryptionAuditOutput struct {
+	// The report of each bucket, sorted by bucket name.
+	Reports []BucketEncryptionReport
+}
+
+// Violations returns the reports of the buckets which are not compliant.
+func (o *EncryptionAuditOutput) Violations() []BucketEncryptionReport {
+	var violations []BucketEncryptionReport
+	for _, r := range o.Reports {
+		if !r.Compliant() {
+			violations = append(violations, r)
+		}
+	}
+	return violations
+}
+
+// Err returns an *EncryptionPolicyError listing the non-compliant buckets,
+// or nil if every bucket is compliant. This is convenient for failing a CI
+// job on a policy violation.
+func (o *EncryptionAuditOutput) Err() error {
+	if v := o.Violations(); len(v) != 0 {
+		return &EncryptionPolicyError{Violations: v}
+	}
+	return nil
+}
+
+// The EncryptionAuditor structure that calls Audit(). It is safe to call
+// Audit() on this structure for multiple inputs and across concurrent
+// goroutines. Mutating the EncryptionAuditor's properties is not safe to be
+// done concurrently.
+type EncryptionAuditor struct {
+	// The number of goroutines to spin up in parallel when checking buckets.
+	// If this is set to zero, the DefaultEncryptionAuditConcurrency value
+	// will be used.
+	Concurrency int
+
+	// The root keys buckets may be encrypted with. An entry is either the
+	// CRN of a root key, or the CRN of a Key Protect or Hyper Protect Crypto
+	// Services instance to allow every key of that instance. If empty, any
+	// root key is allowed.
+	AllowedRootKeys []string
+
+	// Logger to send logging messages to
+	Logger logging.Logger
+
+	// An S3 client to use when auditing buckets.
+	S3 EncryptionAuditAPIClient
+
+	// List of client options that will be passed down to individual API
+	// operation requests made by the auditor.
+	ClientOptions []func(*s3.Options)
+}
+
+// WithEncryptionAuditorClientOptions appends to the EncryptionAuditor's API
+// request options.
+func WithEncryptionAuditorClientOptions(opts ...func(*s3.Options)) func(*EncryptionAuditor) {
+	return func(a *EncryptionAuditor) {
+		a.ClientOptions = append(a.ClientOptions, opts...)
+	}
+}
+
+// NewEncryptionAuditor creates a new EncryptionAuditor instance to check the
+// SSE-KP configuration of buckets. Pass in additional functional options to
+// customize the auditor behavior.
+//
+// Example:
+//
+//	auditor := manager.NewEncryptionAuditor(s3.NewFromConfig(cfg), func(a *manager.EncryptionAuditor) {
+//		a.AllowedRootKeys = []string{"crn:v1:bluemix:public:kms:us-south:a/<account>:<instance>::"}
+//	})
+//
+//	out, err := auditor.Audit(ctx, &manager.EncryptionAuditInput{})
+//	if err != nil {
+//		return err
+//	}
+//	return out.Err()
+func NewEncryptionAuditor(c EncryptionAuditAPIClient, options ...func(*EncryptionAuditor)) *EncryptionAuditor {
+	a := &EncryptionAuditor{
+		S3:          c,
+		Concurrency: DefaultEncryptionAuditConcurrency,
+	}
+	for _, option := range options {
+		option(a)
+	}
+
+	return a
+}
+
+// Audit reports the SSE-KP configuration of each bucket in the input, or of
+// every bucket of the service instance if none are given.
+//
+// Buckets which cannot be checked are reported with their error and are not
+// compliant. An error is only returned if the buckets could not be listed,
+// an allowed root key is not a valid CRN, or the context was canceled; use
+// EncryptionAuditOutput.Err to check the policy.
+func (a EncryptionAuditor) Audit(ctx context.Context, input *EncryptionAuditInput, options ...func(*EncryptionAuditor)) (*EncryptionAuditOutput, error) {
+	impl, err := a.newAuditor(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := input.Buckets
+	if len(buckets) == 0 {
+		if buckets, err = impl.listBuckets(ctx, input.Prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	out := &EncryptionAuditOutput{Reports: make([]BucketEncryptionReport, len(buckets))}
+
+	concurrency := impl.cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultEncryptionAuditConcurrency
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				out.Reports[i] = impl.checkBucket(ctx, buckets[i])
+			}
+		}()
+	}
+	for i := range buckets {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(out.Reports, func(i, j int) bool {
+		return out.Reports[i].Bucket < out.Reports[j].Bucket
+	})
+	return out, nil
+}
+
+// CheckBucket returns the SSE-KP configuration of a single bucket, and an
+// *EncryptionPolicyError if the bucket is not compliant. It can be used to
+// verify a bucket is protected before uploading sensitive data to it.
+func (a EncryptionAuditor) CheckBucket(ctx context.Context, bucket string, options ...func(*EncryptionAuditor)) (BucketEncryptionReport, error) {
+	impl, err := a.newAuditor(ctx, options)
+	if err != nil {
+		return BucketEncryptionReport{}, err
+	}
+
+	report := impl.checkBucket(ctx, bucket)
+	if !report.Compliant() {
+		return report, &EncryptionPolicyError{Violations: []BucketEncryptionReport{report}}
+	}
+	return report, nil
+}
+
+func (a EncryptionAuditor) newAuditor(ctx context.Context, options []func(*EncryptionAuditor)) (*encryptionAuditor, error) {
+	impl := &encryptionAuditor{cfg: a}
+
+	clientOptions := make([]func(*s3.Options), 0, len(impl.cfg.ClientOptions)+1)
+	clientOptions = append(clientOptions, func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions,
+			middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+		)
+	})
+	clientOptions = append(clientOptions, impl.cfg.ClientOptions...)
+	impl.cfg.ClientOptions = clientOptions
+
+	for _, option := range options {
+		option(&impl.cfg)
+	}
+
+	impl.cfg.Logger = logging.WithContext(ctx, impl.cfg.Logger)
+
+	for _, entry := range impl.cfg.AllowedRootKeys {
+		allowed, err := crn.Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed root key %q: %w", entry, err)
+		}
+		impl.allowed = append(impl.allowed, allowed)
+	}
+	return impl, nil
+}
+
+// encryptionAuditor is the implementation structure used internally by
+// EncryptionAuditor.
+type encryptionAuditor struct {
+	cfg     EncryptionAuditor
+	allowed []crn.CRN
+}
+
+func (a *encryptionAuditor) listBuckets(ctx context.Context, prefix *string) ([]string, error) {
+	var buckets []string
+	p := s3.NewListBucketsPaginator(a.cfg.S3, &s3.ListBucketsInput{Prefix: prefix})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx, a.cfg.ClientOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("list buckets: %w", err)
+		}
+		for _, b := range page.Buckets {
+			buckets = append(buckets, aws.ToString(b.Name))
+		}
+	}
+	return buckets, nil
+}
+
+func (a *encryptionAuditor) checkBucket(ctx context.Context, bucket string) BucketEncryptionReport {
+	report := BucketEncryptionReport{Bucket: bucket}
+
+	head, err := a.cfg.S3.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucket),
+	}, a.cfg.ClientOptions...)
+	if err != nil {
+		a.cfg.Logger.Logf(logging.Debug, "failed to check encryption of bucket %s, %v", bucket, err)
+		report.Err = err
+		return report
+	}
+
+	report.SSEKPEnabled = aws.ToBool(head.IBMSSEKPEnabled)
+	report.RootKeyCRN = aws.ToString(head.IBMSSEKPCrkId)
+	if !report.SSEKPEnabled {
+		return report
+	}
+
+	key, err := crn.ParseRootKey(report.RootKeyCRN)
+	if err != nil {
+		a.cfg.Logger.Logf(logging.Debug, "bucket %s has invalid root key %q, %v", bucket, report.RootKeyCRN, err)
+		return report
+	}
+	report.RootKey = key
+	report.RootKeyAllowed = a.isAllowed(key)
+	return report
+}
+
+// isAllowed returns whether the root key matches an allowed key, or belongs
+// to an allowed key management instance.
+func (a *encryptionAuditor) isAllowed(key crn.CRN) bool {
+	if len(a.allowed) == 0 {
+		return true
+	}
+	for _, allowed := range a.allowed {
+		if allowed == key {
+			return true
+		}
+		if len(allowed.ResourceType) == 0 && len(allowed.Resource) == 0 {
+			instance := key
+			instance.ResourceType, instance.Resource = "", ""
+			if allowed == instance {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+const (
+	testKMSInstance = "crn:v1:bluemix:public:kms:us-south:a/acct:kms-instance::"
+	testRootKey     = "crn:v1:bluemix:public:kms:us-south:a/acct:kms-instance:key:key-1"
+	testOtherKey    = "crn:v1:bluemix:public:hs-crypto:us-east:a/acct:hpcs-instance:key:key-2"
+)
+
+type mockEncryptionAuditClient map[string]*s3.HeadBucketOutput
+
+func (c mockEncryptionAuditClient) ListBuckets(ctx context.Context, in *s3.ListBucketsInput, _ ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	out := &s3.ListBucketsOutput{}
+	for _, name := range []string{"plain", "protected", "other-key", "missing"} {
+		if strings.HasPrefix(name, aws.ToString(in.Prefix)) {
+			out.Buckets = append(out.Buckets, types.Bucket{Name: aws.String(name)})
+		}
+	}
+	return out, nil
+}
+
+func (c mockEncryptionAuditClient) HeadBucket(ctx context.Context, in *s3.HeadBucketInput, _ ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	head, ok := c[aws.ToString(in.Bucket)]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return head, nil
+}
+
+func newMockEncryptionAuditClient() mockEncryptionAuditClient {
+	return mockEncryptionAuditClient{
+		"plain":     {},
+		"protected": {IBMSSEKPEnabled: aws.Bool(true), IBMSSEKPCrkId: aws.String(testRootKey)},
+		"other-key": {IBMSSEKPEnabled: aws.Bool(true), IBMSSEKPCrkId: aws.String(testOtherKey)},
+	}
+}
+
+func TestEncryptionAuditor_Audit(t *testing.T) {
+	auditor := NewEncryptionAuditor(newMockEncryptionAuditClient(), func(a *EncryptionAuditor) {
+		a.AllowedRootKeys = []string{testKMSInstance}
+	})
+
+	out, err := auditor.Audit(context.Background(), &EncryptionAuditInput{})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	reports := map[string]BucketEncryptionReport{}
+	for _, r := range out.Reports {
+		reports[r.Bucket] = r
+	}
+	if e, a := 4, len(reports); e != a {
+		t.Fatalf("expect %d reports, got %d", e, a)
+	}
+	if r := reports["protected"]; !r.Compliant() || r.RootKey.Resource != "key-1" {
+		t.Errorf("expect protected bucket to be compliant, got %+v", r)
+	}
+	if r := reports["plain"]; r.Compliant() || r.SSEKPEnabled {
+		t.Errorf("expect plain bucket to be non-compliant, got %+v", r)
+	}
+	if r := reports["other-key"]; r.Compliant() || !r.SSEKPEnabled || r.RootKeyAllowed {
+		t.Errorf("expect bucket with other key to be non-compliant, got %+v", r)
+	}
+	if r := reports["missing"]; r.Compliant() || r.Err == nil {
+		t.Errorf("expect missing bucket to report error, got %+v", r)
+	}
+
+	var policyErr *EncryptionPolicyError
+	if !errors.As(out.Err(), &policyErr) {
+		t.Fatalf("expect policy error, got %v", out.Err())
+	}
+	if e, a := 3, len(policyErr.Violations); e != a {
+		t.Errorf("expect %d violations, got %d", e, a)
+	}
+	if e, a := "other-key: root key "+testOtherKey+" is not allowed", policyErr.Error(); !strings.Contains(a, e) {
+		t.Errorf("expect error to contain %q, got %q", e, a)
+	}
+}
+
+func TestEncryptionAuditor_AuditBuckets(t *testing.T) {
+	auditor := NewEncryptionAuditor(newMockEncryptionAuditClient())
+
+	out, err := auditor.Audit(context.Background(), &EncryptionAuditInput{
+		Buckets: []string{"protected", "other-key"},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := out.Err(); err != nil {
+		t.Errorf("expect any root key to be allowed, got %v", err)
+	}
+
+	out, err = auditor.Audit(context.Background(), &EncryptionAuditInput{
+		Buckets: []string{"protected", "other-key"},
+	}, func(a *EncryptionAuditor) {
+		a.AllowedRootKeys = []string{testOtherKey}
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if v := out.Violations(); len(v) != 1 || v[0].Bucket != "protected" {
+		t.Errorf("expect only protected bucket to violate, got %+v", v)
+	}
+
+	_, err = auditor.Audit(context.Background(), &EncryptionAuditInput{}, func(a *EncryptionAuditor) {
+		a.AllowedRootKeys = []string{"key-1"}
+	})
+	if err == nil {
+		t.Errorf("expect invalid allowed key error")
+	}
+}
+
+func TestEncryptionAuditor_CheckBucket(t *testing.T) {
+	auditor := NewEncryptionAuditor(newMockEncryptionAuditClient())
+
+	report, err := auditor.CheckBucket(context.Background(), "protected")
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := testRootKey, report.RootKeyCRN; e != a {
+		t.Errorf("expect root key %v, got %v", e, a)
+	}
+
+	_, err = auditor.CheckBucket(context.Background(), "plain")
+	if err == nil || !strings.Contains(err.Error(), "SSE-KP is not enabled") {
+		t.Errorf("expect SSE-KP not enabled error, got %v", err)
+	}
+}
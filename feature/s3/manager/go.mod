@@ -9,7 +9,7 @@ require (
 	github.com/IBM/ibm-cos-sdk-go-v2/config v1.29.14
 	github.com/IBM/ibm-cos-sdk-go-v2/credentials v1.17.67
 	github.com/IBM/ibm-cos-sdk-go-v2/service/s3 v1.79.3
-	github.com/aws/smithy-go v1.22.2
+	github.com/aws/smithy-go v1.24.0
 )
 
 require (
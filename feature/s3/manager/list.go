@@ -0,0 +1,775 @@
+package manager
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/logging"
+)
+
+// DefaultListConcurrency is the default number of ListObjectsV2 requests
+// in flight when using List() or ListUnordered().
+const DefaultListConcurrency = 16
+
+// DefaultListMaxPartitions is the default number of partitions the key
+// space is split into when partitions are discovered by delimiter
+// exploration.
+const DefaultListMaxPartitions = 256
+
+// DefaultListDelimiter is the default delimiter prefixes are explored with.
+const DefaultListDelimiter = "/"
+
+// ListPartition is a range of the keys of a bucket listed by a single
+// paginator. The keys of a partition start with Prefix, sort after
+// StartAfter, and sort before or equal to EndAt if it is set.
+//
+// Partitions listed together must not overlap, or the keys they share are
+// listed more than once.
+type ListPartition struct {
+	// The prefix of the keys of the partition.
+	Prefix string
+
+	// The keys of the partition sort strictly after StartAfter.
+	StartAfter string
+
+	// If set, the keys of the partition sort before or equal to EndAt.
+	EndAt string
+
+	// If set, only the keys without Delimiter after Prefix are part of the
+	// partition, those with a delimiter being covered by other partitions.
+	Delimiter string
+}
+
+// SplitListPartitions returns a split plan of the keys under prefix into
+// contiguous partitions separated by the boundaries: the keys up to the
+// first boundary, those after it up to the next boundary, and so on until
+// the keys after the last boundary. A key equal to a boundary is part of the
+// partition ending at it.
+//
+// Example:
+//
+//	// [..., "logs/g"], ("logs/g", "logs/p"], ("logs/p", ...]
+//	partitions := manager.SplitListPartitions("logs/", "logs/g", "logs/p")
+func SplitListPartitions(prefix string, boundaries ...string) []ListPartition {
+	sorted := append([]string{}, boundaries...)
+	sort.Strings(sorted)
+
+	var partitions []ListPartition
+	startAfter := ""
+	for i, b := range sorted {
+		if i > 0 && b == sorted[i-1] {
+			continue
+		}
+		partitions = append(partitions, ListPartition{Prefix: prefix, StartAfter: startAfter, EndAt: b})
+		startAfter = b
+	}
+	return append(partitions, ListPartition{Prefix: prefix, StartAfter: startAfter})
+}
+
+// ListPartitionState is the progress of the listing of a partition.
+type ListPartitionState struct {
+	// The partition.
+	Partition ListPartition
+
+	// The last key of the partition delivered, the listing of the partition
+	// resumes after it.
+	LastKey string
+
+	// Whether every key of the partition was delivered.
+	Done bool
+}
+
+// ListCheckpoint persists the partitions of a parallel listing and the
+// progress of each, so that a listing which was interrupted can be resumed.
+// Progress is recorded once a page of keys was delivered, so that the keys
+// of the last pages delivered before an interruption may be listed again.
+//
+// Implementations must be safe for concurrent use.
+type ListCheckpoint interface {
+	// Load returns the last recorded state of each partition, in the order
+	// they were first recorded, or none if the listing has not started.
+	Load() ([]ListPartitionState, error)
+
+	// Save records the state of a partition.
+	Save(ListPartitionState) error
+}
+
+// ListInput provides the parameters for a parallel listing.
+type ListInput struct {
+	// The bucket to list.
+	//
+	// This member is required.
+	Bucket *string
+
+	// The prefix of the keys to list, when the partitions are discovered.
+	Prefix *string
+
+	// A split plan of the keys to list, e.g. from SplitListPartitions. If
+	// empty, the partitions are discovered by exploring the prefixes under
+	// Prefix with Delimiter, up to Lister.MaxPartitions partitions.
+	Partitions []ListPartition
+
+	// The delimiter prefixes are explored with. If empty, the
+	// DefaultListDelimiter value will be used.
+	Delimiter string
+
+	// Progress store used to resume a listing interrupted in a previous
+	// run. When it holds the partitions of a previous run, they are listed
+	// from where they stopped rather than planned again. May be nil.
+	Checkpoint ListCheckpoint
+}
+
+// ListOutput represents a response from the ListUnordered() call.
+type ListOutput struct {
+	// The partitions listed.
+	Partitions []ListPartition
+
+	// The number of objects listed.
+	ObjectsListed int64
+}
+
+// The Lister structure that calls List() and ListUnordered(). It is safe to
+// call them on this structure for multiple inputs and across concurrent
+// goroutines. Mutating the Lister's properties is not safe to be done
+// concurrently.
+type Lister struct {
+	// The maximum number of ListObjectsV2 requests in flight across the
+	// partitions. If this is set to zero, the DefaultListConcurrency value
+	// will be used.
+	Concurrency int
+
+	// The number of partitions the key space is split into at most when
+	// partitions are discovered. If this is set to zero, the
+	// DefaultListMaxPartitions value will be used.
+	MaxPartitions int
+
+	// Logger to send logging messages to
+	Logger logging.Logger
+
+	// An S3 client to use when listing objects.
+	S3 ListObjectsV2APIClient
+
+	// List of client options that will be passed down to individual API
+	// operation requests made by the lister.
+	ClientOptions []func(*s3.Options)
+}
+
+// WithListerClientOptions appends to the Lister's API request options.
+func WithListerClientOptions(opts ...func(*s3.Options)) func(*Lister) {
+	return func(l *Lister) {
+		l.ClientOptions = append(l.ClientOptions, opts...)
+	}
+}
+
+// NewLister creates a new Lister instance to list the objects of a bucket
+// with concurrent paginators over partitions of its keys. Pass in additional
+// functional options to customize the lister behavior.
+//
+// Example:
+//
+//	lister := manager.NewLister(s3.NewFromConfig(cfg), func(l *manager.Lister) {
+//		l.Concurrency = 32
+//	})
+//
+//	stream, err := lister.List(ctx, &manager.ListInput{
+//		Bucket:     aws.String("bucket"),
+//		Checkpoint: manager.NewFileListCheckpoint("crawl.progress"),
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	for stream.Next() {
+//		fmt.Println(aws.ToString(stream.Object().Key))
+//	}
+//	if err := stream.Err(); err != nil {
+//		return err
+//	}
+func NewLister(c ListObjectsV2APIClient, options ...func(*Lister)) *Lister {
+	l := &Lister{
+		S3:            c,
+		Concurrency:   DefaultListConcurrency,
+		MaxPartitions: DefaultListMaxPartitions,
+	}
+	for _, option := range options {
+		option(l)
+	}
+
+	return l
+}
+
+// List lists the objects of the partitions concurrently and returns them as
+// a stream in lexical order of their keys, merged from the pages of the
+// partitions. The partitions are planned before List returns, and the
+// returned error is that of the planning.
+//
+// The stream must be closed once done with, to stop the listing of the
+// partitions.
+func (l Lister) List(ctx context.Context, input *ListInput, options ...func(*Lister)) (*ListStream, error) {
+	impl, err := newLister(ctx, l, input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ListStream{l: impl, cursors: make(listCursors, 0, len(impl.partitions))}
+	for _, p := range impl.partitions {
+		pages := make(chan []types.Object, 1)
+		s.channels = append(s.channels, pages)
+		impl.wg.Add(1)
+		go func(p ListPartition) {
+			defer impl.wg.Done()
+			defer close(pages)
+			impl.listPartition(p, func(page []types.Object) error {
+				select {
+				case pages <- page:
+					return nil
+				case <-impl.ctx.Done():
+					return impl.ctx.Err()
+				}
+			})
+		}(p)
+	}
+	return s, nil
+}
+
+// ListUnordered lists the objects of the partitions concurrently and sends
+// them to objects as they are listed, in no particular order. It blocks
+// until every partition is listed, and closes objects once it returns.
+//
+// The listing stops at the first error, which is returned along with the
+// output of the objects listed until then.
+func (l Lister) ListUnordered(ctx context.Context, input *ListInput, objects chan<- types.Object, options ...func(*Lister)) (*ListOutput, error) {
+	defer close(objects)
+	impl, err := newLister(ctx, l, input, options)
+	if err != nil {
+		return nil, err
+	}
+	defer impl.cancel()
+
+	var m sync.Mutex
+	var listed int64
+	for _, p := range impl.partitions {
+		impl.wg.Add(1)
+		go func(p ListPartition) {
+			defer impl.wg.Done()
+			done := impl.listPartition(p, func(page []types.Object) error {
+				for _, obj := range page {
+					select {
+					case objects <- obj:
+					case <-impl.ctx.Done():
+						return impl.ctx.Err()
+					}
+				}
+				m.Lock()
+				listed += int64(len(page))
+				m.Unlock()
+				return impl.save(ListPartitionState{Partition: p, LastKey: aws.ToString(page[len(page)-1].Key)})
+			})
+			if done {
+				if err := impl.save(ListPartitionState{Partition: p, Done: true}); err != nil {
+					impl.fail(err)
+				}
+			}
+		}(p)
+	}
+	impl.wg.Wait()
+
+	out := &ListOutput{Partitions: impl.partitions, ObjectsListed: listed}
+	if err := impl.geterr(); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// lister is the implementation structure used internally by Lister.
+type lister struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	cfg    Lister
+	in     *ListInput
+
+	// the partitions listed, and the key each resumes after
+	partitions []ListPartition
+	resume     map[ListPartition]string
+
+	// bounds the ListObjectsV2 requests in flight
+	requests chan struct{}
+	wg       sync.WaitGroup
+
+	m   sync.Mutex
+	err error
+}
+
+func newLister(ctx context.Context, cfg Lister, input *ListInput, options []func(*Lister)) (*lister, error) {
+	l := &lister{in: input, cfg: cfg}
+
+	clientOptions := make([]func(*s3.Options), 0, len(l.cfg.ClientOptions)+1)
+	clientOptions = append(clientOptions, func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions,
+			middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+		)
+	})
+	clientOptions = append(clientOptions, l.cfg.ClientOptions...)
+	l.cfg.ClientOptions = clientOptions
+
+	for _, option := range options {
+		option(&l.cfg)
+	}
+
+	l.cfg.Logger = logging.WithContext(ctx, l.cfg.Logger)
+
+	if err := l.init(ctx); err != nil {
+		return nil, err
+	}
+	l.ctx, l.cancel = context.WithCancel(ctx)
+	return l, nil
+}
+
+func (l *lister) init(ctx context.Context) error {
+	if l.in.Bucket == nil {
+		return fmt.Errorf("bucket is required")
+	}
+	if l.cfg.Concurrency <= 0 {
+		l.cfg.Concurrency = DefaultListConcurrency
+	}
+	if l.cfg.MaxPartitions <= 0 {
+		l.cfg.MaxPartitions = DefaultListMaxPartitions
+	}
+	l.requests = make(chan struct{}, l.cfg.Concurrency)
+	l.resume = map[ListPartition]string{}
+
+	if l.in.Checkpoint != nil {
+		states, err := l.in.Checkpoint.Load()
+		if err != nil {
+			return fmt.Errorf("load list checkpoint: %w", err)
+		}
+		if len(states) > 0 {
+			for _, s := range states {
+				if s.Done {
+					continue
+				}
+				l.partitions = append(l.partitions, s.Partition)
+				l.resume[s.Partition] = s.LastKey
+			}
+			l.cfg.Logger.Logf(logging.Debug, "resuming listing of %d of %d partitions from checkpoint", len(l.partitions), len(states))
+			return nil
+		}
+	}
+
+	if len(l.in.Partitions) > 0 {
+		l.partitions = append([]ListPartition{}, l.in.Partitions...)
+	} else {
+		partitions, err := l.explore(ctx)
+		if err != nil {
+			return err
+		}
+		l.partitions = partitions
+	}
+
+	// the plan is recorded before any partition is listed, so that it is
+	// resumed as is rather than planned again
+	for _, p := range l.partitions {
+		if err := l.save(ListPartitionState{Partition: p}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// explore discovers the partitions of the keys under the input prefix,
+// breadth first: a prefix is split into the prefixes found under it with
+// the delimiter, and a partition of the keys directly under it, until the
+// number of partitions reaches Lister.MaxPartitions. A prefix which has more
+// keys and prefixes directly under it than a single page holds is not split.
+func (l *lister) explore(ctx context.Context) ([]ListPartition, error) {
+	delimiter := l.in.Delimiter
+	if delimiter == "" {
+		delimiter = DefaultListDelimiter
+	}
+
+	var partitions []ListPartition
+	queue := []string{aws.ToString(l.in.Prefix)}
+	for len(queue) > 0 {
+		prefix := queue[0]
+		queue = queue[1:]
+		if len(partitions)+len(queue)+1 >= l.cfg.MaxPartitions {
+			partitions = append(partitions, ListPartition{Prefix: prefix})
+			continue
+		}
+
+		out, err := l.cfg.S3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:    l.in.Bucket,
+			Prefix:    aws.String(prefix),
+			Delimiter: aws.String(delimiter),
+		}, l.cfg.ClientOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("explore prefix %q: %w", prefix, err)
+		}
+		// the prefix is split only if its common prefixes, and its own keys,
+		// fit in the partitions left
+		split := len(partitions) + len(queue) + len(out.CommonPrefixes) + 1
+		if aws.ToBool(out.IsTruncated) || len(out.CommonPrefixes) == 0 || split > l.cfg.MaxPartitions {
+			partitions = append(partitions, ListPartition{Prefix: prefix})
+			continue
+		}
+		if len(out.Contents) > 0 {
+			partitions = append(partitions, ListPartition{Prefix: prefix, Delimiter: delimiter})
+		}
+		for _, p := range out.CommonPrefixes {
+			queue = append(queue, aws.ToString(p.Prefix))
+		}
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].Prefix != partitions[j].Prefix {
+			return partitions[i].Prefix < partitions[j].Prefix
+		}
+		return partitions[i].Delimiter > partitions[j].Delimiter
+	})
+	l.cfg.Logger.Logf(logging.Debug, "discovered %d partitions under %q", len(partitions), aws.ToString(l.in.Prefix))
+	return partitions, nil
+}
+
+// listPartition lists the keys of the partition from where it resumes,
+// calling fn with each non-empty page of them. It returns whether the
+// partition was listed to its end, the first error stopping every
+// partition.
+func (l *lister) listPartition(p ListPartition, fn func([]types.Object) error) bool {
+	startAfter := p.StartAfter
+	if last := l.resume[p]; last > startAfter {
+		startAfter = last
+	}
+	input := &s3.ListObjectsV2Input{
+		Bucket: l.in.Bucket,
+		Prefix: aws.String(p.Prefix),
+	}
+	if startAfter != "" {
+		input.StartAfter = aws.String(startAfter)
+	}
+	if p.Delimiter != "" {
+		input.Delimiter = aws.String(p.Delimiter)
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(l.cfg.S3, input)
+	for paginator.HasMorePages() {
+		select {
+		case l.requests <- struct{}{}:
+		case <-l.ctx.Done():
+			l.fail(l.ctx.Err())
+			return false
+		}
+		page, err := paginator.NextPage(l.ctx, l.cfg.ClientOptions...)
+		<-l.requests
+		if err != nil {
+			l.fail(fmt.Errorf("list partition %q after %q: %w", p.Prefix, startAfter, err))
+			return false
+		}
+
+		objects := page.Contents
+		end := false
+		if p.EndAt != "" {
+			n := sort.Search(len(objects), func(i int) bool {
+				return aws.ToString(objects[i].Key) > p.EndAt
+			})
+			end = n < len(objects)
+			objects = objects[:n]
+		}
+		if len(objects) > 0 {
+			if err := fn(objects); err != nil {
+				l.fail(err)
+				return false
+			}
+		}
+		if end {
+			break
+		}
+	}
+	return true
+}
+
+// save records the state of a partition if there is a checkpoint.
+func (l *lister) save(state ListPartitionState) error {
+	if l.in.Checkpoint == nil {
+		return nil
+	}
+	if err := l.in.Checkpoint.Save(state); err != nil {
+		return fmt.Errorf("save list checkpoint: %w", err)
+	}
+	return nil
+}
+
+// fail records the first error of the listing and stops it.
+func (l *lister) fail(err error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.err == nil {
+		l.err = err
+		l.cancel()
+	}
+}
+
+func (l *lister) geterr() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.err
+}
+
+// ListStream is a stream of the objects of a parallel listing, in lexical
+// order of their keys. It is returned by List, and is not safe for
+// concurrent use.
+type ListStream struct {
+	l        *lister
+	channels []chan []types.Object
+
+	started bool
+	cursors listCursors
+	object  types.Object
+	listed  int64
+	err     error
+}
+
+// Next advances the stream to the next object, which is then returned by
+// Object. It returns false once the stream is exhausted or failed, see Err.
+func (s *ListStream) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	if !s.started {
+		s.started = true
+		for i := range s.channels {
+			if !s.advance(&listCursor{partition: i}) {
+				return false
+			}
+		}
+	} else if len(s.cursors) > 0 {
+		c := s.cursors[0]
+		c.i++
+		if c.i < len(c.page) {
+			heap.Fix(&s.cursors, 0)
+		} else {
+			heap.Pop(&s.cursors)
+			if !s.advance(c) {
+				return false
+			}
+		}
+	}
+
+	if len(s.cursors) == 0 {
+		s.err = s.l.geterr()
+		return false
+	}
+	s.object = s.cursors[0].page[s.cursors[0].i]
+	s.listed++
+	return true
+}
+
+// advance moves the cursor to the next page of its partition, pushing it
+// onto the heap unless the partition is exhausted. The pages consumed, and
+// the partitions exhausted, are recorded in the checkpoint.
+func (s *ListStream) advance(c *listCursor) bool {
+	p := s.l.partitions[c.partition]
+	if len(c.page) > 0 {
+		state := ListPartitionState{Partition: p, LastKey: aws.ToString(c.page[len(c.page)-1].Key)}
+		if err := s.l.save(state); err != nil {
+			s.l.fail(err)
+			s.err = err
+			return false
+		}
+	}
+
+	page, ok := <-s.channels[c.partition]
+	if !ok {
+		if err := s.l.geterr(); err != nil {
+			s.err = err
+			return false
+		}
+		if err := s.l.save(ListPartitionState{Partition: p, Done: true}); err != nil {
+			s.l.fail(err)
+			s.err = err
+			return false
+		}
+		return true
+	}
+	c.page, c.i = page, 0
+	heap.Push(&s.cursors, c)
+	return true
+}
+
+// Object returns the current object of the stream.
+func (s *ListStream) Object() types.Object {
+	return s.object
+}
+
+// Err returns the error which stopped the stream, if any.
+func (s *ListStream) Err() error {
+	return s.err
+}
+
+// Partitions returns the partitions listed.
+func (s *ListStream) Partitions() []ListPartition {
+	return s.l.partitions
+}
+
+// ObjectsListed returns the number of objects returned by the stream so
+// far.
+func (s *ListStream) ObjectsListed() int64 {
+	return s.listed
+}
+
+// Close stops the listing of the partitions and waits for it to return.
+func (s *ListStream) Close() error {
+	s.l.cancel()
+	s.l.wg.Wait()
+	return nil
+}
+
+// listCursor is the position of the stream in the current page of a
+// partition.
+type listCursor struct {
+	partition int
+	page      []types.Object
+	i         int
+}
+
+func (c *listCursor) key() string {
+	return aws.ToString(c.page[c.i].Key)
+}
+
+// listCursors is a heap of the cursors of the partitions by current key.
+type listCursors []*listCursor
+
+func (h listCursors) Len() int { return len(h) }
+func (h listCursors) Less(i, j int) bool {
+	if ki, kj := h[i].key(), h[j].key(); ki != kj {
+		return ki < kj
+	}
+	return h[i].partition < h[j].partition
+}
+func (h listCursors) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *listCursors) Push(x any)   { *h = append(*h, x.(*listCursor)) }
+func (h *listCursors) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// FileListCheckpoint is a ListCheckpoint which appends each recorded state
+// to a local file as a line of JSON.
+type FileListCheckpoint struct {
+	path string
+	m    sync.Mutex
+
+	// set when the file ends in a partially written line
+	needsNewline bool
+}
+
+// NewFileListCheckpoint returns a ListCheckpoint persisted to the file at
+// path. The file is created on the first Save if it does not exist.
+func NewFileListCheckpoint(path string) *FileListCheckpoint {
+	return &FileListCheckpoint{path: path}
+}
+
+type listCheckpointEntry struct {
+	Prefix     string `json:"prefix"`
+	StartAfter string `json:"startAfter,omitempty"`
+	EndAt      string `json:"endAt,omitempty"`
+	Delimiter  string `json:"delimiter,omitempty"`
+	LastKey    string `json:"lastKey,omitempty"`
+	Done       bool   `json:"done,omitempty"`
+}
+
+// Load returns the last recorded state of each partition in the file. A
+// missing file yields no state.
+func (c *FileListCheckpoint) Load() ([]ListPartitionState, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var states []ListPartitionState
+	index := map[ListPartition]int{}
+	f, err := os.Open(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			c.needsNewline = line[len(line)-1] != '\n'
+
+			var entry listCheckpointEntry
+			// a partially written trailing line is expected after a crash
+			if jsonErr := json.Unmarshal(line, &entry); jsonErr == nil {
+				state := ListPartitionState{
+					Partition: ListPartition{
+						Prefix:     entry.Prefix,
+						StartAfter: entry.StartAfter,
+						EndAt:      entry.EndAt,
+						Delimiter:  entry.Delimiter,
+					},
+					LastKey: entry.LastKey,
+					Done:    entry.Done,
+				}
+				if i, ok := index[state.Partition]; ok {
+					states[i] = state
+				} else {
+					index[state.Partition] = len(states)
+					states = append(states, state)
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return states, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Save appends the state of a partition to the file.
+func (c *FileListCheckpoint) Save(state ListPartitionState) error {
+	line, err := json.Marshal(listCheckpointEntry{
+		Prefix:     state.Partition.Prefix,
+		StartAfter: state.Partition.StartAfter,
+		EndAt:      state.Partition.EndAt,
+		Delimiter:  state.Partition.Delimiter,
+		LastKey:    state.LastKey,
+		Done:       state.Done,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if c.needsNewline {
+		line = append([]byte{'\n'}, line...)
+		c.needsNewline = false
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
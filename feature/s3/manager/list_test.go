@@ -0,0 +1,390 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+// mockListClient lists a sorted set of keys in pages of pageSize entries,
+// keys and common prefixes alike.
+type mockListClient struct {
+	keys     []string
+	pageSize int
+	delay    time.Duration
+
+	// returns an error failing the request, if not nil
+	failFn func(*s3.ListObjectsV2Input) error
+
+	m           sync.Mutex
+	calls       []s3.ListObjectsV2Input
+	inFlight    int
+	maxInFlight int
+}
+
+func newMockListClient(keys ...string) *mockListClient {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	return &mockListClient{keys: sorted, pageSize: 3}
+}
+
+func (c *mockListClient) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	c.m.Lock()
+	c.calls = append(c.calls, *in)
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.m.Unlock()
+	defer func() {
+		c.m.Lock()
+		c.inFlight--
+		c.m.Unlock()
+	}()
+	time.Sleep(c.delay)
+
+	if c.failFn != nil {
+		if err := c.failFn(in); err != nil {
+			return nil, err
+		}
+	}
+
+	prefix, delimiter := aws.ToString(in.Prefix), aws.ToString(in.Delimiter)
+	type entry struct {
+		key, commonPrefix string
+	}
+	var entries []entry
+	for _, k := range c.keys {
+		if !strings.HasPrefix(k, prefix) || k <= aws.ToString(in.StartAfter) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				cp := k[:len(prefix)+i+len(delimiter)]
+				if n := len(entries); n == 0 || entries[n-1].commonPrefix != cp {
+					entries = append(entries, entry{commonPrefix: cp})
+				}
+				continue
+			}
+		}
+		entries = append(entries, entry{key: k})
+	}
+
+	start := 0
+	if in.ContinuationToken != nil {
+		start, _ = strconv.Atoi(aws.ToString(in.ContinuationToken))
+	}
+	end := min(start+c.pageSize, len(entries))
+	out := &s3.ListObjectsV2Output{}
+	for _, e := range entries[start:end] {
+		if e.key != "" {
+			out.Contents = append(out.Contents, types.Object{Key: aws.String(e.key)})
+		} else {
+			out.CommonPrefixes = append(out.CommonPrefixes, types.CommonPrefix{Prefix: aws.String(e.commonPrefix)})
+		}
+	}
+	if end < len(entries) {
+		out.IsTruncated = aws.Bool(true)
+		out.NextContinuationToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func listTestKeys() []string {
+	return []string{
+		"a/1", "a/2", "a/3", "a/4", "a/5",
+		"b.txt",
+		"b/x/1", "b/x/2", "b/y/1",
+		"c/1",
+		"d.txt", "e.txt",
+	}
+}
+
+// collect returns the keys of the stream
+func collect(t *testing.T, s *ListStream) []string {
+	t.Helper()
+	var keys []string
+	for s.Next() {
+		keys = append(keys, aws.ToString(s.Object().Key))
+	}
+	return keys
+}
+
+func TestLister_ListExplore(t *testing.T) {
+	client := newMockListClient(listTestKeys()...)
+	client.pageSize = 10
+	l := NewLister(client, func(l *Lister) { l.Concurrency = 3 })
+
+	s, err := l.List(context.Background(), &ListInput{Bucket: aws.String("bucket")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer s.Close()
+
+	keys := collect(t, s)
+	if err := s.Err(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	expect := listTestKeys()
+	sort.Strings(expect)
+	if !reflect.DeepEqual(expect, keys) {
+		t.Errorf("expect keys %v, got %v", expect, keys)
+	}
+	if e, a := int64(len(expect)), s.ObjectsListed(); e != a {
+		t.Errorf("expect %d objects listed, got %d", e, a)
+	}
+
+	// b/ is split into its prefixes, without a partition of the keys
+	// directly under it as there are none
+	partitions := []ListPartition{
+		{Prefix: "", Delimiter: "/"},
+		{Prefix: "a/"},
+		{Prefix: "b/x/"},
+		{Prefix: "b/y/"},
+		{Prefix: "c/"},
+	}
+	if !reflect.DeepEqual(partitions, s.Partitions()) {
+		t.Errorf("expect partitions %v, got %v", partitions, s.Partitions())
+	}
+}
+
+func TestLister_ExploreMaxPartitions(t *testing.T) {
+	client := newMockListClient(listTestKeys()...)
+	client.pageSize = 10
+	l := NewLister(client, func(l *Lister) { l.MaxPartitions = 3 })
+
+	s, err := l.List(context.Background(), &ListInput{Bucket: aws.String("bucket")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer s.Close()
+	keys := collect(t, s)
+	if e, a := len(listTestKeys()), len(keys); e != a {
+		t.Errorf("expect %d keys, got %d", e, a)
+	}
+	if n := len(s.Partitions()); n > 3 {
+		t.Errorf("expect partitions to be bounded, got %d: %v", n, s.Partitions())
+	}
+
+	// the prefixes of a split must all fit in the partitions left
+	client = newMockListClient("a/1", "b/1", "c/1", "d/1", "e/1", "f/1")
+	client.pageSize = 10
+	s, err = NewLister(client, func(l *Lister) { l.MaxPartitions = 2 }).List(context.Background(), &ListInput{Bucket: aws.String("bucket")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer s.Close()
+	if e, a := 6, len(collect(t, s)); e != a {
+		t.Errorf("expect %d keys, got %d", e, a)
+	}
+	if e, a := []ListPartition{{}}, s.Partitions(); !reflect.DeepEqual(e, a) {
+		t.Errorf("expect partitions %v, got %v", e, a)
+	}
+
+	// a prefix with more entries than a page is not explored
+	client = newMockListClient(listTestKeys()...)
+	client.pageSize = 2
+	s, err = NewLister(client).List(context.Background(), &ListInput{Bucket: aws.String("bucket")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer s.Close()
+	if e, a := []ListPartition{{}}, s.Partitions(); !reflect.DeepEqual(e, a) {
+		t.Errorf("expect partitions %v, got %v", e, a)
+	}
+}
+
+func TestLister_ListUnorderedSplitPlan(t *testing.T) {
+	var keys []string
+	for i := 0; i < 100; i++ {
+		keys = append(keys, fmt.Sprintf("k%03d", i))
+	}
+	client := newMockListClient(keys...)
+	client.delay = time.Millisecond
+	l := NewLister(client, func(l *Lister) { l.Concurrency = 2 })
+
+	objects := make(chan types.Object)
+	var listed []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for obj := range objects {
+			listed = append(listed, aws.ToString(obj.Key))
+		}
+	}()
+	out, err := l.ListUnordered(context.Background(), &ListInput{
+		Bucket:     aws.String("bucket"),
+		Partitions: SplitListPartitions("k", "k050", "k025", "k075"),
+	}, objects)
+	<-done
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	sort.Strings(listed)
+	if !reflect.DeepEqual(keys, listed) {
+		t.Errorf("expect every key listed once, got %v", listed)
+	}
+	if e, a := int64(100), out.ObjectsListed; e != a {
+		t.Errorf("expect %d objects listed, got %d", e, a)
+	}
+	if e, a := 4, len(out.Partitions); e != a {
+		t.Errorf("expect %d partitions, got %d", e, a)
+	}
+	if client.maxInFlight > 2 {
+		t.Errorf("expect at most 2 requests in flight, got %d", client.maxInFlight)
+	}
+	for _, call := range client.calls {
+		if call.ContinuationToken == nil && aws.ToString(call.StartAfter) == "k075" {
+			return
+		}
+	}
+	t.Errorf("expect a partition to start after k075")
+}
+
+func TestSplitListPartitions(t *testing.T) {
+	expect := []ListPartition{
+		{Prefix: "p/", EndAt: "p/g"},
+		{Prefix: "p/", StartAfter: "p/g", EndAt: "p/p"},
+		{Prefix: "p/", StartAfter: "p/p"},
+	}
+	if a := SplitListPartitions("p/", "p/p", "p/g", "p/p"); !reflect.DeepEqual(expect, a) {
+		t.Errorf("expect partitions %v, got %v", expect, a)
+	}
+	if e, a := []ListPartition{{Prefix: "p/"}}, SplitListPartitions("p/"); !reflect.DeepEqual(e, a) {
+		t.Errorf("expect partitions %v, got %v", e, a)
+	}
+}
+
+func TestLister_Error(t *testing.T) {
+	client := newMockListClient(listTestKeys()...)
+	client.failFn = func(in *s3.ListObjectsV2Input) error {
+		// the partition fails once listed, after the exploration
+		if aws.ToString(in.Prefix) == "b/x/" && in.Delimiter == nil {
+			return fmt.Errorf("service unavailable")
+		}
+		return nil
+	}
+	client.pageSize = 10
+
+	s, err := NewLister(client).List(context.Background(), &ListInput{Bucket: aws.String("bucket")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer s.Close()
+	collect(t, s)
+	if err := s.Err(); err == nil || !strings.Contains(err.Error(), "service unavailable") {
+		t.Errorf("expect listing error, got %v", err)
+	}
+
+	if _, err := NewLister(client).List(context.Background(), &ListInput{}); err == nil {
+		t.Error("expect error without bucket")
+	}
+}
+
+func TestLister_Checkpoint(t *testing.T) {
+	var keys []string
+	for i := 0; i < 40; i++ {
+		keys = append(keys, fmt.Sprintf("k%03d", i))
+	}
+	path := filepath.Join(t.TempDir(), "list.progress")
+	plan := SplitListPartitions("k", "k019")
+
+	// the second partition fails after its first page
+	client := newMockListClient(keys...)
+	client.failFn = func(in *s3.ListObjectsV2Input) error {
+		if aws.ToString(in.StartAfter) == "k019" && in.ContinuationToken != nil {
+			return fmt.Errorf("connection reset")
+		}
+		return nil
+	}
+	objects := make(chan types.Object)
+	var first []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for obj := range objects {
+			first = append(first, aws.ToString(obj.Key))
+		}
+	}()
+	_, err := NewLister(client).ListUnordered(context.Background(), &ListInput{
+		Bucket:     aws.String("bucket"),
+		Partitions: plan,
+		Checkpoint: NewFileListCheckpoint(path),
+	}, objects)
+	<-done
+	if err == nil {
+		t.Fatalf("expect listing error")
+	}
+
+	states, err := NewFileListCheckpoint(path).Load()
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 2, len(states); e != a {
+		t.Fatalf("expect %d partitions recorded, got %d", e, a)
+	}
+	if e, a := "k022", states[1].LastKey; e != a {
+		t.Errorf("expect the failed partition to be recorded up to %s, got %s", e, a)
+	}
+
+	// the resumed listing ignores the new plan, and lists the remaining
+	// keys of the unfinished partitions
+	client.failFn = nil
+	client.calls = nil
+	s, err := NewLister(client).List(context.Background(), &ListInput{
+		Bucket:     aws.String("bucket"),
+		Partitions: SplitListPartitions("k", "k005"),
+		Checkpoint: NewFileListCheckpoint(path),
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	second := collect(t, s)
+	s.Close()
+	if err := s.Err(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, k := range append(first, second...) {
+		seen[k] = true
+	}
+	if e, a := len(keys), len(seen); e != a {
+		t.Errorf("expect %d keys across both runs, got %d", e, a)
+	}
+	for _, k := range second {
+		if k <= "k019" && states[0].Done {
+			t.Errorf("expect finished partition not to be listed again, got %s", k)
+		}
+	}
+	resumed := false
+	for _, call := range client.calls {
+		if call.ContinuationToken == nil && aws.ToString(call.StartAfter) == "k019" {
+			t.Errorf("expect the failed partition to resume after its last page")
+		}
+		resumed = resumed || aws.ToString(call.StartAfter) == "k022"
+	}
+	if !resumed {
+		t.Errorf("expect the failed partition to resume after k022")
+	}
+
+	states, err = NewFileListCheckpoint(path).Load()
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	for _, state := range states {
+		if !state.Done {
+			t.Errorf("expect partition %v to be done", state.Partition)
+		}
+	}
+}
@@ -0,0 +1,576 @@
+package manager
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/logging"
+)
+
+// DefaultRestoreConcurrency is the default number of goroutines to spin up
+// when using Restore().
+const DefaultRestoreConcurrency = 10
+
+// DefaultRestoreDays is the default number of days a restored copy of an
+// archived object remains available.
+const DefaultRestoreDays int32 = 1
+
+// DefaultRestorePollInterval is the default interval between HeadObject calls
+// when waiting for restores to complete.
+const DefaultRestorePollInterval = 5 * time.Minute
+
+// RestoreAPIClient is an S3 API client that can invoke the ListObjectsV2,
+// HeadObject and RestoreObject operations.
+type RestoreAPIClient interface {
+	ListObjectsV2APIClient
+	HeadObject(context.Context, *s3.HeadObjectInput, ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	RestoreObject(context.Context, *s3.RestoreObjectInput, ...func(*s3.Options)) (*s3.RestoreObjectOutput, error)
+}
+
+// RestoreStatus is the outcome of restoring a single key.
+type RestoreStatus string
+
+// Enumeration of restore outcomes reported by the Restorer.
+const (
+	// RestoreStatusRequested means a RestoreObject request was accepted.
+	RestoreStatusRequested RestoreStatus = "requested"
+
+	// RestoreStatusInProgress means a restore was already underway.
+	RestoreStatusInProgress RestoreStatus = "in-progress"
+
+	// RestoreStatusRestored means a restored copy of the object is available.
+	RestoreStatusRestored RestoreStatus = "restored"
+
+	// RestoreStatusNotArchived means the object is not in an archive storage
+	// class and does not need to be restored.
+	RestoreStatusNotArchived RestoreStatus = "not-archived"
+
+	// RestoreStatusFailed means the key could not be restored, see
+	// RestoreResult.Err for the cause.
+	RestoreStatusFailed RestoreStatus = "failed"
+)
+
+// done returns whether no further action is needed for a key with this status.
+func (s RestoreStatus) done() bool {
+	return s == RestoreStatusRestored || s == RestoreStatusNotArchived
+}
+
+// pending returns whether a key with this status is waiting on the service.
+func (s RestoreStatus) pending() bool {
+	return s == RestoreStatusRequested || s == RestoreStatusInProgress
+}
+
+// RestoreResult is the outcome of restoring a single key.
+type RestoreResult struct {
+	// The key of the object.
+	Key string
+
+	// The restore outcome.
+	Status RestoreStatus
+
+	// Whether the outcome was taken from the Checkpoint without calling the
+	// service.
+	FromCheckpoint bool
+
+	// The error which caused the key to fail, if Status is
+	// RestoreStatusFailed.
+	Err error
+}
+
+// RestoreCheckpoint persists the progress of a bulk restore so that a job
+// that was interrupted can be resumed without re-issuing requests for keys
+// which were already handled.
+//
+// Implementations must be safe for concurrent use.
+type RestoreCheckpoint interface {
+	// Load returns the last recorded status of each key.
+	Load() (map[string]RestoreStatus, error)
+
+	// Save records the status of a key.
+	Save(key string, status RestoreStatus) error
+}
+
+// RestoreInput provides the parameters for a bulk restore.
+type RestoreInput struct {
+	// The bucket holding the archived objects.
+	//
+	// This member is required.
+	Bucket *string
+
+	// The prefix to restore objects under. Ignored if Keys is set.
+	Prefix *string
+
+	// An explicit list of keys to restore. When set, the bucket is not
+	// listed.
+	Keys []string
+
+	// The number of days the restored copy remains available. If zero,
+	// the Restorer's Days value is used.
+	Days int32
+
+	// The retrieval tier to request. If empty, the Restorer's Tier value is
+	// used.
+	Tier types.Tier
+
+	// Progress store used to skip keys handled by a previous run. May be nil.
+	Checkpoint RestoreCheckpoint
+
+	// Whether Restore should block until every requested restore has
+	// completed, polling HeadObject every Restorer.PollInterval.
+	WaitForCompletion bool
+}
+
+// RestoreOutput represents a response from the Restore() call.
+type RestoreOutput struct {
+	// The outcome of each key, in the order they were completed.
+	Results []RestoreResult
+}
+
+// Failed returns the results whose status is RestoreStatusFailed.
+func (o *RestoreOutput) Failed() []RestoreResult {
+	var failed []RestoreResult
+	for _, r := range o.Results {
+		if r.Status == RestoreStatusFailed {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// The Restorer structure that calls Restore(). It is safe to call Restore()
+// on this structure for multiple inputs and across concurrent goroutines.
+// Mutating the Restorer's properties is not safe to be done concurrently.
+type Restorer struct {
+	// The number of goroutines to spin up in parallel when checking and
+	// restoring keys. If this is set to zero, the DefaultRestoreConcurrency
+	// value will be used.
+	Concurrency int
+
+	// The default number of days restored copies remain available. If this
+	// is set to zero, the DefaultRestoreDays value will be used.
+	Days int32
+
+	// The default retrieval tier. If empty, the service default is used.
+	Tier types.Tier
+
+	// The interval between status checks when waiting for restores to
+	// complete. If this is set to zero, the DefaultRestorePollInterval value
+	// will be used.
+	PollInterval time.Duration
+
+	// Logger to send logging messages to
+	Logger logging.Logger
+
+	// An S3 client to use when performing restores.
+	S3 RestoreAPIClient
+
+	// List of client options that will be passed down to individual API
+	// operation requests made by the restorer.
+	ClientOptions []func(*s3.Options)
+}
+
+// WithRestorerClientOptions appends to the Restorer's API request options.
+func WithRestorerClientOptions(opts ...func(*s3.Options)) func(*Restorer) {
+	return func(r *Restorer) {
+		r.ClientOptions = append(r.ClientOptions, opts...)
+	}
+}
+
+// NewRestorer creates a new Restorer instance to restore archived objects in
+// bulk. Pass in additional functional options to customize the restorer
+// behavior.
+//
+// Example:
+//
+//	restorer := manager.NewRestorer(s3.NewFromConfig(cfg), func(r *manager.Restorer) {
+//		r.Days = 7
+//		r.Tier = types.TierBulk
+//	})
+//
+//	out, err := restorer.Restore(ctx, &manager.RestoreInput{
+//		Bucket:     aws.String("bucket"),
+//		Prefix:     aws.String("dataset/2021/"),
+//		Checkpoint: manager.NewFileRestoreCheckpoint("restore.progress"),
+//	})
+func NewRestorer(c RestoreAPIClient, options ...func(*Restorer)) *Restorer {
+	r := &Restorer{
+		S3:           c,
+		Concurrency:  DefaultRestoreConcurrency,
+		Days:         DefaultRestoreDays,
+		PollInterval: DefaultRestorePollInterval,
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+// Restore issues a RestoreObject request for every archived object under
+// the input prefix, or in the input key list, with bounded concurrency.
+//
+// Each key is first checked with HeadObject; objects which are not archived,
+// already restored, or whose restore is already in progress are not
+// requested again. If a Checkpoint is provided every outcome is recorded in
+// it, and keys recorded as restored or not archived by a previous run are
+// skipped.
+//
+// Per-key failures do not stop the job, and are reported in the output
+// results. An error is only returned if the bucket could not be listed, the
+// checkpoint could not be read, or the context was canceled.
+func (r Restorer) Restore(ctx context.Context, input *RestoreInput, options ...func(*Restorer)) (*RestoreOutput, error) {
+	impl := restorer{in: input, cfg: r, ctx: ctx}
+
+	clientOptions := make([]func(*s3.Options), 0, len(impl.cfg.ClientOptions)+1)
+	clientOptions = append(clientOptions, func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions,
+			middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+		)
+	})
+	clientOptions = append(clientOptions, impl.cfg.ClientOptions...)
+	impl.cfg.ClientOptions = clientOptions
+
+	for _, option := range options {
+		option(&impl.cfg)
+	}
+
+	impl.cfg.Logger = logging.WithContext(ctx, impl.cfg.Logger)
+
+	return impl.restore()
+}
+
+// restorer is the implementation structure used internally by Restorer.
+type restorer struct {
+	ctx context.Context
+	cfg Restorer
+
+	in  *RestoreInput
+	out RestoreOutput
+
+	previous map[string]RestoreStatus
+
+	m       sync.Mutex
+	pending []string
+}
+
+func (r *restorer) restore() (*RestoreOutput, error) {
+	if r.in.Bucket == nil {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if r.cfg.Concurrency <= 0 {
+		r.cfg.Concurrency = DefaultRestoreConcurrency
+	}
+	if r.cfg.PollInterval <= 0 {
+		r.cfg.PollInterval = DefaultRestorePollInterval
+	}
+
+	if r.in.Checkpoint != nil {
+		previous, err := r.in.Checkpoint.Load()
+		if err != nil {
+			return nil, fmt.Errorf("load restore checkpoint: %w", err)
+		}
+		r.previous = previous
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+
+	keys := make(chan string, r.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < r.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				r.record(r.restoreKey(ctx, key))
+			}
+		}()
+	}
+
+	listErr := r.listKeys(ctx, keys)
+	close(keys)
+	wg.Wait()
+
+	if listErr != nil {
+		return &r.out, listErr
+	}
+	if err := r.ctx.Err(); err != nil {
+		return &r.out, err
+	}
+
+	if r.in.WaitForCompletion {
+		if err := r.wait(); err != nil {
+			return &r.out, err
+		}
+	}
+
+	return &r.out, nil
+}
+
+func (r *restorer) listKeys(ctx context.Context, keys chan<- string) error {
+	send := func(key string) error {
+		select {
+		case keys <- key:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if len(r.in.Keys) > 0 {
+		for _, key := range r.in.Keys {
+			if err := send(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	p := s3.NewListObjectsV2Paginator(r.cfg.S3, &s3.ListObjectsV2Input{
+		Bucket: r.in.Bucket,
+		Prefix: r.in.Prefix,
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx, r.cfg.ClientOptions...)
+		if err != nil {
+			return fmt.Errorf("list objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if err := send(aws.ToString(obj.Key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *restorer) restoreKey(ctx context.Context, key string) RestoreResult {
+	if status, ok := r.previous[key]; ok && (status.done() || status.pending()) {
+		return RestoreResult{Key: key, Status: status, FromCheckpoint: true}
+	}
+
+	head, err := r.cfg.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: r.in.Bucket,
+		Key:    aws.String(key),
+	}, r.cfg.ClientOptions...)
+	if err != nil {
+		return RestoreResult{Key: key, Status: RestoreStatusFailed, Err: err}
+	}
+
+	if status, ok := restoreStatusFromHead(head); ok {
+		return RestoreResult{Key: key, Status: status}
+	}
+
+	days := r.in.Days
+	if days == 0 {
+		days = r.cfg.Days
+	}
+	if days == 0 {
+		days = DefaultRestoreDays
+	}
+	tier := r.in.Tier
+	if tier == "" {
+		tier = r.cfg.Tier
+	}
+
+	req := &types.RestoreRequest{Days: aws.Int32(days)}
+	if tier != "" {
+		req.GlacierJobParameters = &types.GlacierJobParameters{Tier: tier}
+	}
+	_, err = r.cfg.S3.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket:         r.in.Bucket,
+		Key:            aws.String(key),
+		RestoreRequest: req,
+	}, r.cfg.ClientOptions...)
+	if err != nil {
+		var ae smithy.APIError
+		if errors.As(err, &ae) && ae.ErrorCode() == "RestoreAlreadyInProgress" {
+			return RestoreResult{Key: key, Status: RestoreStatusInProgress}
+		}
+		return RestoreResult{Key: key, Status: RestoreStatusFailed, Err: err}
+	}
+
+	return RestoreResult{Key: key, Status: RestoreStatusRequested}
+}
+
+// record stores the result, persisting it to the checkpoint if one is set.
+func (r *restorer) record(res RestoreResult) {
+	if r.in.Checkpoint != nil && !res.FromCheckpoint && res.Status != RestoreStatusFailed {
+		if err := r.in.Checkpoint.Save(res.Key, res.Status); err != nil {
+			r.cfg.Logger.Logf(logging.Warn, "failed to save restore checkpoint for %s, %v", res.Key, err)
+		}
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if res.Status == RestoreStatusFailed {
+		r.cfg.Logger.Logf(logging.Debug, "restore of %s failed, %v", res.Key, res.Err)
+	}
+	if res.Status.pending() {
+		r.pending = append(r.pending, res.Key)
+	}
+	r.out.Results = append(r.out.Results, res)
+}
+
+// wait polls the keys with pending restores until every one has completed,
+// updating their results in place.
+func (r *restorer) wait() error {
+	index := make(map[string]int, len(r.out.Results))
+	for i, res := range r.out.Results {
+		index[res.Key] = i
+	}
+
+	pending := r.pending
+	for len(pending) > 0 {
+		select {
+		case <-time.After(r.cfg.PollInterval):
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		}
+
+		var still []string
+		for _, key := range pending {
+			head, err := r.cfg.S3.HeadObject(r.ctx, &s3.HeadObjectInput{
+				Bucket: r.in.Bucket,
+				Key:    aws.String(key),
+			}, r.cfg.ClientOptions...)
+			if err != nil {
+				if r.ctx.Err() != nil {
+					return r.ctx.Err()
+				}
+				r.cfg.Logger.Logf(logging.Debug, "failed to check restore status of %s, %v", key, err)
+				still = append(still, key)
+				continue
+			}
+			if status, ok := restoreStatusFromHead(head); ok && status.done() {
+				res := &r.out.Results[index[key]]
+				res.Status = status
+				res.FromCheckpoint = false
+				if r.in.Checkpoint != nil {
+					if err := r.in.Checkpoint.Save(key, status); err != nil {
+						r.cfg.Logger.Logf(logging.Warn, "failed to save restore checkpoint for %s, %v", key, err)
+					}
+				}
+				continue
+			}
+			still = append(still, key)
+		}
+		pending = still
+	}
+	return nil
+}
+
+// restoreStatusFromHead derives the restore status of an object from its
+// HeadObject response. It returns false if the object is archived and no
+// restore has been requested yet.
+func restoreStatusFromHead(head *s3.HeadObjectOutput) (RestoreStatus, bool) {
+	if restore := aws.ToString(head.Restore); restore != "" {
+		if strings.Contains(restore, `ongoing-request="true"`) {
+			return RestoreStatusInProgress, true
+		}
+		return RestoreStatusRestored, true
+	}
+
+	switch head.StorageClass {
+	case types.StorageClassGlacier, types.StorageClassDeepArchive:
+		return "", false
+	}
+	if strings.EqualFold(string(head.StorageClass), "ACCELERATED") {
+		return "", false
+	}
+	return RestoreStatusNotArchived, true
+}
+
+// FileRestoreCheckpoint is a RestoreCheckpoint which appends each recorded
+// status to a local file as a line of JSON.
+type FileRestoreCheckpoint struct {
+	path string
+	m    sync.Mutex
+
+	// set when the file ends in a partially written line
+	needsNewline bool
+}
+
+// NewFileRestoreCheckpoint returns a RestoreCheckpoint persisted to the
+// file at path. The file is created on the first Save if it does not exist.
+func NewFileRestoreCheckpoint(path string) *FileRestoreCheckpoint {
+	return &FileRestoreCheckpoint{path: path}
+}
+
+type restoreCheckpointEntry struct {
+	Key    string        `json:"key"`
+	Status RestoreStatus `json:"status"`
+}
+
+// Load returns the last recorded status of each key in the file. A missing
+// file yields an empty map.
+func (c *FileRestoreCheckpoint) Load() (map[string]RestoreStatus, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	statuses := map[string]RestoreStatus{}
+	f, err := os.Open(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return statuses, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			c.needsNewline = line[len(line)-1] != '\n'
+
+			var entry restoreCheckpointEntry
+			// a partially written trailing line is expected after a crash
+			if jsonErr := json.Unmarshal(line, &entry); jsonErr == nil {
+				statuses[entry.Key] = entry.Status
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return statuses, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Save appends the status of a key to the file.
+func (c *FileRestoreCheckpoint) Save(key string, status RestoreStatus) error {
+	line, err := json.Marshal(restoreCheckpointEntry{Key: key, Status: status})
+	if err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if c.needsNewline {
+		line = append([]byte{'\n'}, line...)
+		c.needsNewline = false
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,256 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+type mockRestoreClient struct {
+	m        sync.Mutex
+	objects  map[string]*s3.HeadObjectOutput
+	restored []string
+	heads    int
+}
+
+func (c *mockRestoreClient) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	var keys []string
+	for k := range c.objects {
+		if strings.HasPrefix(k, aws.ToString(in.Prefix)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	out := &s3.ListObjectsV2Output{}
+	for _, k := range keys {
+		out.Contents = append(out.Contents, types.Object{Key: aws.String(k)})
+	}
+	return out, nil
+}
+
+func (c *mockRestoreClient) HeadObject(ctx context.Context, in *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.heads++
+	head, ok := c.objects[aws.ToString(in.Key)]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return head, nil
+}
+
+func (c *mockRestoreClient) RestoreObject(ctx context.Context, in *s3.RestoreObjectInput, _ ...func(*s3.Options)) (*s3.RestoreObjectOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	key := aws.ToString(in.Key)
+	if key == "conflict" {
+		return nil, &smithy.GenericAPIError{Code: "RestoreAlreadyInProgress"}
+	}
+	c.restored = append(c.restored, key)
+	c.objects[key] = &s3.HeadObjectOutput{
+		StorageClass: types.StorageClassGlacier,
+		Restore:      aws.String(`ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`),
+	}
+	return &s3.RestoreObjectOutput{}, nil
+}
+
+func newMockRestoreClient() *mockRestoreClient {
+	return &mockRestoreClient{
+		objects: map[string]*s3.HeadObjectOutput{
+			"archived":    {StorageClass: types.StorageClassGlacier},
+			"accelerated": {StorageClass: types.StorageClass("ACCELERATED")},
+			"standard":    {StorageClass: types.StorageClassStandard},
+			"ongoing":     {StorageClass: types.StorageClassGlacier, Restore: aws.String(`ongoing-request="true"`)},
+			"restored":    {StorageClass: types.StorageClassGlacier, Restore: aws.String(`ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`)},
+			"conflict":    {StorageClass: types.StorageClassGlacier},
+		},
+	}
+}
+
+func restoreStatuses(out *RestoreOutput) map[string]RestoreStatus {
+	statuses := map[string]RestoreStatus{}
+	for _, r := range out.Results {
+		statuses[r.Key] = r.Status
+	}
+	return statuses
+}
+
+func TestRestorer_Prefix(t *testing.T) {
+	client := newMockRestoreClient()
+	r := NewRestorer(client, func(r *Restorer) { r.Concurrency = 2 })
+
+	out, err := r.Restore(context.Background(), &RestoreInput{Bucket: aws.String("bucket")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	expect := map[string]RestoreStatus{
+		"archived":    RestoreStatusRequested,
+		"accelerated": RestoreStatusRequested,
+		"standard":    RestoreStatusNotArchived,
+		"ongoing":     RestoreStatusInProgress,
+		"restored":    RestoreStatusRestored,
+		"conflict":    RestoreStatusInProgress,
+	}
+	actual := restoreStatuses(out)
+	if len(expect) != len(actual) {
+		t.Fatalf("expect %d results, got %d", len(expect), len(actual))
+	}
+	for k, e := range expect {
+		if a := actual[k]; e != a {
+			t.Errorf("%s: expect status %v, got %v", k, e, a)
+		}
+	}
+
+	sort.Strings(client.restored)
+	if e, a := []string{"accelerated", "archived"}, client.restored; fmt.Sprint(e) != fmt.Sprint(a) {
+		t.Errorf("expect restored %v, got %v", e, a)
+	}
+}
+
+func TestRestorer_PrefixFiltersKeys(t *testing.T) {
+	client := newMockRestoreClient()
+	client.objects["dataset/archived"] = &s3.HeadObjectOutput{StorageClass: types.StorageClassGlacier}
+	client.objects["dataset/standard"] = &s3.HeadObjectOutput{StorageClass: types.StorageClassStandard}
+	r := NewRestorer(client)
+
+	out, err := r.Restore(context.Background(), &RestoreInput{
+		Bucket: aws.String("bucket"),
+		Prefix: aws.String("dataset/"),
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	expect := map[string]RestoreStatus{
+		"dataset/archived": RestoreStatusRequested,
+		"dataset/standard": RestoreStatusNotArchived,
+	}
+	actual := restoreStatuses(out)
+	if len(expect) != len(actual) {
+		t.Fatalf("expect %d results, got %v", len(expect), actual)
+	}
+	for k, e := range expect {
+		if a := actual[k]; e != a {
+			t.Errorf("%s: expect status %v, got %v", k, e, a)
+		}
+	}
+	if e, a := []string{"dataset/archived"}, client.restored; fmt.Sprint(e) != fmt.Sprint(a) {
+		t.Errorf("expect restored %v, got %v", e, a)
+	}
+}
+
+func TestRestorer_KeysWithFailure(t *testing.T) {
+	client := newMockRestoreClient()
+	r := NewRestorer(client)
+
+	out, err := r.Restore(context.Background(), &RestoreInput{
+		Bucket: aws.String("bucket"),
+		Keys:   []string{"archived", "missing"},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	failed := out.Failed()
+	if e, a := 1, len(failed); e != a {
+		t.Fatalf("expect %d failed, got %d", e, a)
+	}
+	if e, a := "missing", failed[0].Key; e != a {
+		t.Errorf("expect failed key %v, got %v", e, a)
+	}
+	if failed[0].Err == nil {
+		t.Errorf("expect failure error")
+	}
+}
+
+func TestRestorer_Checkpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restore.progress")
+	cp := NewFileRestoreCheckpoint(path)
+
+	client := newMockRestoreClient()
+	r := NewRestorer(client)
+	if _, err := r.Restore(context.Background(), &RestoreInput{
+		Bucket:     aws.String("bucket"),
+		Keys:       []string{"archived", "standard"},
+		Checkpoint: cp,
+	}); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	// simulate a crash in the middle of a write
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"key":"trunc`)
+	f.Close()
+
+	client.heads = 0
+	cp = NewFileRestoreCheckpoint(path)
+	out, err := r.Restore(context.Background(), &RestoreInput{
+		Bucket:     aws.String("bucket"),
+		Keys:       []string{"archived", "standard", "accelerated"},
+		Checkpoint: cp,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 1, client.heads; e != a {
+		t.Errorf("expect %d HeadObject calls, got %d", e, a)
+	}
+	for _, res := range out.Results {
+		if e, a := res.Key != "accelerated", res.FromCheckpoint; e != a {
+			t.Errorf("%s: expect from checkpoint %v, got %v", res.Key, e, a)
+		}
+	}
+
+	statuses, err := NewFileRestoreCheckpoint(path).Load()
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	expect := map[string]RestoreStatus{
+		"archived":    RestoreStatusRequested,
+		"standard":    RestoreStatusNotArchived,
+		"accelerated": RestoreStatusRequested,
+	}
+	if e, a := len(expect), len(statuses); e != a {
+		t.Fatalf("expect %d checkpoint entries, got %d: %v", e, a, statuses)
+	}
+	for k, e := range expect {
+		if a := statuses[k]; e != a {
+			t.Errorf("%s: expect checkpoint status %v, got %v", k, e, a)
+		}
+	}
+}
+
+func TestRestorer_WaitForCompletion(t *testing.T) {
+	client := newMockRestoreClient()
+	r := NewRestorer(client, func(r *Restorer) { r.PollInterval = time.Millisecond })
+
+	out, err := r.Restore(context.Background(), &RestoreInput{
+		Bucket:            aws.String("bucket"),
+		Keys:              []string{"archived"},
+		WaitForCompletion: true,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := RestoreStatusRestored, out.Results[0].Status; e != a {
+		t.Errorf("expect status %v, got %v", e, a)
+	}
+}
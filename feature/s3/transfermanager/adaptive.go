@@ -0,0 +1,190 @@
+package transfermanager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/retry"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	smithymiddleware "github.com/aws/smithy-go/middleware"
+)
+
+// adaptivePartSizeStep is the number of parts after which the part size of an
+// adaptive upload of unknown size doubles. Starting from 8 MiB, the 10000
+// parts of an upload reach about 8 TiB. A variable for tests to grow the part
+// size sooner.
+var adaptivePartSizeStep int32 = 1000
+
+// maxPartSizeBytes is the maximum size of a part of a multipart upload
+const maxPartSizeBytes = 1024 * 1024 * 1024 * 5
+
+// adaptivePartSize returns the size of part partNum of an adaptive upload of
+// unknown size, whose first parts are base bytes.
+func adaptivePartSize(base int64, partNum int32) int64 {
+	size := base << ((partNum - 1) / adaptivePartSizeStep)
+	if size > maxPartSizeBytes {
+		return max(base, maxPartSizeBytes)
+	}
+	return size
+}
+
+// adaptivePartOffset returns the offset of part partNum of an adaptive upload
+// of unknown size, whose first parts are base bytes.
+func adaptivePartOffset(base int64, partNum int32) int64 {
+	var offset int64
+	for first := int32(1); first < partNum; first += adaptivePartSizeStep {
+		n := min(partNum-first, adaptivePartSizeStep)
+		offset += int64(n) * adaptivePartSize(base, first)
+	}
+	return offset
+}
+
+// concurrencyTuner adjusts the number of parts of an adaptive upload sent at
+// once, between min and max. After each window of as many parts as the
+// limit, the limit is increased while the throughput of the window improves
+// and decreased when it degrades. It is halved on throttling errors.
+type concurrencyTuner struct {
+	min, max int
+
+	mu     sync.Mutex
+	limit  int
+	active int
+	// closed when a part is done or the limit changes, to wake up the
+	// waiting goroutines
+	changed chan struct{}
+
+	// the parts completed in the current window and their seconds per byte
+	windowParts int
+	windowCost  float64
+	// the throughput, in bytes per second, of the last window the limit was
+	// changed after
+	throughput float64
+	// whether the limit was halved for throttling in the current window
+	throttled bool
+}
+
+func newConcurrencyTuner(limit, lo, hi int) *concurrencyTuner {
+	return &concurrencyTuner{
+		min:     lo,
+		max:     hi,
+		limit:   min(max(limit, lo), hi),
+		changed: make(chan struct{}),
+	}
+}
+
+// Limit returns the current number of parts sent at once
+func (t *concurrencyTuner) Limit() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limit
+}
+
+// acquire blocks until a part may be sent. A nil tuner does not limit parts.
+func (t *concurrencyTuner) acquire(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	for {
+		t.mu.Lock()
+		if t.active < t.limit {
+			t.active++
+			t.mu.Unlock()
+			return nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release records a part of n bytes sent in elapsed, or which failed if n is
+// zero, and lets another part be sent.
+func (t *concurrencyTuner) release(n int64, elapsed time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active--
+	if n > 0 && elapsed > 0 {
+		t.windowParts++
+		t.windowCost += elapsed.Seconds() / float64(n)
+		if t.windowParts >= t.limit {
+			t.adjust()
+		}
+	}
+	t.notify()
+}
+
+// adjust changes the limit from the throughput of the window. t.mu must be
+// held.
+func (t *concurrencyTuner) adjust() {
+	throughput := float64(t.limit) * float64(t.windowParts) / t.windowCost
+	switch {
+	case t.throughput == 0 || throughput >= t.throughput*1.05:
+		t.throughput = throughput
+		t.limit = min(t.limit+1, t.max)
+	case throughput < t.throughput*0.8:
+		t.throughput = throughput
+		t.limit = max(t.limit-1, t.min)
+	}
+	t.windowParts, t.windowCost = 0, 0
+	t.throttled = false
+}
+
+// throttle halves the limit, once per window
+func (t *concurrencyTuner) throttle() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.throttled {
+		return
+	}
+	t.throttled = true
+	t.limit = max(t.limit/2, t.min)
+	t.throughput = 0
+	t.windowParts, t.windowCost = 0, 0
+	t.notify()
+}
+
+// notify wakes up the waiting goroutines. t.mu must be held.
+func (t *concurrencyTuner) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// clientOptions returns the client options of the requests of the tuned
+// parts, which observe the throttling errors of every attempt, including
+// those which are retried.
+func (t *concurrencyTuner) clientOptions(clientOptions []func(*s3.Options)) []func(*s3.Options) {
+	if t == nil {
+		return clientOptions
+	}
+	return append(clientOptions[:len(clientOptions):len(clientOptions)], func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, t.addThrottleObserver)
+	})
+}
+
+var throttleErrorCodes = retry.ThrottleErrorCode{Codes: retry.DefaultThrottleErrorCodes}
+
+func (t *concurrencyTuner) addThrottleObserver(stack *smithymiddleware.Stack) error {
+	// added after the retry middleware, so that it handles each attempt
+	return stack.Finalize.Add(smithymiddleware.FinalizeMiddlewareFunc("TransferManagerThrottleObserver",
+		func(ctx context.Context, in smithymiddleware.FinalizeInput, next smithymiddleware.FinalizeHandler) (
+			smithymiddleware.FinalizeOutput, smithymiddleware.Metadata, error,
+		) {
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			if err != nil && throttleErrorCodes.IsErrorThrottle(err) == aws.TrueTernary {
+				t.throttle()
+			}
+			return out, metadata, err
+		}), smithymiddleware.After)
+}
@@ -0,0 +1,313 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	smithymiddleware "github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func TestAdaptivePartSize(t *testing.T) {
+	const mib = 1024 * 1024
+	cases := []struct {
+		base         int64
+		partNum      int32
+		expectSize   int64
+		expectOffset int64
+	}{
+		{base: 8 * mib, partNum: 1, expectSize: 8 * mib, expectOffset: 0},
+		{base: 8 * mib, partNum: 1000, expectSize: 8 * mib, expectOffset: 999 * 8 * mib},
+		{base: 8 * mib, partNum: 1001, expectSize: 16 * mib, expectOffset: 1000 * 8 * mib},
+		{base: 8 * mib, partNum: 2500, expectSize: 32 * mib, expectOffset: 1000*8*mib + 1000*16*mib + 499*32*mib},
+		{base: 8 * mib, partNum: 10000, expectSize: 4096 * mib, expectOffset: 1000*8*mib*511 + 999*4096*mib},
+		{base: 16 * mib, partNum: 10000, expectSize: maxPartSizeBytes},
+		{base: 6 * 1024 * mib, partNum: 5000, expectSize: 6 * 1024 * mib},
+	}
+	for _, c := range cases {
+		if e, a := c.expectSize, adaptivePartSize(c.base, c.partNum); e != a {
+			t.Errorf("part %d of %d: expect size %d, got %d", c.partNum, c.base, e, a)
+		}
+		if c.expectOffset == 0 && c.partNum != 1 {
+			continue
+		}
+		if e, a := c.expectOffset, adaptivePartOffset(c.base, c.partNum); e != a {
+			t.Errorf("part %d of %d: expect offset %d, got %d", c.partNum, c.base, e, a)
+		}
+	}
+
+	// the total size of the 10000 parts starting at 8 MiB exceeds 5 TiB
+	if total := adaptivePartOffset(8*mib, defaultMaxUploadParts+1); total < 5*1024*1024*mib {
+		t.Errorf("expect the parts to reach 5 TiB, got %d", total)
+	}
+}
+
+func TestConcurrencyTuner(t *testing.T) {
+	tuner := newConcurrencyTuner(2, 1, 4)
+	ctx := context.Background()
+
+	// parts take the same time regardless of the limit, so the throughput
+	// grows with it
+	for i := 0; i < 20; i++ {
+		if err := tuner.acquire(ctx); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		tuner.release(1000, time.Millisecond)
+	}
+	if e, a := 4, tuner.Limit(); e != a {
+		t.Errorf("expect limit %d, got %d", e, a)
+	}
+
+	tuner.throttle()
+	tuner.throttle()
+	if e, a := 2, tuner.Limit(); e != a {
+		t.Errorf("expect limit halved once to %d, got %d", e, a)
+	}
+
+	// parts slow down as much as the limit grows, so the throughput
+	// does not improve
+	for i := 0; i < 20; i++ {
+		tuner.acquire(ctx)
+		tuner.release(1000, time.Duration(tuner.Limit())*time.Millisecond)
+	}
+	if e, a := 3, tuner.Limit(); e != a {
+		t.Errorf("expect limit %d, got %d", e, a)
+	}
+
+	for i := 0; i < 3; i++ {
+		tuner.throttle()
+		tuner.release(0, 0)
+		tuner.active++
+	}
+	if e, a := 1, tuner.Limit(); e != a {
+		t.Errorf("expect limit bounded at %d, got %d", e, a)
+	}
+}
+
+func TestConcurrencyTunerAcquire(t *testing.T) {
+	tuner := newConcurrencyTuner(1, 1, 1)
+	if err := tuner.acquire(context.Background()); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tuner.acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- tuner.acquire(context.Background())
+	}()
+	tuner.release(1, time.Millisecond)
+	if err := <-acquired; err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+}
+
+func TestConcurrencyTunerThrottleObserver(t *testing.T) {
+	tuner := newConcurrencyTuner(4, 1, 4)
+
+	for _, code := range []string{"SlowDown", "NoSuchKey"} {
+		stack := smithymiddleware.NewStack("test", smithyhttp.NewStackRequest)
+		if err := tuner.addThrottleObserver(stack); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		handler := smithymiddleware.DecorateHandler(smithymiddleware.HandlerFunc(
+			func(ctx context.Context, in interface{}) (interface{}, smithymiddleware.Metadata, error) {
+				return nil, smithymiddleware.Metadata{}, &smithy.GenericAPIError{Code: code}
+			}), stack)
+		handler.Handle(context.Background(), struct{}{})
+	}
+
+	if e, a := 2, tuner.Limit(); e != a {
+		t.Errorf("expect limit %d after a throttling error, got %d", e, a)
+	}
+}
+
+func TestPutObjectAdaptive(t *testing.T) {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	var sizes []int
+	c.UploadPartFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+		b, err := io.ReadAll(params.Body)
+		sizes = append(sizes, len(b))
+		return &s3.UploadPartOutput{ETag: aws.String("ETAG")}, err
+	}
+	store := &recordingCheckpointStore{}
+	mgr := New(c, Options{
+		Concurrency:    2,
+		AdaptiveUpload: true,
+		MaxConcurrency: 3,
+	})
+
+	// the size of the body is unknown
+	data := checksumTestData(3*minPartSizeBytes + 10)
+	_, err := mgr.PutObject(context.Background(), &PutObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		Body:            io.MultiReader(bytes.NewReader(data)),
+		CheckpointStore: store,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	sort.Ints(sizes)
+	if diff := cmpDiff([]int{10, int(minPartSizeBytes), int(minPartSizeBytes), int(minPartSizeBytes)}, sizes); diff != "" {
+		t.Error(diff)
+	}
+	if len(store.saved) == 0 || !store.saved[0].AdaptivePartSize {
+		t.Errorf("expect checkpoint to record the adaptive part size")
+	}
+	if e, a := int64(0), mgr.BufferPool().Stats().ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved after the upload, got %d", e, a)
+	}
+}
+
+// zeroReader reads zeros
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// setSmallParts lowers the part size and the number of parts after which an
+// adaptive upload grows it for the duration of the test
+func setSmallParts(t *testing.T, partSize int64, step int32) {
+	origPartSize, origStep := minPartSizeBytes, adaptivePartSizeStep
+	minPartSizeBytes, adaptivePartSizeStep = partSize, step
+	t.Cleanup(func() {
+		minPartSizeBytes, adaptivePartSizeStep = origPartSize, origStep
+	})
+}
+
+func TestPutObjectAdaptiveGrowPartSize(t *testing.T) {
+	setSmallParts(t, 1024, 10)
+
+	cases := map[string]int64{
+		"default pool": 0,
+		"buffer pool":  8 * (minPartSizeBytes * 2),
+	}
+	for name, maxBufferMemory := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, _, _ := s3testing.NewUploadLoggingClient(nil)
+			sizes := map[int32]int64{}
+			c.UploadPartFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+				n, err := io.Copy(io.Discard, params.Body)
+				sizes[aws.ToInt32(params.PartNumber)] = n
+				return &s3.UploadPartOutput{ETag: aws.String("ETAG")}, err
+			}
+			mgr := New(c, Options{
+				Concurrency:          2,
+				AdaptiveUpload:       true,
+				MaxConcurrency:       3,
+				MaxBufferMemoryBytes: maxBufferMemory,
+			})
+
+			// the parts grow past part adaptivePartSizeStep, the size of the
+			// body being unknown
+			size := int64(adaptivePartSizeStep)*minPartSizeBytes + 2*minPartSizeBytes + 10
+			done := make(chan error, 1)
+			go func() {
+				_, err := mgr.PutObject(context.Background(), &PutObjectInput{
+					Bucket: "bucket",
+					Key:    "key",
+					Body:   io.LimitReader(zeroReader{}, size),
+				})
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("expect no error, got %v", err)
+				}
+			case <-time.After(time.Minute):
+				t.Fatal("upload did not complete once the part size grew")
+			}
+
+			if e, a := int(adaptivePartSizeStep)+2, len(sizes); e != a {
+				t.Fatalf("expect %d parts, got %d", e, a)
+			}
+			var total int64
+			for _, n := range sizes {
+				total += n
+			}
+			if e, a := size, total; e != a {
+				t.Errorf("expect %d bytes uploaded, got %d", e, a)
+			}
+			if e, a := int64(minPartSizeBytes), sizes[adaptivePartSizeStep]; e != a {
+				t.Errorf("expect part %d of %d bytes, got %d", adaptivePartSizeStep, e, a)
+			}
+			if e, a := int64(2*minPartSizeBytes), sizes[adaptivePartSizeStep+1]; e != a {
+				t.Errorf("expect part %d of %d bytes, got %d", adaptivePartSizeStep+1, e, a)
+			}
+			if e, a := int64(0), mgr.BufferPool().Stats().ReservedBytes; e != a {
+				t.Errorf("expect %d bytes reserved after the upload, got %d", e, a)
+			}
+		})
+	}
+}
+
+func TestPutObjectAdaptiveConcurrencyBounds(t *testing.T) {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	mgr := New(c, Options{
+		AdaptiveUpload: true,
+		MinConcurrency: 8,
+		MaxConcurrency: 4,
+	})
+
+	_, err := mgr.PutObject(context.Background(), &PutObjectInput{
+		Bucket: "bucket",
+		Key:    "key",
+		Body:   bytes.NewReader(make([]byte, 2*minPartSizeBytes)),
+	})
+	if err == nil {
+		t.Fatal("expect error, got none")
+	}
+}
+
+func TestBoundedSlicePoolPutAfterClose(t *testing.T) {
+	pool := NewBufferPool(0)
+	p := pool.slicePool(10, 2)
+
+	a, _ := p.Get(context.Background())
+	b, _ := p.Get(context.Background())
+	p.Put(a)
+	p.Close()
+	if e, a := int64(10), pool.Stats().ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved by the slice in use, got %d", e, a)
+	}
+	p.Put(b)
+	if e, a := int64(0), pool.Stats().ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved, got %d", e, a)
+	}
+}
+
+// recordingCheckpointStore is an UploadCheckpointStore recording the saved
+// checkpoints
+type recordingCheckpointStore struct {
+	saved []*UploadCheckpoint
+}
+
+func (s *recordingCheckpointStore) Load(ctx context.Context) (*UploadCheckpoint, error) {
+	return nil, nil
+}
+
+func (s *recordingCheckpointStore) Save(ctx context.Context, checkpoint *UploadCheckpoint) error {
+	s.saved = append(s.saved, checkpoint)
+	return nil
+}
+
+func (s *recordingCheckpointStore) Delete(ctx context.Context) error {
+	return nil
+}
@@ -10,8 +10,9 @@ const userAgentKey = "s3-transfer"
 // on Amazon S3.
 const defaultMaxUploadParts = 10000
 
-// defaultPartSizeBytes is the default part size when transferring objects to/from S3
-const minPartSizeBytes = 1024 * 1024 * 8
+// minPartSizeBytes is the minimum and default part size when transferring
+// objects to/from S3, a variable for tests to use smaller parts
+var minPartSizeBytes int64 = 1024 * 1024 * 8
 
 // defaultMultipartUploadThreshold is the default size threshold in bytes indicating when to use multipart upload.
 const defaultMultipartUploadThreshold = 1024 * 1024 * 16
@@ -24,6 +25,14 @@ const defaultPartBodyMaxRetries = 3
 
 const defaultGetBufferSize = 1024 * 1024 * 50
 
+// defaultReadAtBlockSize is the default size of the blocks fetched by an
+// ObjectReaderAt, small enough for the random reads of columnar formats.
+const defaultReadAtBlockSize = 1024 * 1024
+
+// defaultReadAtCacheBlocks is the default number of blocks cached by an
+// ObjectReaderAt.
+const defaultReadAtCacheBlocks = 64
+
 // Client provides the API client to make operations call for Amazon Simple
 // Storage Service's Transfer Manager
 // It is safe to call Client methods concurrently across goroutines.
@@ -46,12 +55,29 @@ func New(s3Client S3APIClient, opts Options, optFns ...func(*Options)) *Client {
 	resolveGetObjectType(&opts)
 	resolvePartBodyMaxRetries(&opts)
 	resolveGetBufferSize(&opts)
+	resolveReadAtBlockSize(&opts)
+	resolveReadAtCacheBlocks(&opts)
+	resolveBandwidthLimiter(&opts)
+	resolveBufferPool(&opts)
 
 	return &Client{
 		options: opts,
 	}
 }
 
+// BandwidthLimiter returns the limiter shared by the operations of the
+// client, which can be used to change its MaxBytesPerSecond while transfers
+// are running.
+func (c *Client) BandwidthLimiter() *BandwidthLimiter {
+	return c.options.BandwidthLimiter
+}
+
+// BufferPool returns the pool bounding the buffer memory of the operations of
+// the client, which reports its utilisation.
+func (c *Client) BufferPool() *BufferPool {
+	return c.options.BufferPool
+}
+
 // NewFromConfig returns a new Client from the provided s3 config
 func NewFromConfig(s3Client S3APIClient, cfg aws.Config, optFns ...func(*Options)) *Client {
 	return New(s3Client, Options{}, optFns...)
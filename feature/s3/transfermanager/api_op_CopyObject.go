@@ -0,0 +1,792 @@
+package transfermanager
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+	smithymiddleware "github.com/aws/smithy-go/middleware"
+)
+
+// maxSingleCopySizeBytes is the largest object a single CopyObject request
+// can copy
+const maxSingleCopySizeBytes = 1024 * 1024 * 1024 * 5
+
+// CopyObjectInput represents a request to the CopyObject() call
+type CopyObjectInput struct {
+	// Bucket the object is copied into
+	Bucket string
+
+	// Key of the copied object
+	Key string
+
+	// Bucket of the source object, which may differ from Bucket
+	SourceBucket string
+
+	// Key of the source object
+	SourceKey string
+
+	// Version of the source object to copy. The latest version is copied if
+	// empty.
+	SourceVersionID string
+
+	// Copies the source object only if its entity tag matches. When the
+	// object is copied in parts it defaults to the entity tag returned by
+	// HeadObject, so that every part is copied from the same object.
+	CopySourceIfMatch string
+
+	// The canned ACL to apply to the copied object
+	ACL types.ObjectCannedACL
+
+	// Whether the metadata and content headers of the copied object are
+	// copied from the source object or replaced with the values of this
+	// input. Defaults to types.MetadataDirectiveCopy.
+	MetadataDirective types.MetadataDirective
+
+	// Can be used to specify caching behavior along the request/reply chain,
+	// used with types.MetadataDirectiveReplace
+	CacheControl string
+
+	// Specifies presentational information for the object, used with
+	// types.MetadataDirectiveReplace
+	ContentDisposition string
+
+	// Specifies what content encodings have been applied to the object, used
+	// with types.MetadataDirectiveReplace
+	ContentEncoding string
+
+	// The language the content is in, used with
+	// types.MetadataDirectiveReplace
+	ContentLanguage string
+
+	// A standard MIME type describing the format of the object data, used
+	// with types.MetadataDirectiveReplace
+	ContentType string
+
+	// The date and time at which the object is no longer cacheable, used with
+	// types.MetadataDirectiveReplace
+	Expires time.Time
+
+	// A map of metadata to store with the object, used with
+	// types.MetadataDirectiveReplace
+	Metadata map[string]string
+
+	// Whether the tag set of the copied object is copied from the source
+	// object or replaced with Tagging. Defaults to types.TaggingDirectiveCopy.
+	TaggingDirective types.TaggingDirective
+
+	// The tag-set for the copied object encoded as URL query parameters,
+	// used with types.TaggingDirectiveReplace
+	Tagging string
+
+	// Whether the retention period and legal holds of the copied object are
+	// copied from the source object or replaced with the retention of this
+	// input. If empty, a single CopyObject leaves it to the service and a
+	// multipart copy behaves as types.RetentionDirectiveCopy.
+	RetentionDirective types.RetentionDirective
+
+	// Date on which it will be legal to delete or modify the copied object,
+	// used with types.RetentionDirectiveReplace
+	RetentionExpirationDate time.Time
+
+	// A legal hold to apply to the copied object, used with
+	// types.RetentionDirectiveReplace
+	RetentionLegalHoldID string
+
+	// Retention period of the copied object in seconds, used with
+	// types.RetentionDirectiveReplace. Zero leaves the bucket's default
+	// retention period in place.
+	RetentionPeriod int64
+
+	// The server-side encryption algorithm used when storing the copied object
+	ServerSideEncryption types.ServerSideEncryption
+
+	// The key used to encrypt the copied object with aws:kms server-side
+	// encryption
+	SSEKMSKeyID string
+
+	// The storage class of the copied object
+	StorageClass types.StorageClass
+
+	// The algorithm used to encrypt the copied object with a customer
+	// provided key
+	SSECustomerAlgorithm string
+
+	// The customer provided key used to encrypt the copied object
+	SSECustomerKey string
+
+	// The MD5 digest of the customer provided key used to encrypt the copied
+	// object
+	SSECustomerKeyMD5 string
+
+	// The customer provided key used to encrypt the copied object. If set,
+	// it replaces SSECustomerAlgorithm, SSECustomerKey and SSECustomerKeyMD5.
+	CustomerKey *SSECustomerKey
+
+	// The algorithm the source object is encrypted with, if it is encrypted
+	// with a customer provided key
+	CopySourceSSECustomerAlgorithm string
+
+	// The customer provided key the source object is encrypted with
+	CopySourceSSECustomerKey string
+
+	// The MD5 digest of the customer provided key the source object is
+	// encrypted with
+	CopySourceSSECustomerKeyMD5 string
+
+	// The customer provided key the source object is encrypted with. If set,
+	// it replaces CopySourceSSECustomerAlgorithm, CopySourceSSECustomerKey
+	// and CopySourceSSECustomerKeyMD5.
+	CopySourceCustomerKey *SSECustomerKey
+
+	// The account ID of the expected destination bucket owner
+	ExpectedBucketOwner string
+
+	// The account ID of the expected source bucket owner
+	ExpectedSourceBucketOwner string
+
+	// Confirms that the requester knows that they will be charged for the
+	// request
+	RequestPayer types.RequestPayer
+}
+
+// withCustomerKeys returns a copy of the input with the members of its
+// CustomerKey and CopySourceCustomerKey set, or the input if it has none
+func (i *CopyObjectInput) withCustomerKeys() *CopyObjectInput {
+	if i == nil || (i.CustomerKey == nil && i.CopySourceCustomerKey == nil) {
+		return i
+	}
+	in := *i
+	in.CustomerKey.apply(&in.SSECustomerAlgorithm, &in.SSECustomerKey, &in.SSECustomerKeyMD5)
+	in.CopySourceCustomerKey.apply(&in.CopySourceSSECustomerAlgorithm, &in.CopySourceSSECustomerKey, &in.CopySourceSSECustomerKeyMD5)
+	return &in
+}
+
+// copySource returns the URL-encoded CopySource of the source object
+func (i CopyObjectInput) copySource() *string {
+	segments := strings.Split(i.SourceKey, "/")
+	for n, s := range segments {
+		segments[n] = url.PathEscape(s)
+	}
+	v := i.SourceBucket + "/" + strings.Join(segments, "/")
+	if i.SourceVersionID != "" {
+		v += "?versionId=" + url.QueryEscape(i.SourceVersionID)
+	}
+	return aws.String(v)
+}
+
+func (i CopyObjectInput) mapHeadObjectInput() *s3.HeadObjectInput {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(i.SourceBucket),
+		Key:    aws.String(i.SourceKey),
+	}
+	if i.RequestPayer != "" {
+		input.RequestPayer = s3types.RequestPayer(i.RequestPayer)
+	}
+	input.VersionId = nzstring(i.SourceVersionID)
+	input.IfMatch = nzstring(i.CopySourceIfMatch)
+	input.ExpectedBucketOwner = nzstring(i.ExpectedSourceBucketOwner)
+	input.SSECustomerAlgorithm = nzstring(i.CopySourceSSECustomerAlgorithm)
+	input.SSECustomerKey = nzstring(i.CopySourceSSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.CopySourceSSECustomerKeyMD5)
+	return input
+}
+
+func (i CopyObjectInput) mapCopyObjectInput() *s3.CopyObjectInput {
+	input := &s3.CopyObjectInput{
+		Bucket:     aws.String(i.Bucket),
+		Key:        aws.String(i.Key),
+		CopySource: i.copySource(),
+	}
+	if i.ACL != "" {
+		input.ACL = s3types.ObjectCannedACL(i.ACL)
+	}
+	if i.MetadataDirective != "" {
+		input.MetadataDirective = s3types.MetadataDirective(i.MetadataDirective)
+	}
+	if i.TaggingDirective != "" {
+		input.TaggingDirective = s3types.TaggingDirective(i.TaggingDirective)
+	}
+	if i.RetentionDirective != "" {
+		input.RetentionDirective = aws.String(string(i.RetentionDirective))
+	}
+	if i.RetentionPeriod != 0 {
+		input.RetentionPeriod = aws.Int64(i.RetentionPeriod)
+	}
+	if i.RequestPayer != "" {
+		input.RequestPayer = s3types.RequestPayer(i.RequestPayer)
+	}
+	if i.ServerSideEncryption != "" {
+		input.ServerSideEncryption = s3types.ServerSideEncryption(i.ServerSideEncryption)
+	}
+	if i.StorageClass != "" {
+		input.StorageClass = s3types.StorageClass(i.StorageClass)
+	}
+	input.CopySourceIfMatch = nzstring(i.CopySourceIfMatch)
+	input.CacheControl = nzstring(i.CacheControl)
+	input.ContentDisposition = nzstring(i.ContentDisposition)
+	input.ContentEncoding = nzstring(i.ContentEncoding)
+	input.ContentLanguage = nzstring(i.ContentLanguage)
+	input.ContentType = nzstring(i.ContentType)
+	input.Expires = nztime(i.Expires)
+	input.Metadata = i.Metadata
+	input.Tagging = nzstring(i.Tagging)
+	input.RetentionExpirationDate = nztime(i.RetentionExpirationDate)
+	input.RetentionLegalHoldId = nzstring(i.RetentionLegalHoldID)
+	input.SSEKMSKeyId = nzstring(i.SSEKMSKeyID)
+	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
+	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
+	input.CopySourceSSECustomerAlgorithm = nzstring(i.CopySourceSSECustomerAlgorithm)
+	input.CopySourceSSECustomerKey = nzstring(i.CopySourceSSECustomerKey)
+	input.CopySourceSSECustomerKeyMD5 = nzstring(i.CopySourceSSECustomerKeyMD5)
+	input.ExpectedBucketOwner = nzstring(i.ExpectedBucketOwner)
+	input.ExpectedSourceBucketOwner = nzstring(i.ExpectedSourceBucketOwner)
+	return input
+}
+
+// mapCreateMultipartUploadInput maps the input to a CreateMultipartUpload of
+// the destination. The metadata of the source object is used unless it is
+// replaced, as a multipart upload does not copy it.
+func (i CopyObjectInput) mapCreateMultipartUploadInput(source *s3.HeadObjectOutput, tagging *string) *s3.CreateMultipartUploadInput {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:  aws.String(i.Bucket),
+		Key:     aws.String(i.Key),
+		Tagging: tagging,
+	}
+	if i.ACL != "" {
+		input.ACL = s3types.ObjectCannedACL(i.ACL)
+	}
+	if i.RequestPayer != "" {
+		input.RequestPayer = s3types.RequestPayer(i.RequestPayer)
+	}
+	if i.ServerSideEncryption != "" {
+		input.ServerSideEncryption = s3types.ServerSideEncryption(i.ServerSideEncryption)
+	}
+	if i.StorageClass != "" {
+		input.StorageClass = s3types.StorageClass(i.StorageClass)
+	}
+	if i.MetadataDirective == types.MetadataDirectiveReplace {
+		input.CacheControl = nzstring(i.CacheControl)
+		input.ContentDisposition = nzstring(i.ContentDisposition)
+		input.ContentEncoding = nzstring(i.ContentEncoding)
+		input.ContentLanguage = nzstring(i.ContentLanguage)
+		input.ContentType = nzstring(i.ContentType)
+		input.Expires = nztime(i.Expires)
+		input.Metadata = i.Metadata
+	} else {
+		input.CacheControl = source.CacheControl
+		input.ContentDisposition = source.ContentDisposition
+		input.ContentEncoding = source.ContentEncoding
+		input.ContentLanguage = source.ContentLanguage
+		input.ContentType = source.ContentType
+		input.Expires = source.Expires
+		input.Metadata = source.Metadata
+		input.WebsiteRedirectLocation = source.WebsiteRedirectLocation
+	}
+	input.SSEKMSKeyId = nzstring(i.SSEKMSKeyID)
+	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
+	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
+	input.ExpectedBucketOwner = nzstring(i.ExpectedBucketOwner)
+	return input
+}
+
+func (i CopyObjectInput) mapUploadPartCopyInput(partNum *int32, uploadID *string, first, last int64, etag *string) *s3.UploadPartCopyInput {
+	input := &s3.UploadPartCopyInput{
+		Bucket:            aws.String(i.Bucket),
+		Key:               aws.String(i.Key),
+		CopySource:        i.copySource(),
+		CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", first, last)),
+		CopySourceIfMatch: etag,
+		PartNumber:        partNum,
+		UploadId:          uploadID,
+	}
+	if i.RequestPayer != "" {
+		input.RequestPayer = s3types.RequestPayer(i.RequestPayer)
+	}
+	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
+	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
+	input.CopySourceSSECustomerAlgorithm = nzstring(i.CopySourceSSECustomerAlgorithm)
+	input.CopySourceSSECustomerKey = nzstring(i.CopySourceSSECustomerKey)
+	input.CopySourceSSECustomerKeyMD5 = nzstring(i.CopySourceSSECustomerKeyMD5)
+	input.ExpectedBucketOwner = nzstring(i.ExpectedBucketOwner)
+	input.ExpectedSourceBucketOwner = nzstring(i.ExpectedSourceBucketOwner)
+	return input
+}
+
+func (i CopyObjectInput) mapCompleteMultipartUploadInput(uploadID *string, completedParts completedParts) *s3.CompleteMultipartUploadInput {
+	input := &s3.CompleteMultipartUploadInput{
+		Bucket:   aws.String(i.Bucket),
+		Key:      aws.String(i.Key),
+		UploadId: uploadID,
+	}
+	if i.RequestPayer != "" {
+		input.RequestPayer = s3types.RequestPayer(i.RequestPayer)
+	}
+	input.ExpectedBucketOwner = nzstring(i.ExpectedBucketOwner)
+	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
+	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
+	var parts []s3types.CompletedPart
+	for _, part := range completedParts {
+		parts = append(parts, part.MapCompletedPart())
+	}
+	if parts != nil {
+		input.MultipartUpload = &s3types.CompletedMultipartUpload{Parts: parts}
+	}
+	return input
+}
+
+func (i CopyObjectInput) mapAbortMultipartUploadInput(uploadID *string) *s3.AbortMultipartUploadInput {
+	return &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(i.Bucket),
+		Key:      aws.String(i.Key),
+		UploadId: uploadID,
+	}
+}
+
+// CopyObjectOutput represents a response from the CopyObject() call
+type CopyObjectOutput struct {
+	// The ID of the multipart upload used to copy the object. Will be empty
+	// if the object was copied with a single CopyObject call.
+	UploadID string
+
+	// The list of parts that were copied. Will be empty if the object was
+	// copied with a single CopyObject call.
+	CompletedParts []types.CompletedPart
+
+	// Bucket the object was copied into
+	Bucket string
+
+	// Key of the copied object
+	Key string
+
+	// Entity tag of the copied object
+	ETag string
+
+	// Version ID of the copied object
+	VersionID string
+
+	// Version ID of the source object that was copied
+	CopySourceVersionID string
+
+	// The size of the copied object
+	ObjectSize int64
+
+	// The server-side encryption algorithm used when storing the copied object
+	ServerSideEncryption types.ServerSideEncryption
+
+	// The key the copied object is encrypted with, if it is stored with
+	// aws:kms server-side encryption
+	SSEKMSKeyID string
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata smithymiddleware.Metadata
+}
+
+func (o *CopyObjectOutput) mapFromCopyObjectOutput(out *s3.CopyObjectOutput, bucket, key string) {
+	o.Bucket = bucket
+	o.Key = key
+	if r := out.CopyObjectResult; r != nil {
+		o.ETag = aws.ToString(r.ETag)
+	}
+	o.VersionID = aws.ToString(out.VersionId)
+	o.CopySourceVersionID = aws.ToString(out.CopySourceVersionId)
+	o.ServerSideEncryption = types.ServerSideEncryption(out.ServerSideEncryption)
+	o.SSEKMSKeyID = aws.ToString(out.SSEKMSKeyId)
+	o.ResultMetadata = out.ResultMetadata.Clone()
+}
+
+func (o *CopyObjectOutput) mapFromCompleteMultipartUploadOutput(out *s3.CompleteMultipartUploadOutput, bucket, key, uploadID string, completedParts completedParts) {
+	o.UploadID = uploadID
+	o.CompletedParts = completedParts
+	o.Bucket = bucket
+	o.Key = key
+	o.ETag = aws.ToString(out.ETag)
+	o.VersionID = aws.ToString(out.VersionId)
+	o.ServerSideEncryption = types.ServerSideEncryption(out.ServerSideEncryption)
+	o.SSEKMSKeyID = aws.ToString(out.SSEKMSKeyId)
+	o.ResultMetadata = out.ResultMetadata
+}
+
+// CopyObject copies an object server-side, within a bucket or across
+// buckets. The source object is inspected with HeadObject, objects smaller
+// than Options.MultipartUploadThreshold are copied with a single CopyObject
+// request and larger ones with a multipart upload whose parts are copied in
+// parallel with UploadPartCopy. Objects larger than 5 GiB are always copied
+// in parts.
+//
+// A multipart copy carries over the metadata, content headers and tag set of
+// the source object unless they are replaced, as a single CopyObject would.
+// As a multipart upload cannot set the retention of an object, the retention
+// period and legal holds are applied once the upload completes. If that
+// fails, the copied object exists and the error is returned along with the
+// output. If a part fails to copy the multipart upload is aborted.
+//
+// Additional functional options can be provided to configure the individual
+// copy. These options are copies of the original Options instance, the client of which CopyObject is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) CopyObject(ctx context.Context, input *CopyObjectInput, opts ...func(*Options)) (*CopyObjectOutput, error) {
+	i := copier{in: input, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+
+	return i.copy(ctx)
+}
+
+type copier struct {
+	options Options
+	in      *CopyObjectInput
+
+	clientOptions []func(*s3.Options)
+	source        *s3.HeadObjectOutput
+	objectSize    int64
+}
+
+func (c *copier) copy(ctx context.Context) (*CopyObjectOutput, error) {
+	c.in = c.in.withCustomerKeys()
+	if err := c.init(); err != nil {
+		return nil, fmt.Errorf("unable to initialize copy: %w", err)
+	}
+
+	headInput := c.in.mapHeadObjectInput()
+	source, err := c.options.S3.HeadObject(ctx, headInput, c.clientOptions...)
+	if err != nil {
+		err = headCustomerKeyError(ctx, c.options.S3, headInput, err, c.clientOptions...)
+		return nil, fmt.Errorf("failed to head source object: %w", err)
+	}
+	c.source = source
+	c.objectSize = aws.ToInt64(source.ContentLength)
+
+	if c.objectSize < c.options.MultipartUploadThreshold && c.objectSize <= maxSingleCopySizeBytes {
+		return c.singleCopy(ctx)
+	}
+
+	mc := multiCopier{copier: c}
+	return mc.copy(ctx)
+}
+
+func (c *copier) init() error {
+	if c.in.Bucket == "" || c.in.Key == "" {
+		return fmt.Errorf("bucket and key are required")
+	}
+	if c.in.SourceBucket == "" || c.in.SourceKey == "" {
+		return fmt.Errorf("source bucket and key are required")
+	}
+	if c.options.PartSizeBytes < minPartSizeBytes {
+		return fmt.Errorf("part size must be at least %d bytes", minPartSizeBytes)
+	}
+
+	c.clientOptions = []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+	return nil
+}
+
+func (c *copier) singleCopy(ctx context.Context) (*CopyObjectOutput, error) {
+	if err := c.options.requestBudget.acquire(ctx); err != nil {
+		return nil, err
+	}
+	out, err := c.options.S3.CopyObject(ctx, c.in.mapCopyObjectInput(), c.clientOptions...)
+	c.options.requestBudget.release()
+	if err != nil {
+		return nil, err
+	}
+
+	var output CopyObjectOutput
+	output.mapFromCopyObjectOutput(out, c.in.Bucket, c.in.Key)
+	output.ObjectSize = c.objectSize
+	return &output, nil
+}
+
+type multiCopier struct {
+	*copier
+	wg       sync.WaitGroup
+	m        sync.Mutex
+	err      error
+	uploadID *string
+	etag     *string
+	parts    completedParts
+}
+
+type copyChunk struct {
+	partNum     *int32
+	first, last int64
+}
+
+func (u *multiCopier) copy(ctx context.Context) (*CopyObjectOutput, error) {
+	partSize := u.options.PartSizeBytes
+	if u.objectSize/partSize >= int64(defaultMaxUploadParts) {
+		partSize = (u.objectSize / int64(defaultMaxUploadParts)) + 1
+	}
+
+	u.etag = nzstring(u.in.CopySourceIfMatch)
+	if u.etag == nil {
+		u.etag = u.source.ETag
+	}
+
+	tagging, err := u.tagging(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := u.in.mapCreateMultipartUploadInput(u.source, tagging)
+	resp, err := u.options.S3.CreateMultipartUpload(ctx, params, u.clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	u.uploadID = resp.UploadId
+
+	ch := make(chan copyChunk, u.options.Concurrency)
+	for i := 0; i < u.options.Concurrency; i++ {
+		u.wg.Add(1)
+		go u.readChunk(ctx, ch)
+	}
+
+	var partNum int32 = 1
+	for first := int64(0); u.geterr() == nil && first < u.objectSize; first += partSize {
+		last := first + partSize - 1
+		if last >= u.objectSize {
+			last = u.objectSize - 1
+		}
+		ch <- copyChunk{partNum: aws.Int32(partNum), first: first, last: last}
+		partNum++
+	}
+
+	close(ch)
+	u.wg.Wait()
+	completeOut := u.complete(ctx)
+
+	if err := u.geterr(); err != nil {
+		return nil, &multipartUploadError{
+			err:      err,
+			uploadID: aws.ToString(u.uploadID),
+		}
+	}
+
+	var out CopyObjectOutput
+	out.mapFromCompleteMultipartUploadOutput(completeOut, u.in.Bucket, u.in.Key, aws.ToString(u.uploadID), u.parts)
+	out.CopySourceVersionID = aws.ToString(u.source.VersionId)
+	out.ObjectSize = u.objectSize
+
+	if err := u.applyRetention(ctx); err != nil {
+		return &out, fmt.Errorf("failed to apply retention to copied object: %w", err)
+	}
+	return &out, nil
+}
+
+// tagging returns the tag set of the copied object, which is read from the
+// source object unless it is replaced.
+func (u *multiCopier) tagging(ctx context.Context) (*string, error) {
+	if u.in.TaggingDirective == types.TaggingDirectiveReplace {
+		return nzstring(u.in.Tagging), nil
+	}
+
+	input := &s3.GetObjectTaggingInput{
+		Bucket:              aws.String(u.in.SourceBucket),
+		Key:                 aws.String(u.in.SourceKey),
+		VersionId:           nzstring(u.in.SourceVersionID),
+		ExpectedBucketOwner: nzstring(u.in.ExpectedSourceBucketOwner),
+	}
+	if u.in.RequestPayer != "" {
+		input.RequestPayer = s3types.RequestPayer(u.in.RequestPayer)
+	}
+	return sourceTagging(ctx, u.options.S3, input, u.clientOptions...)
+}
+
+// sourceTagging returns the tag set of the source object as URL-encoded query
+// parameters, or nil if it has no tags.
+func sourceTagging(ctx context.Context, client S3APIClient, input *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*string, error) {
+	out, err := client.GetObjectTagging(ctx, input, optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source object tagging: %w", err)
+	}
+	if len(out.TagSet) == 0 {
+		return nil, nil
+	}
+
+	tags := url.Values{}
+	for _, t := range out.TagSet {
+		tags.Add(aws.ToString(t.Key), aws.ToString(t.Value))
+	}
+	return aws.String(tags.Encode()), nil
+}
+
+// readChunk runs in worker goroutines to pull part ranges off of the ch
+// channel and send() them as UploadPartCopy requests.
+func (u *multiCopier) readChunk(ctx context.Context, ch chan copyChunk) {
+	defer u.wg.Done()
+	for c := range ch {
+		if u.geterr() == nil {
+			if err := u.send(ctx, c); err != nil {
+				u.seterr(err)
+			}
+		}
+	}
+}
+
+// send performs an UploadPartCopy request and keeps track of the completed
+// part information.
+func (u *multiCopier) send(ctx context.Context, c copyChunk) error {
+	params := u.in.mapUploadPartCopyInput(c.partNum, u.uploadID, c.first, c.last, u.etag)
+	if err := u.options.requestBudget.acquire(ctx); err != nil {
+		return err
+	}
+	resp, err := u.options.S3.UploadPartCopy(ctx, params, u.clientOptions...)
+	u.options.requestBudget.release()
+	if err != nil {
+		return err
+	}
+
+	var completed types.CompletedPart
+	completed.MapFromCopyPart(resp, c.partNum)
+
+	u.m.Lock()
+	u.parts = append(u.parts, completed)
+	u.m.Unlock()
+
+	return nil
+}
+
+// geterr is a thread-safe getter for the error object
+func (u *multiCopier) geterr() error {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	return u.err
+}
+
+// seterr is a thread-safe setter for the error object
+func (u *multiCopier) seterr(e error) {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	u.err = e
+}
+
+func (u *multiCopier) fail(ctx context.Context) {
+	params := u.in.mapAbortMultipartUploadInput(u.uploadID)
+	_, err := u.options.S3.AbortMultipartUpload(ctx, params, u.clientOptions...)
+	if err != nil {
+		u.seterr(fmt.Errorf("failed to abort multipart upload (%v), triggered after multipart copy failed: %v", err, u.geterr()))
+	}
+}
+
+// complete successfully completes a multipart upload and returns the response.
+func (u *multiCopier) complete(ctx context.Context) *s3.CompleteMultipartUploadOutput {
+	if u.geterr() != nil {
+		u.fail(ctx)
+		return nil
+	}
+
+	// Parts must be sorted in PartNumber order.
+	sort.Sort(u.parts)
+
+	params := u.in.mapCompleteMultipartUploadInput(u.uploadID, u.parts)
+
+	resp, err := u.options.S3.CompleteMultipartUpload(ctx, params, u.clientOptions...)
+	if err != nil {
+		u.seterr(err)
+		u.fail(ctx)
+	}
+
+	return resp
+}
+
+// applyRetention sets the retention period and legal holds of the completed
+// object according to the retention directive.
+func (u *multiCopier) applyRetention(ctx context.Context) error {
+	if u.in.RetentionDirective != types.RetentionDirectiveReplace {
+		expiration, legalHolds, err := sourceRetention(ctx, u.options.S3, u.in.SourceBucket, u.in.SourceKey, u.source, u.clientOptions...)
+		if err != nil {
+			return err
+		}
+		return putRetention(ctx, u.options.S3, u.in.Bucket, u.in.Key, expiration, legalHolds, u.clientOptions...)
+	}
+
+	if u.in.RetentionPeriod != 0 || !u.in.RetentionExpirationDate.IsZero() {
+		extend := &s3.ExtendObjectRetentionInput{
+			Bucket:                     aws.String(u.in.Bucket),
+			Key:                        aws.String(u.in.Key),
+			NewRetentionExpirationDate: nztime(u.in.RetentionExpirationDate),
+		}
+		if u.in.RetentionPeriod != 0 {
+			extend.NewRetentionPeriod = aws.Int64(u.in.RetentionPeriod)
+		}
+		if _, err := u.options.S3.ExtendObjectRetention(ctx, extend, u.clientOptions...); err != nil {
+			return err
+		}
+	}
+	if u.in.RetentionLegalHoldID != "" {
+		return putRetention(ctx, u.options.S3, u.in.Bucket, u.in.Key, nil, []string{u.in.RetentionLegalHoldID}, u.clientOptions...)
+	}
+	return nil
+}
+
+// sourceRetention returns the retention expiration date and legal holds of
+// the source object described by head, read with client. The expiration date
+// is nil unless it lies in the future.
+func sourceRetention(ctx context.Context, client S3APIClient, bucket, key string, head *s3.HeadObjectOutput, optFns ...func(*s3.Options)) (*time.Time, []string, error) {
+	if head.RetentionExpirationDate == nil && aws.ToInt64(head.RetentionLegalHoldCount) == 0 {
+		return nil, nil, nil
+	}
+	holds, err := client.ListLegalHolds(ctx, &s3.ListLegalHoldsInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, optFns...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list source object legal holds: %w", err)
+	}
+
+	var expiration *time.Time
+	if t := holds.RetentionPeriodExpirationDate; t != nil && t.After(time.Now()) {
+		expiration = t
+	}
+	var legalHolds []string
+	for _, h := range holds.LegalHolds {
+		legalHolds = append(legalHolds, aws.ToString(h.ID))
+	}
+	return expiration, legalHolds, nil
+}
+
+// putRetention extends the retention of an object to expiration, if set, and
+// adds the legal holds to it.
+func putRetention(ctx context.Context, client S3APIClient, bucket, key string, expiration *time.Time, legalHolds []string, optFns ...func(*s3.Options)) error {
+	if expiration != nil {
+		_, err := client.ExtendObjectRetention(ctx, &s3.ExtendObjectRetentionInput{
+			Bucket:                     aws.String(bucket),
+			Key:                        aws.String(key),
+			NewRetentionExpirationDate: expiration,
+		}, optFns...)
+		if err != nil {
+			return err
+		}
+	}
+	for _, id := range legalHolds {
+		_, err := client.AddLegalHold(ctx, &s3.AddLegalHoldInput{
+			Bucket:               aws.String(bucket),
+			Key:                  aws.String(key),
+			RetentionLegalHoldId: aws.String(id),
+		}, optFns...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
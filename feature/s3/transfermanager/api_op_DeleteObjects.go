@@ -0,0 +1,551 @@
+package transfermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/retry"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+// ErrMaxDeletesExceeded is returned by DeleteObjects when more objects than
+// DeleteObjectsInput.MaxDeletes would be deleted. No object is deleted then.
+var ErrMaxDeletesExceeded = errors.New("objects to delete exceed the max deletes limit")
+
+// defaultDeleteMaxRetries is the default number of times the keys which
+// failed to be deleted with a retryable error are retried
+const defaultDeleteMaxRetries = 3
+
+// deleteRetryBaseDelay is the delay before the first retry of failed keys,
+// doubled for every further retry
+const deleteRetryBaseDelay = 100 * time.Millisecond
+
+// ObjectVersionIterator yields the objects deleted by DeleteObjects, e.g.
+// read from a manifest.
+type ObjectVersionIterator interface {
+	// Next advances to the next object. It returns false when there are no
+	// objects left or the iteration failed.
+	Next(ctx context.Context) bool
+
+	// ObjectVersion returns the current object. Only its Key and VersionID
+	// are required.
+	ObjectVersion() types.ObjectVersion
+
+	// Err returns the error which stopped the iteration, if any
+	Err() error
+}
+
+// NewObjectVersionSliceIterator returns an ObjectVersionIterator yielding
+// objects.
+func NewObjectVersionSliceIterator(objects []types.ObjectVersion) ObjectVersionIterator {
+	return &sliceIterator{objects: objects, index: -1}
+}
+
+// NewKeySliceIterator returns an ObjectVersionIterator yielding the current
+// versions of keys.
+func NewKeySliceIterator(keys []string) ObjectVersionIterator {
+	objects := make([]types.ObjectVersion, len(keys))
+	for i, key := range keys {
+		objects[i].Key = key
+	}
+	return NewObjectVersionSliceIterator(objects)
+}
+
+// DeleteObjectsInput represents a request to the DeleteObjects() call
+type DeleteObjectsInput struct {
+	// Bucket the objects are deleted from
+	Bucket string
+
+	// The objects to delete. If nil, the objects under Prefix are listed
+	// and deleted.
+	Objects ObjectVersionIterator
+
+	// The key prefix of the objects to delete, if Objects is nil. An empty
+	// prefix deletes every object of the bucket.
+	Prefix string
+
+	// Whether every version and delete marker under Prefix is deleted, as
+	// listed with ListObjectVersions. Otherwise the current versions are
+	// listed with ListObjectsV2 and deleted, which adds delete markers in a
+	// versioned bucket.
+	AllVersions bool
+
+	// Selects the objects to delete, whether listed or yielded by Objects.
+	// If nil, every object is deleted.
+	Filter func(types.ObjectVersion) bool
+
+	// Whether the objects to delete are only reported in
+	// DeleteObjectsOutput.Objects, without being deleted.
+	DryRun bool
+
+	// The maximum number of objects deleted. If more objects would be
+	// deleted, DeleteObjects returns ErrMaxDeletesExceeded before deleting
+	// any. If zero, the number of objects is not limited.
+	//
+	// The objects to delete are held in memory until they are all known.
+	MaxDeletes int
+
+	// The number of times the objects which failed to be deleted with a
+	// retryable error, e.g. SlowDown or InternalError, are retried. If zero,
+	// defaultDeleteMaxRetries is used.
+	MaxRetries int
+
+	// The account ID of the expected bucket owner
+	ExpectedBucketOwner string
+}
+
+// DeleteObjectsFailure describes an object which could not be deleted
+type DeleteObjectsFailure struct {
+	// The key of the object
+	Key string
+
+	// The version of the object, empty for its current version
+	VersionID string
+
+	// The error code reported by the service, empty if the request failed
+	Code string
+
+	// The cause of the failure
+	Err error
+}
+
+// DeleteObjectsOutput represents a response from the DeleteObjects() call
+type DeleteObjectsOutput struct {
+	// The number of objects deleted, or which would be deleted in a dry run
+	ObjectsDeleted int
+
+	// The objects which would be deleted, only set in a dry run
+	Objects []types.ObjectVersion
+
+	// The objects which could not be deleted because they are protected by
+	// a legal hold or a retention period, sorted by key
+	Protected []DeleteObjectsFailure
+
+	// The objects which failed to be deleted otherwise, sorted by key
+	Failures []DeleteObjectsFailure
+}
+
+// DeleteObjects deletes objects in batches of up to 1000 keys, sent with
+// concurrent DeleteObjects requests. The objects are either yielded by an
+// iterator or listed under a key prefix, optionally with all their versions,
+// and can be selected with a filter.
+//
+// The keys which fail to be deleted with a retryable error are retried. Those
+// under a legal hold or a retention period are reported apart from the other
+// failures. The failure of an object does not stop the deletion of the
+// others, while a failure to list or iterate the objects does.
+//
+// Additional functional options can be provided to configure the individual
+// deletion. These options are copies of the original Options instance, the client of which DeleteObjects is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) DeleteObjects(ctx context.Context, input *DeleteObjectsInput, opts ...func(*Options)) (*DeleteObjectsOutput, error) {
+	i := batchDeleter{in: input, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+
+	return i.delete(ctx)
+}
+
+type batchDeleter struct {
+	options Options
+	in      *DeleteObjectsInput
+
+	maxRetries int
+
+	m   sync.Mutex
+	out DeleteObjectsOutput
+}
+
+func (d *batchDeleter) delete(ctx context.Context) (*DeleteObjectsOutput, error) {
+	if err := d.init(); err != nil {
+		return nil, err
+	}
+
+	objects := d.iterator()
+	if d.in.MaxDeletes > 0 {
+		collected, err := d.collect(ctx, objects)
+		if err != nil {
+			return nil, err
+		}
+		objects = NewObjectVersionSliceIterator(collected)
+	}
+
+	ch := make(chan []types.ObjectVersion, d.options.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < d.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range ch {
+				d.deleteBatch(ctx, batch)
+			}
+		}()
+	}
+
+	batch := make([]types.ObjectVersion, 0, maxDeleteObjects)
+	for ctx.Err() == nil && objects.Next(ctx) {
+		obj := objects.ObjectVersion()
+		if d.in.DryRun {
+			d.out.Objects = append(d.out.Objects, obj)
+			d.out.ObjectsDeleted++
+			continue
+		}
+		batch = append(batch, obj)
+		if len(batch) == maxDeleteObjects {
+			ch <- batch
+			batch = make([]types.ObjectVersion, 0, maxDeleteObjects)
+		}
+	}
+	if len(batch) > 0 && ctx.Err() == nil {
+		ch <- batch
+	}
+	close(ch)
+	wg.Wait()
+
+	out := d.output()
+	if err := objects.Err(); err != nil {
+		return out, err
+	}
+	return out, ctx.Err()
+}
+
+func (d *batchDeleter) init() error {
+	if d.in == nil {
+		return fmt.Errorf("input is required")
+	}
+	if d.in.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if d.in.Objects != nil && (d.in.Prefix != "" || d.in.AllVersions) {
+		return fmt.Errorf("prefix and all versions cannot be set with objects")
+	}
+	if d.in.MaxDeletes < 0 {
+		return fmt.Errorf("max deletes must not be negative")
+	}
+	resolveConcurrency(&d.options)
+
+	d.maxRetries = d.in.MaxRetries
+	if d.maxRetries == 0 {
+		d.maxRetries = defaultDeleteMaxRetries
+	}
+	return nil
+}
+
+// iterator returns the iterator of the objects to delete, selected by the
+// filter
+func (d *batchDeleter) iterator() ObjectVersionIterator {
+	objects := d.in.Objects
+	if objects == nil {
+		objects = &listIterator{
+			client:      d.options.S3,
+			bucket:      d.in.Bucket,
+			prefix:      d.in.Prefix,
+			allVersions: d.in.AllVersions,
+			owner:       d.in.ExpectedBucketOwner,
+		}
+	}
+	if d.in.Filter != nil {
+		objects = &filterIterator{ObjectVersionIterator: objects, filter: d.in.Filter}
+	}
+	return objects
+}
+
+// collect returns all the objects to delete, or ErrMaxDeletesExceeded if there
+// are more than MaxDeletes.
+func (d *batchDeleter) collect(ctx context.Context, objects ObjectVersionIterator) ([]types.ObjectVersion, error) {
+	var collected []types.ObjectVersion
+	for objects.Next(ctx) {
+		if len(collected) == d.in.MaxDeletes {
+			return nil, fmt.Errorf("more than %d objects to delete: %w", d.in.MaxDeletes, ErrMaxDeletesExceeded)
+		}
+		collected = append(collected, objects.ObjectVersion())
+	}
+	return collected, objects.Err()
+}
+
+// deleteBatch deletes a batch of objects, retrying the objects which failed
+// with a retryable error.
+func (d *batchDeleter) deleteBatch(ctx context.Context, batch []types.ObjectVersion) {
+	clientOptions := []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+
+	for attempt := 0; len(batch) > 0; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(deleteRetryBaseDelay << (attempt - 1)):
+			case <-ctx.Done():
+				d.failAll(batch, ctx.Err())
+				return
+			}
+		}
+
+		byID := make(map[string]types.ObjectVersion, len(batch))
+		objects := make([]s3types.ObjectIdentifier, 0, len(batch))
+		for _, obj := range batch {
+			byID[objectVersionID(obj.Key, obj.VersionID)] = obj
+			objects = append(objects, s3types.ObjectIdentifier{
+				Key:       aws.String(obj.Key),
+				VersionId: nzstring(obj.VersionID),
+			})
+		}
+
+		if err := d.options.requestBudget.acquire(ctx); err != nil {
+			d.failAll(batch, err)
+			return
+		}
+		out, err := d.options.S3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket:              aws.String(d.in.Bucket),
+			Delete:              &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+			ExpectedBucketOwner: nzstring(d.in.ExpectedBucketOwner),
+		}, clientOptions...)
+		d.options.requestBudget.release()
+		if err != nil {
+			// the request was retried by the client already
+			d.failAll(batch, err)
+			return
+		}
+
+		var retried []types.ObjectVersion
+		d.m.Lock()
+		for _, e := range out.Errors {
+			obj, ok := byID[objectVersionID(aws.ToString(e.Key), aws.ToString(e.VersionId))]
+			if !ok {
+				continue
+			}
+			code, message := aws.ToString(e.Code), aws.ToString(e.Message)
+			failure := DeleteObjectsFailure{
+				Key:       obj.Key,
+				VersionID: obj.VersionID,
+				Code:      code,
+				Err:       fmt.Errorf("%s: %s", code, message),
+			}
+			switch {
+			case isProtectedError(code, message):
+				d.out.Protected = append(d.out.Protected, failure)
+			case isRetryableDeleteError(code) && attempt < d.maxRetries:
+				retried = append(retried, obj)
+			default:
+				d.out.Failures = append(d.out.Failures, failure)
+			}
+		}
+		d.out.ObjectsDeleted += len(batch) - len(out.Errors)
+		d.m.Unlock()
+
+		batch = retried
+	}
+}
+
+// failAll records the failure of all the objects of a batch
+func (d *batchDeleter) failAll(batch []types.ObjectVersion, err error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	for _, obj := range batch {
+		d.out.Failures = append(d.out.Failures, DeleteObjectsFailure{
+			Key:       obj.Key,
+			VersionID: obj.VersionID,
+			Err:       err,
+		})
+	}
+}
+
+func (d *batchDeleter) output() *DeleteObjectsOutput {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	out := d.out
+	sortDeleteFailures(out.Protected)
+	sortDeleteFailures(out.Failures)
+	return &out
+}
+
+func sortDeleteFailures(failures []DeleteObjectsFailure) {
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Key != failures[j].Key {
+			return failures[i].Key < failures[j].Key
+		}
+		return failures[i].VersionID < failures[j].VersionID
+	})
+}
+
+// objectVersionID identifies a version of an object in a batch
+func objectVersionID(key, versionID string) string {
+	return key + "\x00" + versionID
+}
+
+// isProtectedError returns whether the error of a deleted key reports an
+// object under a legal hold or a retention period
+func isProtectedError(code, message string) bool {
+	switch code {
+	case "ObjectLocked", "ObjectLockedException":
+		return true
+	case "AccessDenied":
+		message = strings.ToLower(message)
+		for _, s := range []string{"retention", "legal hold", "object lock", "protected"} {
+			if strings.Contains(message, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRetryableDeleteError returns whether the error of a deleted key is
+// transient, so that deleting the key again may succeed
+func isRetryableDeleteError(code string) bool {
+	switch code {
+	case "InternalError", "ServiceUnavailable", "SlowDown", "RequestTimeout", "OperationAborted":
+		return true
+	}
+	_, ok := retry.DefaultThrottleErrorCodes[code]
+	return ok
+}
+
+// sliceIterator is an ObjectVersionIterator over a slice
+type sliceIterator struct {
+	objects []types.ObjectVersion
+	index   int
+}
+
+func (it *sliceIterator) Next(ctx context.Context) bool {
+	if it.index+1 >= len(it.objects) {
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *sliceIterator) ObjectVersion() types.ObjectVersion {
+	return it.objects[it.index]
+}
+
+func (it *sliceIterator) Err() error {
+	return nil
+}
+
+// filterIterator yields the objects of an ObjectVersionIterator selected by
+// filter
+type filterIterator struct {
+	ObjectVersionIterator
+	filter func(types.ObjectVersion) bool
+}
+
+func (it *filterIterator) Next(ctx context.Context) bool {
+	for it.ObjectVersionIterator.Next(ctx) {
+		if it.filter(it.ObjectVersion()) {
+			return true
+		}
+	}
+	return false
+}
+
+// listIterator yields the objects, or all the versions of the objects,
+// under a key prefix
+type listIterator struct {
+	client      S3APIClient
+	bucket      string
+	prefix      string
+	allVersions bool
+	owner       string
+
+	objects  *s3.ListObjectsV2Paginator
+	versions *s3.ListObjectVersionsPaginator
+	page     []types.ObjectVersion
+	cur      types.ObjectVersion
+	err      error
+}
+
+func (it *listIterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.err != nil || !it.fetch(ctx) {
+			return false
+		}
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// fetch lists the next page of objects, returning false if there are none
+func (it *listIterator) fetch(ctx context.Context) bool {
+	clientOptions := []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+
+	if it.allVersions {
+		if it.versions == nil {
+			it.versions = s3.NewListObjectVersionsPaginator(it.client, &s3.ListObjectVersionsInput{
+				Bucket:              aws.String(it.bucket),
+				Prefix:              nzstring(it.prefix),
+				ExpectedBucketOwner: nzstring(it.owner),
+			})
+		}
+		if !it.versions.HasMorePages() {
+			return false
+		}
+		page, err := it.versions.NextPage(ctx, clientOptions...)
+		if err != nil {
+			it.err = fmt.Errorf("failed to list object versions: %w", err)
+			return false
+		}
+		for _, v := range page.Versions {
+			var obj types.ObjectVersion
+			obj.MapFromVersion(v)
+			it.page = append(it.page, obj)
+		}
+		for _, m := range page.DeleteMarkers {
+			var obj types.ObjectVersion
+			obj.MapFromDeleteMarker(m)
+			it.page = append(it.page, obj)
+		}
+		return true
+	}
+
+	if it.objects == nil {
+		it.objects = s3.NewListObjectsV2Paginator(it.client, &s3.ListObjectsV2Input{
+			Bucket:              aws.String(it.bucket),
+			Prefix:              nzstring(it.prefix),
+			ExpectedBucketOwner: nzstring(it.owner),
+		})
+	}
+	if !it.objects.HasMorePages() {
+		return false
+	}
+	page, err := it.objects.NextPage(ctx, clientOptions...)
+	if err != nil {
+		it.err = fmt.Errorf("failed to list objects: %w", err)
+		return false
+	}
+	for _, o := range page.Contents {
+		var obj types.ObjectVersion
+		obj.MapFromObject(o)
+		it.page = append(it.page, obj)
+	}
+	return true
+}
+
+func (it *listIterator) ObjectVersion() types.ObjectVersion {
+	return it.cur
+}
+
+func (it *listIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,367 @@
+package transfermanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// DownloadDirectoryInput represents a request to the DownloadDirectory() call
+type DownloadDirectoryInput struct {
+	// Bucket the objects are downloaded from
+	Bucket string
+
+	// The local directory the objects are written to. It is created if it
+	// does not exist.
+	Destination string
+
+	// Only objects whose key starts with this prefix are downloaded. The
+	// prefix is stripped from the keys to form the local paths, so that an
+	// object "logs/2024/app.log" downloaded with prefix "logs/" is written to
+	// "2024/app.log" under Destination.
+	KeyPrefix string
+
+	// Invoked for each listed object. If set, only objects for which it
+	// returns true are downloaded.
+	Filter func(types.Object) bool
+
+	// Invoked with the input of each object before it is downloaded, e.g. to
+	// set its SSECustomerKey.
+	Callback func(*DownloadObjectInput)
+
+	// How the failure of a single object is handled. Defaults to
+	// types.FailurePolicyAbort.
+	FailurePolicy types.FailurePolicy
+}
+
+// DownloadDirectoryFailure describes an object which could not be downloaded
+type DownloadDirectoryFailure struct {
+	// The key of the object
+	Key string
+
+	// The local path the object was written to, empty if the key does not
+	// map to a path inside Destination
+	Path string
+
+	// The cause of the failure
+	Err error
+}
+
+// DownloadDirectoryOutput represents a response from the DownloadDirectory() call
+type DownloadDirectoryOutput struct {
+	// The number of objects downloaded
+	ObjectsDownloaded int
+
+	// The number of objects which failed to download
+	ObjectsFailed int
+
+	// The total size of the objects downloaded
+	BytesDownloaded int64
+
+	// The objects which failed to download, sorted by key. With
+	// types.FailurePolicyAbort it holds at most the failure which aborted the
+	// download.
+	Failures []DownloadDirectoryFailure
+}
+
+// DownloadDirectory downloads the objects under a key prefix to a local
+// directory, each with DownloadObject, so that large objects are fetched in
+// parallel parts or ranges.
+//
+// Keys are mapped to paths by stripping KeyPrefix and treating "/" as the
+// path separator. Keys which would resolve outside of Destination, such as
+// keys with ".." segments, are reported as failures and never written, and
+// keys ending in "/" are skipped as directory markers. Each object is written
+// to a temporary file in its target directory which is renamed into place
+// once complete, so that an interrupted download never leaves a truncated
+// file behind. The modification time of each file is set to the object's
+// LastModified time.
+//
+// Up to Options.Concurrency objects are downloaded at once, and their
+// GetObject requests share a budget of Options.Concurrency in-flight
+// requests.
+//
+// Additional functional options can be provided to configure the individual
+// download. These options are copies of the original Options instance, the client of which DownloadDirectory is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) DownloadDirectory(ctx context.Context, input *DownloadDirectoryInput, opts ...func(*Options)) (*DownloadDirectoryOutput, error) {
+	i := directoryDownloader{in: input, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
+
+	return i.download(ctx)
+}
+
+type directoryDownloader struct {
+	options Options
+	in      *DownloadDirectoryInput
+	cancel  context.CancelFunc
+
+	failurePolicy types.FailurePolicy
+
+	progressEmitter *directoryProgressEmitter
+
+	m   sync.Mutex
+	out DownloadDirectoryOutput
+	err error
+}
+
+func (d *directoryDownloader) download(ctx context.Context) (*DownloadDirectoryOutput, error) {
+	if err := d.init(); err != nil {
+		return nil, fmt.Errorf("unable to initialize directory download: %w", err)
+	}
+
+	ctx, d.cancel = context.WithCancel(ctx)
+	defer d.cancel()
+
+	d.progressEmitter.Start(ctx, d.in)
+	out, err := d.downloadObjects(ctx)
+	if err != nil {
+		d.progressEmitter.Failed(ctx, err)
+		return out, err
+	}
+	d.progressEmitter.Complete(ctx, out)
+	return out, nil
+}
+
+func (d *directoryDownloader) downloadObjects(ctx context.Context) (*DownloadDirectoryOutput, error) {
+	objects := make(chan types.Object)
+	var wg sync.WaitGroup
+	for i := 0; i < d.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range objects {
+				d.downloadObject(ctx, obj)
+			}
+		}()
+	}
+
+	listErr := d.listObjects(ctx, objects)
+	close(objects)
+	if listErr == nil {
+		d.progressEmitter.DiscoveryComplete()
+	}
+	wg.Wait()
+
+	sort.Slice(d.out.Failures, func(i, j int) bool {
+		return d.out.Failures[i].Key < d.out.Failures[j].Key
+	})
+	if err := d.geterr(); err != nil {
+		return &d.out, err
+	}
+	if listErr != nil {
+		return &d.out, listErr
+	}
+	return &d.out, nil
+}
+
+func (d *directoryDownloader) init() error {
+	if d.in.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if d.in.Destination == "" {
+		return fmt.Errorf("destination is required")
+	}
+	if err := os.MkdirAll(d.in.Destination, 0755); err != nil {
+		return err
+	}
+
+	d.failurePolicy = d.in.FailurePolicy
+	if d.failurePolicy == "" {
+		d.failurePolicy = types.FailurePolicyAbort
+	}
+
+	resolveConcurrency(&d.options)
+	d.options.requestBudget = newRequestBudget(d.options.Concurrency)
+	d.progressEmitter = &directoryProgressEmitter{
+		Listeners: d.options.ProgressListeners,
+	}
+	return nil
+}
+
+func (d *directoryDownloader) listObjects(ctx context.Context, objects chan<- types.Object) error {
+	clientOptions := []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+
+	p := s3.NewListObjectsV2Paginator(d.options.S3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(d.in.Bucket),
+		Prefix: nzstring(d.in.KeyPrefix),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx, clientOptions...)
+		if err != nil {
+			if d.geterr() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, o := range page.Contents {
+			var obj types.Object
+			obj.MapFrom(o)
+			if strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
+			if d.in.Filter != nil && !d.in.Filter(obj) {
+				continue
+			}
+			d.progressEmitter.Discovered(obj.Size)
+			select {
+			case objects <- obj:
+			case <-ctx.Done():
+				if d.geterr() != nil {
+					return nil
+				}
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// localPath returns the path an object is written to, or an error if the key
+// does not map to a path inside the destination directory.
+func (d *directoryDownloader) localPath(key string) (string, error) {
+	return objectLocalPath(d.in.Destination, d.in.KeyPrefix, key)
+}
+
+// objectLocalPath returns the path under dir an object is written to once
+// prefix is stripped from its key, or an error if the key does not map to a
+// path inside dir.
+func objectLocalPath(dir, prefix, key string) (string, error) {
+	rel := strings.TrimPrefix(key, prefix)
+	rel = strings.TrimLeft(rel, "/")
+	for _, seg := range strings.Split(rel, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("key %q resolves outside of the destination directory", key)
+		}
+	}
+	rel = filepath.FromSlash(rel)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("key %q resolves outside of the destination directory", key)
+	}
+	return filepath.Join(dir, rel), nil
+}
+
+func (d *directoryDownloader) downloadObject(ctx context.Context, obj types.Object) {
+	if d.geterr() != nil {
+		return
+	}
+
+	path, err := d.localPath(obj.Key)
+	if err != nil {
+		d.fail(ctx, obj.Key, "", err)
+		return
+	}
+
+	options := d.options.Copy()
+	options.ProgressListeners = d.progressEmitter.ObjectListeners(options.ProgressListeners)
+	n, err := downloadFile(ctx, options, d.in.Bucket, obj, path, d.in.Callback)
+	if err != nil {
+		d.fail(ctx, obj.Key, path, err)
+		return
+	}
+
+	d.m.Lock()
+	d.out.ObjectsDownloaded++
+	d.out.BytesDownloaded += n
+	d.m.Unlock()
+
+	d.progressEmitter.ObjectTransferred(ctx)
+}
+
+// downloadFile downloads the object to a temporary file which is renamed to
+// path once complete, and sets its modification time to the object's.
+func downloadFile(ctx context.Context, options Options, bucket string, obj types.Object, path string, callback func(*DownloadObjectInput)) (int64, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	input := &DownloadObjectInput{
+		Bucket:   bucket,
+		Key:      obj.Key,
+		WriterAt: tmp,
+	}
+	if callback != nil {
+		callback(input)
+	}
+
+	dl := downloader{in: input, options: options}
+	out, err := dl.download(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	lastModified := obj.LastModified
+	if !out.LastModified.IsZero() {
+		lastModified = out.LastModified
+	}
+	if !lastModified.IsZero() {
+		if err := os.Chtimes(tmp.Name(), lastModified, lastModified); err != nil {
+			return 0, err
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	committed = true
+	return out.ContentLength, nil
+}
+
+// fail records the failure of an object according to the failure policy
+func (d *directoryDownloader) fail(ctx context.Context, key, path string, err error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.err != nil {
+		// failures caused by the abort are not recorded
+		return
+	}
+	d.progressEmitter.ObjectFailed(ctx)
+	d.out.ObjectsFailed++
+	d.out.Failures = append(d.out.Failures, DownloadDirectoryFailure{Key: key, Path: path, Err: err})
+	if d.failurePolicy == types.FailurePolicyAbort {
+		d.err = fmt.Errorf("failed to download %s: %w", key, err)
+		d.cancel()
+	}
+}
+
+// geterr is a thread-safe getter for the error which aborted the download
+func (d *directoryDownloader) geterr() error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.err
+}
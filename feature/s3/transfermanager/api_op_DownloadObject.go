@@ -2,9 +2,13 @@ package transfermanager
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,6 +19,7 @@ import (
 	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
 	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
 	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+	smithy "github.com/aws/smithy-go"
 	smithymiddleware "github.com/aws/smithy-go/middleware"
 )
 
@@ -203,6 +208,10 @@ type DownloadObjectInput struct {
 	// [Server-Side Encryption (Using Customer-Provided Encryption Keys)]: https://docs.aws.amazon.com/AmazonS3/latest/dev/ServerSideEncryptionCustomerKeys.html
 	SSECustomerKeyMD5 string
 
+	// The customer provided key the object is encrypted with. If set, it
+	// replaces SSECustomerAlgorithm, SSECustomerKey and SSECustomerKeyMD5.
+	CustomerKey *SSECustomerKey
+
 	// Version ID used to reference a specific version of the object.
 	//
 	// By default, the GetObject operation returns the current version of an object.
@@ -225,8 +234,40 @@ type DownloadObjectInput struct {
 	//
 	// [PutBucketVersioning]: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
 	VersionID string
+
+	// Persists the ranges written to WriterAt, so that a download which
+	// failed can be resumed by calling DownloadObject again with the same
+	// store and WriterAt, downloading only the missing ranges. The object is
+	// downloaded in ranges of Options.PartSizeBytes, or of the part size of
+	// the checkpoint when resuming, regardless of Options.GetObjectType.
+	//
+	// Every request is conditional on the ETag of the object when the
+	// download started, and ErrObjectChanged is returned if the object has
+	// changed since. The checkpoint is deleted once the download completes.
+	// Cannot be used with PartNumber or Range.
+	CheckpointStore DownloadCheckpointStore
+
+	// Validates the content written to WriterAt against the checksum of the
+	// whole object, which is retrieved with a HeadObject request before the
+	// download. Every request is conditional on the ETag of that object.
+	//
+	// A full-object checksum is validated when downloading parts or ranges,
+	// except for a SHA-1 or SHA-256 one, which cannot be combined from the
+	// checksums of ranges, so that the object is downloaded with a single
+	// request. A composite checksum of a multipart upload, such as "Zm9v-3",
+	// can only be validated when downloading parts with
+	// types.GetObjectParts. An error wrapping ErrChecksumMismatch is returned
+	// if the content does not match. Cannot be used with CheckpointStore,
+	// PartNumber or Range.
+	ValidateObjectChecksum bool
 }
 
+// ErrObjectChanged is returned by a resumable download when the object has
+// changed since the download started, so that the ranges already written
+// belong to a different object. Delete the checkpoint and the partially
+// written data to download the object again.
+var ErrObjectChanged = errors.New("object changed since the download started")
+
 func (i DownloadObjectInput) mapGetObjectInput(enableChecksumValidation bool) *s3.GetObjectInput {
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(i.Bucket),
@@ -262,6 +303,41 @@ func (i DownloadObjectInput) mapGetObjectInput(enableChecksumValidation bool) *s
 	return input
 }
 
+// withCustomerKey returns a copy of the input with the members of its
+// CustomerKey set, or the input if it has none
+func (i *DownloadObjectInput) withCustomerKey() *DownloadObjectInput {
+	if i == nil || i.CustomerKey == nil {
+		return i
+	}
+	in := *i
+	in.CustomerKey.apply(&in.SSECustomerAlgorithm, &in.SSECustomerKey, &in.SSECustomerKeyMD5)
+	return &in
+}
+
+func (i DownloadObjectInput) mapHeadObjectInput() *s3.HeadObjectInput {
+	input := &s3.HeadObjectInput{
+		Bucket:       aws.String(i.Bucket),
+		Key:          aws.String(i.Key),
+		ChecksumMode: s3types.ChecksumModeEnabled,
+	}
+
+	if i.RequestPayer != "" {
+		input.RequestPayer = s3types.RequestPayer(i.RequestPayer)
+	}
+
+	input.ExpectedBucketOwner = nzstring(i.ExpectedBucketOwner)
+	input.IfMatch = nzstring(i.IfMatch)
+	input.IfNoneMatch = nzstring(i.IfNoneMatch)
+	input.IfModifiedSince = nztime(i.IfModifiedSince)
+	input.IfUnmodifiedSince = nztime(i.IfUnmodifiedSince)
+	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
+	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
+	input.VersionId = nzstring(i.VersionID)
+
+	return input
+}
+
 // DownloadObjectOutput represents a response from DownloadObject() call. It contains common fields
 // of s3 GetObject output except Body which is replaced by WriterAt of input
 type DownloadObjectOutput struct {
@@ -292,6 +368,13 @@ type DownloadObjectOutput struct {
 	// [Checking object integrity]: https://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html
 	ChecksumCRC32C string
 
+	// The base64-encoded, 64-bit CRC64NVME checksum of the object. This will only
+	// be present if it was uploaded with the object. For more information, see [Checking object integrity]in the
+	// Amazon S3 User Guide.
+	//
+	// [Checking object integrity]: https://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html
+	ChecksumCRC64NVME string
+
 	// The base64-encoded, 160-bit SHA-1 digest of the object. This will only be
 	// present if it was uploaded with the object. For more information, see [Checking object integrity]in the
 	// Amazon S3 User Guide.
@@ -306,6 +389,10 @@ type DownloadObjectOutput struct {
 	// [Checking object integrity]: https://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html
 	ChecksumSHA256 string
 
+	// Indicates whether the checksum of the object is a composite or a
+	// full-object checksum.
+	ChecksumType types.ChecksumType
+
 	// Specifies presentational information for the object.
 	ContentDisposition string
 
@@ -484,8 +571,10 @@ func (o *DownloadObjectOutput) mapFromGetObjectOutput(out *s3.GetObjectOutput, c
 	o.ChecksumMode = types.ChecksumMode(checksumMode)
 	o.ChecksumCRC32 = aws.ToString(out.ChecksumCRC32)
 	o.ChecksumCRC32C = aws.ToString(out.ChecksumCRC32C)
+	o.ChecksumCRC64NVME = aws.ToString(out.ChecksumCRC64NVME)
 	o.ChecksumSHA1 = aws.ToString(out.ChecksumSHA1)
 	o.ChecksumSHA256 = aws.ToString(out.ChecksumSHA256)
+	o.ChecksumType = types.ChecksumType(out.ChecksumType)
 	o.ContentDisposition = aws.ToString(out.ContentDisposition)
 	o.ContentEncoding = aws.ToString(out.ContentEncoding)
 	o.ContentLanguage = aws.ToString(out.ContentLanguage)
@@ -532,6 +621,7 @@ func (c *Client) DownloadObject(ctx context.Context, input *DownloadObjectInput,
 	for _, opt := range opts {
 		opt(&i.options)
 	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
 
 	return i.download(ctx)
 }
@@ -555,9 +645,37 @@ type downloader struct {
 	err error
 
 	emitter *singleObjectProgressEmitter
+
+	// the checkpoint of a resumable download
+	checkpoint   *DownloadCheckpoint
+	checkpointMu sync.Mutex
+	// whether the checkpoint was loaded rather than started by the download
+	resumed bool
+	// makes every request conditional on the etag, even for a version
+	pinETag bool
+
+	// the checksum of the whole object the content is validated against, and
+	// the checksums of the chunks written
+	checksum  *objectChecksum
+	chunkSums []chunkChecksum
+}
+
+// objectChecksum is the checksum of an object as reported by HeadObject
+type objectChecksum struct {
+	algorithm    types.ChecksumAlgorithm
+	checksumType types.ChecksumType
+	value        string
+}
+
+// chunkChecksum is the checksum of a chunk of the object
+type chunkChecksum struct {
+	start int64
+	size  int64
+	sum   []byte
 }
 
 func (d *downloader) download(ctx context.Context) (*DownloadObjectOutput, error) {
+	d.in = d.in.withCustomerKey()
 	if err := d.init(); err != nil {
 		return nil, fmt.Errorf("unable to initialize download: %w", err)
 	}
@@ -570,10 +688,21 @@ func (d *downloader) download(ctx context.Context) (*DownloadObjectOutput, error
 			)
 		}}
 
+	if d.in.CheckpointStore != nil {
+		return d.resumableDownload(ctx, clientOptions...)
+	}
+
 	if d.in.PartNumber > 0 {
 		return d.singleDownload(ctx, clientOptions...)
 	}
 
+	if d.in.ValidateObjectChecksum {
+		if err := d.headChecksum(ctx, clientOptions...); err != nil {
+			d.emitter.Failed(ctx, err)
+			return nil, err
+		}
+	}
+
 	var output *DownloadObjectOutput
 	if d.options.GetObjectType == types.GetObjectParts {
 		if d.in.Range != "" {
@@ -642,6 +771,11 @@ func (d *downloader) download(ctx context.Context) (*DownloadObjectOutput, error
 		return nil, d.err
 	}
 
+	if err := d.validateChecksum(); err != nil {
+		d.emitter.Failed(ctx, err)
+		return nil, err
+	}
+
 	d.emitter.Complete(ctx, d.out)
 
 	d.out.ContentLength = d.written
@@ -658,6 +792,14 @@ func (d *downloader) init() error {
 		return fmt.Errorf("part body retry must be non-negative")
 	}
 
+	if d.in.CheckpointStore != nil && (d.in.PartNumber > 0 || d.in.Range != "") {
+		return fmt.Errorf("checkpoint store cannot be used with part number or range")
+	}
+
+	if d.in.ValidateObjectChecksum && (d.in.CheckpointStore != nil || d.in.PartNumber > 0 || d.in.Range != "") {
+		return fmt.Errorf("object checksum validation cannot be used with checkpoint store, part number or range")
+	}
+
 	d.totalBytes = -1
 	d.emitter = &singleObjectProgressEmitter{
 		Listeners: d.options.ProgressListeners,
@@ -699,6 +841,249 @@ func (d *downloader) downloadPart(ctx context.Context, ch chan dlChunk, clientOp
 	}
 }
 
+// resumableDownload downloads the ranges of the object missing from the
+// checkpoint, saving the checkpoint as ranges are written.
+func (d *downloader) resumableDownload(ctx context.Context, clientOptions ...func(*s3.Options)) (*DownloadObjectOutput, error) {
+	checkpoint, err := d.in.CheckpointStore.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load download checkpoint: %w", err)
+	}
+
+	if checkpoint != nil {
+		if checkpoint.Bucket != d.in.Bucket || checkpoint.Key != d.in.Key || checkpoint.VersionID != d.in.VersionID {
+			return nil, fmt.Errorf("checkpoint is for %s/%s, not %s/%s", checkpoint.Bucket, checkpoint.Key, d.in.Bucket, d.in.Key)
+		}
+		if checkpoint.PartSizeBytes < minPartSizeBytes {
+			return nil, fmt.Errorf("checkpoint part size must be at least %d bytes", minPartSizeBytes)
+		}
+		d.options.PartSizeBytes = checkpoint.PartSizeBytes
+		d.etagOnce.Do(func() {
+			d.etag = checkpoint.ETag
+		})
+		d.totalBytesOnce.Do(func() {
+			d.totalBytes = checkpoint.ObjectSize
+			d.emitter.Start(ctx, d.in, d.totalBytes)
+		})
+		d.pinETag = true
+		d.checkpoint = checkpoint
+		d.resumed = true
+	} else {
+		d.pinETag = true
+		output := d.getChunk(ctx, 0, d.byteRange(), clientOptions...)
+		if d.err != nil {
+			d.emitter.Failed(ctx, d.err)
+			return nil, d.err
+		}
+		d.checkpoint = &DownloadCheckpoint{
+			Bucket:        d.in.Bucket,
+			Key:           d.in.Key,
+			VersionID:     d.in.VersionID,
+			ETag:          d.etag,
+			ObjectSize:    d.totalBytes,
+			PartSizeBytes: d.options.PartSizeBytes,
+		}
+		if err := d.saveCheckpoint(ctx, 0, output.ContentLength); err != nil {
+			d.emitter.Failed(ctx, err)
+			return nil, err
+		}
+	}
+
+	ch := make(chan dlChunk, d.options.Concurrency)
+	for i := 0; i < d.options.Concurrency; i++ {
+		d.wg.Add(1)
+		go d.downloadRange(ctx, ch, clientOptions...)
+	}
+
+	for start := int64(0); start < d.totalBytes && d.getErr() == nil; start += d.options.PartSizeBytes {
+		end := start + d.options.PartSizeBytes
+		if end > d.totalBytes {
+			end = d.totalBytes
+		}
+		if d.rangeWritten(start, end) {
+			continue
+		}
+		ch <- dlChunk{w: d.in.WriterAt, start: start, withRange: fmt.Sprintf("bytes=%d-%d", start, end-1)}
+	}
+
+	close(ch)
+	d.wg.Wait()
+
+	if d.err != nil {
+		d.emitter.Failed(ctx, d.err)
+		return nil, d.err
+	}
+
+	// a checkpoint left behind only causes the completed download to be
+	// verified against the object again
+	d.in.CheckpointStore.Delete(ctx)
+
+	if d.out == nil {
+		// every range was written before the download was resumed
+		d.out = &DownloadObjectOutput{ETag: d.etag, VersionID: d.in.VersionID}
+	}
+	d.emitter.Complete(ctx, d.out)
+
+	d.out.ContentLength = d.totalBytes
+	d.out.ContentRange = fmt.Sprintf("bytes=0-%d", d.totalBytes-1)
+	return d.out, nil
+}
+
+// headChecksum retrieves the checksum of the object the content is validated
+// against, and pins the download to the ETag of the object.
+func (d *downloader) headChecksum(ctx context.Context, clientOptions ...func(*s3.Options)) error {
+	if err := d.options.requestBudget.acquire(ctx); err != nil {
+		return err
+	}
+	input := d.in.mapHeadObjectInput()
+	out, err := d.options.S3.HeadObject(ctx, input, clientOptions...)
+	d.options.requestBudget.release()
+	if err != nil {
+		return headCustomerKeyError(ctx, d.options.S3, input, err, clientOptions...)
+	}
+
+	checksum := &objectChecksum{checksumType: types.ChecksumType(out.ChecksumType)}
+	switch {
+	case out.ChecksumCRC64NVME != nil:
+		checksum.algorithm, checksum.value = types.ChecksumAlgorithmCrc64nvme, *out.ChecksumCRC64NVME
+	case out.ChecksumCRC32C != nil:
+		checksum.algorithm, checksum.value = types.ChecksumAlgorithmCrc32c, *out.ChecksumCRC32C
+	case out.ChecksumCRC32 != nil:
+		checksum.algorithm, checksum.value = types.ChecksumAlgorithmCrc32, *out.ChecksumCRC32
+	case out.ChecksumSHA256 != nil:
+		checksum.algorithm, checksum.value = types.ChecksumAlgorithmSha256, *out.ChecksumSHA256
+	case out.ChecksumSHA1 != nil:
+		checksum.algorithm, checksum.value = types.ChecksumAlgorithmSha1, *out.ChecksumSHA1
+	default:
+		return fmt.Errorf("object %s has no checksum to validate", d.in.Key)
+	}
+	if strings.Contains(checksum.value, "-") {
+		// base64 has no "-", which separates the part count of a composite
+		// checksum
+		checksum.checksumType = types.ChecksumTypeComposite
+	} else if checksum.checksumType == "" {
+		checksum.checksumType = types.ChecksumTypeFullObject
+	}
+	if checksum.checksumType == types.ChecksumTypeComposite && d.options.GetObjectType != types.GetObjectParts {
+		return fmt.Errorf("composite %s checksum of object %s can only be validated when downloading parts", checksum.algorithm, d.in.Key)
+	}
+	if _, _, isCRC := crcParameters(checksum.algorithm); checksum.checksumType == types.ChecksumTypeFullObject && !isCRC {
+		// the checksum of the whole content cannot be combined from the
+		// checksums of several chunks, the object is downloaded at once
+		d.options.GetObjectType = types.GetObjectRanges
+		d.options.PartSizeBytes = max(d.options.PartSizeBytes, aws.ToInt64(out.ContentLength))
+	}
+
+	d.checksum = checksum
+	d.pinETag = true
+	d.etagOnce.Do(func() {
+		d.etag = aws.ToString(out.ETag)
+	})
+	return nil
+}
+
+// addChunkChecksum records the checksum of a chunk written
+func (d *downloader) addChunkChecksum(start, size int64, sum []byte) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.chunkSums = append(d.chunkSums, chunkChecksum{start: start, size: size, sum: sum})
+}
+
+// validateChecksum compares the checksum of the object with the one computed
+// from the checksums of the chunks written, if the object checksum is
+// validated.
+func (d *downloader) validateChecksum() error {
+	if d.checksum == nil {
+		return nil
+	}
+
+	sort.Slice(d.chunkSums, func(i, j int) bool {
+		return d.chunkSums[i].start < d.chunkSums[j].start
+	})
+	sums := make([][]byte, 0, len(d.chunkSums))
+	sizes := make([]int64, 0, len(d.chunkSums))
+	for _, c := range d.chunkSums {
+		sums = append(sums, c.sum)
+		sizes = append(sizes, c.size)
+	}
+
+	var (
+		checksum string
+		err      error
+	)
+	if _, _, isCRC := crcParameters(d.checksum.algorithm); d.checksum.checksumType == types.ChecksumTypeFullObject && !isCRC {
+		// the checksum of the whole content cannot be combined from the
+		// checksums of several chunks
+		if len(sums) != 1 {
+			return fmt.Errorf("%s checksum of object %s cannot be validated from %d chunks", d.checksum.algorithm, d.in.Key, len(sums))
+		}
+		checksum = base64.StdEncoding.EncodeToString(sums[0])
+	} else {
+		checksum, err = combineChecksums(d.checksum.algorithm, d.checksum.checksumType, sums, sizes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if checksum != d.checksum.value {
+		return fmt.Errorf("%s checksum %s of object %s does not match %s computed from the content: %w",
+			d.checksum.algorithm, d.checksum.value, d.in.Key, checksum, ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// downloadRange runs in worker goroutines to download the ranges of a
+// resumable download and record them in the checkpoint.
+func (d *downloader) downloadRange(ctx context.Context, ch chan dlChunk, clientOptions ...func(*s3.Options)) {
+	defer d.wg.Done()
+	for chunk := range ch {
+		if d.getErr() != nil {
+			continue
+		}
+		out, err := d.downloadChunk(ctx, chunk, clientOptions...)
+		if err == nil && out.ETag != d.etag {
+			err = fmt.Errorf("%w: expected ETag %s, got %s", ErrObjectChanged, d.etag, out.ETag)
+		}
+		if err == nil {
+			err = d.saveCheckpoint(ctx, chunk.start, chunk.start+out.ContentLength)
+		}
+		if err != nil {
+			d.setErr(err)
+			continue
+		}
+		d.setOutput(out)
+	}
+}
+
+// rangeWritten returns whether the checkpoint covers the range from start to end.
+// The bytes of a range covered before the download was resumed are reported
+// as transferred, those of the first range of a new download having been
+// reported as it was written.
+func (d *downloader) rangeWritten(start, end int64) bool {
+	d.checkpointMu.Lock()
+	defer d.checkpointMu.Unlock()
+
+	if !d.checkpoint.covers(start, end) {
+		return false
+	}
+	if d.resumed {
+		d.emitter.BytesTransferred(context.Background(), end-start)
+	}
+	return true
+}
+
+// saveCheckpoint records a written range and saves the checkpoint
+func (d *downloader) saveCheckpoint(ctx context.Context, start, end int64) error {
+	d.checkpointMu.Lock()
+	defer d.checkpointMu.Unlock()
+
+	d.checkpoint.add(start, end)
+	if err := d.in.CheckpointStore.Save(ctx, d.checkpoint); err != nil {
+		return fmt.Errorf("failed to save download checkpoint: %w", err)
+	}
+	return nil
+}
+
 // getChunk grabs a chunk of data from the body.
 // Not thread safe. Should only used when grabbing data on a single thread.
 func (d *downloader) getChunk(ctx context.Context, part int32, rng string, clientOptions ...func(*s3.Options)) *DownloadObjectOutput {
@@ -724,10 +1109,18 @@ func (d *downloader) downloadChunk(ctx context.Context, chunk dlChunk, clientOpt
 	if chunk.withRange != "" {
 		params.Range = aws.String(chunk.withRange)
 	}
-	if params.VersionId == nil && d.etag != "" {
+	if (params.VersionId == nil || d.pinETag) && d.etag != "" {
 		params.IfMatch = aws.String(d.etag)
 	}
 
+	if d.checksum != nil {
+		h, err := newChecksumHash(d.checksum.algorithm)
+		if err != nil {
+			return nil, err
+		}
+		chunk.hash = h
+	}
+
 	var out *s3.GetObjectOutput
 	var err error
 	for retry := 0; retry < d.options.PartBodyMaxRetries; retry++ {
@@ -743,10 +1136,21 @@ func (d *downloader) downloadChunk(ctx context.Context, chunk dlChunk, clientOpt
 		if bodyErr, ok := err.(*errReadingBody); ok {
 			err = bodyErr
 		} else {
+			var apiErr smithy.APIError
+			if d.pinETag && errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+				err = fmt.Errorf("%w: %w", ErrObjectChanged, err)
+			}
 			return nil, err
 		}
 
 		chunk.cur = 0
+		if chunk.hash != nil {
+			chunk.hash.Reset()
+		}
+	}
+
+	if err == nil && chunk.hash != nil {
+		d.addChunkChecksum(chunk.start, chunk.cur, chunk.hash.Sum(nil))
 	}
 
 	var output *DownloadObjectOutput
@@ -761,9 +1165,14 @@ func (d *downloader) downloadChunk(ctx context.Context, chunk dlChunk, clientOpt
 }
 
 func (d *downloader) tryDownloadChunk(ctx context.Context, params *s3.GetObjectInput, chunk *dlChunk, clientOptions ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if err := d.options.requestBudget.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer d.options.requestBudget.release()
+
 	out, err := d.options.S3.GetObject(ctx, params, clientOptions...)
 	if err != nil {
-		return nil, err
+		return nil, sseCustomerKeyError(err, d.in.SSECustomerKey, d.in.SSECustomerKeyMD5, false)
 	}
 
 	d.totalBytesOnce.Do(func() {
@@ -773,7 +1182,7 @@ func (d *downloader) tryDownloadChunk(ctx context.Context, params *s3.GetObjectI
 
 	var n int64
 	defer out.Body.Close()
-	n, err = io.Copy(chunk, out.Body)
+	n, err = io.Copy(chunk, d.options.BandwidthLimiter.reader(ctx, out.Body))
 	if err != nil {
 		return nil, &errReadingBody{err: err}
 	}
@@ -877,11 +1286,17 @@ type dlChunk struct {
 
 	part      int32
 	withRange string
+
+	// hashes the content written, if the object checksum is validated
+	hash hash.Hash
 }
 
 func (c *dlChunk) Write(p []byte) (int, error) {
 	n, err := c.w.WriteAt(p, c.start+c.cur)
 	c.cur += int64(n)
+	if c.hash != nil {
+		c.hash.Write(p[:n])
+	}
 
 	return n, err
 }
@@ -214,6 +214,10 @@ type GetObjectInput struct {
 	// [Server-Side Encryption (Using Customer-Provided Encryption Keys)]: https://docs.aws.amazon.com/AmazonS3/latest/dev/ServerSideEncryptionCustomerKeys.html
 	SSECustomerKeyMD5 string
 
+	// The customer provided key the object is encrypted with. If set, it
+	// replaces SSECustomerAlgorithm, SSECustomerKey and SSECustomerKeyMD5.
+	CustomerKey *SSECustomerKey
+
 	// Version ID used to reference a specific version of the object.
 	//
 	// By default, the GetObject operation returns the current version of an object.
@@ -238,6 +242,30 @@ type GetObjectInput struct {
 	VersionID string
 }
 
+// withCustomerKey returns a copy of the input with the members of its
+// CustomerKey set, or the input if it has none
+func (i *GetObjectInput) withCustomerKey() *GetObjectInput {
+	if i == nil || i.CustomerKey == nil {
+		return i
+	}
+	in := *i
+	in.CustomerKey.apply(&in.SSECustomerAlgorithm, &in.SSECustomerKey, &in.SSECustomerKeyMD5)
+	return &in
+}
+
+// mapHeadObjectInput maps the input to the HeadObject request retrieving the
+// size of the object, or of its part partNumber if not nil
+func (i GetObjectInput) mapHeadObjectInput(partNumber *int32) *s3.HeadObjectInput {
+	return &s3.HeadObjectInput{
+		Bucket:               aws.String(i.Bucket),
+		Key:                  aws.String(i.Key),
+		PartNumber:           partNumber,
+		SSECustomerAlgorithm: nzstring(i.SSECustomerAlgorithm),
+		SSECustomerKey:       nzstring(i.SSECustomerKey),
+		SSECustomerKeyMD5:    nzstring(i.SSECustomerKeyMD5),
+	}
+}
+
 func (i GetObjectInput) mapGetObjectInput(enableChecksumValidation bool) *s3.GetObjectInput {
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(i.Bucket),
@@ -591,6 +619,7 @@ func (c *Client) GetObject(ctx context.Context, input *GetObjectInput, opts ...f
 	for _, opt := range opts {
 		opt(&i.options)
 	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
 
 	return i.get(ctx)
 }
@@ -601,6 +630,7 @@ type getter struct {
 }
 
 func (g *getter) get(ctx context.Context) (out *GetObjectOutput, err error) {
+	g.in = g.in.withCustomerKey()
 	if err := g.init(); err != nil {
 		return nil, fmt.Errorf("unable to initialize download: %w", err)
 	}
@@ -632,13 +662,10 @@ func (g *getter) get(ctx context.Context) (out *GetObjectOutput, err error) {
 			return g.singleDownload(ctx, clientOptions...)
 		}
 		// must know the part size before creating stream reader
-		out, err := g.options.S3.HeadObject(ctx, &s3.HeadObjectInput{
-			Bucket:     aws.String(g.in.Bucket),
-			Key:        aws.String(g.in.Key),
-			PartNumber: aws.Int32(1),
-		}, clientOptions...)
+		input := g.in.mapHeadObjectInput(aws.Int32(1))
+		out, err := g.options.S3.HeadObject(ctx, input, clientOptions...)
 		if err != nil {
-			return nil, err
+			return nil, headCustomerKeyError(ctx, g.options.S3, input, err, clientOptions...)
 		}
 
 		output.mapFromHeadObjectOutput(out, g.in.ChecksumMode, !g.options.DisableChecksumValidation, r)
@@ -647,19 +674,17 @@ func (g *getter) get(ctx context.Context) (out *GetObjectOutput, err error) {
 
 		partsCount := max(aws.ToInt32(out.PartsCount), 1)
 		partSize := max(aws.ToInt64(out.ContentLength), 1)
-		sectionParts := int32(max(1, g.options.GetBufferSize/partSize))
+		sectionParts := g.sectionParts(partSize)
 		capacity := sectionParts
 		r.sectionParts = sectionParts
 		r.partSize = partSize
 		r.setCapacity(min(capacity, partsCount))
 		r.partsCount = partsCount
 	} else {
-		out, err := g.options.S3.HeadObject(ctx, &s3.HeadObjectInput{
-			Bucket: aws.String(g.in.Bucket),
-			Key:    aws.String(g.in.Key),
-		}, clientOptions...)
+		input := g.in.mapHeadObjectInput(nil)
+		out, err := g.options.S3.HeadObject(ctx, input, clientOptions...)
 		if err != nil {
-			return nil, err
+			return nil, headCustomerKeyError(ctx, g.options.S3, input, err, clientOptions...)
 		}
 		if aws.ToInt64(out.ContentLength) == 0 {
 			return g.singleDownload(ctx, clientOptions...)
@@ -683,7 +708,7 @@ func (g *getter) get(ctx context.Context) (out *GetObjectOutput, err error) {
 		output.ContentRange = fmt.Sprintf("bytes=%d-%d/%d", pos, total-1, aws.ToInt64(out.ContentLength))
 
 		partsCount := int32((contentLength-1)/g.options.PartSizeBytes + 1)
-		sectionParts := int32(max(1, g.options.GetBufferSize/g.options.PartSizeBytes))
+		sectionParts := g.sectionParts(g.options.PartSizeBytes)
 		capacity := min(sectionParts, partsCount)
 		r.partSize = g.options.PartSizeBytes
 		r.setCapacity(capacity)
@@ -698,6 +723,16 @@ func (g *getter) get(ctx context.Context) (out *GetObjectOutput, err error) {
 	return output, nil
 }
 
+// sectionParts returns the number of parts the body buffers at once, which
+// must fit in the buffer memory limit for the body to make progress.
+func (g *getter) sectionParts(partSize int64) int32 {
+	n := max(1, g.options.GetBufferSize/partSize)
+	if l := g.options.BufferPool.limit(); l > 0 {
+		n = max(1, min(n, l/partSize))
+	}
+	return int32(n)
+}
+
 func (g *getter) init() error {
 	if g.options.PartSizeBytes < minPartSizeBytes {
 		return fmt.Errorf("part size must be at least %d bytes", minPartSizeBytes)
@@ -710,11 +745,12 @@ func (g *getter) singleDownload(ctx context.Context, clientOptions ...func(*s3.O
 	params := g.in.mapGetObjectInput(!g.options.DisableChecksumValidation)
 	out, err := g.options.S3.GetObject(ctx, params, clientOptions...)
 	if err != nil {
-		return nil, err
+		return nil, sseCustomerKeyError(err, g.in.SSECustomerKey, g.in.SSECustomerKeyMD5, false)
 	}
 
 	output := &GetObjectOutput{}
 	output.mapFromGetObjectOutput(out, params.ChecksumMode)
+	output.Body = g.options.BandwidthLimiter.reader(ctx, out.Body)
 	return output, nil
 }
 
@@ -0,0 +1,755 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// MigrateObjectInput represents a request to the MigrateObject() call
+type MigrateObjectInput struct {
+	// The client the source object is read with. It may use other
+	// credentials and another endpoint than the Client, which writes the
+	// migrated object, so that objects can be migrated across service
+	// instances, accounts and regions.
+	//
+	// This member is required.
+	SourceClient S3APIClient
+
+	// Bucket of the source object
+	//
+	// This member is required.
+	SourceBucket string
+
+	// Key of the source object
+	//
+	// This member is required.
+	SourceKey string
+
+	// Version of the source object, the current version if empty
+	SourceVersionID string
+
+	// Bucket the object is migrated to
+	//
+	// This member is required.
+	Bucket string
+
+	// Key of the migrated object. Defaults to SourceKey.
+	Key string
+
+	// If set, the tag set of the source object is not copied
+	IgnoreTags bool
+
+	// If set, the retention period and legal holds of the source object are
+	// not applied to the migrated object, e.g. because the destination bucket
+	// has no retention policy.
+	IgnoreRetention bool
+
+	// If set, the object is migrated even if the destination object matches
+	// the source object already.
+	Overwrite bool
+
+	// Invoked with the input of the migrated object before it is written,
+	// e.g. to set its StorageClass or SSECustomerKey. It holds the metadata
+	// and tags of the source object. Its Bucket and Key must not be changed.
+	Callback func(*PutObjectInput)
+}
+
+// MigrateObjectOutput represents a response from the MigrateObject() call
+type MigrateObjectOutput struct {
+	// Bucket of the migrated object
+	Bucket string
+
+	// Key of the migrated object
+	Key string
+
+	// Entity tag of the migrated object
+	ETag string
+
+	// Version of the migrated object, if the destination bucket is versioned
+	VersionID string
+
+	// Version of the source object which was migrated
+	SourceVersionID string
+
+	// The ID of the multipart upload, empty if the object was written with a
+	// single PutObject request or skipped
+	UploadID string
+
+	// Size of the object
+	ObjectSize int64
+
+	// Whether the object was skipped, as the destination object matched the
+	// ETag or a checksum of the source object already
+	Skipped bool
+}
+
+// MigrateObject streams an object read with SourceClient to the bucket of
+// the Client, without staging it on local disk. Each part is buffered in
+// memory between its GetObject and UploadPart requests.
+//
+// A multipart source object is migrated part by part with the same part
+// boundaries, so that the migrated object has the same ETag. Other objects
+// are written with a single PutObject request if they are smaller than
+// Options.MultipartUploadThreshold, and in parts of Options.PartSizeBytes
+// otherwise. Every GetObject request is conditional on the ETag of the
+// source object, failing with ErrObjectChanged if it changes.
+//
+// Unless Options.DisableChecksumValidation is set, the MD5 of the content
+// read is checked against the source ETag when it is one, failing with
+// ErrChecksumMismatch before the object is completed. The content written is
+// checked by the destination against the checksum of
+// Options.ChecksumAlgorithm.
+//
+// Unless Overwrite is set, the object is skipped if the destination object
+// has the same ETag or the same checksum as the source object, so that an
+// interrupted migration can be run again.
+//
+// The metadata and, unless IgnoreTags is set, the tags of the source object
+// are copied. Unless IgnoreRetention is set, the retention period and legal
+// holds of the source object are applied once the object is written. If that
+// fails, the migrated object exists and the error is returned along with the
+// output.
+//
+// Additional functional options can be provided to configure the individual
+// migration. These options are copies of the original Options instance, the client of which MigrateObject is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) MigrateObject(ctx context.Context, input *MigrateObjectInput, opts ...func(*Options)) (*MigrateObjectOutput, error) {
+	i := migrator{in: input, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
+
+	return i.migrate(ctx)
+}
+
+type migrator struct {
+	options Options
+	in      *MigrateObjectInput
+
+	clientOptions []func(*s3.Options)
+	key           string
+	source        *s3.HeadObjectOutput
+	etag          string
+	objectSize    int64
+
+	// the input of the migrated object
+	put PutObjectInput
+
+	progressEmitter *singleObjectProgressEmitter
+}
+
+func (m *migrator) migrate(ctx context.Context) (*MigrateObjectOutput, error) {
+	if err := m.init(); err != nil {
+		return nil, fmt.Errorf("unable to initialize migration: %w", err)
+	}
+
+	source, err := m.in.SourceClient.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(m.in.SourceBucket),
+		Key:          aws.String(m.in.SourceKey),
+		VersionId:    nzstring(m.in.SourceVersionID),
+		ChecksumMode: s3types.ChecksumModeEnabled,
+	}, m.clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to head source object: %w", err)
+	}
+	m.source = source
+	m.etag = aws.ToString(source.ETag)
+	m.objectSize = aws.ToInt64(source.ContentLength)
+
+	if !m.in.Overwrite {
+		out, err := m.skip(ctx)
+		if err != nil || out != nil {
+			return out, err
+		}
+	}
+
+	if err := m.initPut(ctx); err != nil {
+		return nil, err
+	}
+
+	m.progressEmitter.Start(ctx, m.in, m.objectSize)
+	out, err := m.transfer(ctx)
+	if err != nil {
+		m.progressEmitter.Failed(ctx, err)
+		return nil, err
+	}
+	out.Bucket = m.in.Bucket
+	out.Key = m.key
+	out.SourceVersionID = aws.ToString(m.source.VersionId)
+	out.ObjectSize = m.objectSize
+
+	if !m.in.IgnoreRetention {
+		if err := m.applyRetention(ctx); err != nil {
+			err = fmt.Errorf("failed to apply retention to migrated object: %w", err)
+			m.progressEmitter.Failed(ctx, err)
+			return out, err
+		}
+	}
+	m.progressEmitter.Complete(ctx, out)
+	return out, nil
+}
+
+func (m *migrator) init() error {
+	if m.in.SourceClient == nil {
+		return fmt.Errorf("source client is required")
+	}
+	if m.in.SourceBucket == "" || m.in.SourceKey == "" {
+		return fmt.Errorf("source bucket and key are required")
+	}
+	if m.in.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if m.options.PartSizeBytes < minPartSizeBytes {
+		return fmt.Errorf("part size must be at least %d bytes", minPartSizeBytes)
+	}
+
+	m.key = m.in.Key
+	if m.key == "" {
+		m.key = m.in.SourceKey
+	}
+	m.clientOptions = []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+	m.progressEmitter = &singleObjectProgressEmitter{
+		Listeners: m.options.ProgressListeners,
+	}
+	return nil
+}
+
+// skip returns the output of a skipped migration if the destination object
+// matches the source object, or nil if the object has to be migrated.
+func (m *migrator) skip(ctx context.Context) (*MigrateObjectOutput, error) {
+	dest, err := m.options.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(m.in.Bucket),
+		Key:          aws.String(m.key),
+		ChecksumMode: s3types.ChecksumModeEnabled,
+	}, m.clientOptions...)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to head destination object: %w", err)
+	}
+	if aws.ToInt64(dest.ContentLength) != m.objectSize || !headersMatch(m.source, dest) {
+		return nil, nil
+	}
+	return &MigrateObjectOutput{
+		Bucket:          m.in.Bucket,
+		Key:             m.key,
+		ETag:            aws.ToString(dest.ETag),
+		VersionID:       aws.ToString(dest.VersionId),
+		SourceVersionID: aws.ToString(m.source.VersionId),
+		ObjectSize:      m.objectSize,
+		Skipped:         true,
+	}, nil
+}
+
+// headersMatch returns whether two objects have the same ETag or the same
+// value of a checksum both report.
+func headersMatch(a, b *s3.HeadObjectOutput) bool {
+	if strings.Trim(aws.ToString(a.ETag), `"`) == strings.Trim(aws.ToString(b.ETag), `"`) {
+		return true
+	}
+	for _, sums := range [][2]*string{
+		{a.ChecksumCRC32, b.ChecksumCRC32},
+		{a.ChecksumCRC32C, b.ChecksumCRC32C},
+		{a.ChecksumCRC64NVME, b.ChecksumCRC64NVME},
+		{a.ChecksumSHA1, b.ChecksumSHA1},
+		{a.ChecksumSHA256, b.ChecksumSHA256},
+	} {
+		if sums[0] != nil && aws.ToString(sums[0]) == aws.ToString(sums[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "NotFound", "NoSuchKey":
+		return true
+	}
+	return false
+}
+
+// initPut sets up the input of the migrated object from the metadata and tags
+// of the source object.
+func (m *migrator) initPut(ctx context.Context) error {
+	m.put = PutObjectInput{
+		Bucket:                  m.in.Bucket,
+		Key:                     m.key,
+		CacheControl:            aws.ToString(m.source.CacheControl),
+		ContentDisposition:      aws.ToString(m.source.ContentDisposition),
+		ContentEncoding:         aws.ToString(m.source.ContentEncoding),
+		ContentLanguage:         aws.ToString(m.source.ContentLanguage),
+		ContentType:             aws.ToString(m.source.ContentType),
+		Metadata:                m.source.Metadata,
+		WebsiteRedirectLocation: aws.ToString(m.source.WebsiteRedirectLocation),
+	}
+	if m.source.Expires != nil {
+		m.put.Expires = *m.source.Expires
+	}
+
+	if !m.in.IgnoreTags {
+		tagging, err := sourceTagging(ctx, m.in.SourceClient, &s3.GetObjectTaggingInput{
+			Bucket:    aws.String(m.in.SourceBucket),
+			Key:       aws.String(m.in.SourceKey),
+			VersionId: m.source.VersionId,
+		}, m.clientOptions...)
+		if err != nil {
+			return err
+		}
+		m.put.Tagging = aws.ToString(tagging)
+	}
+
+	if m.in.Callback != nil {
+		m.in.Callback(&m.put)
+	}
+	return nil
+}
+
+func (m *migrator) transfer(ctx context.Context) (*MigrateObjectOutput, error) {
+	_, parts, _ := splitETag(m.etag)
+	if parts == 0 && m.objectSize < m.options.MultipartUploadThreshold {
+		return m.singleTransfer(ctx)
+	}
+
+	mm := multiMigrator{migrator: m}
+	return mm.transfer(ctx, parts)
+}
+
+// getSource performs a GetObject request of the source object for a part
+// number or a range and returns its content. The memory of the content must
+// be reserved from the buffer pool by the caller, before the request takes a
+// token of the request budget, so that no request in flight waits for
+// memory held by requests waiting for a token.
+func (m *migrator) getSource(ctx context.Context, partNum *int32, rng *string) ([]byte, error) {
+	input := &s3.GetObjectInput{
+		Bucket:     aws.String(m.in.SourceBucket),
+		Key:        aws.String(m.in.SourceKey),
+		VersionId:  m.source.VersionId,
+		IfMatch:    nzstring(m.etag),
+		PartNumber: partNum,
+		Range:      rng,
+	}
+	if err := m.options.requestBudget.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer m.options.requestBudget.release()
+
+	out, err := m.in.SourceClient.GetObject(ctx, input, m.clientOptions...)
+	if err != nil {
+		return nil, sourceChangedError(err)
+	}
+	defer out.Body.Close()
+
+	size := aws.ToInt64(out.ContentLength)
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	_, err = io.Copy(buf, out.Body)
+	if err == nil && out.ContentLength != nil && int64(buf.Len()) != size {
+		err = fmt.Errorf("read %d bytes of source object, expected %d", buf.Len(), size)
+	} else if err != nil {
+		err = fmt.Errorf("failed to read source object: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sourcePartSize returns the size of a part of the source object, to reserve
+// its memory before it is read
+func (m *migrator) sourcePartSize(ctx context.Context, partNum *int32) (int64, error) {
+	out, err := m.in.SourceClient.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:     aws.String(m.in.SourceBucket),
+		Key:        aws.String(m.in.SourceKey),
+		VersionId:  m.source.VersionId,
+		IfMatch:    nzstring(m.etag),
+		PartNumber: partNum,
+	}, m.clientOptions...)
+	if err != nil {
+		return 0, sourceChangedError(err)
+	}
+	return aws.ToInt64(out.ContentLength), nil
+}
+
+// sourceChangedError wraps the error of a request failing the ETag
+// precondition of the source object with ErrObjectChanged
+func sourceChangedError(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+		return fmt.Errorf("%w: %v", ErrObjectChanged, err)
+	}
+	return err
+}
+
+// validate checks a computed ETag against the ETag of the source object if
+// the latter is an MD5 based one.
+func (m *migrator) validate(etag string) error {
+	if m.options.DisableChecksumValidation {
+		return nil
+	}
+	if _, _, ok := splitETag(m.etag); !ok {
+		return nil
+	}
+	if e := strings.Trim(m.etag, `"`); e != etag {
+		return fmt.Errorf("%w: expected ETag %s, computed %s", ErrChecksumMismatch, e, etag)
+	}
+	return nil
+}
+
+func (m *migrator) singleTransfer(ctx context.Context) (*MigrateObjectOutput, error) {
+	if err := m.options.BufferPool.reserve(ctx, m.objectSize); err != nil {
+		return nil, err
+	}
+	defer m.options.BufferPool.release(m.objectSize)
+	body, err := m.getSource(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	sum := md5.Sum(body)
+	if err := m.validate(hex.EncodeToString(sum[:])); err != nil {
+		return nil, err
+	}
+
+	params := m.put.mapSingleUploadInput(m.options.BandwidthLimiter.reader(ctx, bytes.NewReader(body)), m.options.ChecksumAlgorithm)
+	if err := m.options.requestBudget.acquire(ctx); err != nil {
+		return nil, err
+	}
+	out, err := m.options.S3.PutObject(ctx, params, m.clientOptions...)
+	m.options.requestBudget.release()
+	if err != nil {
+		return nil, err
+	}
+	m.progressEmitter.BytesTransferred(ctx, int64(len(body)))
+
+	return &MigrateObjectOutput{
+		ETag:      aws.ToString(out.ETag),
+		VersionID: aws.ToString(out.VersionId),
+	}, nil
+}
+
+func (m *migrator) applyRetention(ctx context.Context) error {
+	expiration, legalHolds, err := sourceRetention(ctx, m.in.SourceClient, m.in.SourceBucket, m.in.SourceKey, m.source, m.clientOptions...)
+	if err != nil {
+		return err
+	}
+	return putRetention(ctx, m.options.S3, m.in.Bucket, m.key, expiration, legalHolds, m.clientOptions...)
+}
+
+type multiMigrator struct {
+	*migrator
+	wg       sync.WaitGroup
+	m        sync.Mutex
+	err      error
+	uploadID *string
+	parts    completedParts
+
+	// the MD5 of each part, by part number
+	sums [][]byte
+	// the MD5 of the whole content, for parts split from a source object
+	// which was not uploaded in parts
+	hash *sequentialHash
+}
+
+type migrateChunk struct {
+	partNum *int32
+	// the part of the source object read, or nil to read rng
+	sourcePart *int32
+	rng        *string
+
+	// the size of rng, and the memory reserved for it
+	size     int64
+	reserved int64
+}
+
+// transfer migrates the object in sourceParts parts of the source object, or
+// in ranges of the part size if sourceParts is zero.
+func (u *multiMigrator) transfer(ctx context.Context, sourceParts int) (*MigrateObjectOutput, error) {
+	var chunks []migrateChunk
+	if sourceParts > 0 {
+		for n := int32(1); n <= int32(sourceParts); n++ {
+			chunks = append(chunks, migrateChunk{partNum: aws.Int32(n), sourcePart: aws.Int32(n)})
+		}
+	} else {
+		partSize := u.options.PartSizeBytes
+		if u.objectSize/partSize >= int64(defaultMaxUploadParts) {
+			partSize = (u.objectSize / int64(defaultMaxUploadParts)) + 1
+		}
+		var n int32 = 1
+		for first := int64(0); first < u.objectSize; first += partSize {
+			last := min(first+partSize, u.objectSize) - 1
+			chunks = append(chunks, migrateChunk{
+				partNum: aws.Int32(n),
+				rng:     aws.String(fmt.Sprintf("bytes=%d-%d", first, last)),
+				size:    last - first + 1,
+			})
+			n++
+		}
+		u.hash = newSequentialHash(md5.New())
+	}
+	u.sums = make([][]byte, len(chunks))
+
+	params := u.put.mapCreateMultipartUploadInput(u.options.ChecksumAlgorithm)
+	resp, err := u.options.S3.CreateMultipartUpload(ctx, params, u.clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	u.uploadID = resp.UploadId
+
+	ch := make(chan migrateChunk, u.options.Concurrency)
+	for i := 0; i < u.options.Concurrency; i++ {
+		u.wg.Add(1)
+		go u.readChunk(ctx, ch)
+	}
+	for _, c := range chunks {
+		if u.geterr() != nil {
+			break
+		}
+		if c.rng != nil {
+			// ranges are hashed in order, so their memory is reserved in
+			// order for the part being hashed never to wait for memory
+			if err := u.options.BufferPool.reserve(ctx, c.size); err != nil {
+				u.seterr(err)
+				break
+			}
+			c.reserved = c.size
+		}
+		ch <- c
+	}
+	close(ch)
+	u.wg.Wait()
+
+	if u.geterr() == nil {
+		if err := u.validate(u.etag()); err != nil {
+			u.seterr(err)
+		}
+	}
+	completeOut := u.complete(ctx)
+	if err := u.geterr(); err != nil {
+		return nil, &multipartUploadError{
+			err:      err,
+			uploadID: aws.ToString(u.uploadID),
+		}
+	}
+
+	return &MigrateObjectOutput{
+		ETag:      aws.ToString(completeOut.ETag),
+		VersionID: aws.ToString(completeOut.VersionId),
+		UploadID:  aws.ToString(u.uploadID),
+	}, nil
+}
+
+// etag returns the ETag of the content read, in the form of the source
+// object's ETag.
+func (u *multiMigrator) etag() string {
+	if u.hash != nil {
+		return hex.EncodeToString(u.hash.Sum())
+	}
+	h := md5.New()
+	for _, sum := range u.sums {
+		h.Write(sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)) + "-" + strconv.Itoa(len(u.sums))
+}
+
+// readChunk runs in worker goroutines to pull chunks off of the ch channel
+// and send() them.
+func (u *multiMigrator) readChunk(ctx context.Context, ch chan migrateChunk) {
+	defer u.wg.Done()
+	for c := range ch {
+		if u.geterr() == nil {
+			if err := u.send(ctx, c); err != nil {
+				u.seterr(err)
+			}
+		}
+		u.options.BufferPool.release(c.reserved)
+	}
+}
+
+// send reads a chunk of the source object and uploads it as a part
+func (u *multiMigrator) send(ctx context.Context, c migrateChunk) error {
+	if c.rng == nil {
+		// parts are not hashed in order, their memory is reserved once
+		// their size is known
+		size, err := u.sourcePartSize(ctx, c.sourcePart)
+		if err != nil {
+			return err
+		}
+		if err := u.options.BufferPool.reserve(ctx, size); err != nil {
+			return err
+		}
+		defer u.options.BufferPool.release(size)
+	}
+	body, err := u.getSource(ctx, c.sourcePart, c.rng)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(body)
+	u.sums[aws.ToInt32(c.partNum)-1] = sum[:]
+	if u.hash != nil && !u.hash.Write(aws.ToInt32(c.partNum), body) {
+		return nil
+	}
+
+	params := u.put.mapUploadPartInput(u.options.BandwidthLimiter.reader(ctx, bytes.NewReader(body)), c.partNum, u.uploadID, u.options.ChecksumAlgorithm)
+	if err := u.options.requestBudget.acquire(ctx); err != nil {
+		return err
+	}
+	resp, err := u.options.S3.UploadPart(ctx, params, u.clientOptions...)
+	u.options.requestBudget.release()
+	if err != nil {
+		return err
+	}
+	u.progressEmitter.BytesTransferred(ctx, int64(len(body)))
+
+	var completed types.CompletedPart
+	completed.MapFrom(resp, c.partNum)
+
+	u.m.Lock()
+	u.parts = append(u.parts, completed)
+	u.m.Unlock()
+	return nil
+}
+
+// geterr is a thread-safe getter for the error object
+func (u *multiMigrator) geterr() error {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	return u.err
+}
+
+// seterr is a thread-safe setter for the error object, which also releases
+// the workers waiting to hash their part.
+func (u *multiMigrator) seterr(e error) {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	u.err = e
+	if u.hash != nil {
+		u.hash.Abort()
+	}
+}
+
+func (u *multiMigrator) fail(ctx context.Context) {
+	params := u.put.mapAbortMultipartUploadInput(u.uploadID)
+	_, err := u.options.S3.AbortMultipartUpload(ctx, params, u.clientOptions...)
+	if err != nil {
+		u.seterr(fmt.Errorf("failed to abort multipart upload (%v), triggered after multipart migration failed: %v", err, u.geterr()))
+	}
+}
+
+// complete successfully completes a multipart upload and returns the response.
+func (u *multiMigrator) complete(ctx context.Context) *s3.CompleteMultipartUploadOutput {
+	if u.geterr() != nil {
+		u.fail(ctx)
+		return nil
+	}
+
+	// Parts must be sorted in PartNumber order.
+	sort.Sort(u.parts)
+
+	params := u.put.mapCompleteMultipartUploadInput(u.uploadID, u.parts)
+	resp, err := u.options.S3.CompleteMultipartUpload(ctx, params, u.clientOptions...)
+	if err != nil {
+		u.seterr(err)
+		u.fail(ctx)
+	}
+	return resp
+}
+
+// sequentialHash computes the hash of parts which are read concurrently, by
+// writing them in part number order.
+type sequentialHash struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	h       hash.Hash
+	next    int32
+	aborted bool
+}
+
+func newSequentialHash(h hash.Hash) *sequentialHash {
+	s := &sequentialHash{h: h, next: 1}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Write waits for the previous parts to be written and writes part partNum.
+// It returns false if the hash was aborted.
+func (s *sequentialHash) Write(partNum int32, b []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.next != partNum && !s.aborted {
+		s.cond.Wait()
+	}
+	if s.aborted {
+		return false
+	}
+	s.h.Write(b)
+	s.next++
+	s.cond.Broadcast()
+	return true
+}
+
+// Abort releases the writers waiting for their turn
+func (s *sequentialHash) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.aborted = true
+	s.cond.Broadcast()
+}
+
+// Sum returns the hash of the parts written
+func (s *sequentialHash) Sum() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.h.Sum(nil)
+}
+
+// splitETag splits an MD5 based ETag into its hex digest and part count,
+// which is zero unless the object was uploaded in parts. ok is false if the
+// ETag is not MD5 based, e.g. because the object is encrypted with a
+// customer-provided key.
+func splitETag(etag string) (sum string, parts int, ok bool) {
+	sum = strings.Trim(etag, `"`)
+	if i := strings.LastIndexByte(sum, '-'); i >= 0 {
+		n, err := strconv.Atoi(sum[i+1:])
+		if err != nil || n <= 0 {
+			return "", 0, false
+		}
+		sum, parts = sum[:i], n
+	}
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != 2*md5.Size {
+		return "", parts, false
+	}
+	return sum, parts, true
+}
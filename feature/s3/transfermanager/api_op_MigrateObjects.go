@@ -0,0 +1,303 @@
+package transfermanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// MigrateObjectsInput represents a request to the MigrateObjects() call
+type MigrateObjectsInput struct {
+	// The client the source objects are listed and read with. It may use
+	// other credentials and another endpoint than the Client, which writes
+	// the migrated objects.
+	//
+	// This member is required.
+	SourceClient S3APIClient
+
+	// Bucket the objects are migrated from
+	//
+	// This member is required.
+	SourceBucket string
+
+	// Only objects whose key starts with this prefix are migrated
+	SourcePrefix string
+
+	// Bucket the objects are migrated to
+	//
+	// This member is required.
+	Bucket string
+
+	// Replaces SourcePrefix in the keys of the migrated objects, so that an
+	// object "logs/app.log" migrated from prefix "logs/" to prefix
+	// "archive/logs/" is written to "archive/logs/app.log".
+	KeyPrefix string
+
+	// Invoked for each listed object. If set, only objects for which it
+	// returns true are migrated.
+	Filter func(types.Object) bool
+
+	// Invoked with the input of each object before it is migrated, e.g. to
+	// set its Callback or IgnoreRetention.
+	Callback func(*MigrateObjectInput)
+
+	// How the failure of a single object is handled. Defaults to
+	// types.FailurePolicyAbort.
+	FailurePolicy types.FailurePolicy
+}
+
+// MigrateObjectsFailure describes an object which could not be migrated
+type MigrateObjectsFailure struct {
+	// The key of the source object
+	Key string
+
+	// The cause of the failure
+	Err error
+}
+
+// MigrateObjectsOutput represents a response from the MigrateObjects() call
+type MigrateObjectsOutput struct {
+	// The number of objects migrated
+	ObjectsMigrated int
+
+	// The number of objects skipped, as the destination object matched the
+	// source object already
+	ObjectsSkipped int
+
+	// The number of objects which failed to migrate
+	ObjectsFailed int
+
+	// The total size of the objects migrated, not counting skipped objects
+	BytesMigrated int64
+
+	// The objects which failed to migrate, sorted by key. With
+	// types.FailurePolicyAbort it holds at most the failure which aborted the
+	// migration.
+	Failures []MigrateObjectsFailure
+}
+
+// MigrateObjects migrates the objects under a key prefix, listed with
+// SourceClient, to the bucket of the Client, each with MigrateObject. Objects
+// which were migrated already are skipped, so that an interrupted migration
+// can be run again. Keys ending in "/" are migrated as well, as they may be
+// directory markers of the source bucket.
+//
+// Up to Options.Concurrency objects are migrated at once, and their source
+// and destination requests share a budget of Options.Concurrency in-flight
+// requests.
+//
+// Additional functional options can be provided to configure the individual
+// migration. These options are copies of the original Options instance, the client of which MigrateObjects is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) MigrateObjects(ctx context.Context, input *MigrateObjectsInput, opts ...func(*Options)) (*MigrateObjectsOutput, error) {
+	i := prefixMigrator{in: input, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
+
+	return i.migrate(ctx)
+}
+
+type prefixMigrator struct {
+	options Options
+	in      *MigrateObjectsInput
+	cancel  context.CancelFunc
+
+	failurePolicy types.FailurePolicy
+
+	progressEmitter *directoryProgressEmitter
+
+	m   sync.Mutex
+	out MigrateObjectsOutput
+	err error
+}
+
+func (p *prefixMigrator) migrate(ctx context.Context) (*MigrateObjectsOutput, error) {
+	if err := p.init(); err != nil {
+		return nil, fmt.Errorf("unable to initialize migration: %w", err)
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	defer p.cancel()
+
+	p.progressEmitter.Start(ctx, p.in)
+	out, err := p.migrateObjects(ctx)
+	if err != nil {
+		p.progressEmitter.Failed(ctx, err)
+		return out, err
+	}
+	p.progressEmitter.Complete(ctx, out)
+	return out, nil
+}
+
+func (p *prefixMigrator) init() error {
+	if p.in.SourceClient == nil {
+		return fmt.Errorf("source client is required")
+	}
+	if p.in.SourceBucket == "" {
+		return fmt.Errorf("source bucket is required")
+	}
+	if p.in.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+
+	p.failurePolicy = p.in.FailurePolicy
+	if p.failurePolicy == "" {
+		p.failurePolicy = types.FailurePolicyAbort
+	}
+
+	resolveConcurrency(&p.options)
+	p.options.requestBudget = newRequestBudget(p.options.Concurrency)
+	p.progressEmitter = &directoryProgressEmitter{
+		Listeners: p.options.ProgressListeners,
+	}
+	return nil
+}
+
+func (p *prefixMigrator) migrateObjects(ctx context.Context) (*MigrateObjectsOutput, error) {
+	objects := make(chan types.Object)
+	var wg sync.WaitGroup
+	for i := 0; i < p.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range objects {
+				p.migrateObject(ctx, obj)
+			}
+		}()
+	}
+
+	listErr := p.listObjects(ctx, objects)
+	close(objects)
+	if listErr == nil {
+		p.progressEmitter.DiscoveryComplete()
+	}
+	wg.Wait()
+
+	sort.Slice(p.out.Failures, func(i, j int) bool {
+		return p.out.Failures[i].Key < p.out.Failures[j].Key
+	})
+	if err := p.geterr(); err != nil {
+		return &p.out, err
+	}
+	if listErr != nil {
+		return &p.out, listErr
+	}
+	return &p.out, nil
+}
+
+func (p *prefixMigrator) listObjects(ctx context.Context, objects chan<- types.Object) error {
+	clientOptions := []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+
+	pg := s3.NewListObjectsV2Paginator(p.in.SourceClient, &s3.ListObjectsV2Input{
+		Bucket: aws.String(p.in.SourceBucket),
+		Prefix: nzstring(p.in.SourcePrefix),
+	})
+	for pg.HasMorePages() {
+		page, err := pg.NextPage(ctx, clientOptions...)
+		if err != nil {
+			if p.geterr() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to list source objects: %w", err)
+		}
+		for _, o := range page.Contents {
+			var obj types.Object
+			obj.MapFrom(o)
+			if p.in.Filter != nil && !p.in.Filter(obj) {
+				continue
+			}
+			p.progressEmitter.Discovered(obj.Size)
+			select {
+			case objects <- obj:
+			case <-ctx.Done():
+				if p.geterr() != nil {
+					return nil
+				}
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+func (p *prefixMigrator) migrateObject(ctx context.Context, obj types.Object) {
+	if p.geterr() != nil {
+		return
+	}
+
+	input := &MigrateObjectInput{
+		SourceClient: p.in.SourceClient,
+		SourceBucket: p.in.SourceBucket,
+		SourceKey:    obj.Key,
+		Bucket:       p.in.Bucket,
+		Key:          p.in.KeyPrefix + strings.TrimPrefix(obj.Key, p.in.SourcePrefix),
+	}
+	if p.in.Callback != nil {
+		p.in.Callback(input)
+	}
+
+	options := p.options.Copy()
+	options.ProgressListeners = p.progressEmitter.ObjectListeners(options.ProgressListeners)
+	m := migrator{in: input, options: options}
+	out, err := m.migrate(ctx)
+	if err != nil {
+		p.fail(ctx, obj.Key, err)
+		return
+	}
+
+	p.m.Lock()
+	if out.Skipped {
+		p.out.ObjectsSkipped++
+	} else {
+		p.out.ObjectsMigrated++
+		p.out.BytesMigrated += out.ObjectSize
+	}
+	p.m.Unlock()
+
+	if out.Skipped {
+		// skipped objects count towards the progress of the migration
+		p.progressEmitter.BytesTransferred(ctx, out.ObjectSize)
+	}
+	p.progressEmitter.ObjectTransferred(ctx)
+}
+
+// fail records the failure of an object according to the failure policy
+func (p *prefixMigrator) fail(ctx context.Context, key string, err error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.err != nil {
+		// failures caused by the abort are not recorded
+		return
+	}
+	p.progressEmitter.ObjectFailed(ctx)
+	p.out.ObjectsFailed++
+	p.out.Failures = append(p.out.Failures, MigrateObjectsFailure{Key: key, Err: err})
+	if p.failurePolicy == types.FailurePolicyAbort {
+		p.err = fmt.Errorf("failed to migrate %s: %w", key, err)
+		p.cancel()
+	}
+}
+
+// geterr is a thread-safe getter for the error which aborted the migration
+func (p *prefixMigrator) geterr() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.err
+}
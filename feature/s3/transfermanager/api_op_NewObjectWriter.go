@@ -0,0 +1,137 @@
+package transfermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrObjectWriterAborted is returned by Write and Close of an ObjectWriter
+// which was aborted.
+var ErrObjectWriterAborted = errors.New("object writer aborted")
+
+// ObjectWriter uploads the data written to it as an object. It is returned by
+// NewObjectWriter.
+//
+// Either Close or Abort must be called to release the resources of the
+// upload. An ObjectWriter is not safe for concurrent use.
+type ObjectWriter interface {
+	// Write buffers p into parts of Options.PartSizeBytes, which are uploaded
+	// concurrently as they fill. If the upload failed, Write returns the
+	// error of the upload.
+	Write(p []byte) (int, error)
+
+	// Close uploads the remaining data, completes the upload and returns its
+	// error, if any.
+	Close() error
+
+	// Abort stops the upload, waiting for in-flight parts, and aborts the
+	// multipart upload, if any. Abort has no effect once the writer is
+	// closed or aborted.
+	Abort() error
+
+	// Output returns the output of the upload once Close returned without
+	// error, and nil otherwise.
+	Output() *PutObjectOutput
+}
+
+// NewObjectWriter returns an ObjectWriter uploading the data written to it as
+// an object, as PutObject would with a reader. Objects smaller than
+// Options.PartSizeBytes are uploaded with a single PutObject request, and
+// others with a multipart upload.
+//
+// The Body of the input must be nil, as the data is written to the
+// ObjectWriter. If the size of the object is known, ContentLength may be set
+// so that the part size is adjusted for objects too large for the default
+// part size.
+//
+// Additional functional options can be provided to configure the individual
+// upload. These options are copies of the original Options instance, the client of which NewObjectWriter is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) NewObjectWriter(ctx context.Context, input *PutObjectInput, opts ...func(*Options)) (ObjectWriter, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input is required")
+	}
+	if input.Body != nil {
+		return nil, fmt.Errorf("input body must be nil, data is written to the object writer")
+	}
+
+	pr, pw := io.Pipe()
+	in := *input
+	in.Body = pr
+
+	i := uploader{in: &in, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
+
+	w := &objectWriter{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		w.out, w.err = i.upload(ctx)
+		// unblocks and fails writes once the upload stopped reading
+		if w.err != nil {
+			pr.CloseWithError(w.err)
+		} else {
+			pr.Close()
+		}
+	}()
+	return w, nil
+}
+
+type objectWriter struct {
+	pw *io.PipeWriter
+
+	// closed when the upload returned, guarding out and err
+	done chan struct{}
+	out  *PutObjectOutput
+	err  error
+
+	closed  bool
+	aborted bool
+}
+
+func (w *objectWriter) Write(p []byte) (int, error) {
+	if w.aborted {
+		return 0, ErrObjectWriterAborted
+	}
+	if w.closed {
+		return 0, fmt.Errorf("object writer is closed")
+	}
+	return w.pw.Write(p)
+}
+
+func (w *objectWriter) Close() error {
+	if w.aborted {
+		return ErrObjectWriterAborted
+	}
+	if w.closed {
+		return fmt.Errorf("object writer is closed")
+	}
+	w.closed = true
+
+	w.pw.Close()
+	<-w.done
+	return w.err
+}
+
+func (w *objectWriter) Abort() error {
+	if w.closed {
+		return nil
+	}
+	w.closed, w.aborted = true, true
+
+	w.pw.CloseWithError(ErrObjectWriterAborted)
+	<-w.done
+	return nil
+}
+
+func (w *objectWriter) Output() *PutObjectOutput {
+	// the upload returned once the writer is closed
+	if !w.closed || w.aborted || w.err != nil {
+		return nil
+	}
+	return w.out
+}
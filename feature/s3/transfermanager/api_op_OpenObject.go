@@ -0,0 +1,354 @@
+package transfermanager
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	smithy "github.com/aws/smithy-go"
+)
+
+// OpenObject opens an object for random access reads, e.g. by Parquet, ZIP or
+// SQLite readers, returning an ObjectReaderAt. The object is read with ranged
+// GetObject requests, conditional on the ETag and version of the object when
+// it was opened, so that reads fail with ErrObjectChanged rather than mix the
+// content of two objects.
+//
+// The object is fetched in blocks of Options.ReadAtBlockSizeBytes, of which
+// Options.ReadAtCacheBlocks are cached. When reads are sequential, up to
+// Options.Concurrency following blocks are fetched ahead of them.
+//
+// Additional functional options can be provided to configure the individual
+// reader. These options are copies of the original Options instance, the client of which OpenObject is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) OpenObject(ctx context.Context, bucket, key string, opts ...func(*Options)) (*ObjectReaderAt, error) {
+	r := &ObjectReaderAt{bucket: bucket, key: key, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	resolveOperationBandwidthLimiter(&r.options, &c.options)
+
+	if err := r.open(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ObjectReaderAt reads an object at arbitrary offsets. It implements
+// io.ReaderAt, io.ReadSeeker and io.Closer, and is returned by OpenObject.
+//
+// ReadAt is safe for concurrent use, while Read and Seek share the offset of
+// the reader and are not. Close must be called to cancel the requests in
+// flight and release the cached blocks.
+type ObjectReaderAt struct {
+	options Options
+	bucket  string
+	key     string
+
+	size      int64
+	etag      string
+	versionID string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	m      sync.Mutex
+	blocks map[int64]*readBlock
+	// blocks from the most to the least recently used
+	lru    *list.List
+	closed bool
+
+	// the last block read, and the number of blocks fetched ahead of
+	// sequential reads
+	lastBlock int64
+	readAhead int
+
+	// the offset of Read and Seek
+	pos int64
+}
+
+// readBlock is a block of the object, fetched or being fetched
+type readBlock struct {
+	index int64
+	elem  *list.Element
+
+	// closed once data and err are set
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+func (r *ObjectReaderAt) open(ctx context.Context) error {
+	if r.bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if r.key == "" {
+		return fmt.Errorf("key is required")
+	}
+	if r.options.ReadAtBlockSizeBytes <= 0 {
+		return fmt.Errorf("read at block size must be positive")
+	}
+	if r.options.ReadAtCacheBlocks <= 0 {
+		return fmt.Errorf("read at cache blocks must be positive")
+	}
+	resolveConcurrency(&r.options)
+	if r.options.requestBudget == nil {
+		r.options.requestBudget = newRequestBudget(r.options.Concurrency)
+	}
+
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.key),
+	}
+	r.customerKey(&input.SSECustomerAlgorithm, &input.SSECustomerKey, &input.SSECustomerKeyMD5)
+	out, err := r.options.S3.HeadObject(ctx, input, r.clientOptions()...)
+	if err != nil {
+		err = headCustomerKeyError(ctx, r.options.S3, input, err, r.clientOptions()...)
+		return fmt.Errorf("failed to open %s: %w", r.key, err)
+	}
+	r.size = aws.ToInt64(out.ContentLength)
+	r.etag = aws.ToString(out.ETag)
+	r.versionID = aws.ToString(out.VersionId)
+
+	r.ctx, r.cancel = context.WithCancel(ctx)
+	r.blocks = map[int64]*readBlock{}
+	r.lru = list.New()
+	r.lastBlock = -1
+	return nil
+}
+
+// Size returns the size of the object in bytes
+func (r *ObjectReaderAt) Size() int64 {
+	return r.size
+}
+
+// ETag returns the ETag of the object the reads are pinned to
+func (r *ObjectReaderAt) ETag() string {
+	return r.etag
+}
+
+// VersionID returns the version of the object the reads are pinned to, if
+// the bucket is versioned
+func (r *ObjectReaderAt) VersionID() string {
+	return r.versionID
+}
+
+// ReadAt implements io.ReaderAt, reading len(p) bytes of the object at off.
+// It returns io.EOF if fewer bytes are read because the object ends.
+func (r *ObjectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), r.size)
+	if end == off {
+		return 0, nil
+	}
+
+	bs := r.options.ReadAtBlockSizeBytes
+	first, last := off/bs, (end-1)/bs
+	blocks, err := r.acquire(first, last)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, b := range blocks {
+		select {
+		case <-b.done:
+		case <-r.ctx.Done():
+			return n, r.ctx.Err()
+		}
+		if b.err != nil {
+			r.discard(b)
+			return n, b.err
+		}
+		start := b.index * bs
+		n += copy(p[n:], b.data[off+int64(n)-start:])
+	}
+
+	if end < off+int64(len(p)) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// acquire returns the blocks first to last, fetching those not cached, and
+// fetches the following blocks ahead of sequential reads.
+func (r *ObjectReaderAt) acquire(first, last int64) ([]*readBlock, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.closed {
+		return nil, fmt.Errorf("object reader is closed")
+	}
+
+	blocks := make([]*readBlock, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		blocks = append(blocks, r.block(i))
+	}
+
+	if first == r.lastBlock || first == r.lastBlock+1 {
+		limit := min(r.options.Concurrency, r.options.ReadAtCacheBlocks/2)
+		r.readAhead = min(max(1, 2*r.readAhead), limit)
+	} else {
+		r.readAhead = 0
+	}
+	r.lastBlock = last
+
+	count := (r.size + r.options.ReadAtBlockSizeBytes - 1) / r.options.ReadAtBlockSizeBytes
+	for i := last + 1; i <= last+int64(r.readAhead) && i < count; i++ {
+		if _, ok := r.blocks[i]; !ok {
+			r.block(i)
+		}
+	}
+	return blocks, nil
+}
+
+// block returns the block at index, marking it as the most recently used, and
+// starts fetching it if it is not cached. The caller must hold r.m.
+func (r *ObjectReaderAt) block(index int64) *readBlock {
+	if b, ok := r.blocks[index]; ok {
+		r.lru.MoveToFront(b.elem)
+		return b
+	}
+
+	b := &readBlock{index: index, done: make(chan struct{})}
+	b.elem = r.lru.PushFront(b)
+	r.blocks[index] = b
+	for r.lru.Len() > r.options.ReadAtCacheBlocks {
+		// a block evicted while it is read stays valid for its readers
+		evicted := r.lru.Remove(r.lru.Back()).(*readBlock)
+		delete(r.blocks, evicted.index)
+	}
+
+	r.wg.Add(1)
+	go r.fetch(b)
+	return b
+}
+
+// discard removes a block which failed to be fetched from the cache, so that
+// it is fetched again by the next read.
+func (r *ObjectReaderAt) discard(b *readBlock) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.blocks[b.index] == b {
+		r.lru.Remove(b.elem)
+		delete(r.blocks, b.index)
+	}
+}
+
+func (r *ObjectReaderAt) fetch(b *readBlock) {
+	defer r.wg.Done()
+	defer close(b.done)
+
+	start := b.index * r.options.ReadAtBlockSizeBytes
+	end := min(start+r.options.ReadAtBlockSizeBytes, r.size)
+	b.data = make([]byte, end-start)
+
+	if err := r.options.requestBudget.acquire(r.ctx); err != nil {
+		b.err = err
+		return
+	}
+	defer r.options.requestBudget.release()
+
+	input := &s3.GetObjectInput{
+		Bucket:  aws.String(r.bucket),
+		Key:     aws.String(r.key),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end-1)),
+		IfMatch: nzstring(r.etag),
+	}
+	input.VersionId = nzstring(r.versionID)
+	r.customerKey(&input.SSECustomerAlgorithm, &input.SSECustomerKey, &input.SSECustomerKeyMD5)
+
+	for retry := 0; retry < max(1, r.options.PartBodyMaxRetries); retry++ {
+		out, err := r.options.S3.GetObject(r.ctx, input, r.clientOptions()...)
+		if err != nil {
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+				err = fmt.Errorf("%w: %w", ErrObjectChanged, err)
+			}
+			err = sseCustomerKeyError(err, aws.ToString(input.SSECustomerKey), aws.ToString(input.SSECustomerKeyMD5), false)
+			b.err = fmt.Errorf("failed to read %s at %d: %w", r.key, start, err)
+			return
+		}
+		_, err = io.ReadFull(r.options.BandwidthLimiter.reader(r.ctx, out.Body), b.data)
+		out.Body.Close()
+		if err == nil {
+			b.err = nil
+			return
+		}
+		// the body is read again, as by DownloadObject
+		b.err = fmt.Errorf("failed to read %s at %d: %w", r.key, start, err)
+	}
+}
+
+// customerKey sets the SSE-C members of a request to those of
+// Options.ReadAtCustomerKey
+func (r *ObjectReaderAt) customerKey(algorithm, key, keyMD5 **string) {
+	if k := r.options.ReadAtCustomerKey; k != nil {
+		*algorithm, *key, *keyMD5 = aws.String(k.Algorithm()), aws.String(k.Key()), aws.String(k.KeyMD5())
+	}
+}
+
+// Read implements io.Reader, reading from the offset of the reader
+func (r *ObjectReaderAt) Read(p []byte) (int, error) {
+	n, err := r.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	return n, err
+}
+
+// Seek implements io.Seeker, setting the offset of the next Read
+func (r *ObjectReaderAt) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+// Close cancels the requests in flight and releases the cached blocks
+func (r *ObjectReaderAt) Close() error {
+	r.m.Lock()
+	if r.closed {
+		r.m.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.blocks = nil
+	r.lru.Init()
+	r.m.Unlock()
+
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
+
+func (r *ObjectReaderAt) clientOptions() []func(*s3.Options) {
+	return []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+}
@@ -0,0 +1,152 @@
+package transfermanager
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OpenZipArchive opens a zip archive object to list and extract its members
+// without downloading it as a whole. The central directory at the end of the
+// archive is read with ranged GetObject requests when the archive is opened,
+// and the members are read the same way when they are opened, through an
+// ObjectReaderAt configured by the options.
+//
+// Additional functional options can be provided to configure the individual
+// reader. These options are copies of the original Options instance, the client of which OpenZipArchive is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) OpenZipArchive(ctx context.Context, bucket, key string, opts ...func(*Options)) (*ZipArchive, error) {
+	r, err := c.OpenObject(ctx, bucket, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(r, r.Size())
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("failed to read zip archive %s: %w", key, err)
+	}
+
+	a := &ZipArchive{r: r, zr: zr, files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		// the first of duplicate names wins, as with zip.Reader.Open
+		if _, ok := a.files[f.Name]; !ok {
+			a.files[f.Name] = f
+		}
+	}
+	return a, nil
+}
+
+// ArchiveMember describes a member of an archive object
+type ArchiveMember struct {
+	// Name of the member, a slash-separated path
+	Name string
+
+	// Size of the member once extracted
+	Size int64
+
+	// Size of the member as stored in the archive
+	CompressedSize int64
+
+	// Modification time of the member
+	ModTime time.Time
+
+	// Mode and permission bits of the member
+	Mode fs.FileMode
+}
+
+// ZipArchive lists and extracts the members of a zip archive object. It is
+// returned by OpenZipArchive.
+//
+// Members can be opened and extracted concurrently. Close must be called to
+// cancel the requests in flight and release the cached blocks of the
+// archive.
+type ZipArchive struct {
+	r     *ObjectReaderAt
+	zr    *zip.Reader
+	files map[string]*zip.File
+}
+
+// Members returns the members of the archive, in the order of its central
+// directory
+func (a *ZipArchive) Members() []ArchiveMember {
+	members := make([]ArchiveMember, 0, len(a.zr.File))
+	for _, f := range a.zr.File {
+		members = append(members, ArchiveMember{
+			Name:           f.Name,
+			Size:           int64(f.UncompressedSize64),
+			CompressedSize: int64(f.CompressedSize64),
+			ModTime:        f.Modified,
+			Mode:           f.Mode(),
+		})
+	}
+	return members
+}
+
+// Open returns a reader of the content of the member name. The content is
+// checked against the CRC-32 of the member once read to its end.
+func (a *ZipArchive) Open(name string) (io.ReadCloser, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("member %s: %w", name, fs.ErrNotExist)
+	}
+	if f.Mode().IsDir() {
+		return nil, fmt.Errorf("member %s is a directory", name)
+	}
+	return f.Open()
+}
+
+// Extract writes the member name to the local file at path, through a
+// temporary file which is renamed to path once complete, and sets its
+// modification time to the member's. It returns the size of the member.
+func (a *ZipArchive) Extract(name, path string) (int64, error) {
+	r, err := a.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if modTime := a.files[name].Modified; !modTime.IsZero() {
+		if err := os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+			return 0, err
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	committed = true
+	return n, nil
+}
+
+// Close cancels the requests in flight and releases the cached blocks of
+// the archive
+func (a *ZipArchive) Close() error {
+	return a.r.Close()
+}
@@ -3,6 +3,7 @@ package transfermanager
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
@@ -163,6 +164,13 @@ type PutObjectInput struct {
 	// [Checking object integrity]: https://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html
 	ChecksumAlgorithm types.ChecksumAlgorithm
 
+	// Indicates whether the checksum of an object uploaded with a multipart
+	// upload is a composite checksum of its part checksums or a full-object
+	// checksum of its content. Full-object checksums are only supported by the
+	// CRC algorithms. Defaults to types.ChecksumTypeFullObject for CRC64NVME
+	// and to types.ChecksumTypeComposite otherwise.
+	ChecksumType types.ChecksumType
+
 	// Size of the body in bytes. This parameter is useful when the size of the body
 	// cannot be determined automatically. For more information, see [https://www.rfc-editor.org/rfc/rfc9110.html#name-content-length].
 	//
@@ -277,6 +285,17 @@ type PutObjectInput struct {
 	// This functionality is not supported for directory buckets.
 	SSECustomerKey string
 
+	// Specifies the 128-bit MD5 digest of the encryption key according to RFC
+	// 1321. Amazon S3 uses this header for a message integrity check to ensure
+	// that the encryption key was transmitted without error.
+	//
+	// This functionality is not supported for directory buckets.
+	SSECustomerKeyMD5 string
+
+	// The customer provided key the object is encrypted with. If set, it
+	// replaces SSECustomerAlgorithm, SSECustomerKey and SSECustomerKeyMD5.
+	CustomerKey *SSECustomerKey
+
 	// Specifies the Amazon Web Services KMS Encryption Context to use for object
 	// encryption. The value of this header is a base64-encoded UTF-8 string holding
 	// JSON with the encryption context key-value pairs. This value is stored as object
@@ -362,6 +381,17 @@ type PutObjectInput struct {
 	// [Hosting Websites on Amazon S3]: https://docs.aws.amazon.com/AmazonS3/latest/dev/WebsiteHosting.html
 	// [Object Key and Metadata]: https://docs.aws.amazon.com/AmazonS3/latest/dev/UsingMetadata.html
 	WebsiteRedirectLocation string
+
+	// Persists the progress of a multipart upload, so that an upload which
+	// failed can be resumed by calling PutObject again with the same store and
+	// body. The parts which the service lists as uploaded are skipped, and
+	// seeked past if Body is an io.Seeker. The part size and checksum
+	// algorithm of the checkpoint are kept.
+	//
+	// An upload with a checkpoint store is not aborted when it fails, use
+	// Client.AbortCheckpointedUpload to discard it. The checkpoint is deleted
+	// once the upload completes.
+	CheckpointStore UploadCheckpointStore
 }
 
 // map non-zero string to *string
@@ -380,6 +410,17 @@ func nztime(t time.Time) *time.Time {
 	return aws.Time(t)
 }
 
+// withCustomerKey returns a copy of the input with the members of its
+// CustomerKey set, or the input if it has none
+func (i *PutObjectInput) withCustomerKey() *PutObjectInput {
+	if i == nil || i.CustomerKey == nil {
+		return i
+	}
+	in := *i
+	in.CustomerKey.apply(&in.SSECustomerAlgorithm, &in.SSECustomerKey, &in.SSECustomerKeyMD5)
+	return &in
+}
+
 func (i PutObjectInput) mapSingleUploadInput(body io.Reader, checksumAlgorithm types.ChecksumAlgorithm) *s3.PutObjectInput {
 	input := &s3.PutObjectInput{
 		Bucket: aws.String(i.Bucket),
@@ -423,6 +464,7 @@ func (i PutObjectInput) mapSingleUploadInput(body io.Reader, checksumAlgorithm t
 	input.Metadata = i.Metadata
 	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
 	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
 	input.SSEKMSEncryptionContext = nzstring(i.SSEKMSEncryptionContext)
 	input.SSEKMSKeyId = nzstring(i.SSEKMSKeyID)
 	input.Tagging = nzstring(i.Tagging)
@@ -445,6 +487,9 @@ func (i PutObjectInput) mapCreateMultipartUploadInput(checksumAlgorithm types.Ch
 	} else {
 		input.ChecksumAlgorithm = s3types.ChecksumAlgorithm(checksumAlgorithm)
 	}
+	if i.ChecksumType != "" {
+		input.ChecksumType = s3types.ChecksumType(i.ChecksumType)
+	}
 	if i.ObjectLockLegalHoldStatus != "" {
 		input.ObjectLockLegalHoldStatus = s3types.ObjectLockLegalHoldStatus(i.ObjectLockLegalHoldStatus)
 	}
@@ -474,6 +519,7 @@ func (i PutObjectInput) mapCreateMultipartUploadInput(checksumAlgorithm types.Ch
 	input.Metadata = i.Metadata
 	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
 	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
 	input.SSEKMSEncryptionContext = nzstring(i.SSEKMSEncryptionContext)
 	input.SSEKMSKeyId = nzstring(i.SSEKMSKeyID)
 	input.Tagging = nzstring(i.Tagging)
@@ -495,6 +541,7 @@ func (i PutObjectInput) mapCompleteMultipartUploadInput(uploadID *string, comple
 	input.ExpectedBucketOwner = nzstring(i.ExpectedBucketOwner)
 	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
 	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
 	var parts []s3types.CompletedPart
 	for _, part := range completedParts {
 		parts = append(parts, part.MapCompletedPart())
@@ -525,6 +572,7 @@ func (i PutObjectInput) mapUploadPartInput(body io.Reader, partNum *int32, uploa
 	input.ExpectedBucketOwner = nzstring(i.ExpectedBucketOwner)
 	input.SSECustomerAlgorithm = nzstring(i.SSECustomerAlgorithm)
 	input.SSECustomerKey = nzstring(i.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(i.SSECustomerKeyMD5)
 	return input
 }
 
@@ -561,12 +609,25 @@ type PutObjectOutput struct {
 	// The base64-encoded, 32-bit CRC32C checksum of the object.
 	ChecksumCRC32C string
 
+	// The base64-encoded, 64-bit CRC64NVME checksum of the object.
+	ChecksumCRC64NVME string
+
 	// The base64-encoded, 160-bit SHA-1 digest of the object.
 	ChecksumSHA1 string
 
 	// The base64-encoded, 256-bit SHA-256 digest of the object.
 	ChecksumSHA256 string
 
+	// The checksum of the object for the checksum algorithm of the upload, as
+	// S3 reports it. For a multipart upload it is computed from the checksums
+	// and sizes of the parts, e.g. "Zm9v-3" for a composite checksum of three
+	// parts, so that it can be compared with ComputeChecksum. Empty if the
+	// parts have no checksum.
+	Checksum string
+
+	// Indicates whether Checksum is a composite or a full-object checksum
+	ChecksumType types.ChecksumType
+
 	// Entity tag for the uploaded object.
 	ETag string
 
@@ -608,6 +669,8 @@ func (o *PutObjectOutput) mapFromPutObjectOutput(out *s3.PutObjectOutput, bucket
 	o.BucketKeyEnabled = aws.ToBool(out.BucketKeyEnabled)
 	o.ChecksumCRC32 = aws.ToString(out.ChecksumCRC32)
 	o.ChecksumCRC32C = aws.ToString(out.ChecksumCRC32C)
+	o.ChecksumCRC64NVME = aws.ToString(out.ChecksumCRC64NVME)
+	o.ChecksumType = types.ChecksumType(out.ChecksumType)
 	o.ChecksumSHA1 = aws.ToString(out.ChecksumSHA1)
 	o.ChecksumSHA256 = aws.ToString(out.ChecksumSHA256)
 	o.ETag = aws.ToString(out.ETag)
@@ -627,6 +690,8 @@ func (o *PutObjectOutput) mapFromCompleteMultipartUploadOutput(out *s3.CompleteM
 	o.BucketKeyEnabled = aws.ToBool(out.BucketKeyEnabled)
 	o.ChecksumCRC32 = aws.ToString(out.ChecksumCRC32)
 	o.ChecksumCRC32C = aws.ToString(out.ChecksumCRC32C)
+	o.ChecksumCRC64NVME = aws.ToString(out.ChecksumCRC64NVME)
+	o.ChecksumType = types.ChecksumType(out.ChecksumType)
 	o.ChecksumSHA1 = aws.ToString(out.ChecksumSHA1)
 	o.ChecksumSHA256 = aws.ToString(out.ChecksumSHA256)
 	o.ETag = aws.ToString(out.ETag)
@@ -640,6 +705,23 @@ func (o *PutObjectOutput) mapFromCompleteMultipartUploadOutput(out *s3.CompleteM
 	o.ResultMetadata = out.ResultMetadata
 }
 
+// checksum returns the checksum of the object for the checksum algorithm
+func (o *PutObjectOutput) checksum(algorithm types.ChecksumAlgorithm) string {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32:
+		return o.ChecksumCRC32
+	case types.ChecksumAlgorithmCrc32c:
+		return o.ChecksumCRC32C
+	case types.ChecksumAlgorithmCrc64nvme:
+		return o.ChecksumCRC64NVME
+	case types.ChecksumAlgorithmSha1:
+		return o.ChecksumSHA1
+	case types.ChecksumAlgorithmSha256:
+		return o.ChecksumSHA256
+	}
+	return ""
+}
+
 // PutObject uploads an object to S3, intelligently buffering large
 // files into smaller chunks and sending them in parallel across multiple
 // goroutines. You can configure the chunk size and concurrency through the
@@ -653,6 +735,7 @@ func (c *Client) PutObject(ctx context.Context, input *PutObjectInput, opts ...f
 	for _, opt := range opts {
 		opt(&i.options)
 	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
 
 	return i.upload(ctx)
 }
@@ -665,14 +748,30 @@ type uploader struct {
 	partPool   bytesBufferPool
 	objectSize int64
 
+	// whether the part size grows with the part number, and the size of the
+	// buffers of partPool
+	growPartSize bool
+	poolPartSize int64
+
+	// tunes the concurrency of adaptive uploads
+	tuner *concurrencyTuner
+
 	progressEmitter *singleObjectProgressEmitter
+
+	// the checkpoint of the upload being resumed
+	checkpoint *UploadCheckpoint
 }
 
 func (u *uploader) upload(ctx context.Context) (*PutObjectOutput, error) {
+	u.in = u.in.withCustomerKey()
+	if err := u.loadCheckpoint(ctx); err != nil {
+		return nil, fmt.Errorf("unable to load upload checkpoint: %w", err)
+	}
 	if err := u.init(); err != nil {
 		return nil, fmt.Errorf("unable to initialize upload: %w", err)
 	}
-	defer u.partPool.Close()
+	// the part pool is replaced once the part size grows
+	defer func() { u.partPool.Close() }()
 
 	clientOptions := []func(o *s3.Options){
 		func(o *s3.Options) {
@@ -684,9 +783,9 @@ func (u *uploader) upload(ctx context.Context) (*PutObjectOutput, error) {
 
 	r, n, cleanUp, err := u.nextReader(ctx)
 
-	if err == io.EOF {
+	if err == io.EOF && u.checkpoint == nil {
 		return u.singleUpload(ctx, r, n, cleanUp, clientOptions...)
-	} else if err != nil {
+	} else if err != nil && err != io.EOF {
 		cleanUp()
 		return nil, err
 	}
@@ -704,11 +803,100 @@ func (u *uploader) init() error {
 	if err := u.initSize(); err != nil {
 		return err
 	}
-	u.partPool = newDefaultSlicePool(u.options.PartSizeBytes, u.options.Concurrency+1)
+	if u.checkpoint != nil {
+		u.options.PartSizeBytes = u.checkpoint.PartSizeBytes
+	}
+	u.growPartSize = u.options.AdaptiveUpload && u.objectSize < 0
+	if u.checkpoint != nil {
+		u.growPartSize = u.checkpoint.AdaptivePartSize
+	}
+	if u.options.AdaptiveUpload {
+		lo := max(u.options.MinConcurrency, 1)
+		hi := u.options.MaxConcurrency
+		if hi == 0 {
+			hi = max(2*u.options.Concurrency, lo)
+		}
+		if lo > hi {
+			return fmt.Errorf("min concurrency %d exceeds max concurrency %d", lo, hi)
+		}
+		u.tuner = newConcurrencyTuner(u.options.Concurrency, lo, hi)
+	}
+	u.poolPartSize = u.options.PartSizeBytes
+	u.partPool = u.options.BufferPool.slicePool(u.poolPartSize, u.workers()+1)
 
 	return nil
 }
 
+// workers returns the number of goroutines sending parts, which is the upper
+// bound of the concurrency of adaptive uploads.
+func (u *uploader) workers() int {
+	if u.tuner != nil {
+		return u.tuner.max
+	}
+	return u.options.Concurrency
+}
+
+// partSize returns the size of part partNum
+func (u *uploader) partSize(partNum int32) int64 {
+	if u.growPartSize {
+		return adaptivePartSize(u.options.PartSizeBytes, partNum)
+	}
+	return u.options.PartSizeBytes
+}
+
+// partOffset returns the offset of part partNum in the object
+func (u *uploader) partOffset(partNum int32) int64 {
+	if u.growPartSize {
+		return adaptivePartOffset(u.options.PartSizeBytes, partNum)
+	}
+	return int64(partNum-1) * u.options.PartSizeBytes
+}
+
+// growPartPool replaces the part pool by one of larger buffers once the part
+// size of partNum grows. The buffers of the previous pool are released as
+// the parts using them are sent.
+func (u *uploader) growPartPool(partNum int32) {
+	size := u.partSize(partNum)
+	if size == u.poolPartSize {
+		return
+	}
+	u.partPool.Close()
+	u.poolPartSize = size
+	u.partPool = u.options.BufferPool.slicePool(size, u.workers()+1)
+}
+
+// loadCheckpoint loads the checkpoint of the upload being resumed, if any
+func (u *uploader) loadCheckpoint(ctx context.Context) error {
+	if u.in.CheckpointStore == nil {
+		return nil
+	}
+
+	checkpoint, err := u.in.CheckpointStore.Load(ctx)
+	if err != nil || checkpoint == nil {
+		return err
+	}
+	if checkpoint.Bucket != u.in.Bucket || checkpoint.Key != u.in.Key {
+		return fmt.Errorf("checkpoint is for %s/%s, not %s/%s", checkpoint.Bucket, checkpoint.Key, u.in.Bucket, u.in.Key)
+	}
+	if checkpoint.PartSizeBytes < minPartSizeBytes {
+		return fmt.Errorf("checkpoint part size must be at least %d bytes", minPartSizeBytes)
+	}
+	if u.in.ChecksumAlgorithm != "" && u.in.ChecksumAlgorithm != checkpoint.ChecksumAlgorithm {
+		return fmt.Errorf("checkpoint checksum algorithm %s does not match %s", checkpoint.ChecksumAlgorithm, u.in.ChecksumAlgorithm)
+	}
+	u.options.ChecksumAlgorithm = checkpoint.ChecksumAlgorithm
+	u.checkpoint = checkpoint
+	return nil
+}
+
+// checksumAlgorithm returns the checksum algorithm the parts are uploaded with
+func (u *uploader) checksumAlgorithm() types.ChecksumAlgorithm {
+	if u.in.ChecksumAlgorithm != "" {
+		return u.in.ChecksumAlgorithm
+	}
+	return u.options.ChecksumAlgorithm
+}
+
 // initSize checks user configured partsize and up-size it if calculated part count exceeds max value
 func (u *uploader) initSize() error {
 	if u.options.PartSizeBytes < minPartSizeBytes {
@@ -742,11 +930,16 @@ func (u *uploader) initSize() error {
 func (u *uploader) singleUpload(ctx context.Context, r io.Reader, sz int, cleanUp func(), clientOptions ...func(*s3.Options)) (*PutObjectOutput, error) {
 	defer cleanUp()
 
-	params := u.in.mapSingleUploadInput(r, u.options.ChecksumAlgorithm)
+	params := u.in.mapSingleUploadInput(u.options.BandwidthLimiter.reader(ctx, r), u.options.ChecksumAlgorithm)
 	objectSize := int64(sz)
 
 	u.progressEmitter.Start(ctx, u.in, objectSize)
+	if err := u.options.requestBudget.acquire(ctx); err != nil {
+		u.progressEmitter.Failed(ctx, err)
+		return nil, err
+	}
 	out, err := u.options.S3.PutObject(ctx, params, clientOptions...)
+	u.options.requestBudget.release()
 	if err != nil {
 		u.progressEmitter.Failed(ctx, err)
 		return nil, err
@@ -754,6 +947,10 @@ func (u *uploader) singleUpload(ctx context.Context, r io.Reader, sz int, cleanU
 
 	var output PutObjectOutput
 	output.mapFromPutObjectOutput(out, u.in.Bucket, u.in.Key)
+	output.Checksum = output.checksum(u.checksumAlgorithm())
+	if output.Checksum != "" && output.ChecksumType == "" {
+		output.ChecksumType = types.ChecksumTypeFullObject
+	}
 
 	u.progressEmitter.BytesTransferred(ctx, objectSize)
 	u.progressEmitter.Complete(ctx, &output)
@@ -762,7 +959,10 @@ func (u *uploader) singleUpload(ctx context.Context, r io.Reader, sz int, cleanU
 
 // nextReader reads the next chunk of data from input Body
 func (u *uploader) nextReader(ctx context.Context) (io.Reader, int, func(), error) {
-	part, err := u.partPool.Get(ctx)
+	// the part is put back to the pool it was taken from, which is
+	// replaced once the part size grows
+	pool := u.partPool
+	part, err := pool.Get(ctx)
 	if err != nil {
 		return nil, 0, func() {}, err
 	}
@@ -770,7 +970,7 @@ func (u *uploader) nextReader(ctx context.Context) (io.Reader, int, func(), erro
 	n, err := readFillBuf(u.in.Body, part)
 
 	cleanup := func() {
-		u.partPool.Put(part)
+		pool.Put(part)
 	}
 	return bytes.NewReader(part[0:n]), n, cleanup, err
 }
@@ -791,6 +991,16 @@ type multiUploader struct {
 	err      error
 	uploadID *string
 	parts    completedParts
+
+	// the checksum type of the object and the sizes of its parts, from which
+	// the checksum of the object is computed
+	checksumType types.ChecksumType
+	partSizes    map[int32]int64
+
+	// parts which were uploaded before the upload was resumed
+	resumed map[int32]types.CompletedPart
+	// serializes checkpoint saves
+	checkpointMu sync.Mutex
 }
 
 type ulChunk struct {
@@ -819,18 +1029,43 @@ func (cp completedParts) Swap(i, j int) {
 func (u *multiUploader) upload(ctx context.Context, firstBuf io.Reader, firstBuflen int, cleanup func(), clientOptions ...func(*s3.Options)) (*PutObjectOutput, error) {
 	params := u.uploader.in.mapCreateMultipartUploadInput(u.options.ChecksumAlgorithm)
 
-	// Create a multipart
+	// Create a multipart, unless one is resumed
 	u.progressEmitter.Start(ctx, u.in, u.objectSize)
-	resp, err := u.uploader.options.S3.CreateMultipartUpload(ctx, params, clientOptions...)
+	checksumType, err := resolveChecksumType(u.checksumAlgorithm(), u.in.ChecksumType, true)
 	if err != nil {
 		cleanup()
 		u.progressEmitter.Failed(ctx, err)
 		return nil, err
 	}
-	u.uploadID = resp.UploadId
+	u.checksumType = checksumType
+	u.partSizes = map[int32]int64{}
+	if u.checkpoint != nil {
+		if err := u.resume(ctx, clientOptions...); err != nil {
+			cleanup()
+			u.progressEmitter.Failed(ctx, err)
+			return nil, err
+		}
+	}
+	if u.uploadID == nil {
+		resp, err := u.uploader.options.S3.CreateMultipartUpload(ctx, params, clientOptions...)
+		if err != nil {
+			cleanup()
+			u.progressEmitter.Failed(ctx, err)
+			return nil, err
+		}
+		u.uploadID = resp.UploadId
+
+		if err := u.saveCheckpoint(ctx); err != nil {
+			cleanup()
+			// without a checkpoint the upload cannot be resumed
+			u.options.S3.AbortMultipartUpload(ctx, u.in.mapAbortMultipartUploadInput(u.uploadID), clientOptions...)
+			u.progressEmitter.Failed(ctx, err)
+			return nil, err
+		}
+	}
 
-	ch := make(chan ulChunk, u.options.Concurrency)
-	for i := 0; i < u.options.Concurrency; i++ {
+	ch := make(chan ulChunk, u.workers())
+	for i := 0; i < u.workers(); i++ {
 		// launch workers
 		u.wg.Add(1)
 		go u.readChunk(ctx, ch, clientOptions...)
@@ -845,6 +1080,10 @@ func (u *multiUploader) upload(ctx context.Context, firstBuf io.Reader, firstBuf
 	}
 	for u.geterr() == nil && err == nil {
 		partNum++
+		if u.skipPart(ctx, partNum) {
+			continue
+		}
+		u.growPartPool(partNum)
 		var (
 			data         io.Reader
 			nextChunkLen int
@@ -881,13 +1120,43 @@ func (u *multiUploader) upload(ctx context.Context, firstBuf io.Reader, firstBuf
 		}
 	}
 
+	if u.in.CheckpointStore != nil {
+		// a checkpoint left behind is discarded when it is resumed, as the
+		// service no longer knows its upload
+		u.in.CheckpointStore.Delete(ctx)
+	}
+
 	var out PutObjectOutput
 	out.mapFromCompleteMultipartUploadOutput(completeOut, aws.ToString(params.Bucket), aws.ToString(u.uploadID), u.parts)
 
+	if err := u.setChecksum(&out); err != nil {
+		u.progressEmitter.Failed(ctx, err)
+		return &out, err
+	}
+
 	u.progressEmitter.Complete(ctx, &out)
 	return &out, nil
 }
 
+// setChecksum sets the checksum of the object computed from its parts on the
+// output, and returns an error if it differs from the one S3 reported.
+func (u *multiUploader) setChecksum(out *PutObjectOutput) error {
+	algorithm := u.checksumAlgorithm()
+	checksum, err := partsChecksum(algorithm, u.checksumType, u.parts, u.partSizes)
+	if err != nil || checksum == "" {
+		return err
+	}
+
+	reported := out.checksum(algorithm)
+	out.Checksum = checksum
+	out.ChecksumType = u.checksumType
+	if reported != "" && reported != checksum {
+		return fmt.Errorf("%s checksum %s of the object does not match %s computed from its parts: %w",
+			algorithm, reported, checksum, ErrChecksumMismatch)
+	}
+	return nil
+}
+
 func (u *multiUploader) shouldContinue(part int32, nextChunkLen int, err error) (bool, error) {
 	if err != nil && err != io.EOF {
 		return false, fmt.Errorf("read multipart upload data failed, %w", err)
@@ -902,7 +1171,7 @@ func (u *multiUploader) shouldContinue(part int32, nextChunkLen int, err error)
 
 	// This upload exceeded maximum number of supported parts, error now.
 	if part > defaultMaxUploadParts {
-		return false, fmt.Errorf(fmt.Sprintf("exceeded total allowed S3 limit MaxUploadParts (%d). Adjust PartSize to fit in this limit", defaultMaxUploadParts))
+		return false, fmt.Errorf("exceeded total allowed S3 limit MaxUploadParts (%d). Adjust PartSize to fit in this limit", defaultMaxUploadParts)
 	}
 
 	return true, err
@@ -932,12 +1201,32 @@ func (u *multiUploader) readChunk(ctx context.Context, ch chan ulChunk, clientOp
 // send performs an UploadPart request and keeps track of the completed
 // part information.
 func (u *multiUploader) send(ctx context.Context, c ulChunk, clientOptions ...func(*s3.Options)) error {
-	params := u.in.mapUploadPartInput(c.buf, c.partNum, u.uploadID, u.options.ChecksumAlgorithm)
-	resp, err := u.options.S3.UploadPart(ctx, params, clientOptions...)
+	if completed, ok := u.resumed[aws.ToInt32(c.partNum)]; ok {
+		u.progressEmitter.BytesTransferred(ctx, c.buflen)
+		u.m.Lock()
+		u.parts = append(u.parts, completed)
+		u.partSizes[aws.ToInt32(c.partNum)] = c.buflen
+		u.m.Unlock()
+		return nil
+	}
+
+	params := u.in.mapUploadPartInput(u.options.BandwidthLimiter.reader(ctx, c.buf), c.partNum, u.uploadID, u.options.ChecksumAlgorithm)
+	if err := u.tuner.acquire(ctx); err != nil {
+		return err
+	}
+	if err := u.options.requestBudget.acquire(ctx); err != nil {
+		u.tuner.release(0, 0)
+		return err
+	}
+	start := time.Now()
+	resp, err := u.options.S3.UploadPart(ctx, params, u.tuner.clientOptions(clientOptions)...)
+	u.options.requestBudget.release()
 	if err != nil {
+		u.tuner.release(0, 0)
 		// progress failed() is NOT emitted here, it's emitted once at the end
-		return err
+		return sseCustomerKeyError(err, u.in.SSECustomerKey, u.in.SSECustomerKeyMD5, false)
 	}
+	u.tuner.release(c.buflen, time.Since(start))
 
 	u.progressEmitter.BytesTransferred(ctx, c.buflen)
 	var completed types.CompletedPart
@@ -945,8 +1234,123 @@ func (u *multiUploader) send(ctx context.Context, c ulChunk, clientOptions ...fu
 
 	u.m.Lock()
 	u.parts = append(u.parts, completed)
+	u.partSizes[aws.ToInt32(c.partNum)] = c.buflen
 	u.m.Unlock()
 
+	return u.saveCheckpoint(ctx)
+}
+
+// resume lists the parts of the checkpointed upload, so that the parts which
+// were uploaded already are skipped. Parts whose ETag differs from the one
+// recorded in the checkpoint are uploaded again. If the service no longer
+// knows the upload, a new one is started.
+func (u *multiUploader) resume(ctx context.Context, clientOptions ...func(*s3.Options)) error {
+	recorded := map[int32]string{}
+	for _, part := range u.checkpoint.CompletedParts {
+		recorded[aws.ToInt32(part.PartNumber)] = aws.ToString(part.ETag)
+	}
+
+	input := &s3.ListPartsInput{
+		Bucket:   aws.String(u.in.Bucket),
+		Key:      aws.String(u.in.Key),
+		UploadId: aws.String(u.checkpoint.UploadID),
+	}
+	if u.in.RequestPayer != "" {
+		input.RequestPayer = s3types.RequestPayer(u.in.RequestPayer)
+	}
+	input.ExpectedBucketOwner = nzstring(u.in.ExpectedBucketOwner)
+	input.SSECustomerAlgorithm = nzstring(u.in.SSECustomerAlgorithm)
+	input.SSECustomerKey = nzstring(u.in.SSECustomerKey)
+	input.SSECustomerKeyMD5 = nzstring(u.in.SSECustomerKeyMD5)
+
+	resumed := map[int32]types.CompletedPart{}
+	p := s3.NewListPartsPaginator(u.options.S3, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx, clientOptions...)
+		if err != nil {
+			var noSuchUpload *s3types.NoSuchUpload
+			if errors.As(err, &noSuchUpload) {
+				return nil
+			}
+			return fmt.Errorf("failed to list parts of upload %s: %w", u.checkpoint.UploadID, err)
+		}
+		for _, part := range page.Parts {
+			n := aws.ToInt32(part.PartNumber)
+			if etag, ok := recorded[n]; ok && etag != aws.ToString(part.ETag) {
+				continue
+			}
+			resumed[n] = types.CompletedPart{
+				ChecksumCRC32:     part.ChecksumCRC32,
+				ChecksumCRC32C:    part.ChecksumCRC32C,
+				ChecksumCRC64NVME: part.ChecksumCRC64NVME,
+				ChecksumSHA1:      part.ChecksumSHA1,
+				ChecksumSHA256:    part.ChecksumSHA256,
+				ETag:              part.ETag,
+				PartNumber:        aws.Int32(n),
+			}
+		}
+	}
+
+	u.uploadID = aws.String(u.checkpoint.UploadID)
+	u.resumed = resumed
+	return nil
+}
+
+// skipPart seeks past a part which was uploaded before the upload was
+// resumed, instead of reading it from the body again. It returns false if the
+// part must be read.
+func (u *multiUploader) skipPart(ctx context.Context, partNum int32) bool {
+	completed, ok := u.resumed[partNum]
+	seeker, isSeeker := u.in.Body.(io.Seeker)
+	if !ok || !isSeeker || u.objectSize < 0 {
+		return false
+	}
+
+	n := u.objectSize - u.partOffset(partNum)
+	if n <= 0 {
+		return false
+	}
+	if n > u.partSize(partNum) {
+		n = u.partSize(partNum)
+	}
+	if _, err := seeker.Seek(n, io.SeekCurrent); err != nil {
+		return false
+	}
+
+	u.progressEmitter.BytesTransferred(ctx, n)
+	u.m.Lock()
+	u.parts = append(u.parts, completed)
+	u.partSizes[partNum] = n
+	u.m.Unlock()
+	return true
+}
+
+// saveCheckpoint saves the upload ID and the parts uploaded so far to the
+// checkpoint store, if any.
+func (u *multiUploader) saveCheckpoint(ctx context.Context) error {
+	if u.in.CheckpointStore == nil {
+		return nil
+	}
+
+	u.checkpointMu.Lock()
+	defer u.checkpointMu.Unlock()
+
+	u.m.Lock()
+	parts := append(completedParts(nil), u.parts...)
+	u.m.Unlock()
+
+	err := u.in.CheckpointStore.Save(ctx, &UploadCheckpoint{
+		Bucket:            u.in.Bucket,
+		Key:               u.in.Key,
+		UploadID:          aws.ToString(u.uploadID),
+		PartSizeBytes:     u.options.PartSizeBytes,
+		AdaptivePartSize:  u.growPartSize,
+		ChecksumAlgorithm: u.checksumAlgorithm(),
+		CompletedParts:    parts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save upload checkpoint: %w", err)
+	}
 	return nil
 }
 
@@ -967,6 +1371,11 @@ func (u *multiUploader) seterr(e error) {
 }
 
 func (u *multiUploader) fail(ctx context.Context, clientOptions ...func(*s3.Options)) {
+	if u.in.CheckpointStore != nil {
+		// keep the upload so that it can be resumed
+		return
+	}
+
 	params := u.in.mapAbortMultipartUploadInput(u.uploadID)
 	_, err := u.options.S3.AbortMultipartUpload(ctx, params, clientOptions...)
 	if err != nil {
@@ -977,7 +1386,7 @@ func (u *multiUploader) fail(ctx context.Context, clientOptions ...func(*s3.Opti
 // complete successfully completes a multipart upload and returns the response.
 func (u *multiUploader) complete(ctx context.Context, clientOptions ...func(*s3.Options)) *s3.CompleteMultipartUploadOutput {
 	if u.geterr() != nil {
-		u.fail(ctx)
+		u.fail(ctx, clientOptions...)
 		return nil
 	}
 
@@ -985,16 +1394,44 @@ func (u *multiUploader) complete(ctx context.Context, clientOptions ...func(*s3.
 	sort.Sort(u.parts)
 
 	params := u.in.mapCompleteMultipartUploadInput(u.uploadID, u.parts)
+	if u.checksumType == types.ChecksumTypeFullObject {
+		// a full-object checksum is validated by the service when the upload
+		// is completed
+		checksum, err := partsChecksum(u.checksumAlgorithm(), u.checksumType, u.parts, u.partSizes)
+		if err != nil {
+			u.seterr(err)
+			u.fail(ctx, clientOptions...)
+			return nil
+		}
+		setCompleteChecksum(params, u.checksumAlgorithm(), checksum)
+	}
 
 	resp, err := u.options.S3.CompleteMultipartUpload(ctx, params, clientOptions...)
 	if err != nil {
 		u.seterr(err)
-		u.fail(ctx)
+		u.fail(ctx, clientOptions...)
 	}
 
 	return resp
 }
 
+// setCompleteChecksum sets the full-object checksum of the algorithm on the
+// input, if any.
+func setCompleteChecksum(input *s3.CompleteMultipartUploadInput, algorithm types.ChecksumAlgorithm, checksum string) {
+	if checksum == "" {
+		return
+	}
+	input.ChecksumType = s3types.ChecksumTypeFullObject
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32:
+		input.ChecksumCRC32 = aws.String(checksum)
+	case types.ChecksumAlgorithmCrc32c:
+		input.ChecksumCRC32C = aws.String(checksum)
+	case types.ChecksumAlgorithmCrc64nvme:
+		input.ChecksumCRC64NVME = aws.String(checksum)
+	}
+}
+
 func addFeatureUserAgent(stack *smithymiddleware.Stack) error {
 	ua, err := getOrAddRequestUserAgent(stack)
 	if err != nil {
@@ -0,0 +1,649 @@
+package transfermanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+// maxDeleteObjects is the maximum number of keys of a DeleteObjects request
+const maxDeleteObjects = 1000
+
+// SyncInput represents a request to the Sync() call
+type SyncInput struct {
+	// Bucket the objects are mirrored in
+	Bucket string
+
+	// The local directory mirrored with the bucket prefix. Its
+	// subdirectories are walked recursively.
+	LocalDirectory string
+
+	// Prefix of the mirrored objects. A "/" is inserted between the prefix
+	// and the relative path of each file if the prefix does not end with one.
+	KeyPrefix string
+
+	// Which side is mirrored to the other. Required.
+	Direction types.SyncDirection
+
+	// Glob patterns selecting the files and objects to sync, matched against
+	// their slash-separated path relative to LocalDirectory or KeyPrefix,
+	// with the syntax of UploadDirectoryInput.Include. If empty, everything
+	// is selected.
+	Include []string
+
+	// Glob patterns of files, objects and directories to skip. Exclusions
+	// take precedence over inclusions. Excluded files and objects are never
+	// deleted.
+	Exclude []string
+
+	// How symbolic links are handled. Defaults to types.SymlinkPolicySkip.
+	SymlinkPolicy types.SymlinkPolicy
+
+	// Compare the content of files and objects of the same size, instead of
+	// their modification times. The MD5 of each such file is computed and
+	// compared with the object's ETag, which for multipart objects is
+	// assumed to be made of Options.PartSizeBytes parts. Objects whose ETag
+	// is not an MD5, such as objects encrypted with SSE-C, are always
+	// transferred.
+	CompareChecksum bool
+
+	// Delete the objects, or files, of the destination side which do not
+	// exist on the mirrored side. Deletions are only made once every
+	// transfer succeeded. Objects are deleted with batched DeleteObjects
+	// requests.
+	Delete bool
+
+	// Only plan the actions, which are returned in the output, without
+	// transferring or deleting anything.
+	DryRun bool
+
+	// Invoked with the input of each object before it is uploaded.
+	UploadCallback func(*PutObjectInput)
+
+	// Invoked with the input of each object before it is downloaded.
+	DownloadCallback func(*DownloadObjectInput)
+
+	// How the failure of a single action is handled. Defaults to
+	// types.FailurePolicyAbort.
+	FailurePolicy types.FailurePolicy
+}
+
+// SyncAction describes what a Sync does to a file or object
+type SyncAction struct {
+	// What is done
+	Type types.SyncActionType
+
+	// Why it is done
+	Reason types.SyncReason
+
+	// The slash-separated path of the file or object relative to
+	// LocalDirectory or KeyPrefix
+	Name string
+
+	// Path of the file on the local filesystem, empty for the deletion of an
+	// object
+	Path string
+
+	// Key of the object, empty for the deletion of a file
+	Key string
+
+	// The size of the transferred or deleted file or object
+	Size int64
+}
+
+// SyncFailure describes an action which could not be performed
+type SyncFailure struct {
+	// The failed action
+	Action SyncAction
+
+	// The cause of the failure
+	Err error
+}
+
+// SyncOutput represents a response from the Sync() call
+type SyncOutput struct {
+	// The planned actions, sorted by name. With DryRun none of them was
+	// performed.
+	Actions []SyncAction
+
+	// The number of files uploaded
+	ObjectsUploaded int
+
+	// The number of objects downloaded
+	ObjectsDownloaded int
+
+	// The number of objects or files deleted
+	Deleted int
+
+	// The total size of the files and objects transferred
+	BytesTransferred int64
+
+	// The actions which failed, sorted by name. With
+	// types.FailurePolicyAbort it holds at most the failure which aborted the
+	// sync.
+	Failures []SyncFailure
+}
+
+// Sync mirrors a local directory and a bucket prefix incrementally, in the
+// given direction. Files and objects are compared by size and modification
+// time, or content with CompareChecksum, and only those which differ are
+// uploaded with PutObject or downloaded with DownloadObject.
+//
+// When uploading, a file is transferred if its object is missing, has a
+// different size, or was last modified before the file. When downloading, an
+// object is transferred if its file is missing, has a different size, or has
+// a modification time other than the object's LastModified time, which
+// downloaded files are set to. Downloads are written like DownloadDirectory
+// does, through temporary files renamed into place.
+//
+// Up to Options.Concurrency transfers run at once, and their requests share a
+// budget of Options.Concurrency in-flight requests.
+//
+// Additional functional options can be provided to configure the individual
+// sync. These options are copies of the original Options instance, the client of which Sync is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) Sync(ctx context.Context, input *SyncInput, opts ...func(*Options)) (*SyncOutput, error) {
+	i := syncer{in: input, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
+
+	return i.sync(ctx)
+}
+
+type syncer struct {
+	options Options
+	in      *SyncInput
+	filter  *pathFilter
+	cancel  context.CancelFunc
+
+	// prefix of the listed keys
+	listPrefix string
+
+	symlinks      types.SymlinkPolicy
+	failurePolicy types.FailurePolicy
+
+	progressEmitter *directoryProgressEmitter
+
+	m   sync.Mutex
+	out SyncOutput
+	err error
+}
+
+func (s *syncer) sync(ctx context.Context) (*SyncOutput, error) {
+	if err := s.init(); err != nil {
+		return nil, fmt.Errorf("unable to initialize sync: %w", err)
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	defer s.cancel()
+
+	local, err := s.listLocal(ctx)
+	if err != nil {
+		return s.output(), err
+	}
+	remote, err := s.listRemote(ctx)
+	if err != nil {
+		return s.output(), err
+	}
+	s.out.Actions = s.plan(local, remote)
+	if err := s.geterr(); err != nil {
+		return s.output(), err
+	}
+	if s.in.DryRun {
+		return s.output(), nil
+	}
+
+	s.progressEmitter.Start(ctx, s.in)
+	s.apply(ctx)
+	out := s.output()
+	if err := s.geterr(); err != nil {
+		s.progressEmitter.Failed(ctx, err)
+		return out, err
+	}
+	s.progressEmitter.Complete(ctx, out)
+	return out, nil
+}
+
+func (s *syncer) init() error {
+	if s.in.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if s.in.LocalDirectory == "" {
+		return fmt.Errorf("local directory is required")
+	}
+
+	switch s.in.Direction {
+	case types.SyncDirectionUpload:
+		info, err := os.Stat(s.in.LocalDirectory)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("local directory %s is not a directory", s.in.LocalDirectory)
+		}
+	case types.SyncDirectionDownload:
+		if err := os.MkdirAll(s.in.LocalDirectory, 0755); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid sync direction %q", s.in.Direction)
+	}
+
+	var err error
+	if s.filter, err = newPathFilter(s.in.Include, s.in.Exclude); err != nil {
+		return err
+	}
+	s.listPrefix = prefixedKey(s.in.KeyPrefix, "")
+	s.symlinks = s.in.SymlinkPolicy
+	if s.symlinks == "" {
+		s.symlinks = types.SymlinkPolicySkip
+	}
+	s.failurePolicy = s.in.FailurePolicy
+	if s.failurePolicy == "" {
+		s.failurePolicy = types.FailurePolicyAbort
+	}
+
+	resolveConcurrency(&s.options)
+	resolvePartSizeBytes(&s.options)
+	s.options.requestBudget = newRequestBudget(s.options.Concurrency)
+	s.progressEmitter = &directoryProgressEmitter{
+		Listeners: s.options.ProgressListeners,
+	}
+	return nil
+}
+
+// listLocal returns the selected files of the local directory by relative
+// path.
+func (s *syncer) listLocal(ctx context.Context) (map[string]localFile, error) {
+	files := map[string]localFile{}
+	err := walkLocalDirectory(ctx, s.in.LocalDirectory, s.symlinks, s.filter, func(f localFile, err error) error {
+		if err != nil {
+			s.fail(SyncAction{Type: s.transferType(), Name: f.rel, Path: f.path}, err)
+			return s.geterr()
+		}
+		files[f.rel] = f
+		return nil
+	})
+	return files, err
+}
+
+// listRemote returns the selected objects under the key prefix by relative
+// path.
+func (s *syncer) listRemote(ctx context.Context) (map[string]types.Object, error) {
+	clientOptions := []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+
+	objects := map[string]types.Object{}
+	p := s3.NewListObjectsV2Paginator(s.options.S3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.in.Bucket),
+		Prefix: nzstring(s.listPrefix),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx, clientOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, o := range page.Contents {
+			var obj types.Object
+			obj.MapFrom(o)
+			rel := strings.TrimPrefix(obj.Key, s.listPrefix)
+			if rel == "" || strings.HasSuffix(rel, "/") || !s.filter.matchPath(rel) {
+				continue
+			}
+			objects[rel] = obj
+		}
+	}
+	return objects, nil
+}
+
+func (s *syncer) transferType() types.SyncActionType {
+	if s.in.Direction == types.SyncDirectionUpload {
+		return types.SyncActionUpload
+	}
+	return types.SyncActionDownload
+}
+
+// plan compares the local files with the objects and returns the actions
+// mirroring them, sorted by name.
+func (s *syncer) plan(local map[string]localFile, remote map[string]types.Object) []SyncAction {
+	var actions []SyncAction
+	if s.in.Direction == types.SyncDirectionUpload {
+		for rel, f := range local {
+			action := SyncAction{Type: types.SyncActionUpload, Name: rel, Path: f.path, Key: s.listPrefix + rel, Size: f.size}
+			obj, ok := remote[rel]
+			reason, err := s.compare(f, obj, ok)
+			if err != nil {
+				s.fail(action, err)
+				continue
+			}
+			if reason != "" {
+				action.Reason = reason
+				actions = append(actions, action)
+			}
+		}
+		if s.in.Delete {
+			for rel, obj := range remote {
+				if _, ok := local[rel]; !ok {
+					actions = append(actions, SyncAction{Type: types.SyncActionDelete, Reason: types.SyncReasonExtraneous, Name: rel, Key: obj.Key, Size: obj.Size})
+				}
+			}
+		}
+	} else {
+		for rel, obj := range remote {
+			action := SyncAction{Type: types.SyncActionDownload, Name: rel, Key: obj.Key, Size: obj.Size}
+			path, err := objectLocalPath(s.in.LocalDirectory, s.listPrefix, obj.Key)
+			if err != nil {
+				s.fail(action, err)
+				continue
+			}
+			action.Path = path
+			f, ok := local[rel]
+			reason, err := s.compare(f, obj, ok)
+			if err != nil {
+				s.fail(action, err)
+				continue
+			}
+			if reason != "" {
+				action.Reason = reason
+				actions = append(actions, action)
+			}
+		}
+		if s.in.Delete {
+			for rel, f := range local {
+				if _, ok := remote[rel]; !ok {
+					actions = append(actions, SyncAction{Type: types.SyncActionDelete, Reason: types.SyncReasonExtraneous, Name: rel, Path: f.path, Size: f.size})
+				}
+			}
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Name < actions[j].Name
+	})
+	return actions
+}
+
+// compare returns why a file and an object differ, or an empty reason if they
+// match. exists is false if the destination side is missing.
+func (s *syncer) compare(f localFile, obj types.Object, exists bool) (types.SyncReason, error) {
+	if !exists {
+		return types.SyncReasonMissing, nil
+	}
+	if f.size != obj.Size {
+		return types.SyncReasonSizeChanged, nil
+	}
+	if s.in.CompareChecksum {
+		match, err := fileMatchesETag(f.path, obj.ETag, s.options.PartSizeBytes)
+		if err != nil {
+			return "", err
+		}
+		if !match {
+			return types.SyncReasonChecksumChanged, nil
+		}
+		return "", nil
+	}
+
+	if s.in.Direction == types.SyncDirectionUpload {
+		if f.modTime.After(obj.LastModified) {
+			return types.SyncReasonModified, nil
+		}
+		return "", nil
+	}
+	// listings only carry seconds
+	if !f.modTime.Truncate(time.Second).Equal(obj.LastModified.Truncate(time.Second)) {
+		return types.SyncReasonModified, nil
+	}
+	return "", nil
+}
+
+// apply performs the planned actions: the transfers first, then the
+// deletions if every transfer succeeded.
+func (s *syncer) apply(ctx context.Context) {
+	var transfers, deletes []SyncAction
+	for _, a := range s.out.Actions {
+		if a.Type == types.SyncActionDelete {
+			deletes = append(deletes, a)
+			continue
+		}
+		transfers = append(transfers, a)
+		s.progressEmitter.Discovered(a.Size)
+	}
+	s.progressEmitter.DiscoveryComplete()
+
+	ch := make(chan SyncAction)
+	var wg sync.WaitGroup
+	for i := 0; i < s.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range ch {
+				s.transfer(ctx, a)
+			}
+		}()
+	}
+	for _, a := range transfers {
+		if s.geterr() != nil {
+			break
+		}
+		ch <- a
+	}
+	close(ch)
+	wg.Wait()
+
+	if len(deletes) == 0 || s.failed() {
+		return
+	}
+	if s.in.Direction == types.SyncDirectionUpload {
+		s.deleteObjects(ctx, deletes)
+	} else {
+		s.deleteFiles(deletes)
+	}
+}
+
+func (s *syncer) transfer(ctx context.Context, a SyncAction) {
+	if s.geterr() != nil {
+		return
+	}
+
+	options := s.options.Copy()
+	options.ProgressListeners = s.progressEmitter.ObjectListeners(options.ProgressListeners)
+
+	var err error
+	n := a.Size
+	if a.Type == types.SyncActionUpload {
+		err = uploadFile(ctx, options, s.in.Bucket, a.Key, localFile{path: a.Path, rel: a.Name, size: a.Size}, s.in.UploadCallback)
+	} else {
+		obj := types.Object{Key: a.Key, Size: a.Size}
+		n, err = downloadFile(ctx, options, s.in.Bucket, obj, a.Path, s.in.DownloadCallback)
+	}
+	if err != nil {
+		s.fail(a, err)
+		s.progressEmitter.ObjectFailed(ctx)
+		return
+	}
+
+	s.m.Lock()
+	if a.Type == types.SyncActionUpload {
+		s.out.ObjectsUploaded++
+	} else {
+		s.out.ObjectsDownloaded++
+	}
+	s.out.BytesTransferred += n
+	s.m.Unlock()
+
+	s.progressEmitter.ObjectTransferred(ctx)
+}
+
+// deleteObjects deletes the objects of the actions with batched DeleteObjects
+// requests.
+func (s *syncer) deleteObjects(ctx context.Context, actions []SyncAction) {
+	clientOptions := []func(*s3.Options){
+		func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions,
+				middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+				addFeatureUserAgent,
+			)
+		}}
+
+	for len(actions) > 0 && s.geterr() == nil {
+		batch := actions[:min(len(actions), maxDeleteObjects)]
+		actions = actions[len(batch):]
+
+		byKey := make(map[string]SyncAction, len(batch))
+		objects := make([]s3types.ObjectIdentifier, 0, len(batch))
+		for _, a := range batch {
+			byKey[a.Key] = a
+			objects = append(objects, s3types.ObjectIdentifier{Key: aws.String(a.Key)})
+		}
+
+		out, err := s.options.S3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.in.Bucket),
+			Delete: &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		}, clientOptions...)
+		if err != nil {
+			for _, a := range batch {
+				s.fail(a, err)
+			}
+			continue
+		}
+
+		for _, e := range out.Errors {
+			a, ok := byKey[aws.ToString(e.Key)]
+			if !ok {
+				continue
+			}
+			s.fail(a, fmt.Errorf("%s: %s", aws.ToString(e.Code), aws.ToString(e.Message)))
+		}
+		s.m.Lock()
+		s.out.Deleted += len(batch) - len(out.Errors)
+		s.m.Unlock()
+	}
+}
+
+func (s *syncer) deleteFiles(actions []SyncAction) {
+	for _, a := range actions {
+		if s.geterr() != nil {
+			return
+		}
+		if err := os.Remove(a.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.fail(a, err)
+			continue
+		}
+		s.m.Lock()
+		s.out.Deleted++
+		s.m.Unlock()
+	}
+}
+
+// fail records the failure of an action according to the failure policy
+func (s *syncer) fail(a SyncAction, err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.err != nil {
+		// failures caused by the abort are not recorded
+		return
+	}
+	s.out.Failures = append(s.out.Failures, SyncFailure{Action: a, Err: err})
+	if s.failurePolicy == types.FailurePolicyAbort {
+		s.err = fmt.Errorf("failed to %s %s: %w", strings.ToLower(string(a.Type)), a.Name, err)
+		s.cancel()
+	}
+}
+
+// failed returns whether any action failed
+func (s *syncer) failed() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return len(s.out.Failures) > 0
+}
+
+// geterr is a thread-safe getter for the error which aborted the sync
+func (s *syncer) geterr() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.err
+}
+
+// output returns the output with its failures sorted
+func (s *syncer) output() *SyncOutput {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	sort.Slice(s.out.Failures, func(i, j int) bool {
+		return s.out.Failures[i].Action.Name < s.out.Failures[j].Action.Name
+	})
+	return &s.out
+}
+
+// fileMatchesETag returns whether the content of the file at path matches an
+// ETag. The ETag of an object uploaded in a single request is the MD5 of its
+// content, and the ETag of a multipart object is the MD5 of its concatenated
+// part MD5s followed by its part count, assumed to be of partSize parts.
+func fileMatchesETag(path, etag string, partSize int64) (bool, error) {
+	etag = strings.Trim(etag, `"`)
+	parts := 0
+	if i := strings.LastIndexByte(etag, '-'); i >= 0 {
+		n, err := strconv.Atoi(etag[i+1:])
+		if err != nil {
+			return false, nil
+		}
+		parts, etag = n, etag[:i]
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	if parts == 0 {
+		h := md5.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return false, err
+		}
+		return hex.EncodeToString(h.Sum(nil)) == etag, nil
+	}
+
+	sums := md5.New()
+	count := 0
+	for {
+		h := md5.New()
+		n, err := io.CopyN(h, f, partSize)
+		if n > 0 {
+			sums.Write(h.Sum(nil))
+			count++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return count == parts && hex.EncodeToString(sums.Sum(nil)) == etag, nil
+}
@@ -0,0 +1,269 @@
+package transfermanager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+)
+
+// archiveBufferSize is the size of the buffer between the archive writer and
+// the upload, so that small header writes are not sent through the upload
+// pipe one by one
+const archiveBufferSize = 64 * 1024
+
+// UploadArchiveInput represents a request to the UploadArchive() call
+type UploadArchiveInput struct {
+	// Bucket the archive is uploaded into
+	Bucket string
+
+	// Key of the archive object
+	Key string
+
+	// The local directory whose files are archived. Its subdirectories are
+	// walked recursively.
+	Source string
+
+	// The format of the archive. Defaults to the format matching the
+	// extension of Key: ".tar", ".tar.gz", ".tgz" or ".zip".
+	Format types.ArchiveFormat
+
+	// Glob patterns selecting the files to archive, matched against the
+	// slash-separated path of each file relative to Source, as with
+	// UploadDirectoryInput.Include. If empty, every file is selected.
+	Include []string
+
+	// Glob patterns of files and directories to skip, using the same syntax
+	// as Include. Exclusions take precedence over inclusions.
+	Exclude []string
+
+	// How symbolic links are handled. Defaults to types.SymlinkPolicySkip.
+	SymlinkPolicy types.SymlinkPolicy
+
+	// Maps the slash-separated path of a file relative to Source to the name
+	// of its member in the archive. If the function returns an empty name
+	// the file is skipped. Defaults to the relative path itself.
+	NameFunc func(path string) string
+
+	// Invoked with the input of the archive object before it is uploaded,
+	// e.g. to set its Metadata. The Body must be left nil.
+	Callback func(*PutObjectInput)
+}
+
+// UploadArchiveOutput represents a response from the UploadArchive() call
+type UploadArchiveOutput struct {
+	// The number of files archived
+	MembersArchived int
+
+	// The total size of the files archived, before compression
+	BytesArchived int64
+
+	// The output of the upload of the archive object
+	Object *PutObjectOutput
+}
+
+// UploadArchive packs the files of a local directory tree into a single tar,
+// gzip compressed tar, or zip object. The archive is streamed into the
+// upload as it is written, without a temporary file, so that it is sent as
+// a multipart upload once it outgrows Options.PartSizeBytes.
+//
+// Files are archived one at a time, in lexical order. The upload is aborted
+// if any file cannot be read, as the archive would be incomplete.
+//
+// Additional functional options can be provided to configure the individual
+// upload. These options are copies of the original Options instance, the client of which UploadArchive is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) UploadArchive(ctx context.Context, input *UploadArchiveInput, opts ...func(*Options)) (*UploadArchiveOutput, error) {
+	if input.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if input.Key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	format, err := archiveFormat(input.Format, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(input.Source)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("source %s is not a directory", input.Source)
+	}
+	filter, err := newPathFilter(input.Include, input.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	symlinks := input.SymlinkPolicy
+	if symlinks == "" {
+		symlinks = types.SymlinkPolicySkip
+	}
+
+	in := &PutObjectInput{
+		Bucket:      input.Bucket,
+		Key:         input.Key,
+		ContentType: archiveContentType(format),
+	}
+	if input.Callback != nil {
+		input.Callback(in)
+	}
+	w, err := c.NewObjectWriter(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewWriterSize(w, archiveBufferSize)
+	a := newArchiveWriter(format, buf)
+	out := &UploadArchiveOutput{}
+	err = walkLocalDirectory(ctx, input.Source, symlinks, filter, func(f localFile, err error) error {
+		if err != nil {
+			return err
+		}
+		name := f.rel
+		if input.NameFunc != nil {
+			name = input.NameFunc(f.rel)
+		}
+		if name == "" {
+			return nil
+		}
+		n, err := a.add(name, f.path)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", f.path, err)
+		}
+		out.MembersArchived++
+		out.BytesArchived += n
+		return nil
+	})
+	if err == nil {
+		err = a.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		w.Abort()
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	out.Object = w.Output()
+	return out, nil
+}
+
+// archiveFormat returns the format of an archive, inferred from the
+// extension of its key if not set
+func archiveFormat(format types.ArchiveFormat, key string) (types.ArchiveFormat, error) {
+	switch format {
+	case types.ArchiveFormatTar, types.ArchiveFormatTarGzip, types.ArchiveFormatZip:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown archive format %s", format)
+	}
+
+	switch k := strings.ToLower(key); {
+	case strings.HasSuffix(k, ".tar"):
+		return types.ArchiveFormatTar, nil
+	case strings.HasSuffix(k, ".tar.gz"), strings.HasSuffix(k, ".tgz"):
+		return types.ArchiveFormatTarGzip, nil
+	case strings.HasSuffix(k, ".zip"):
+		return types.ArchiveFormatZip, nil
+	}
+	return "", fmt.Errorf("unable to infer the archive format of %s, format is required", key)
+}
+
+func archiveContentType(format types.ArchiveFormat) string {
+	switch format {
+	case types.ArchiveFormatTarGzip:
+		return "application/gzip"
+	case types.ArchiveFormatZip:
+		return "application/zip"
+	}
+	return "application/x-tar"
+}
+
+// archiveWriter writes the members of an archive of any format
+type archiveWriter struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+	zw *zip.Writer
+}
+
+func newArchiveWriter(format types.ArchiveFormat, w io.Writer) *archiveWriter {
+	switch format {
+	case types.ArchiveFormatZip:
+		return &archiveWriter{zw: zip.NewWriter(w)}
+	case types.ArchiveFormatTarGzip:
+		gz := gzip.NewWriter(w)
+		return &archiveWriter{tw: tar.NewWriter(gz), gz: gz}
+	}
+	return &archiveWriter{tw: tar.NewWriter(w)}
+}
+
+// add writes the local file at path as the member name, returning its size
+func (a *archiveWriter) add(name, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var dst io.Writer
+	if a.zw != nil {
+		hdr, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return 0, err
+		}
+		hdr.Name = name
+		hdr.Method = zip.Deflate
+		if dst, err = a.zw.CreateHeader(hdr); err != nil {
+			return 0, err
+		}
+	} else {
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return 0, err
+		}
+		hdr.Name = name
+		if err := a.tw.WriteHeader(hdr); err != nil {
+			return 0, err
+		}
+		dst = a.tw
+	}
+
+	// the size of a tar member is in its header, the file is copied up to
+	// that size and must not shrink in the meantime
+	n, err := io.CopyN(dst, f, info.Size())
+	if err == io.EOF {
+		err = fmt.Errorf("file shrank to %d bytes while archived", n)
+	}
+	return n, err
+}
+
+// Close writes the end of the archive
+func (a *archiveWriter) Close() error {
+	if a.zw != nil {
+		return a.zw.Close()
+	}
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	if a.gz != nil {
+		return a.gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package transfermanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+)
+
+// UploadDirectoryInput represents a request to the UploadDirectory() call
+type UploadDirectoryInput struct {
+	// Bucket the objects are uploaded into
+	Bucket string
+
+	// The local directory whose files are uploaded. Its subdirectories are
+	// walked recursively.
+	Source string
+
+	// Prefix prepended to the key of every object. A "/" is inserted between
+	// the prefix and the mapped key if the prefix does not end with one.
+	KeyPrefix string
+
+	// Glob patterns selecting the files to upload, matched against the
+	// slash-separated path of each file relative to Source. A pattern without
+	// a slash matches the base name of a file, and a "**" segment matches
+	// any number of directories. If empty, every file is selected.
+	Include []string
+
+	// Glob patterns of files and directories to skip, using the same syntax
+	// as Include. Exclusions take precedence over inclusions.
+	Exclude []string
+
+	// How symbolic links are handled. Defaults to types.SymlinkPolicySkip.
+	SymlinkPolicy types.SymlinkPolicy
+
+	// Maps the slash-separated path of a file relative to Source to its key,
+	// before KeyPrefix is applied. If the function returns an empty key the
+	// file is skipped. Defaults to the relative path itself.
+	KeyFunc func(path string) string
+
+	// Invoked with the input of each object before it is uploaded, e.g. to
+	// set its ContentType or Metadata.
+	Callback func(*PutObjectInput)
+
+	// How the failure of a single file is handled. Defaults to
+	// types.FailurePolicyAbort.
+	FailurePolicy types.FailurePolicy
+}
+
+// UploadDirectoryFailure describes a file which could not be uploaded
+type UploadDirectoryFailure struct {
+	// Path of the file on the local filesystem
+	Path string
+
+	// The key the file was uploaded to, empty if the file could not be read
+	Key string
+
+	// The cause of the failure
+	Err error
+}
+
+// UploadDirectoryOutput represents a response from the UploadDirectory() call
+type UploadDirectoryOutput struct {
+	// The number of files uploaded
+	ObjectsUploaded int
+
+	// The number of files which failed to upload
+	ObjectsFailed int
+
+	// The total size of the files uploaded
+	BytesUploaded int64
+
+	// The files which failed to upload, sorted by path. With
+	// types.FailurePolicyAbort it holds at most the failure which aborted the
+	// upload.
+	Failures []UploadDirectoryFailure
+}
+
+// UploadDirectory uploads the files of a local directory tree to S3, each
+// with PutObject, so that large files are sent as multipart uploads.
+//
+// Up to Options.Concurrency files are uploaded at once, and their PutObject
+// and UploadPart requests share a budget of Options.Concurrency in-flight
+// requests, so that the directory upload as a whole never sends more
+// requests at once than a single PutObject call would.
+//
+// With types.FailurePolicyAbort the remaining uploads are canceled when a
+// file fails, and its error is returned along with the partial output. With
+// types.FailurePolicyContinue failures are only listed in the output.
+//
+// Additional functional options can be provided to configure the individual
+// upload. These options are copies of the original Options instance, the client of which UploadDirectory is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) UploadDirectory(ctx context.Context, input *UploadDirectoryInput, opts ...func(*Options)) (*UploadDirectoryOutput, error) {
+	i := directoryUploader{in: input, options: c.options.Copy()}
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+	resolveOperationBandwidthLimiter(&i.options, &c.options)
+
+	return i.upload(ctx)
+}
+
+type directoryUploader struct {
+	options Options
+	in      *UploadDirectoryInput
+	filter  *pathFilter
+	cancel  context.CancelFunc
+
+	symlinks      types.SymlinkPolicy
+	failurePolicy types.FailurePolicy
+
+	progressEmitter *directoryProgressEmitter
+
+	m   sync.Mutex
+	out UploadDirectoryOutput
+	err error
+}
+
+// fileUpload is a file queued for upload along with its key
+type fileUpload struct {
+	localFile
+	key string
+}
+
+func (u *directoryUploader) upload(ctx context.Context) (*UploadDirectoryOutput, error) {
+	if err := u.init(); err != nil {
+		return nil, fmt.Errorf("unable to initialize directory upload: %w", err)
+	}
+
+	ctx, u.cancel = context.WithCancel(ctx)
+	defer u.cancel()
+
+	u.progressEmitter.Start(ctx, u.in)
+	out, err := u.uploadFiles(ctx)
+	if err != nil {
+		u.progressEmitter.Failed(ctx, err)
+		return out, err
+	}
+	u.progressEmitter.Complete(ctx, out)
+	return out, nil
+}
+
+func (u *directoryUploader) uploadFiles(ctx context.Context) (*UploadDirectoryOutput, error) {
+	files := make(chan fileUpload)
+	var wg sync.WaitGroup
+	for i := 0; i < u.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range files {
+				u.uploadFile(ctx, f)
+			}
+		}()
+	}
+
+	walkErr := walkLocalDirectory(ctx, u.in.Source, u.symlinks, u.filter, func(f localFile, err error) error {
+		if err != nil {
+			u.progressEmitter.Discovered(0)
+			u.fail(ctx, f.path, "", err)
+			return u.geterr()
+		}
+		key := u.objectKey(f.rel)
+		if key == "" {
+			return nil
+		}
+		u.progressEmitter.Discovered(f.size)
+		select {
+		case files <- fileUpload{localFile: f, key: key}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(files)
+	if walkErr == nil {
+		u.progressEmitter.DiscoveryComplete()
+	}
+	wg.Wait()
+
+	sort.Slice(u.out.Failures, func(i, j int) bool {
+		return u.out.Failures[i].Path < u.out.Failures[j].Path
+	})
+	if err := u.geterr(); err != nil {
+		return &u.out, err
+	}
+	if walkErr != nil {
+		return &u.out, walkErr
+	}
+	return &u.out, nil
+}
+
+func (u *directoryUploader) init() error {
+	if u.in.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	info, err := os.Stat(u.in.Source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("source %s is not a directory", u.in.Source)
+	}
+
+	if u.filter, err = newPathFilter(u.in.Include, u.in.Exclude); err != nil {
+		return err
+	}
+	u.symlinks = u.in.SymlinkPolicy
+	if u.symlinks == "" {
+		u.symlinks = types.SymlinkPolicySkip
+	}
+	u.failurePolicy = u.in.FailurePolicy
+	if u.failurePolicy == "" {
+		u.failurePolicy = types.FailurePolicyAbort
+	}
+
+	resolveConcurrency(&u.options)
+	u.options.requestBudget = newRequestBudget(u.options.Concurrency)
+	u.progressEmitter = &directoryProgressEmitter{
+		Listeners: u.options.ProgressListeners,
+	}
+	return nil
+}
+
+// objectKey returns the key of the file at the relative path, or an empty
+// string if the file is skipped.
+func (u *directoryUploader) objectKey(rel string) string {
+	key := rel
+	if u.in.KeyFunc != nil {
+		key = u.in.KeyFunc(rel)
+	}
+	if key == "" {
+		return ""
+	}
+	return prefixedKey(u.in.KeyPrefix, key)
+}
+
+// prefixedKey prepends prefix to key, inserting a "/" between them if the
+// prefix does not end with one.
+func prefixedKey(prefix, key string) string {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		return prefix + "/" + key
+	}
+	return prefix + key
+}
+
+func (u *directoryUploader) uploadFile(ctx context.Context, f fileUpload) {
+	if u.geterr() != nil {
+		return
+	}
+
+	options := u.options.Copy()
+	options.ProgressListeners = u.progressEmitter.ObjectListeners(options.ProgressListeners)
+	if err := uploadFile(ctx, options, u.in.Bucket, f.key, f.localFile, u.in.Callback); err != nil {
+		u.fail(ctx, f.path, f.key, err)
+		return
+	}
+
+	u.m.Lock()
+	u.out.ObjectsUploaded++
+	u.out.BytesUploaded += f.size
+	u.m.Unlock()
+
+	u.progressEmitter.ObjectTransferred(ctx)
+}
+
+// uploadFile uploads the local file f to key with PutObject.
+func uploadFile(ctx context.Context, options Options, bucket, key string, f localFile, callback func(*PutObjectInput)) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	input := &PutObjectInput{
+		Bucket:        bucket,
+		Key:           key,
+		Body:          file,
+		ContentLength: f.size,
+	}
+	if callback != nil {
+		callback(input)
+	}
+
+	ul := uploader{in: input, options: options}
+	_, err = ul.upload(ctx)
+	return err
+}
+
+// fail records the failure of a file according to the failure policy
+func (u *directoryUploader) fail(ctx context.Context, path, key string, err error) {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if u.err != nil {
+		// failures caused by the abort are not recorded
+		return
+	}
+	u.progressEmitter.ObjectFailed(ctx)
+	u.out.ObjectsFailed++
+	u.out.Failures = append(u.out.Failures, UploadDirectoryFailure{Path: path, Key: key, Err: err})
+	if u.failurePolicy == types.FailurePolicyAbort {
+		u.err = fmt.Errorf("failed to upload %s: %w", path, err)
+		u.cancel()
+	}
+}
+
+// geterr is a thread-safe getter for the error which aborted the upload
+func (u *directoryUploader) geterr() error {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	return u.err
+}
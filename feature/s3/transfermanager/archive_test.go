@@ -0,0 +1,334 @@
+package transfermanager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// writeArchiveSource writes the files of an archive source directory
+func writeArchiveSource(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+	return dir
+}
+
+// readArchive returns the members of an archive by name
+func readArchive(t *testing.T, format types.ArchiveFormat, data []byte) map[string][]byte {
+	t.Helper()
+	members := map[string][]byte{}
+	if format == types.ArchiveFormatZip {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		for _, f := range zr.File {
+			r, err := f.Open()
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if members[f.Name], err = io.ReadAll(r); err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			r.Close()
+		}
+		return members
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if format == types.ArchiveFormatTarGzip {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return members
+		}
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if members[hdr.Name], err = io.ReadAll(tr); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+}
+
+func TestUploadArchive(t *testing.T) {
+	files := map[string][]byte{
+		"a.txt":          []byte("hello"),
+		"sub/b.bin":      checksumTestData(100_000),
+		"sub/deep/c.txt": []byte(strings.Repeat("compressible ", 1000)),
+		"skip.log":       []byte("excluded"),
+	}
+	source := writeArchiveSource(t, files)
+
+	cases := map[string]struct {
+		key         string
+		format      types.ArchiveFormat
+		contentType string
+	}{
+		"tar":             {key: "backup.tar", format: types.ArchiveFormatTar, contentType: "application/x-tar"},
+		"tar gzip":        {key: "backup.tar.gz", format: types.ArchiveFormatTarGzip, contentType: "application/gzip"},
+		"tgz":             {key: "backup.tgz", format: types.ArchiveFormatTarGzip, contentType: "application/gzip"},
+		"zip":             {key: "backup.zip", format: types.ArchiveFormatZip, contentType: "application/zip"},
+		"explicit format": {key: "backup", format: types.ArchiveFormatZip, contentType: "application/zip"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s3Client, _, _ := s3testing.NewUploadLoggingClient(nil)
+			var body []byte
+			var contentType string
+			s3Client.PutObjectFn = func(_ *s3testing.TransferManagerLoggingClient, params *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+				var err error
+				body, err = io.ReadAll(params.Body)
+				contentType = aws.ToString(params.ContentType)
+				return &s3.PutObjectOutput{ETag: aws.String("etag")}, err
+			}
+
+			input := &UploadArchiveInput{
+				Bucket:  "bucket",
+				Key:     c.key,
+				Source:  source,
+				Exclude: []string{"*.log"},
+			}
+			if c.key == "backup" {
+				input.Format = c.format
+			}
+			out, err := New(s3Client, Options{}).UploadArchive(context.Background(), input)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if e, a := 3, out.MembersArchived; e != a {
+				t.Errorf("expect %d members, got %d", e, a)
+			}
+			if e, a := int64(5+100_000+13_000), out.BytesArchived; e != a {
+				t.Errorf("expect %d bytes, got %d", e, a)
+			}
+			if e, a := "etag", out.Object.ETag; e != a {
+				t.Errorf("expect ETag %s, got %s", e, a)
+			}
+			if e, a := c.contentType, contentType; e != a {
+				t.Errorf("expect content type %s, got %s", e, a)
+			}
+
+			members := readArchive(t, c.format, body)
+			if e, a := 3, len(members); e != a {
+				t.Errorf("expect %d members, got %d", e, a)
+			}
+			for name, data := range files {
+				if name == "skip.log" {
+					continue
+				}
+				if !bytes.Equal(data, members[name]) {
+					t.Errorf("expect member %s to match the file", name)
+				}
+			}
+		})
+	}
+}
+
+func TestUploadArchiveMultipart(t *testing.T) {
+	data := checksumTestData(minPartSizeBytes + 1000)
+	source := writeArchiveSource(t, map[string][]byte{"large.bin": data})
+	parts := map[int32][]byte{}
+	c := newObjectWriterClient(parts)
+
+	_, err := New(c, Options{}).UploadArchive(context.Background(), &UploadArchiveInput{
+		Bucket: "bucket",
+		Key:    "large.tar",
+		Source: source,
+		NameFunc: func(path string) string {
+			return "renamed/" + path
+		},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 2, len(parts); e != a {
+		t.Fatalf("expect %d parts, got %d", e, a)
+	}
+	members := readArchive(t, types.ArchiveFormatTar, append(parts[1], parts[2]...))
+	if !bytes.Equal(data, members["renamed/large.bin"]) {
+		t.Error("expect the member to match the file")
+	}
+}
+
+func TestUploadArchiveErrors(t *testing.T) {
+	source := writeArchiveSource(t, map[string][]byte{"a.txt": []byte("hello")})
+	file := filepath.Join(source, "a.txt")
+
+	cases := map[string]*UploadArchiveInput{
+		"no format":      {Bucket: "bucket", Key: "backup", Source: source},
+		"unknown format": {Bucket: "bucket", Key: "backup.zip", Source: source, Format: "RAR"},
+		"not directory":  {Bucket: "bucket", Key: "backup.zip", Source: file},
+		"no bucket":      {Key: "backup.zip", Source: source},
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			s3Client, invocations, _ := s3testing.NewUploadLoggingClient(nil)
+			if _, err := New(s3Client, Options{}).UploadArchive(context.Background(), input); err == nil {
+				t.Error("expect error")
+			}
+			if e, a := 0, len(*invocations); e != a {
+				t.Errorf("expect %d requests, got %d", e, a)
+			}
+		})
+	}
+}
+
+// newZipObject returns a zip archive of the files, in the order of names
+func newZipObject(t *testing.T, names []string, files map[string][]byte, modTime time.Time) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+		if strings.HasSuffix(name, "/") {
+			hdr.SetMode(fs.ModeDir | 0755)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestZipArchive(t *testing.T) {
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	files := map[string][]byte{
+		"docs/":          nil,
+		"docs/a.txt":     []byte("hello"),
+		"data/large.bin": checksumTestData(500_000),
+	}
+	names := []string{"docs/", "docs/a.txt", "data/large.bin"}
+	data := newZipObject(t, names, files, modTime)
+
+	c := newOpenObjectClient(data, "etag", "etag")
+	a, err := New(c, Options{}).OpenZipArchive(context.Background(), "bucket", "archive.zip", func(o *Options) {
+		o.ReadAtBlockSizeBytes = 4096
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer a.Close()
+
+	// only the end of the archive is read for its central directory
+	for _, r := range c.RetrievedRanges {
+		var start int64
+		if _, err := fmt.Sscanf(r, "bytes=%d-", &start); err != nil || start < int64(len(data))-2*4096 {
+			t.Errorf("expect only the end of the archive to be read, got %s", r)
+		}
+	}
+
+	members := a.Members()
+	if e, a := len(names), len(members); e != a {
+		t.Fatalf("expect %d members, got %d", e, a)
+	}
+	for i, m := range members {
+		if e, a := names[i], m.Name; e != a {
+			t.Errorf("expect member %s, got %s", e, a)
+		}
+		if e, a := int64(len(files[m.Name])), m.Size; e != a {
+			t.Errorf("%s: expect size %d, got %d", m.Name, e, a)
+		}
+		if !m.ModTime.Equal(modTime) {
+			t.Errorf("%s: expect modification time %v, got %v", m.Name, modTime, m.ModTime)
+		}
+	}
+	if !members[0].Mode.IsDir() {
+		t.Errorf("expect docs/ to be a directory")
+	}
+
+	r, err := a.Open("docs/a.txt")
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	b, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := "hello", string(b); e != a {
+		t.Errorf("expect content %s, got %s", e, a)
+	}
+
+	path := filepath.Join(t.TempDir(), "out", "large.bin")
+	n, err := a.Extract("data/large.bin", path)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := int64(500_000), n; e != a {
+		t.Errorf("expect %d bytes, got %d", e, a)
+	}
+	extracted, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if !bytes.Equal(files["data/large.bin"], extracted) {
+		t.Error("expect the extracted file to match the member")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("expect modification time %v, got %v", modTime, info.ModTime())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if e, a := 1, len(entries); e != a {
+		t.Errorf("expect no temporary file left, got %d entries", a)
+	}
+
+	if _, err := a.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expect fs.ErrNotExist, got %v", err)
+	}
+	if _, err := a.Open("docs/"); err == nil {
+		t.Error("expect error opening a directory")
+	}
+}
+
+func TestZipArchiveCorrupt(t *testing.T) {
+	data := newZipObject(t, []string{"a.txt"}, map[string][]byte{"a.txt": []byte("hello")}, time.Time{})
+	c := newOpenObjectClient(data[:len(data)/2], "etag", "etag")
+	if _, err := New(c, Options{}).OpenZipArchive(context.Background(), "bucket", "archive.zip"); err == nil {
+		t.Error("expect error for a truncated archive")
+	}
+}
@@ -0,0 +1,161 @@
+package transfermanager
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// BandwidthLimiter is a token bucket capping the rate at which object bodies
+// are uploaded or downloaded. It is shared by all the part goroutines of the
+// operations using it, and its rate can be changed while transfers are
+// running.
+//
+// It is safe to use a BandwidthLimiter concurrently across goroutines.
+type BandwidthLimiter struct {
+	// the limiter of the client, for an operation with a limit of its own
+	parent *BandwidthLimiter
+
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+
+	// closed when the rate changes, to wake up the waiting goroutines
+	changed chan struct{}
+}
+
+// NewBandwidthLimiter returns a BandwidthLimiter allowing maxBytesPerSecond
+// bytes per second, with bursts of up to one second worth of bytes. Zero
+// means unlimited.
+func NewBandwidthLimiter(maxBytesPerSecond int64) *BandwidthLimiter {
+	rate := max(maxBytesPerSecond, 0)
+	return &BandwidthLimiter{
+		rate:    rate,
+		tokens:  float64(rate),
+		last:    time.Now(),
+		changed: make(chan struct{}),
+	}
+}
+
+// MaxBytesPerSecond returns the current limit, zero meaning unlimited.
+func (l *BandwidthLimiter) MaxBytesPerSecond() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// SetMaxBytesPerSecond changes the limit, which takes effect immediately for
+// the transfers in progress. Zero means unlimited.
+func (l *BandwidthLimiter) SetMaxBytesPerSecond(n int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	l.rate = max(n, 0)
+	l.tokens = min(l.tokens, float64(l.rate))
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// refill adds the tokens accrued since the last refill. l.mu must be held.
+func (l *BandwidthLimiter) refill(now time.Time) {
+	if l.rate > 0 {
+		l.tokens = min(float64(l.rate), l.tokens+now.Sub(l.last).Seconds()*float64(l.rate))
+	}
+	l.last = now
+}
+
+// wait blocks until n bytes may be transferred through the limiter and its
+// parents. A nil limiter is unlimited.
+func (l *BandwidthLimiter) wait(ctx context.Context, n int) error {
+	for ; l != nil; l = l.parent {
+		if err := l.take(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *BandwidthLimiter) take(ctx context.Context, n int) error {
+	for {
+		l.mu.Lock()
+		l.refill(time.Now())
+		if l.rate == 0 {
+			l.mu.Unlock()
+			return nil
+		}
+
+		// a transfer larger than a burst is admitted once the bucket is full,
+		// leaving it in debt so that the average rate is kept
+		need := min(float64(n), float64(l.rate))
+		if l.tokens >= need {
+			l.tokens -= float64(n)
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((need - l.tokens) / float64(l.rate) * float64(time.Second))
+		changed := l.changed
+		l.mu.Unlock()
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-changed:
+			t.Stop()
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reader returns r throttled by the limiter, keeping it seekable if r is, so
+// that request bodies can still be rewound and measured.
+func (l *BandwidthLimiter) reader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	tr := &throttledReader{ctx: ctx, r: r, limiter: l}
+	if s, ok := r.(io.ReadSeeker); ok {
+		return &throttledReadSeeker{throttledReader: tr, s: s}
+	}
+	return tr
+}
+
+// throttledReader waits for the limiter after each read, so that bytes are
+// only reported as transferred once they were let through.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *BandwidthLimiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.limiter.wait(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Close closes the underlying reader, if it is an io.Closer.
+func (r *throttledReader) Close() error {
+	if c, ok := r.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+type throttledReadSeeker struct {
+	*throttledReader
+	s io.Seeker
+}
+
+func (r *throttledReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return r.s.Seek(offset, whence)
+}
@@ -0,0 +1,154 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+)
+
+func TestBandwidthLimiterWait(t *testing.T) {
+	ctx := context.Background()
+	l := NewBandwidthLimiter(64 * 1024)
+
+	start := time.Now()
+	if err := l.wait(ctx, 64*1024); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expect burst to be admitted at once, took %v", elapsed)
+	}
+
+	if err := l.wait(ctx, 32*1024); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expect wait for tokens, took %v", elapsed)
+	}
+}
+
+func TestBandwidthLimiterSetMaxBytesPerSecond(t *testing.T) {
+	ctx := context.Background()
+	l := NewBandwidthLimiter(1)
+	if err := l.wait(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- l.wait(ctx, 1024)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	l.SetMaxBytesPerSecond(0)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expect waiting transfer to be released by the new limit")
+	}
+	if e, a := int64(0), l.MaxBytesPerSecond(); e != a {
+		t.Errorf("expect limit %d, got %d", e, a)
+	}
+}
+
+func TestBandwidthLimiterCanceled(t *testing.T) {
+	l := NewBandwidthLimiter(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := l.wait(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.wait(ctx, 1024); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect deadline exceeded, got %v", err)
+	}
+}
+
+func TestPutObjectBandwidthLimit(t *testing.T) {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.ConsumeBody = true
+
+	listener := &mockListener{}
+	opts := Options{MaxBytesPerSecond: 256 * 1024}
+	opts.ProgressListeners.Register(listener)
+	mgr := New(c, opts)
+
+	body := make([]byte, 512*1024)
+	in := &PutObjectInput{
+		Bucket: "Bucket",
+		Key:    "Key",
+		Body:   bytes.NewReader(body),
+	}
+
+	start := time.Now()
+	out, err := mgr.PutObject(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("expect upload to be throttled, took %v", elapsed)
+	}
+
+	listener.expectComplete(t, in, out)
+	listener.expectByteTransfers(t, int64(len(body)))
+}
+
+func TestDownloadObjectOperationBandwidthLimit(t *testing.T) {
+	data := make([]byte, 512*1024)
+	c := newResumableDownloadClient(data, "etag")
+	mgr := New(c, Options{GetObjectType: types.GetObjectRanges})
+
+	start := time.Now()
+	w := types.NewWriteAtBuffer(make([]byte, len(data)))
+	_, err := mgr.DownloadObject(context.Background(), &DownloadObjectInput{
+		Bucket:   "bucket",
+		Key:      "key",
+		WriterAt: w,
+	}, func(o *Options) {
+		o.MaxBytesPerSecond = 256 * 1024
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("expect download to be throttled, took %v", elapsed)
+	}
+	if !bytes.Equal(data, w.Bytes()) {
+		t.Error("expect downloaded data to match")
+	}
+	if e, a := int64(0), mgr.BandwidthLimiter().MaxBytesPerSecond(); e != a {
+		t.Errorf("expect client limit to be unchanged, got %d", a)
+	}
+}
+
+func TestResolveOperationBandwidthLimiter(t *testing.T) {
+	client := Options{MaxBytesPerSecond: 100}
+	resolveBandwidthLimiter(&client)
+
+	o := client.Copy()
+	resolveOperationBandwidthLimiter(&o, &client)
+	if o.BandwidthLimiter != client.BandwidthLimiter {
+		t.Error("expect operation to share the client limiter")
+	}
+
+	o = client.Copy()
+	o.MaxBytesPerSecond = 50
+	resolveOperationBandwidthLimiter(&o, &client)
+	if o.BandwidthLimiter == client.BandwidthLimiter {
+		t.Fatal("expect operation to get a limiter of its own")
+	}
+	if o.BandwidthLimiter.parent != client.BandwidthLimiter {
+		t.Error("expect operation limiter to be nested in the client limiter")
+	}
+	if e, a := int64(50), o.BandwidthLimiter.MaxBytesPerSecond(); e != a {
+		t.Errorf("expect limit %d, got %d", e, a)
+	}
+}
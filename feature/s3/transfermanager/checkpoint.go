@@ -0,0 +1,282 @@
+package transfermanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+// UploadCheckpoint records the progress of a multipart upload so that it can
+// be resumed by a later PutObject call.
+type UploadCheckpoint struct {
+	// Bucket the object is uploaded into
+	Bucket string `json:"bucket"`
+
+	// Key of the uploaded object
+	Key string `json:"key"`
+
+	// ID of the multipart upload
+	UploadID string `json:"upload_id"`
+
+	// The part size of the upload, which a resumed upload must keep
+	PartSizeBytes int64 `json:"part_size_bytes"`
+
+	// Whether the part size doubles every 1000 parts, as for an adaptive
+	// upload of unknown size
+	AdaptivePartSize bool `json:"adaptive_part_size,omitempty"`
+
+	// The checksum algorithm the parts are uploaded with
+	ChecksumAlgorithm types.ChecksumAlgorithm `json:"checksum_algorithm,omitempty"`
+
+	// The parts uploaded so far, in no particular order
+	CompletedParts []types.CompletedPart `json:"completed_parts"`
+}
+
+// UploadCheckpointStore persists the checkpoint of a single multipart upload.
+// Implementations are called from multiple goroutines, but never
+// concurrently.
+type UploadCheckpointStore interface {
+	// Load returns the saved checkpoint, or nil if there is none.
+	Load(ctx context.Context) (*UploadCheckpoint, error)
+
+	// Save replaces the saved checkpoint.
+	Save(ctx context.Context, checkpoint *UploadCheckpoint) error
+
+	// Delete removes the saved checkpoint, if any.
+	Delete(ctx context.Context) error
+}
+
+// FileCheckpointStore is an UploadCheckpointStore saving the checkpoint as a
+// JSON file.
+type FileCheckpointStore struct {
+	// Path of the checkpoint file
+	Path string
+}
+
+// NewFileCheckpointStore returns a FileCheckpointStore saving the checkpoint
+// to path.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{Path: path}
+}
+
+// Load reads the checkpoint file, returning nil if it does not exist.
+func (s *FileCheckpointStore) Load(ctx context.Context) (*UploadCheckpoint, error) {
+	var checkpoint UploadCheckpoint
+	if ok, err := readCheckpointFile(s.Path, &checkpoint); !ok || err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint to a temporary file which replaces the
+// checkpoint file, so that a crash never leaves a partial checkpoint behind.
+func (s *FileCheckpointStore) Save(ctx context.Context, checkpoint *UploadCheckpoint) error {
+	return writeCheckpointFile(s.Path, checkpoint)
+}
+
+// Delete removes the checkpoint file.
+func (s *FileCheckpointStore) Delete(ctx context.Context) error {
+	return deleteCheckpointFile(s.Path)
+}
+
+// DownloadRange is a range of bytes written by a download, from Start up to
+// but excluding End.
+type DownloadRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// DownloadCheckpoint records the ranges of an object a download has written,
+// so that it can be resumed by a later DownloadObject call.
+type DownloadCheckpoint struct {
+	// Bucket the object is downloaded from
+	Bucket string `json:"bucket"`
+
+	// Key of the downloaded object
+	Key string `json:"key"`
+
+	// Version of the downloaded object, if one was requested
+	VersionID string `json:"version_id,omitempty"`
+
+	// Entity tag of the downloaded object. Every request of a resumed
+	// download is conditional on it.
+	ETag string `json:"etag"`
+
+	// The size of the downloaded object
+	ObjectSize int64 `json:"object_size"`
+
+	// The range size of the download, which a resumed download must keep
+	PartSizeBytes int64 `json:"part_size_bytes"`
+
+	// The ranges written so far, sorted and merged
+	Ranges []DownloadRange `json:"ranges"`
+}
+
+// covers returns whether the range from start to end was written
+func (c *DownloadCheckpoint) covers(start, end int64) bool {
+	for _, r := range c.Ranges {
+		if r.Start <= start && end <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// add records a written range, merging it with adjacent ones
+func (c *DownloadCheckpoint) add(start, end int64) {
+	ranges := append(c.Ranges, DownloadRange{Start: start, End: end})
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	c.Ranges = merged
+}
+
+// DownloadCheckpointStore persists the checkpoint of a single download.
+// Implementations are called from multiple goroutines, but never
+// concurrently.
+type DownloadCheckpointStore interface {
+	// Load returns the saved checkpoint, or nil if there is none.
+	Load(ctx context.Context) (*DownloadCheckpoint, error)
+
+	// Save replaces the saved checkpoint.
+	Save(ctx context.Context, checkpoint *DownloadCheckpoint) error
+
+	// Delete removes the saved checkpoint, if any.
+	Delete(ctx context.Context) error
+}
+
+// FileDownloadCheckpointStore is a DownloadCheckpointStore saving the
+// checkpoint as a JSON file, usually a sidecar file next to the file being
+// downloaded to.
+type FileDownloadCheckpointStore struct {
+	// Path of the checkpoint file
+	Path string
+}
+
+// NewFileDownloadCheckpointStore returns a FileDownloadCheckpointStore saving
+// the checkpoint to path.
+func NewFileDownloadCheckpointStore(path string) *FileDownloadCheckpointStore {
+	return &FileDownloadCheckpointStore{Path: path}
+}
+
+// Load reads the checkpoint file, returning nil if it does not exist.
+func (s *FileDownloadCheckpointStore) Load(ctx context.Context) (*DownloadCheckpoint, error) {
+	var checkpoint DownloadCheckpoint
+	if ok, err := readCheckpointFile(s.Path, &checkpoint); !ok || err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint to a temporary file which replaces the
+// checkpoint file, so that a crash never leaves a partial checkpoint behind.
+func (s *FileDownloadCheckpointStore) Save(ctx context.Context, checkpoint *DownloadCheckpoint) error {
+	return writeCheckpointFile(s.Path, checkpoint)
+}
+
+// Delete removes the checkpoint file.
+func (s *FileDownloadCheckpointStore) Delete(ctx context.Context) error {
+	return deleteCheckpointFile(s.Path)
+}
+
+// readCheckpointFile decodes the JSON file at path into v, returning false if
+// it does not exist.
+func readCheckpointFile(path string, v interface{}) (bool, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return false, fmt.Errorf("failed to decode checkpoint %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeCheckpointFile atomically replaces the file at path with v encoded as
+// JSON.
+func writeCheckpointFile(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func deleteCheckpointFile(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// AbortCheckpointedUpload aborts the multipart upload recorded by a
+// checkpoint store and deletes the checkpoint. Uploads with a checkpoint
+// store are never aborted by PutObject, so that they can be resumed; use this
+// to discard one instead. It is a no-op if the store holds no checkpoint.
+func (c *Client) AbortCheckpointedUpload(ctx context.Context, store UploadCheckpointStore, opts ...func(*Options)) error {
+	options := c.options.Copy()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	checkpoint, err := store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to load upload checkpoint: %w", err)
+	}
+	if checkpoint == nil {
+		return nil
+	}
+
+	_, err = options.S3.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(checkpoint.Bucket),
+		Key:      aws.String(checkpoint.Key),
+		UploadId: aws.String(checkpoint.UploadID),
+	}, func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions,
+			middleware.AddSDKAgentKey(middleware.FeatureMetadata, userAgentKey),
+			addFeatureUserAgent,
+		)
+	})
+	var noSuchUpload *s3types.NoSuchUpload
+	if err != nil && !errors.As(err, &noSuchUpload) {
+		return fmt.Errorf("failed to abort multipart upload %s: %w", checkpoint.UploadID, err)
+	}
+	return store.Delete(ctx)
+}
@@ -0,0 +1,331 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+// checkpointBody returns the body of a four part upload
+func checkpointBody() []byte {
+	return make([]byte, 3*minPartSizeBytes+1)
+}
+
+// uploadedParts returns the sorted part numbers of the UploadPart calls
+// logged by c.
+func uploadedParts(c *s3testing.TransferManagerLoggingClient) []int32 {
+	var parts []int32
+	for _, p := range c.Params {
+		if in, ok := p.(*s3.UploadPartInput); ok {
+			parts = append(parts, aws.ToInt32(in.PartNumber))
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i] < parts[j] })
+	return parts
+}
+
+func countOperation(c *s3testing.TransferManagerLoggingClient, name string) int {
+	var n int
+	for _, op := range c.UploadInvocations {
+		if op == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "upload.json"))
+
+	checkpoint, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if checkpoint != nil {
+		t.Fatalf("expect no checkpoint, got %v", checkpoint)
+	}
+
+	expect := &UploadCheckpoint{
+		Bucket:            "bucket",
+		Key:               "key",
+		UploadID:          "upload-id",
+		PartSizeBytes:     minPartSizeBytes,
+		ChecksumAlgorithm: types.ChecksumAlgorithmCrc32,
+		CompletedParts: []types.CompletedPart{
+			{ETag: aws.String("etag1"), PartNumber: aws.Int32(1), ChecksumCRC32: aws.String("crc")},
+		},
+	}
+	if err := store.Save(ctx, expect); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	checkpoint, err = store.Load(ctx)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if diff := cmpDiff(expect, checkpoint); len(diff) != 0 {
+		t.Errorf("unexpected checkpoint: %s", diff)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(store.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, a := 1, len(entries); e != a {
+		t.Errorf("expect %d file, got %d", e, a)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := store.Delete(ctx); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+	if checkpoint, _ := store.Load(ctx); checkpoint != nil {
+		t.Errorf("expect checkpoint to be deleted, got %v", checkpoint)
+	}
+}
+
+func TestPutObjectCheckpointFailure(t *testing.T) {
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "upload.json"))
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.UploadPartFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+		if aws.ToInt32(params.PartNumber) == 3 {
+			return nil, fmt.Errorf("connection reset")
+		}
+		return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("ETAG%d", *params.PartNumber))}, nil
+	}
+	mgr := New(c, Options{Concurrency: 1})
+
+	_, err := mgr.PutObject(context.Background(), &PutObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		Body:            bytes.NewReader(checkpointBody()),
+		CheckpointStore: store,
+	})
+	var uploadErr MultipartUploadError
+	if !errors.As(err, &uploadErr) {
+		t.Fatalf("expect MultipartUploadError, got %v", err)
+	}
+	if e, a := 0, countOperation(c, "AbortMultipartUpload"); e != a {
+		t.Errorf("expect upload not to be aborted, got %d aborts", a)
+	}
+
+	checkpoint, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if checkpoint == nil {
+		t.Fatal("expect checkpoint, got none")
+	}
+	if e, a := "UPLOAD-ID", checkpoint.UploadID; e != a {
+		t.Errorf("expect upload ID %s, got %s", e, a)
+	}
+	if e, a := int64(minPartSizeBytes), checkpoint.PartSizeBytes; e != a {
+		t.Errorf("expect part size %d, got %d", e, a)
+	}
+	var parts []int32
+	for _, p := range checkpoint.CompletedParts {
+		parts = append(parts, aws.ToInt32(p.PartNumber))
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i] < parts[j] })
+	if diff := cmpDiff([]int32{1, 2}, parts); len(diff) != 0 {
+		t.Errorf("unexpected checkpoint parts: %s", diff)
+	}
+}
+
+func TestPutObjectCheckpointResume(t *testing.T) {
+	cases := map[string]struct {
+		body        func() io.Reader
+		listedETags map[int32]string
+		listErr     error
+		expectCalls []string
+		expectParts []int32
+	}{
+		"seekable body": {
+			body:        func() io.Reader { return bytes.NewReader(checkpointBody()) },
+			listedETags: map[int32]string{1: "ETAG1", 2: "ETAG2"},
+			expectParts: []int32{3, 4},
+		},
+		"non-seekable body": {
+			body:        func() io.Reader { return io.MultiReader(bytes.NewReader(checkpointBody())) },
+			listedETags: map[int32]string{1: "ETAG1", 2: "ETAG2"},
+			expectParts: []int32{3, 4},
+		},
+		"part etag mismatch": {
+			body:        func() io.Reader { return bytes.NewReader(checkpointBody()) },
+			listedETags: map[int32]string{1: "ETAG1", 2: "OTHER"},
+			expectParts: []int32{2, 3, 4},
+		},
+		"part not in checkpoint": {
+			body:        func() io.Reader { return bytes.NewReader(checkpointBody()) },
+			listedETags: map[int32]string{1: "ETAG1", 2: "ETAG2", 3: "ETAG3"},
+			expectParts: []int32{4},
+		},
+		"upload no longer exists": {
+			body:        func() io.Reader { return bytes.NewReader(checkpointBody()) },
+			listErr:     &s3types.NoSuchUpload{},
+			expectParts: []int32{1, 2, 3, 4},
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "upload.json"))
+			err := store.Save(ctx, &UploadCheckpoint{
+				Bucket:            "bucket",
+				Key:               "key",
+				UploadID:          "OLD-UPLOAD-ID",
+				PartSizeBytes:     minPartSizeBytes,
+				ChecksumAlgorithm: types.ChecksumAlgorithmCrc32,
+				CompletedParts: []types.CompletedPart{
+					{ETag: aws.String("ETAG1"), PartNumber: aws.Int32(1)},
+					{ETag: aws.String("ETAG2"), PartNumber: aws.Int32(2)},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, _, _ := s3testing.NewUploadLoggingClient(nil)
+			c.ListPartsFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListPartsInput) (*s3.ListPartsOutput, error) {
+				if e, a := "OLD-UPLOAD-ID", aws.ToString(params.UploadId); e != a {
+					t.Errorf("expect upload ID %s, got %s", e, a)
+				}
+				if tt.listErr != nil {
+					return nil, tt.listErr
+				}
+				out := &s3.ListPartsOutput{}
+				for n, etag := range tt.listedETags {
+					out.Parts = append(out.Parts, s3types.Part{PartNumber: aws.Int32(n), ETag: aws.String(etag)})
+				}
+				return out, nil
+			}
+			mgr := New(c, Options{})
+
+			out, err := mgr.PutObject(ctx, &PutObjectInput{
+				Bucket:          "bucket",
+				Key:             "key",
+				Body:            tt.body(),
+				CheckpointStore: store,
+			})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+
+			if diff := cmpDiff(tt.expectParts, uploadedParts(c)); len(diff) != 0 {
+				t.Errorf("unexpected uploaded parts: %s", diff)
+			}
+			expectCreate := 0
+			expectUploadID := "OLD-UPLOAD-ID"
+			if tt.listErr != nil {
+				expectCreate = 1
+				expectUploadID = "UPLOAD-ID"
+			}
+			if e, a := expectCreate, countOperation(c, "CreateMultipartUpload"); e != a {
+				t.Errorf("expect %d CreateMultipartUpload, got %d", e, a)
+			}
+			if e, a := expectUploadID, out.UploadID; e != a {
+				t.Errorf("expect upload ID %s, got %s", e, a)
+			}
+
+			complete := c.Params[len(c.Params)-1].(*s3.CompleteMultipartUploadInput)
+			if e, a := expectUploadID, aws.ToString(complete.UploadId); e != a {
+				t.Errorf("expect completed upload ID %s, got %s", e, a)
+			}
+			for i, p := range complete.MultipartUpload.Parts {
+				if e, a := int32(i+1), aws.ToInt32(p.PartNumber); e != a {
+					t.Errorf("expect part %d, got %d", e, a)
+				}
+				if e, a := fmt.Sprintf("ETAG%d", i+1), aws.ToString(p.ETag); e != a {
+					t.Errorf("expect part etag %s, got %s", e, a)
+				}
+			}
+			if e, a := 4, len(complete.MultipartUpload.Parts); e != a {
+				t.Errorf("expect %d parts, got %d", e, a)
+			}
+
+			if checkpoint, _ := store.Load(ctx); checkpoint != nil {
+				t.Errorf("expect checkpoint to be deleted, got %v", checkpoint)
+			}
+		})
+	}
+}
+
+func TestPutObjectCheckpointMismatch(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "upload.json"))
+	if err := store.Save(ctx, &UploadCheckpoint{
+		Bucket:        "bucket",
+		Key:           "other-key",
+		UploadID:      "UPLOAD-ID",
+		PartSizeBytes: minPartSizeBytes,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	mgr := New(c, Options{})
+
+	_, err := mgr.PutObject(ctx, &PutObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		Body:            bytes.NewReader(checkpointBody()),
+		CheckpointStore: store,
+	})
+	if err == nil {
+		t.Fatal("expect error, got none")
+	}
+	if e, a := 0, len(c.UploadInvocations); e != a {
+		t.Errorf("expect no operations, got %v", c.UploadInvocations)
+	}
+}
+
+func TestAbortCheckpointedUpload(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "upload.json"))
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	mgr := New(c, Options{})
+
+	if err := mgr.AbortCheckpointedUpload(ctx, store); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 0, len(c.UploadInvocations); e != a {
+		t.Errorf("expect no operations without checkpoint, got %v", c.UploadInvocations)
+	}
+
+	if err := store.Save(ctx, &UploadCheckpoint{
+		Bucket:        "bucket",
+		Key:           "key",
+		UploadID:      "UPLOAD-ID",
+		PartSizeBytes: minPartSizeBytes,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.AbortCheckpointedUpload(ctx, store); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if diff := cmpDiff([]string{"AbortMultipartUpload"}, c.UploadInvocations); len(diff) != 0 {
+		t.Fatalf("unexpected operations: %s", diff)
+	}
+	params := c.Params[0].(*s3.AbortMultipartUploadInput)
+	if e, a := "UPLOAD-ID", aws.ToString(params.UploadId); e != a {
+		t.Errorf("expect upload ID %s, got %s", e, a)
+	}
+	if checkpoint, _ := store.Load(ctx); checkpoint != nil {
+		t.Errorf("expect checkpoint to be deleted, got %v", checkpoint)
+	}
+}
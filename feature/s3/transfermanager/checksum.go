@@ -0,0 +1,299 @@
+package transfermanager
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+)
+
+// crc64NVME is the reversed polynomial of the CRC-64/NVME checksum
+const crc64NVME = 0x9a6c_9329_ac4b_c9b5
+
+var (
+	crc32cTable    = crc32.MakeTable(crc32.Castagnoli)
+	crc64NVMETable = crc64.MakeTable(crc64NVME)
+)
+
+// ErrChecksumMismatch is returned when transferred content does not match the
+// ETag or checksum of the object.
+var ErrChecksumMismatch = errors.New("content does not match the checksum of the object")
+
+// ComputeChecksum returns the checksum S3 reports for an object with the
+// content of r, uploaded with the checksum algorithm in parts of partSize
+// bytes, or with a single request if partSize is zero.
+//
+// The checksum of an object uploaded with a single request is the base64
+// encoded checksum of its content. For a multipart upload with
+// types.ChecksumTypeComposite it is the checksum of the concatenated part
+// checksums followed by the part count, e.g. "Zm9v-3", and with
+// types.ChecksumTypeFullObject it is the checksum of the whole content, which
+// is only supported by the CRC algorithms. If checksumType is empty, CRC64NVME
+// checksums are full-object checksums and others are composite, as with S3.
+//
+// To compare the result with the checksum of an upload with PutObject,
+// partSize must be the Options.PartSizeBytes of the upload, and zero if the
+// object was smaller than Options.MultipartUploadThreshold.
+func ComputeChecksum(r io.Reader, algorithm types.ChecksumAlgorithm, checksumType types.ChecksumType, partSize int64) (string, error) {
+	checksumType, err := resolveChecksumType(algorithm, checksumType, partSize > 0)
+	if err != nil {
+		return "", err
+	}
+
+	if partSize <= 0 {
+		h, err := newChecksumHash(algorithm)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(h, r); err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+	}
+
+	var (
+		sums  [][]byte
+		sizes []int64
+	)
+	for {
+		h, err := newChecksumHash(algorithm)
+		if err != nil {
+			return "", err
+		}
+		n, err := io.CopyN(h, r, partSize)
+		// an empty object is uploaded as a single empty part
+		if n > 0 || len(sums) == 0 {
+			sums = append(sums, h.Sum(nil))
+			sizes = append(sizes, n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return combineChecksums(algorithm, checksumType, sums, sizes)
+}
+
+// ComputeFileChecksum returns the checksum S3 reports for an object with the
+// content of the file at path, as ComputeChecksum does.
+func ComputeFileChecksum(path string, algorithm types.ChecksumAlgorithm, checksumType types.ChecksumType, partSize int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return ComputeChecksum(f, algorithm, checksumType, partSize)
+}
+
+// resolveChecksumType returns the checksum type of an object, defaulting as
+// S3 does, or an error if the algorithm does not support it.
+func resolveChecksumType(algorithm types.ChecksumAlgorithm, checksumType types.ChecksumType, multipart bool) (types.ChecksumType, error) {
+	_, _, isCRC := crcParameters(algorithm)
+	if checksumType == "" {
+		checksumType = types.ChecksumTypeComposite
+		if algorithm == types.ChecksumAlgorithmCrc64nvme {
+			checksumType = types.ChecksumTypeFullObject
+		}
+	}
+	if !multipart {
+		return checksumType, nil
+	}
+
+	switch {
+	case checksumType == types.ChecksumTypeFullObject && !isCRC:
+		return "", fmt.Errorf("%s checksums of multipart uploads cannot be full-object checksums", algorithm)
+	case checksumType == types.ChecksumTypeComposite && algorithm == types.ChecksumAlgorithmCrc64nvme:
+		return "", fmt.Errorf("%s checksums of multipart uploads cannot be composite checksums", algorithm)
+	}
+	return checksumType, nil
+}
+
+// newChecksumHash returns a hash.Hash for the checksum algorithm
+func newChecksumHash(algorithm types.ChecksumAlgorithm) (hash.Hash, error) {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32:
+		return crc32.NewIEEE(), nil
+	case types.ChecksumAlgorithmCrc32c:
+		return crc32.New(crc32cTable), nil
+	case types.ChecksumAlgorithmCrc64nvme:
+		return crc64.New(crc64NVMETable), nil
+	case types.ChecksumAlgorithmSha1:
+		return sha1.New(), nil
+	case types.ChecksumAlgorithmSha256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown checksum algorithm %q", algorithm)
+	}
+}
+
+// crcParameters returns the reversed polynomial and the width in bits of a
+// CRC algorithm. ok is false if the algorithm is not a CRC.
+func crcParameters(algorithm types.ChecksumAlgorithm) (poly uint64, width int, ok bool) {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32:
+		return crc32.IEEE, 32, true
+	case types.ChecksumAlgorithmCrc32c:
+		return crc32.Castagnoli, 32, true
+	case types.ChecksumAlgorithmCrc64nvme:
+		return crc64NVME, 64, true
+	}
+	return 0, 0, false
+}
+
+// combineChecksums returns the checksum of an object from the raw checksums
+// and sizes of its consecutive parts.
+func combineChecksums(algorithm types.ChecksumAlgorithm, checksumType types.ChecksumType, sums [][]byte, sizes []int64) (string, error) {
+	if checksumType != types.ChecksumTypeFullObject {
+		h, err := newChecksumHash(algorithm)
+		if err != nil {
+			return "", err
+		}
+		for _, sum := range sums {
+			h.Write(sum)
+		}
+		return base64.StdEncoding.EncodeToString(h.Sum(nil)) + "-" + strconv.Itoa(len(sums)), nil
+	}
+
+	poly, width, ok := crcParameters(algorithm)
+	if !ok {
+		return "", fmt.Errorf("%s checksums cannot be combined into a full-object checksum", algorithm)
+	}
+	var crc uint64
+	for i, sum := range sums {
+		if len(sum) != width/8 {
+			return "", fmt.Errorf("invalid %s checksum length %d", algorithm, len(sum))
+		}
+		var v uint64
+		if width == 32 {
+			v = uint64(binary.BigEndian.Uint32(sum))
+		} else {
+			v = binary.BigEndian.Uint64(sum)
+		}
+		if i == 0 {
+			crc = v
+			continue
+		}
+		crc = crcCombine(poly, width, crc, v, sizes[i])
+	}
+
+	b := make([]byte, width/8)
+	if width == 32 {
+		binary.BigEndian.PutUint32(b, uint32(crc))
+	} else {
+		binary.BigEndian.PutUint64(b, crc)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// crcCombine returns the CRC of the concatenation of two blocks of data from
+// the CRCs of each block and the length of the second one, for a reflected
+// CRC of the given reversed polynomial and width, as zlib's crc32_combine.
+func crcCombine(poly uint64, width int, crc1, crc2 uint64, len2 int64) uint64 {
+	if len2 <= 0 {
+		return crc1
+	}
+
+	// the operator of a single zero bit
+	odd := make([]uint64, width)
+	odd[0] = poly
+	row := uint64(1)
+	for n := 1; n < width; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+	even := make([]uint64, width)
+	gf2MatrixSquare(even, odd) // two zero bits
+	gf2MatrixSquare(odd, even) // four zero bits
+
+	// apply len2 zero bytes to crc1, squaring the operator for each bit of
+	// len2 starting with one zero byte
+	for {
+		gf2MatrixSquare(even, odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+
+		gf2MatrixSquare(odd, even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+	return crc1 ^ crc2
+}
+
+func gf2MatrixTimes(mat []uint64, vec uint64) uint64 {
+	var sum uint64
+	for i := 0; vec != 0; i++ {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+		vec >>= 1
+	}
+	return sum
+}
+
+func gf2MatrixSquare(square, mat []uint64) {
+	for n := range mat {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
+
+// partChecksum returns the checksum of a part for the checksum algorithm
+func partChecksum(part types.CompletedPart, algorithm types.ChecksumAlgorithm) string {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32:
+		return aws.ToString(part.ChecksumCRC32)
+	case types.ChecksumAlgorithmCrc32c:
+		return aws.ToString(part.ChecksumCRC32C)
+	case types.ChecksumAlgorithmCrc64nvme:
+		return aws.ToString(part.ChecksumCRC64NVME)
+	case types.ChecksumAlgorithmSha1:
+		return aws.ToString(part.ChecksumSHA1)
+	case types.ChecksumAlgorithmSha256:
+		return aws.ToString(part.ChecksumSHA256)
+	}
+	return ""
+}
+
+// partsChecksum returns the checksum of an object from the checksums of its
+// parts, sorted by part number, and their sizes. It returns an empty string if
+// a part has no checksum for the algorithm.
+func partsChecksum(algorithm types.ChecksumAlgorithm, checksumType types.ChecksumType, parts []types.CompletedPart, sizes map[int32]int64) (string, error) {
+	sums := make([][]byte, 0, len(parts))
+	partSizes := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		v := partChecksum(part, algorithm)
+		if v == "" {
+			return "", nil
+		}
+		sum, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid checksum of part %d: %w", aws.ToInt32(part.PartNumber), err)
+		}
+		sums = append(sums, sum)
+		partSizes = append(partSizes, sizes[aws.ToInt32(part.PartNumber)])
+	}
+	return combineChecksums(algorithm, checksumType, sums, partSizes)
+}
@@ -0,0 +1,397 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+func checksumTestData(n int64) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i*7 + i/251)
+	}
+	return data
+}
+
+func base64Checksum(t *testing.T, algorithm types.ChecksumAlgorithm, data []byte) string {
+	t.Helper()
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	h.Write(data)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func TestCRCCombine(t *testing.T) {
+	data := checksumTestData(100003)
+	cases := map[string]struct {
+		poly  uint64
+		width int
+		sum   func([]byte) uint64
+	}{
+		"crc32": {
+			poly: crc32.IEEE, width: 32,
+			sum: func(b []byte) uint64 { return uint64(crc32.ChecksumIEEE(b)) },
+		},
+		"crc32c": {
+			poly: crc32.Castagnoli, width: 32,
+			sum: func(b []byte) uint64 { return uint64(crc32.Checksum(b, crc32cTable)) },
+		},
+		"crc64nvme": {
+			poly: crc64NVME, width: 64,
+			sum: func(b []byte) uint64 { return crc64.Checksum(b, crc64NVMETable) },
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			for _, split := range []int{0, 1, 4096, 65537, len(data)} {
+				a, b := data[:split], data[split:]
+				e := c.sum(data)
+				if a := crcCombine(c.poly, c.width, c.sum(a), c.sum(b), int64(len(b))); e != a {
+					t.Errorf("split %d: expect %x, got %x", split, e, a)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeChecksum(t *testing.T) {
+	data := checksumTestData(2*minPartSizeBytes + 3)
+	parts := [][]byte{data[:minPartSizeBytes], data[minPartSizeBytes : 2*minPartSizeBytes], data[2*minPartSizeBytes:]}
+
+	var composite []byte
+	for _, part := range parts {
+		composite = binary.BigEndian.AppendUint32(composite, crc32.Checksum(part, crc32cTable))
+	}
+	sha := sha256.Sum256(data)
+
+	cases := map[string]struct {
+		algorithm    types.ChecksumAlgorithm
+		checksumType types.ChecksumType
+		partSize     int64
+		expect       string
+		expectErr    string
+	}{
+		"single": {
+			algorithm: types.ChecksumAlgorithmSha256,
+			expect:    base64.StdEncoding.EncodeToString(sha[:]),
+		},
+		"composite": {
+			algorithm: types.ChecksumAlgorithmCrc32c,
+			partSize:  minPartSizeBytes,
+			expect:    base64Checksum(t, types.ChecksumAlgorithmCrc32c, composite) + "-3",
+		},
+		"full object": {
+			algorithm:    types.ChecksumAlgorithmCrc32c,
+			checksumType: types.ChecksumTypeFullObject,
+			partSize:     minPartSizeBytes,
+			expect:       base64Checksum(t, types.ChecksumAlgorithmCrc32c, data),
+		},
+		"crc64nvme defaults to full object": {
+			algorithm: types.ChecksumAlgorithmCrc64nvme,
+			partSize:  minPartSizeBytes,
+			expect:    base64Checksum(t, types.ChecksumAlgorithmCrc64nvme, data),
+		},
+		"sha256 full object": {
+			algorithm:    types.ChecksumAlgorithmSha256,
+			checksumType: types.ChecksumTypeFullObject,
+			partSize:     minPartSizeBytes,
+			expectErr:    "cannot be full-object",
+		},
+		"crc64nvme composite": {
+			algorithm:    types.ChecksumAlgorithmCrc64nvme,
+			checksumType: types.ChecksumTypeComposite,
+			partSize:     minPartSizeBytes,
+			expectErr:    "cannot be composite",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			checksum, err := ComputeChecksum(bytes.NewReader(data), c.algorithm, c.checksumType, c.partSize)
+			if c.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.expectErr) {
+					t.Fatalf("expect error containing %q, got %v", c.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if e, a := c.expect, checksum; e != a {
+				t.Errorf("expect checksum %s, got %s", e, a)
+			}
+		})
+	}
+}
+
+// newChecksumUploadClient returns a client which reports the checksums of
+// the uploaded parts for the algorithm
+func newChecksumUploadClient(algorithm types.ChecksumAlgorithm) *s3testing.TransferManagerLoggingClient {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.UploadPartFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+		h, _ := newChecksumHash(algorithm)
+		io.Copy(h, params.Body)
+		sum := aws.String(base64.StdEncoding.EncodeToString(h.Sum(nil)))
+		out := &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("ETAG%d", aws.ToInt32(params.PartNumber)))}
+		switch algorithm {
+		case types.ChecksumAlgorithmCrc32c:
+			out.ChecksumCRC32C = sum
+		case types.ChecksumAlgorithmCrc64nvme:
+			out.ChecksumCRC64NVME = sum
+		}
+		return out, nil
+	}
+	return c
+}
+
+func TestPutObjectChecksum(t *testing.T) {
+	data := checksumTestData(2*minPartSizeBytes + 3)
+
+	t.Run("composite", func(t *testing.T) {
+		c := newChecksumUploadClient(types.ChecksumAlgorithmCrc32c)
+		mgr := New(c, Options{ChecksumAlgorithm: types.ChecksumAlgorithmCrc32c})
+		out, err := mgr.PutObject(context.Background(), &PutObjectInput{
+			Bucket: "bucket",
+			Key:    "key",
+			Body:   bytes.NewReader(data),
+		})
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+
+		expect, err := ComputeChecksum(bytes.NewReader(data), types.ChecksumAlgorithmCrc32c, "", minPartSizeBytes)
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if e, a := expect, out.Checksum; e != a {
+			t.Errorf("expect checksum %s, got %s", e, a)
+		}
+		if e, a := types.ChecksumTypeComposite, out.ChecksumType; e != a {
+			t.Errorf("expect checksum type %s, got %s", e, a)
+		}
+	})
+
+	t.Run("full object", func(t *testing.T) {
+		c := newChecksumUploadClient(types.ChecksumAlgorithmCrc64nvme)
+		var completed *s3.CompleteMultipartUploadInput
+		c.CompleteMultipartUploadFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+			completed = params
+			return &s3.CompleteMultipartUploadOutput{ChecksumCRC64NVME: params.ChecksumCRC64NVME}, nil
+		}
+		mgr := New(c, Options{ChecksumAlgorithm: types.ChecksumAlgorithmCrc64nvme})
+		out, err := mgr.PutObject(context.Background(), &PutObjectInput{
+			Bucket: "bucket",
+			Key:    "key",
+			Body:   bytes.NewReader(data),
+		})
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+
+		expect := base64Checksum(t, types.ChecksumAlgorithmCrc64nvme, data)
+		if e, a := expect, out.Checksum; e != a {
+			t.Errorf("expect checksum %s, got %s", e, a)
+		}
+		if e, a := expect, aws.ToString(completed.ChecksumCRC64NVME); e != a {
+			t.Errorf("expect completed checksum %s, got %s", e, a)
+		}
+		if e, a := s3types.ChecksumTypeFullObject, completed.ChecksumType; e != a {
+			t.Errorf("expect completed checksum type %s, got %s", e, a)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		c := newChecksumUploadClient(types.ChecksumAlgorithmCrc32c)
+		c.CompleteMultipartUploadFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+			return &s3.CompleteMultipartUploadOutput{ChecksumCRC32C: aws.String("AAAAAA==-3")}, nil
+		}
+		mgr := New(c, Options{ChecksumAlgorithm: types.ChecksumAlgorithmCrc32c})
+		_, err := mgr.PutObject(context.Background(), &PutObjectInput{
+			Bucket: "bucket",
+			Key:    "key",
+			Body:   bytes.NewReader(data),
+		})
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("expect ErrChecksumMismatch, got %v", err)
+		}
+	})
+}
+
+// newChecksumDownloadClient returns a client serving the parts of data, of
+// partSize bytes, which reports the checksum of the object on HeadObject
+func newChecksumDownloadClient(data []byte, partSize int64, checksum string) *s3testing.TransferManagerLoggingClient {
+	c := newResumableDownloadClient(data, "etag")
+	ranges := c.GetObjectFn
+	c.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		if params.PartNumber == nil {
+			return ranges(c, params)
+		}
+		start := int64(aws.ToInt32(params.PartNumber)-1) * partSize
+		end := min(start+partSize, int64(len(data))) - 1
+		return &s3.GetObjectOutput{
+			Body:          io.NopCloser(bytes.NewReader(data[start : end+1])),
+			ContentLength: aws.Int64(end - start + 1),
+			ContentRange:  aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(data))),
+			ETag:          aws.String("etag"),
+			PartsCount:    aws.Int32(int32((int64(len(data)) + partSize - 1) / partSize)),
+		}, nil
+	}
+	c.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		return &s3.HeadObjectOutput{
+			ContentLength:  aws.Int64(int64(len(data))),
+			ETag:           aws.String("etag"),
+			ChecksumCRC32C: aws.String(checksum),
+		}, nil
+	}
+	return c
+}
+
+func TestDownloadObjectValidateChecksum(t *testing.T) {
+	data := checksumTestData(2*minPartSizeBytes + 3)
+	full := base64Checksum(t, types.ChecksumAlgorithmCrc32c, data)
+	composite, err := ComputeChecksum(bytes.NewReader(data), types.ChecksumAlgorithmCrc32c, types.ChecksumTypeComposite, minPartSizeBytes)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	cases := map[string]struct {
+		getObjectType types.GetObjectType
+		checksum      string
+		expectErr     string
+	}{
+		"full object ranges": {
+			getObjectType: types.GetObjectRanges,
+			checksum:      full,
+		},
+		"full object parts": {
+			getObjectType: types.GetObjectParts,
+			checksum:      full,
+		},
+		"composite parts": {
+			getObjectType: types.GetObjectParts,
+			checksum:      composite,
+		},
+		"composite ranges": {
+			getObjectType: types.GetObjectRanges,
+			checksum:      composite,
+			expectErr:     "can only be validated when downloading parts",
+		},
+		"mismatch": {
+			getObjectType: types.GetObjectRanges,
+			checksum:      "AAAAAA==",
+			expectErr:     ErrChecksumMismatch.Error(),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := newChecksumDownloadClient(data, minPartSizeBytes, c.checksum)
+			mgr := New(client, Options{GetObjectType: c.getObjectType, Concurrency: 2})
+			w := types.NewWriteAtBuffer(make([]byte, len(data)))
+			_, err := mgr.DownloadObject(context.Background(), &DownloadObjectInput{
+				Bucket:                 "bucket",
+				Key:                    "key",
+				WriterAt:               w,
+				ValidateObjectChecksum: true,
+			})
+			if c.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.expectErr) {
+					t.Fatalf("expect error containing %q, got %v", c.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !bytes.Equal(data, w.Bytes()) {
+				t.Errorf("expect downloaded content to match the object")
+			}
+			for _, etag := range client.Etags {
+				if e, a := "etag", etag; e != a {
+					t.Errorf("expect requests conditional on ETag %s, got %s", e, a)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadObjectValidateChecksumSHA256(t *testing.T) {
+	data := checksumTestData(2*minPartSizeBytes + 3)
+	cases := map[string]struct {
+		getObjectType types.GetObjectType
+		checksum      string
+		expectErr     string
+	}{
+		"ranges": {
+			getObjectType: types.GetObjectRanges,
+			checksum:      base64Checksum(t, types.ChecksumAlgorithmSha256, data),
+		},
+		"parts": {
+			getObjectType: types.GetObjectParts,
+			checksum:      base64Checksum(t, types.ChecksumAlgorithmSha256, data),
+		},
+		"mismatch": {
+			getObjectType: types.GetObjectRanges,
+			checksum:      base64Checksum(t, types.ChecksumAlgorithmSha256, data[1:]),
+			expectErr:     ErrChecksumMismatch.Error(),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := newChecksumDownloadClient(data, minPartSizeBytes, "")
+			client.HeadObjectFn = func(*s3testing.TransferManagerLoggingClient, *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+				return &s3.HeadObjectOutput{
+					ContentLength:  aws.Int64(int64(len(data))),
+					ETag:           aws.String("etag"),
+					ChecksumSHA256: aws.String(c.checksum),
+				}, nil
+			}
+			mgr := New(client, Options{GetObjectType: c.getObjectType, Concurrency: 2})
+			w := types.NewWriteAtBuffer(make([]byte, len(data)))
+			_, err := mgr.DownloadObject(context.Background(), &DownloadObjectInput{
+				Bucket:                 "bucket",
+				Key:                    "key",
+				WriterAt:               w,
+				ValidateObjectChecksum: true,
+			})
+
+			// the checksum cannot be combined from ranges, the object is
+			// downloaded with a single request
+			if diff := cmpDiff([]string{fmt.Sprintf("bytes=0-%d", len(data)-1)}, client.RetrievedRanges); diff != "" {
+				t.Error(diff)
+			}
+			if c.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.expectErr) {
+					t.Fatalf("expect error containing %q, got %v", c.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !bytes.Equal(data, w.Bytes()) {
+				t.Errorf("expect downloaded content to match the object")
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ type concurrentReader struct {
 	capacity     int32
 	sectionParts int32
 	sendCount    int32
+	reserved     int32
 	receiveCount int32
 	readCount    int32
 	totalBytes   int64
@@ -81,14 +82,28 @@ func (r *concurrentReader) Read(p []byte) (int, error) {
 			break
 		}
 
-		if r.index == r.getCapacity() {
+		capacity := r.getCapacity()
+		if r.index == capacity {
 			continue
 		}
 
+		if r.index == r.reserved {
+			// the parts of a section are reserved at once and in order
+			// before their requests are sent, so that readers sharing the
+			// pool never hold the memory of some parts while waiting for
+			// the memory of the part they read first
+			if err := r.options.BufferPool.reserve(r.ctx, r.chunkBytes(r.index, capacity)); err != nil {
+				r.setErr(err)
+				break
+			}
+			r.reserved = capacity
+		}
+
+		size := r.chunkBytes(r.index, r.index+1)
 		if r.options.GetObjectType == types.GetObjectParts {
-			ch <- getChunk{part: r.index + 1, index: r.index}
+			ch <- getChunk{part: r.index + 1, index: r.index, reserved: size}
 		} else {
-			ch <- getChunk{withRange: r.byteRange(), index: r.index}
+			ch <- getChunk{withRange: r.byteRange(), index: r.index, reserved: size}
 		}
 
 		r.pos += r.partSize
@@ -97,6 +112,12 @@ func (r *concurrentReader) Read(p []byte) (int, error) {
 
 	close(ch)
 	r.wg.Wait()
+	if r.index < r.reserved {
+		// the parts reserved but not sent are reserved again by the next
+		// call
+		r.options.BufferPool.release(r.chunkBytes(r.index, r.reserved))
+		r.reserved = r.index
+	}
 
 	if e := r.getErr(); e != nil && e != io.EOF {
 		close(r.ch)
@@ -117,10 +138,12 @@ func (r *concurrentReader) downloadPart(ctx context.Context, ch chan getChunk, c
 			break
 		}
 		if r.getErr() != nil {
+			r.options.BufferPool.release(chunk.reserved)
 			continue
 		}
 		_, err := r.downloadChunk(ctx, chunk, clientOptions...)
 		if err != nil {
+			r.options.BufferPool.release(chunk.reserved)
 			r.setErr(err)
 		}
 	}
@@ -141,16 +164,17 @@ func (r *concurrentReader) downloadChunk(ctx context.Context, chunk getChunk, cl
 
 	out, err := r.options.S3.GetObject(ctx, params, clientOptions...)
 	if err != nil {
-		return nil, err
+		return nil, sseCustomerKeyError(err, r.in.SSECustomerKey, r.in.SSECustomerKeyMD5, false)
 	}
 
 	defer out.Body.Close()
-	buf, err := io.ReadAll(out.Body)
+	length := aws.ToInt64(out.ContentLength)
+	buf, err := io.ReadAll(r.options.BandwidthLimiter.reader(ctx, out.Body))
 
 	if err != nil {
 		return nil, err
 	}
-	r.ch <- outChunk{body: bytes.NewReader(buf), index: chunk.index, length: aws.ToInt64(out.ContentLength)}
+	r.ch <- outChunk{body: bytes.NewReader(buf), index: chunk.index, length: length, reserved: chunk.reserved}
 
 	output := &GetObjectOutput{}
 	output.mapFromGetObjectOutput(out, params.ChecksumMode)
@@ -163,19 +187,31 @@ func (r *concurrentReader) byteRange() string {
 	return fmt.Sprintf("bytes=%d-%d", r.pos, min(r.totalBytes-1, r.pos+r.partSize-1))
 }
 
+// chunkBytes returns the memory reserved for the chunks from index from to
+// index to, excluded. Parts are assumed to be of the size of the first one.
+func (r *concurrentReader) chunkBytes(from, to int32) int64 {
+	if r.options.GetObjectType == types.GetObjectParts {
+		return int64(to-from) * r.partSize
+	}
+	pos := r.pos + int64(from-r.index)*r.partSize
+	return max(0, min(r.totalBytes, pos+int64(to-from)*r.partSize)-pos)
+}
+
 type getChunk struct {
 	part      int32
 	withRange string
 
-	index int32
+	index    int32
+	reserved int64
 }
 
 type outChunk struct {
 	body  io.Reader
 	index int32
 
-	length int64
-	cur    int64
+	length   int64
+	cur      int64
+	reserved int64
 }
 
 func (r *concurrentReader) read(p []byte) (int, error) {
@@ -206,6 +242,7 @@ func (r *concurrentReader) read(p []byte) (int, error) {
 				return written, r.getErr()
 			}
 			if c.cur >= c.length {
+				r.options.BufferPool.release(c.reserved)
 				r.readCount++
 				delete(r.buf, i)
 				if r.readCount == r.getCapacity() {
@@ -247,6 +284,7 @@ func (r *concurrentReader) read(p []byte) (int, error) {
 		if oc.cur < oc.length {
 			r.buf[oc.index] = &oc
 		} else {
+			r.options.BufferPool.release(oc.reserved)
 			r.readCount++
 			if r.readCount == r.getCapacity() {
 				capacity := min(r.getCapacity()+r.sectionParts, r.partsCount)
@@ -304,11 +342,15 @@ func (r *concurrentReader) getErr() error {
 }
 
 func (r *concurrentReader) clean() {
+	for _, c := range r.buf {
+		r.options.BufferPool.release(c.reserved)
+	}
 	r.buf = nil
 	for {
-		_, ok := <-r.ch
+		c, ok := <-r.ch
 		if !ok {
 			break
 		}
+		r.options.BufferPool.release(c.reserved)
 	}
 }
@@ -0,0 +1,336 @@
+package transfermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+// newCopyClient returns a client whose source object has the given size
+func newCopyClient(size int64) *s3testing.TransferManagerLoggingClient {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		return &s3.HeadObjectOutput{
+			ContentLength: aws.Int64(size),
+			ContentType:   aws.String("application/json"),
+			CacheControl:  aws.String("no-cache"),
+			Metadata:      map[string]string{"owner": "team-a"},
+			ETag:          aws.String("source-etag"),
+			VersionId:     aws.String("source-version"),
+		}, nil
+	}
+	return c
+}
+
+func TestCopyObjectSingle(t *testing.T) {
+	c := newCopyClient(1024)
+	mgr := New(c, Options{})
+
+	out, err := mgr.CopyObject(context.Background(), &CopyObjectInput{
+		Bucket:             "dst-bucket",
+		Key:                "dst/key",
+		SourceBucket:       "src-bucket",
+		SourceKey:          "src dir/key+1",
+		SourceVersionID:    "v1",
+		RetentionDirective: types.RetentionDirectiveCopy,
+		TaggingDirective:   types.TaggingDirectiveReplace,
+		Tagging:            "a=b",
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if diff := cmpDiff([]string{"CopyObject"}, c.UploadInvocations); len(diff) != 0 {
+		t.Fatalf("unexpected operations: %s", diff)
+	}
+	params := c.Params[0].(*s3.CopyObjectInput)
+	if e, a := "src-bucket/src%20dir/key+1?versionId=v1", aws.ToString(params.CopySource); e != a {
+		t.Errorf("expect copy source %s, got %s", e, a)
+	}
+	if e, a := "COPY", aws.ToString(params.RetentionDirective); e != a {
+		t.Errorf("expect retention directive %s, got %s", e, a)
+	}
+	if e, a := s3types.TaggingDirectiveReplace, params.TaggingDirective; e != a {
+		t.Errorf("expect tagging directive %s, got %s", e, a)
+	}
+	if e, a := "myetag", out.ETag; e != a {
+		t.Errorf("expect etag %s, got %s", e, a)
+	}
+	if e, a := int64(1024), out.ObjectSize; e != a {
+		t.Errorf("expect object size %d, got %d", e, a)
+	}
+	if len(out.UploadID) != 0 {
+		t.Errorf("expect no upload ID, got %s", out.UploadID)
+	}
+}
+
+func TestCopyObjectMultipart(t *testing.T) {
+	c := newCopyClient(3*minPartSizeBytes + 1)
+	c.GetObjectTaggingFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectTaggingInput) (*s3.GetObjectTaggingOutput, error) {
+		return &s3.GetObjectTaggingOutput{
+			TagSet: []s3types.Tag{
+				{Key: aws.String("env"), Value: aws.String("prod")},
+				{Key: aws.String("team"), Value: aws.String("a b")},
+			},
+		}, nil
+	}
+	mgr := New(c, Options{})
+
+	out, err := mgr.CopyObject(context.Background(), &CopyObjectInput{
+		Bucket:       "dst-bucket",
+		Key:          "dst-key",
+		SourceBucket: "src-bucket",
+		SourceKey:    "src-key",
+		StorageClass: types.StorageClassStandardIa,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	expectOps := []string{
+		"GetObjectTagging", "CreateMultipartUpload",
+		"UploadPartCopy", "UploadPartCopy", "UploadPartCopy", "UploadPartCopy",
+		"CompleteMultipartUpload",
+	}
+	if diff := cmpDiff(expectOps, c.UploadInvocations); len(diff) != 0 {
+		t.Fatalf("unexpected operations: %s", diff)
+	}
+
+	create := c.Params[1].(*s3.CreateMultipartUploadInput)
+	if e, a := "application/json", aws.ToString(create.ContentType); e != a {
+		t.Errorf("expect content type %s, got %s", e, a)
+	}
+	if e, a := "no-cache", aws.ToString(create.CacheControl); e != a {
+		t.Errorf("expect cache control %s, got %s", e, a)
+	}
+	if diff := cmpDiff(map[string]string{"owner": "team-a"}, create.Metadata); len(diff) != 0 {
+		t.Errorf("unexpected metadata: %s", diff)
+	}
+	if e, a := "env=prod&team=a+b", aws.ToString(create.Tagging); e != a {
+		t.Errorf("expect tagging %s, got %s", e, a)
+	}
+	if e, a := s3types.StorageClassStandardIa, create.StorageClass; e != a {
+		t.Errorf("expect storage class %s, got %s", e, a)
+	}
+
+	ranges := map[int32]string{}
+	for _, p := range c.Params[2:6] {
+		in := p.(*s3.UploadPartCopyInput)
+		ranges[aws.ToInt32(in.PartNumber)] = aws.ToString(in.CopySourceRange)
+		if e, a := "source-etag", aws.ToString(in.CopySourceIfMatch); e != a {
+			t.Errorf("expect copy source if-match %s, got %s", e, a)
+		}
+		if e, a := "src-bucket/src-key", aws.ToString(in.CopySource); e != a {
+			t.Errorf("expect copy source %s, got %s", e, a)
+		}
+	}
+	expectRanges := map[int32]string{
+		1: fmt.Sprintf("bytes=0-%d", minPartSizeBytes-1),
+		2: fmt.Sprintf("bytes=%d-%d", minPartSizeBytes, 2*minPartSizeBytes-1),
+		3: fmt.Sprintf("bytes=%d-%d", 2*minPartSizeBytes, 3*minPartSizeBytes-1),
+		4: fmt.Sprintf("bytes=%d-%d", 3*minPartSizeBytes, 3*minPartSizeBytes),
+	}
+	if diff := cmpDiff(expectRanges, ranges); len(diff) != 0 {
+		t.Errorf("unexpected ranges: %s", diff)
+	}
+
+	complete := c.Params[6].(*s3.CompleteMultipartUploadInput)
+	for i, p := range complete.MultipartUpload.Parts {
+		if e, a := int32(i+1), aws.ToInt32(p.PartNumber); e != a {
+			t.Errorf("expect part %d at %d, got %d", e, i, a)
+		}
+		if e, a := fmt.Sprintf("ETAG%d", i+1), aws.ToString(p.ETag); e != a {
+			t.Errorf("expect part etag %s, got %s", e, a)
+		}
+	}
+
+	if e, a := "UPLOAD-ID", out.UploadID; e != a {
+		t.Errorf("expect upload ID %s, got %s", e, a)
+	}
+	if e, a := "source-version", out.CopySourceVersionID; e != a {
+		t.Errorf("expect source version %s, got %s", e, a)
+	}
+	if e, a := 4, len(out.CompletedParts); e != a {
+		t.Errorf("expect %d completed parts, got %d", e, a)
+	}
+}
+
+func TestCopyObjectMultipartReplaceMetadata(t *testing.T) {
+	c := newCopyClient(2 * minPartSizeBytes)
+	mgr := New(c, Options{})
+
+	_, err := mgr.CopyObject(context.Background(), &CopyObjectInput{
+		Bucket:            "bucket",
+		Key:               "dst-key",
+		SourceBucket:      "bucket",
+		SourceKey:         "src-key",
+		MetadataDirective: types.MetadataDirectiveReplace,
+		ContentType:       "text/plain",
+		Metadata:          map[string]string{"owner": "team-b"},
+		TaggingDirective:  types.TaggingDirectiveReplace,
+		Tagging:           "a=b",
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	create := c.Params[0].(*s3.CreateMultipartUploadInput)
+	if e, a := "text/plain", aws.ToString(create.ContentType); e != a {
+		t.Errorf("expect content type %s, got %s", e, a)
+	}
+	if create.CacheControl != nil {
+		t.Errorf("expect no cache control, got %s", *create.CacheControl)
+	}
+	if diff := cmpDiff(map[string]string{"owner": "team-b"}, create.Metadata); len(diff) != 0 {
+		t.Errorf("unexpected metadata: %s", diff)
+	}
+	if e, a := "a=b", aws.ToString(create.Tagging); e != a {
+		t.Errorf("expect tagging %s, got %s", e, a)
+	}
+}
+
+func TestCopyObjectMultipartFailure(t *testing.T) {
+	c := newCopyClient(4 * minPartSizeBytes)
+	c.UploadPartCopyFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.UploadPartCopyInput) (*s3.UploadPartCopyOutput, error) {
+		if aws.ToInt32(params.PartNumber) == 2 {
+			return nil, fmt.Errorf("part copy failed")
+		}
+		return &s3.UploadPartCopyOutput{CopyPartResult: &s3types.CopyPartResult{ETag: aws.String("etag")}}, nil
+	}
+	mgr := New(c, Options{})
+
+	_, err := mgr.CopyObject(context.Background(), &CopyObjectInput{
+		Bucket:       "bucket",
+		Key:          "dst-key",
+		SourceBucket: "bucket",
+		SourceKey:    "src-key",
+	}, func(o *Options) {
+		o.Concurrency = 1
+	})
+	if err == nil {
+		t.Fatal("expect error, got none")
+	}
+
+	var uploadErr MultipartUploadError
+	if !errors.As(err, &uploadErr) {
+		t.Fatalf("expect MultipartUploadError, got %T", err)
+	}
+	if e, a := "UPLOAD-ID", uploadErr.UploadID(); e != a {
+		t.Errorf("expect upload ID %s, got %s", e, a)
+	}
+	if e, a := "AbortMultipartUpload", c.UploadInvocations[len(c.UploadInvocations)-1]; e != a {
+		t.Errorf("expect last operation %s, got %s", e, a)
+	}
+	for _, op := range c.UploadInvocations {
+		if op == "CompleteMultipartUpload" {
+			t.Errorf("expect upload not to be completed")
+		}
+	}
+}
+
+func TestCopyObjectMultipartRetention(t *testing.T) {
+	expiration := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	cases := map[string]struct {
+		input        CopyObjectInput
+		expectOps    []string
+		expectExtend *s3.ExtendObjectRetentionInput
+		expectHolds  []string
+	}{
+		"copy": {
+			expectOps:    []string{"ListLegalHolds", "ExtendObjectRetention", "AddLegalHold", "AddLegalHold"},
+			expectExtend: &s3.ExtendObjectRetentionInput{NewRetentionExpirationDate: &expiration},
+			expectHolds:  []string{"hold-1", "hold-2"},
+		},
+		"replace": {
+			input: CopyObjectInput{
+				RetentionDirective:   types.RetentionDirectiveReplace,
+				RetentionPeriod:      3600,
+				RetentionLegalHoldID: "new-hold",
+			},
+			expectOps:    []string{"ExtendObjectRetention", "AddLegalHold"},
+			expectExtend: &s3.ExtendObjectRetentionInput{NewRetentionPeriod: aws.Int64(3600)},
+			expectHolds:  []string{"new-hold"},
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newCopyClient(2 * minPartSizeBytes)
+			c.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+				return &s3.HeadObjectOutput{
+					ContentLength:           aws.Int64(2 * minPartSizeBytes),
+					RetentionExpirationDate: aws.Time(expiration),
+					RetentionLegalHoldCount: aws.Int64(2),
+				}, nil
+			}
+			c.ListLegalHoldsFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListLegalHoldsInput) (*s3.ListLegalHoldsOutput, error) {
+				return &s3.ListLegalHoldsOutput{
+					RetentionPeriodExpirationDate: aws.Time(expiration),
+					LegalHolds: []s3types.LegalHold{
+						{ID: aws.String("hold-1")},
+						{ID: aws.String("hold-2")},
+					},
+				}, nil
+			}
+			mgr := New(c, Options{})
+
+			input := tt.input
+			input.Bucket = "dst-bucket"
+			input.Key = "dst-key"
+			input.SourceBucket = "src-bucket"
+			input.SourceKey = "src-key"
+			input.TaggingDirective = types.TaggingDirectiveReplace
+			if _, err := mgr.CopyObject(context.Background(), &input); err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+
+			// CreateMultipartUpload, 2 x UploadPartCopy, CompleteMultipartUpload
+			ops := c.UploadInvocations[4:]
+			if diff := cmpDiff(tt.expectOps, ops); len(diff) != 0 {
+				t.Fatalf("unexpected operations: %s", diff)
+			}
+
+			var holds []string
+			for _, p := range c.Params[4:] {
+				switch in := p.(type) {
+				case *s3.ExtendObjectRetentionInput:
+					if e, a := "dst-bucket", aws.ToString(in.Bucket); e != a {
+						t.Errorf("expect bucket %s, got %s", e, a)
+					}
+					if diff := cmpDiff(tt.expectExtend.NewRetentionExpirationDate, in.NewRetentionExpirationDate); len(diff) != 0 {
+						t.Errorf("unexpected expiration date: %s", diff)
+					}
+					if diff := cmpDiff(tt.expectExtend.NewRetentionPeriod, in.NewRetentionPeriod); len(diff) != 0 {
+						t.Errorf("unexpected retention period: %s", diff)
+					}
+				case *s3.AddLegalHoldInput:
+					holds = append(holds, aws.ToString(in.RetentionLegalHoldId))
+				}
+			}
+			if diff := cmpDiff(tt.expectHolds, holds); len(diff) != 0 {
+				t.Errorf("unexpected legal holds: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCopyObjectValidation(t *testing.T) {
+	c := newCopyClient(0)
+	mgr := New(c, Options{})
+
+	if _, err := mgr.CopyObject(context.Background(), &CopyObjectInput{Bucket: "bucket", Key: "key"}); err == nil {
+		t.Error("expect error for missing source, got none")
+	}
+	if _, err := mgr.CopyObject(context.Background(), &CopyObjectInput{SourceBucket: "bucket", SourceKey: "key"}); err == nil {
+		t.Error("expect error for missing destination, got none")
+	}
+}
@@ -0,0 +1,297 @@
+package transfermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+// newListingClient returns a client listing n keys under prefix in pages of
+// 1000 keys
+func newListingClient(prefix string, n int) *s3testing.TransferManagerLoggingClient {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.ListObjectsV2Fn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+		start, _ := strconv.Atoi(aws.ToString(params.ContinuationToken))
+		out := &s3.ListObjectsV2Output{}
+		for i := start; i < min(start+1000, n); i++ {
+			out.Contents = append(out.Contents, s3types.Object{Key: aws.String(fmt.Sprintf("%s%05d", prefix, i))})
+		}
+		if start+1000 < n {
+			out.IsTruncated = aws.Bool(true)
+			out.NextContinuationToken = aws.String(strconv.Itoa(start + 1000))
+		}
+		return out, nil
+	}
+	return c
+}
+
+// batchDeletedKeys returns the keys, with their versions if any, of the
+// DeleteObjects requests of the client
+func batchDeletedKeys(c *s3testing.TransferManagerLoggingClient) (keys []string, batches []int) {
+	for _, p := range c.Params {
+		in, ok := p.(*s3.DeleteObjectsInput)
+		if !ok {
+			continue
+		}
+		batches = append(batches, len(in.Delete.Objects))
+		for _, o := range in.Delete.Objects {
+			key := aws.ToString(o.Key)
+			if o.VersionId != nil {
+				key += "@" + aws.ToString(o.VersionId)
+			}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	sort.Ints(batches)
+	return keys, batches
+}
+
+func TestDeleteObjectsPrefix(t *testing.T) {
+	c := newListingClient("logs/", 2500)
+	mgr := New(c, Options{Concurrency: 2})
+
+	out, err := mgr.DeleteObjects(context.Background(), &DeleteObjectsInput{
+		Bucket: "bucket",
+		Prefix: "logs/",
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if e, a := 2500, out.ObjectsDeleted; e != a {
+		t.Errorf("expect %d objects deleted, got %d", e, a)
+	}
+	keys, batches := batchDeletedKeys(c)
+	if diff := cmpDiff([]int{500, 1000, 1000}, batches); diff != "" {
+		t.Error(diff)
+	}
+	if e, a := 2500, len(keys); e != a {
+		t.Errorf("expect %d keys deleted, got %d", e, a)
+	}
+	for _, p := range c.Params {
+		if in, ok := p.(*s3.ListObjectsV2Input); ok && aws.ToString(in.Prefix) != "logs/" {
+			t.Errorf("expect objects listed under logs/, got %q", aws.ToString(in.Prefix))
+		}
+	}
+}
+
+func TestDeleteObjectsAllVersions(t *testing.T) {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.ListObjectVersionsFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListObjectVersionsInput) (*s3.ListObjectVersionsOutput, error) {
+		return &s3.ListObjectVersionsOutput{
+			Versions: []s3types.ObjectVersion{
+				{Key: aws.String("a"), VersionId: aws.String("v2"), IsLatest: aws.Bool(true)},
+				{Key: aws.String("a"), VersionId: aws.String("v1")},
+				{Key: aws.String("keep"), VersionId: aws.String("v1"), IsLatest: aws.Bool(true)},
+			},
+			DeleteMarkers: []s3types.DeleteMarkerEntry{
+				{Key: aws.String("b"), VersionId: aws.String("m1"), IsLatest: aws.Bool(true)},
+			},
+		}, nil
+	}
+	mgr := New(c, Options{})
+
+	var markers int
+	out, err := mgr.DeleteObjects(context.Background(), &DeleteObjectsInput{
+		Bucket:      "bucket",
+		AllVersions: true,
+		Filter: func(obj types.ObjectVersion) bool {
+			if obj.IsDeleteMarker {
+				markers++
+			}
+			return obj.Key != "keep"
+		},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if e, a := 3, out.ObjectsDeleted; e != a {
+		t.Errorf("expect %d objects deleted, got %d", e, a)
+	}
+	keys, _ := batchDeletedKeys(c)
+	if diff := cmpDiff([]string{"a@v1", "a@v2", "b@m1"}, keys); diff != "" {
+		t.Error(diff)
+	}
+	if e, a := 1, markers; e != a {
+		t.Errorf("expect %d delete marker, got %d", e, a)
+	}
+}
+
+func TestDeleteObjectsErrors(t *testing.T) {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	attempts := map[string]int{}
+	c.DeleteObjectsFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error) {
+		out := &s3.DeleteObjectsOutput{}
+		for _, o := range params.Delete.Objects {
+			key := aws.ToString(o.Key)
+			attempts[key]++
+			var code, message string
+			switch {
+			case key == "slow" && attempts[key] < 3:
+				code, message = "SlowDown", "Please reduce your request rate."
+			case key == "always-slow":
+				code, message = "SlowDown", "Please reduce your request rate."
+			case key == "held":
+				code, message = "AccessDenied", "Access Denied because object protected by legal hold"
+			case key == "retained":
+				code, message = "AccessDenied", "Object is under retention"
+			case key == "denied":
+				code, message = "AccessDenied", "Access Denied"
+			default:
+				continue
+			}
+			out.Errors = append(out.Errors, s3types.Error{Key: o.Key, Code: aws.String(code), Message: aws.String(message)})
+		}
+		return out, nil
+	}
+	mgr := New(c, Options{})
+
+	out, err := mgr.DeleteObjects(context.Background(), &DeleteObjectsInput{
+		Bucket:     "bucket",
+		Objects:    NewKeySliceIterator([]string{"ok", "slow", "always-slow", "held", "retained", "denied"}),
+		MaxRetries: 2,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if e, a := 2, out.ObjectsDeleted; e != a {
+		t.Errorf("expect %d objects deleted, got %d", e, a)
+	}
+	if e, a := 3, attempts["always-slow"]; e != a {
+		t.Errorf("expect %d attempts, got %d", e, a)
+	}
+	var protected, failed []string
+	for _, f := range out.Protected {
+		protected = append(protected, f.Key)
+	}
+	for _, f := range out.Failures {
+		failed = append(failed, f.Key+":"+f.Code)
+	}
+	if diff := cmpDiff([]string{"held", "retained"}, protected); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmpDiff([]string{"always-slow:SlowDown", "denied:AccessDenied"}, failed); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDeleteObjectsRequestError(t *testing.T) {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.DeleteObjectsFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error) {
+		return nil, fmt.Errorf("connection reset")
+	}
+	mgr := New(c, Options{})
+
+	out, err := mgr.DeleteObjects(context.Background(), &DeleteObjectsInput{
+		Bucket:  "bucket",
+		Objects: NewKeySliceIterator([]string{"a", "b"}),
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 2, len(out.Failures); e != a {
+		t.Fatalf("expect %d failures, got %d", e, a)
+	}
+	if e, a := "connection reset", out.Failures[0].Err.Error(); !strings.Contains(a, e) {
+		t.Errorf("expect error to contain %q, got %q", e, a)
+	}
+}
+
+func TestDeleteObjectsDryRun(t *testing.T) {
+	c := newListingClient("", 1500)
+	mgr := New(c, Options{})
+
+	out, err := mgr.DeleteObjects(context.Background(), &DeleteObjectsInput{
+		Bucket: "bucket",
+		DryRun: true,
+		Filter: func(obj types.ObjectVersion) bool {
+			return strings.HasSuffix(obj.Key, "0")
+		},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if e, a := 150, out.ObjectsDeleted; e != a {
+		t.Errorf("expect %d objects, got %d", e, a)
+	}
+	if e, a := 150, len(out.Objects); e != a {
+		t.Errorf("expect %d objects reported, got %d", e, a)
+	}
+	if keys, _ := batchDeletedKeys(c); len(keys) != 0 {
+		t.Errorf("expect no object deleted in a dry run, got %d", len(keys))
+	}
+}
+
+func TestDeleteObjectsMaxDeletes(t *testing.T) {
+	c := newListingClient("", 1500)
+	mgr := New(c, Options{})
+
+	_, err := mgr.DeleteObjects(context.Background(), &DeleteObjectsInput{
+		Bucket:     "bucket",
+		MaxDeletes: 1000,
+	})
+	if !errors.Is(err, ErrMaxDeletesExceeded) {
+		t.Fatalf("expect ErrMaxDeletesExceeded, got %v", err)
+	}
+	if keys, _ := batchDeletedKeys(c); len(keys) != 0 {
+		t.Errorf("expect no object deleted, got %d", len(keys))
+	}
+
+	out, err := mgr.DeleteObjects(context.Background(), &DeleteObjectsInput{
+		Bucket:     "bucket",
+		MaxDeletes: 1500,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 1500, out.ObjectsDeleted; e != a {
+		t.Errorf("expect %d objects deleted, got %d", e, a)
+	}
+}
+
+func TestDeleteObjectsListError(t *testing.T) {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.ListObjectsV2Fn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+		return nil, fmt.Errorf("access denied")
+	}
+	mgr := New(c, Options{})
+
+	if _, err := mgr.DeleteObjects(context.Background(), &DeleteObjectsInput{Bucket: "bucket"}); err == nil {
+		t.Fatal("expect error, got none")
+	}
+}
+
+func TestDeleteObjectsValidation(t *testing.T) {
+	mgr := New(&s3testing.TransferManagerLoggingClient{}, Options{})
+	cases := map[string]*DeleteObjectsInput{
+		"no input":  nil,
+		"no bucket": {Prefix: "p"},
+		"objects and prefix": {
+			Bucket:  "bucket",
+			Objects: NewKeySliceIterator([]string{"a"}),
+			Prefix:  "p",
+		},
+		"negative max deletes": {Bucket: "bucket", MaxDeletes: -1},
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := mgr.DeleteObjects(context.Background(), input); err == nil {
+				t.Error("expect error, got none")
+			}
+		})
+	}
+}
@@ -19,7 +19,55 @@
 // Package transfermanager implements a high-level S3 client with support for the
 // following:
 //   - [Client.PutObject] - enhanced object write support w/ automatic
-//     multipart upload for large objects
+//     multipart upload for large objects, resumable w/ an
+//     [UploadCheckpointStore]
+//   - [Client.NewObjectWriter] - upload of data pushed by a producer, e.g. an
+//     encoder, through an io.Writer w/ the same part buffering
+//   - [Client.DownloadObject] - parallel ranged object download, resumable
+//     into a partially written file w/ a [DownloadCheckpointStore]
+//   - [Client.OpenObject] - random access reads of an object through an
+//     io.ReaderAt w/ a block cache and read-ahead, e.g. for Parquet or ZIP
+//   - [Client.UploadDirectory] - upload of a local directory tree w/ glob
+//     filters and a shared request concurrency budget
+//   - [Client.DownloadDirectory] - download of a key prefix to a local
+//     directory w/ path traversal protection and atomic file writes
+//   - [Client.UploadArchive] - streaming of a local directory tree into a
+//     single tar, tar.gz or zip object w/o a temporary file
+//   - [Client.OpenZipArchive] - listing and extraction of single members of
+//     a zip object from its central directory w/ ranged reads
+//   - [Client.Sync] - incremental mirroring of a local directory and a key
+//     prefix in either direction, w/ optional deletion and dry run
+//   - [Client.CopyObject] - server-side copy w/ automatic parallel
+//     UploadPartCopy for large objects
+//   - [Client.MigrateObject], [Client.MigrateObjects] - streaming migration
+//     from a bucket read w/ another client, e.g. of another service instance,
+//     w/ end-to-end checksum validation and skipping of migrated objects
+//   - [Client.DeleteObjects] - concurrent batched deletion of keys, versions
+//     or a key prefix w/ filters, retries, dry run and a max deletes limit
+//
+// Progress is reported through [ProgressListeners], per object and, for
+// directory transfers, in aggregate w/ throughput and ETA. [ProgressBar] is a
+// ready-made listener rendering a terminal progress bar.
+//
+// Transfers can be capped to a number of bytes per second w/
+// [Options.MaxBytesPerSecond], enforced by a [BandwidthLimiter] whose limit can
+// be changed while transfers are running. Likewise, the memory of the buffers
+// holding parts is bounded across all transfers by
+// [Options.MaxBufferMemoryBytes], whose [BufferPool] reports its utilisation.
+//
+// With [Options.AdaptiveUpload], uploads of unknown size grow their part size
+// as part numbers climb, so that they can reach the maximum object size, and
+// uploads tune their concurrency from the observed throughput and throttling.
+//
+// Objects encrypted with a customer provided key (SSE-C) are read and written
+// w/ an [SSECustomerKey], which the operations apply to each of their
+// requests. [Client.RotateSSECustomerKey] re-encrypts an object w/ a new key,
+// and reads w/ the wrong key fail w/ an [SSECustomerKeyMismatchError].
+//
+// [PutObjectOutput.Checksum] is the composite or full-object checksum of an
+// uploaded object, which [ComputeChecksum] and [ComputeFileChecksum] compute
+// locally for a part size, and [DownloadObjectInput.ValidateObjectChecksum]
+// validates a download against it.
 //
 // The package also exposes several opt-in hooks that configure an
 // http.Transport that may convey performance/reliability enhancements in
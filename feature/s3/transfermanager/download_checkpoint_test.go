@@ -0,0 +1,294 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	smithy "github.com/aws/smithy-go"
+)
+
+// newResumableDownloadClient returns a client serving ranges of data with the
+// given ETag, failing the ranges starting at the offsets in failing.
+func newResumableDownloadClient(data []byte, etag string, failing ...int64) *s3testing.TransferManagerLoggingClient {
+	c, _, _, _, _, _ := s3testing.NewDownloadClient()
+	c.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		if m := aws.ToString(params.IfMatch); m != "" && m != etag {
+			return nil, &smithy.GenericAPIError{Code: "PreconditionFailed", Message: "At least one of the pre-conditions you specified did not hold"}
+		}
+
+		var start, end int64
+		fmt.Sscanf(aws.ToString(params.Range), "bytes=%d-%d", &start, &end)
+		for _, f := range failing {
+			if start == f {
+				return nil, fmt.Errorf("connection reset")
+			}
+		}
+		if end >= int64(len(data)) {
+			end = int64(len(data)) - 1
+		}
+		return &s3.GetObjectOutput{
+			Body:          io.NopCloser(bytes.NewReader(data[start : end+1])),
+			ContentLength: aws.Int64(end - start + 1),
+			ContentRange:  aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(data))),
+			ETag:          aws.String(etag),
+		}, nil
+	}
+	return c
+}
+
+func resumableDownloadData() []byte {
+	data := make([]byte, 3*minPartSizeBytes+5)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestDownloadObjectCheckpointResume(t *testing.T) {
+	ctx := context.Background()
+	data := resumableDownloadData()
+	store := NewFileDownloadCheckpointStore(filepath.Join(t.TempDir(), "object.checkpoint"))
+	w := types.NewWriteAtBuffer(make([]byte, len(data)))
+
+	c := newResumableDownloadClient(data, "etag-1", 2*minPartSizeBytes)
+	mgr := New(c, Options{Concurrency: 1})
+	_, err := mgr.DownloadObject(ctx, &DownloadObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		WriterAt:        w,
+		CheckpointStore: store,
+	})
+	if err == nil {
+		t.Fatal("expect error, got none")
+	}
+
+	checkpoint, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if checkpoint == nil {
+		t.Fatal("expect checkpoint, got none")
+	}
+	if e, a := "etag-1", checkpoint.ETag; e != a {
+		t.Errorf("expect etag %s, got %s", e, a)
+	}
+	if e, a := int64(len(data)), checkpoint.ObjectSize; e != a {
+		t.Errorf("expect object size %d, got %d", e, a)
+	}
+	if diff := cmpDiff([]DownloadRange{{Start: 0, End: 2 * minPartSizeBytes}}, checkpoint.Ranges); len(diff) != 0 {
+		t.Errorf("unexpected ranges: %s", diff)
+	}
+
+	c = newResumableDownloadClient(data, "etag-1")
+	mgr = New(c, Options{Concurrency: 1})
+	out, err := mgr.DownloadObject(ctx, &DownloadObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		WriterAt:        w,
+		CheckpointStore: store,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	expectRanges := []string{
+		fmt.Sprintf("bytes=%d-%d", 2*minPartSizeBytes, 3*minPartSizeBytes-1),
+		fmt.Sprintf("bytes=%d-%d", 3*minPartSizeBytes, len(data)-1),
+	}
+	if diff := cmpDiff(expectRanges, c.RetrievedRanges); len(diff) != 0 {
+		t.Errorf("unexpected ranges: %s", diff)
+	}
+	if diff := cmpDiff([]string{"etag-1", "etag-1"}, c.Etags); len(diff) != 0 {
+		t.Errorf("expect every request to be conditional: %s", diff)
+	}
+	if !bytes.Equal(data, w.Bytes()) {
+		t.Error("expect downloaded data to match")
+	}
+	if e, a := int64(len(data)), out.ContentLength; e != a {
+		t.Errorf("expect content length %d, got %d", e, a)
+	}
+	if checkpoint, _ := store.Load(ctx); checkpoint != nil {
+		t.Errorf("expect checkpoint to be deleted, got %v", checkpoint)
+	}
+}
+
+func TestDownloadObjectCheckpointProgress(t *testing.T) {
+	ctx := context.Background()
+	data := resumableDownloadData()
+	store := NewFileDownloadCheckpointStore(filepath.Join(t.TempDir(), "object.checkpoint"))
+	w := types.NewWriteAtBuffer(make([]byte, len(data)))
+
+	download := func(c *s3testing.TransferManagerLoggingClient) (*mockListener, error) {
+		listener := &mockListener{}
+		_, err := New(c, Options{Concurrency: 1}).DownloadObject(ctx, &DownloadObjectInput{
+			Bucket:          "bucket",
+			Key:             "key",
+			WriterAt:        w,
+			CheckpointStore: store,
+		}, func(o *Options) {
+			o.ProgressListeners.Register(listener)
+		})
+		return listener, err
+	}
+	// the bytes transferred of the events are cumulative
+	transferred := func(l *mockListener) int64 {
+		if len(l.transfer) == 0 {
+			return 0
+		}
+		return l.transfer[len(l.transfer)-1].BytesTransferred
+	}
+
+	// a new download fails once its first two ranges are written
+	listener, err := download(newResumableDownloadClient(data, "etag-1", 2*minPartSizeBytes))
+	if err == nil {
+		t.Fatal("expect error, got none")
+	}
+	if e, a := int64(2*minPartSizeBytes), transferred(listener); e != a {
+		t.Errorf("expect %d bytes transferred, got %d", e, a)
+	}
+
+	// the resumed download reports the ranges written before as well
+	listener, err = download(newResumableDownloadClient(data, "etag-1"))
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := int64(len(data)), transferred(listener); e != a {
+		t.Errorf("expect %d bytes transferred, got %d", e, a)
+	}
+	if e, a := 1, len(listener.complete); e != a {
+		t.Fatalf("expect %d complete event, got %d", e, a)
+	}
+	if e, a := int64(len(data)), listener.complete[0].BytesTransferred; e != a {
+		t.Errorf("expect %d bytes transferred on completion, got %d", e, a)
+	}
+}
+
+func TestDownloadObjectCheckpointObjectChanged(t *testing.T) {
+	ctx := context.Background()
+	data := resumableDownloadData()
+	store := NewFileDownloadCheckpointStore(filepath.Join(t.TempDir(), "object.checkpoint"))
+	checkpoint := &DownloadCheckpoint{
+		Bucket:        "bucket",
+		Key:           "key",
+		ETag:          "etag-1",
+		ObjectSize:    int64(len(data)),
+		PartSizeBytes: minPartSizeBytes,
+		Ranges:        []DownloadRange{{Start: 0, End: minPartSizeBytes}},
+	}
+	if err := store.Save(ctx, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newResumableDownloadClient(data, "etag-2")
+	mgr := New(c, Options{})
+	_, err := mgr.DownloadObject(ctx, &DownloadObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		WriterAt:        types.NewWriteAtBuffer(make([]byte, len(data))),
+		CheckpointStore: store,
+	})
+	if !errors.Is(err, ErrObjectChanged) {
+		t.Fatalf("expect ErrObjectChanged, got %v", err)
+	}
+
+	// the checkpoint is kept so that the caller decides how to recover
+	if saved, _ := store.Load(ctx); saved == nil {
+		t.Error("expect checkpoint to be kept")
+	}
+}
+
+func TestDownloadObjectCheckpointComplete(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileDownloadCheckpointStore(filepath.Join(t.TempDir(), "object.checkpoint"))
+	if err := store.Save(ctx, &DownloadCheckpoint{
+		Bucket:        "bucket",
+		Key:           "key",
+		ETag:          "etag-1",
+		ObjectSize:    2 * minPartSizeBytes,
+		PartSizeBytes: minPartSizeBytes,
+		Ranges:        []DownloadRange{{Start: 0, End: 2 * minPartSizeBytes}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newResumableDownloadClient(nil, "etag-1")
+	mgr := New(c, Options{})
+	out, err := mgr.DownloadObject(ctx, &DownloadObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		WriterAt:        types.NewWriteAtBuffer(nil),
+		CheckpointStore: store,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 0, c.GetObjectInvocations; e != a {
+		t.Errorf("expect %d GetObject calls, got %d", e, a)
+	}
+	if e, a := "etag-1", out.ETag; e != a {
+		t.Errorf("expect etag %s, got %s", e, a)
+	}
+	if e, a := int64(2*minPartSizeBytes), out.ContentLength; e != a {
+		t.Errorf("expect content length %d, got %d", e, a)
+	}
+}
+
+func TestDownloadObjectCheckpointValidation(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileDownloadCheckpointStore(filepath.Join(t.TempDir(), "object.checkpoint"))
+	c := newResumableDownloadClient(nil, "etag-1")
+	mgr := New(c, Options{})
+
+	_, err := mgr.DownloadObject(ctx, &DownloadObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		Range:           "bytes=0-10",
+		WriterAt:        types.NewWriteAtBuffer(nil),
+		CheckpointStore: store,
+	})
+	if err == nil {
+		t.Fatal("expect error for range, got none")
+	}
+
+	if err := store.Save(ctx, &DownloadCheckpoint{Bucket: "bucket", Key: "other", PartSizeBytes: minPartSizeBytes}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = mgr.DownloadObject(ctx, &DownloadObjectInput{
+		Bucket:          "bucket",
+		Key:             "key",
+		WriterAt:        types.NewWriteAtBuffer(nil),
+		CheckpointStore: store,
+	})
+	if err == nil || !strings.Contains(err.Error(), "checkpoint is for bucket/other") {
+		t.Errorf("expect checkpoint mismatch error, got %v", err)
+	}
+}
+
+func TestDownloadCheckpointAdd(t *testing.T) {
+	var c DownloadCheckpoint
+	c.add(20, 30)
+	c.add(0, 10)
+	c.add(10, 20)
+	c.add(40, 50)
+
+	expect := []DownloadRange{{Start: 0, End: 30}, {Start: 40, End: 50}}
+	if diff := cmpDiff(expect, c.Ranges); len(diff) != 0 {
+		t.Errorf("unexpected ranges: %s", diff)
+	}
+	if !c.covers(5, 25) {
+		t.Error("expect 5-25 to be covered")
+	}
+	if c.covers(25, 45) {
+		t.Error("expect 25-45 not to be covered")
+	}
+}
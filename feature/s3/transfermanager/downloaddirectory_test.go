@@ -0,0 +1,261 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+var downloadDirectoryModTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newDirectoryDownloadClient returns a client serving objects whose content is
+// their key, listed two per page. Keys in failing return an error on GET.
+func newDirectoryDownloadClient(keys []string, failing ...string) *s3testing.TransferManagerLoggingClient {
+	c, _, _, _, _, _ := s3testing.NewDownloadClient()
+	c.ListObjectsV2Fn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+		var matched []string
+		for _, k := range keys {
+			if strings.HasPrefix(k, aws.ToString(params.Prefix)) {
+				matched = append(matched, k)
+			}
+		}
+		start := 0
+		if params.ContinuationToken != nil {
+			fmt.Sscan(*params.ContinuationToken, &start)
+		}
+		end := start + 2
+		out := &s3.ListObjectsV2Output{}
+		if end < len(matched) {
+			out.IsTruncated = aws.Bool(true)
+			out.NextContinuationToken = aws.String(fmt.Sprint(end))
+		} else {
+			end = len(matched)
+		}
+		for _, k := range matched[start:end] {
+			out.Contents = append(out.Contents, s3types.Object{
+				Key:          aws.String(k),
+				Size:         aws.Int64(int64(len(k))),
+				LastModified: aws.Time(downloadDirectoryModTime),
+			})
+		}
+		return out, nil
+	}
+	c.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		key := aws.ToString(params.Key)
+		for _, f := range failing {
+			if key == f {
+				return nil, fmt.Errorf("get %s failed", key)
+			}
+		}
+		return &s3.GetObjectOutput{
+			Body:          io.NopCloser(bytes.NewReader([]byte(key))),
+			ContentLength: aws.Int64(int64(len(key))),
+			PartsCount:    aws.Int32(1),
+			LastModified:  aws.Time(downloadDirectoryModTime),
+		}, nil
+	}
+	return c
+}
+
+// readTree returns the relative slash separated paths of the files in dir.
+func readTree(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestDownloadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	c := newDirectoryDownloadClient([]string{
+		"backup/a.txt",
+		"backup/b.log",
+		"backup/sub/",
+		"backup/sub/c.txt",
+		"backup/sub/deeper/d.txt",
+		"other/e.txt",
+	})
+	mgr := New(c, Options{})
+
+	var called []string
+	out, err := mgr.DownloadDirectory(context.Background(), &DownloadDirectoryInput{
+		Bucket:      "bucket",
+		Destination: dir,
+		KeyPrefix:   "backup/",
+		Filter: func(obj types.Object) bool {
+			return strings.HasSuffix(obj.Key, ".txt")
+		},
+		Callback: func(in *DownloadObjectInput) {
+			called = append(called, in.Key)
+		},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if e, a := 3, out.ObjectsDownloaded; e != a {
+		t.Errorf("expect %d objects downloaded, got %d", e, a)
+	}
+	if e, a := int64(len("backup/a.txt")+len("backup/sub/c.txt")+len("backup/sub/deeper/d.txt")), out.BytesDownloaded; e != a {
+		t.Errorf("expect %d bytes downloaded, got %d", e, a)
+	}
+	if e, a := 3, len(called); e != a {
+		t.Errorf("expect callback invoked %d times, got %d", e, a)
+	}
+
+	files := readTree(t, dir)
+	if diff := cmpDiff([]string{"a.txt", "sub/c.txt", "sub/deeper/d.txt"}, files); len(diff) != 0 {
+		t.Errorf("unexpected files: %s", diff)
+	}
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e, a := "backup/"+f, string(b); e != a {
+			t.Errorf("expect %s to contain %q, got %q", f, e, a)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e, a := downloadDirectoryModTime, info.ModTime(); !e.Equal(a) {
+			t.Errorf("expect %s mtime %v, got %v", f, e, a)
+		}
+	}
+}
+
+func TestDownloadDirectoryPathTraversal(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "dest")
+	c := newDirectoryDownloadClient([]string{
+		"a.txt",
+		"../evil.txt",
+		"sub/../../evil.txt",
+		"/abs.txt",
+	})
+	mgr := New(c, Options{})
+
+	out, err := mgr.DownloadDirectory(context.Background(), &DownloadDirectoryInput{
+		Bucket:        "bucket",
+		Destination:   dir,
+		FailurePolicy: types.FailurePolicyContinue,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if e, a := 2, out.ObjectsDownloaded; e != a {
+		t.Errorf("expect %d objects downloaded, got %d", e, a)
+	}
+	var failed []string
+	for _, f := range out.Failures {
+		failed = append(failed, f.Key)
+		if len(f.Path) != 0 {
+			t.Errorf("expect no path for %s, got %s", f.Key, f.Path)
+		}
+	}
+	if diff := cmpDiff([]string{"../evil.txt", "sub/../../evil.txt"}, failed); len(diff) != 0 {
+		t.Errorf("unexpected failures: %s", diff)
+	}
+	if diff := cmpDiff([]string{"dest/a.txt", "dest/abs.txt"}, readTree(t, root)); len(diff) != 0 {
+		t.Errorf("unexpected files: %s", diff)
+	}
+}
+
+func TestDownloadDirectoryFailurePolicy(t *testing.T) {
+	keys := []string{"a.txt", "b.txt", "c.txt", "d.txt"}
+
+	t.Run("continue", func(t *testing.T) {
+		dir := t.TempDir()
+		c := newDirectoryDownloadClient(keys, "b.txt")
+		mgr := New(c, Options{})
+
+		out, err := mgr.DownloadDirectory(context.Background(), &DownloadDirectoryInput{
+			Bucket:        "bucket",
+			Destination:   dir,
+			FailurePolicy: types.FailurePolicyContinue,
+		})
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if e, a := 3, out.ObjectsDownloaded; e != a {
+			t.Errorf("expect %d objects downloaded, got %d", e, a)
+		}
+		if e, a := 1, out.ObjectsFailed; e != a {
+			t.Fatalf("expect %d objects failed, got %d", e, a)
+		}
+		if e, a := filepath.Join(dir, "b.txt"), out.Failures[0].Path; e != a {
+			t.Errorf("expect failure path %s, got %s", e, a)
+		}
+		// the temp file of the failed object must be removed
+		if diff := cmpDiff([]string{"a.txt", "c.txt", "d.txt"}, readTree(t, dir)); len(diff) != 0 {
+			t.Errorf("unexpected files: %s", diff)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		dir := t.TempDir()
+		c := newDirectoryDownloadClient(keys, "a.txt")
+		mgr := New(c, Options{})
+
+		out, err := mgr.DownloadDirectory(context.Background(), &DownloadDirectoryInput{
+			Bucket:      "bucket",
+			Destination: dir,
+		}, func(o *Options) {
+			o.Concurrency = 1
+		})
+		if err == nil {
+			t.Fatal("expect error, got none")
+		}
+		if e, a := "failed to download a.txt", err.Error(); !strings.Contains(a, e) {
+			t.Errorf("expect error to contain %q, got %q", e, a)
+		}
+		if e, a := 1, len(out.Failures); e != a {
+			t.Errorf("expect %d failures, got %d", e, a)
+		}
+		if e, a := 0, len(readTree(t, dir)); e != a {
+			t.Errorf("expect %d files, got %d", e, a)
+		}
+	})
+}
+
+func TestDownloadDirectoryValidation(t *testing.T) {
+	c := newDirectoryDownloadClient(nil)
+	mgr := New(c, Options{})
+
+	if _, err := mgr.DownloadDirectory(context.Background(), &DownloadDirectoryInput{Destination: t.TempDir()}); err == nil {
+		t.Error("expect error for missing bucket, got none")
+	}
+	if _, err := mgr.DownloadDirectory(context.Background(), &DownloadDirectoryInput{Bucket: "bucket"}); err == nil {
+		t.Error("expect error for missing destination, got none")
+	}
+}
@@ -0,0 +1,115 @@
+package transfermanager
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// pathFilter selects the files of a directory transfer by matching their
+// slash-separated relative paths against include and exclude globs.
+//
+// Patterns use the syntax of path.Match for each path segment, and a "**"
+// segment matches any number of segments, e.g. "logs/**/*.gz". A pattern
+// without a slash is matched against the base name of the path, so "*.tmp"
+// matches temporary files in every directory.
+type pathFilter struct {
+	include []string
+	exclude []string
+}
+
+func newPathFilter(include, exclude []string) (*pathFilter, error) {
+	for _, patterns := range [][]string{include, exclude} {
+		for _, p := range patterns {
+			if err := validateGlob(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &pathFilter{include: include, exclude: exclude}, nil
+}
+
+// matchFile returns whether the file at the relative path is selected: it
+// matches an include pattern, if any are set, and no exclude pattern.
+func (f *pathFilter) matchFile(rel string) bool {
+	if f.excluded(rel) {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, p := range f.include {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath returns whether the file at the relative path is selected, and
+// none of its parent directories is excluded. It selects the same files as a
+// walk does, for paths which are not walked, such as object keys.
+func (f *pathFilter) matchPath(rel string) bool {
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && f.excluded(rel[:i]) {
+			return false
+		}
+	}
+	return f.matchFile(rel)
+}
+
+// excluded returns whether the relative path matches an exclude pattern.
+// Directories which are excluded are not walked.
+func (f *pathFilter) excluded(rel string) bool {
+	for _, p := range f.exclude {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateGlob(pattern string) error {
+	if len(pattern) == 0 {
+		return fmt.Errorf("empty glob pattern")
+	}
+	for _, seg := range strings.Split(pattern, "/") {
+		if seg == "**" {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchGlob returns whether the slash-separated path matches the pattern.
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,64 @@
+package transfermanager
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		expect        bool
+	}{
+		{"*.txt", "a.txt", true},
+		{"*.txt", "dir/sub/a.txt", true},
+		{"*.txt", "a.txt.bak", false},
+		{"dir/*.txt", "dir/a.txt", true},
+		{"dir/*.txt", "dir/sub/a.txt", false},
+		{"dir/**/*.txt", "dir/a.txt", true},
+		{"dir/**/*.txt", "dir/sub/deeper/a.txt", true},
+		{"dir/**", "dir/sub/a.txt", true},
+		{"dir/**", "other/a.txt", false},
+		{"**/cache", "a/b/cache", true},
+		{"**/cache", "cache", true},
+		{"logs/2024-0?/*.gz", "logs/2024-01/x.gz", true},
+		{"logs/2024-0?/*.gz", "logs/2024-10/x.gz", false},
+	}
+
+	for _, c := range cases {
+		if e, a := c.expect, matchGlob(c.pattern, c.name); e != a {
+			t.Errorf("expect %q match %q to be %v, got %v", c.pattern, c.name, e, a)
+		}
+	}
+}
+
+func TestPathFilter(t *testing.T) {
+	f, err := newPathFilter([]string{"*.go", "docs/**"}, []string{"vendor", "*_test.go"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	cases := map[string]bool{
+		"main.go":           true,
+		"pkg/util.go":       true,
+		"pkg/util_test.go":  false,
+		"docs/guide/a.md":   true,
+		"README.md":         false,
+		"vendor/lib/lib.go": true, // directory exclusion is applied by the walker
+	}
+	for name, expect := range cases {
+		if e, a := expect, f.matchFile(name); e != a {
+			t.Errorf("expect %q to be selected %v, got %v", name, e, a)
+		}
+	}
+	if !f.excluded("vendor") {
+		t.Errorf("expect vendor directory to be excluded")
+	}
+	if f.matchPath("vendor/lib/lib.go") {
+		t.Errorf("expect path under excluded directory not to be selected")
+	}
+	if !f.matchPath("pkg/util.go") {
+		t.Errorf("expect pkg/util.go to be selected")
+	}
+
+	if _, err := newPathFilter([]string{"[a-"}, nil); err == nil {
+		t.Errorf("expect invalid pattern error")
+	}
+}
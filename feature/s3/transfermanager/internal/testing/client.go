@@ -15,6 +15,7 @@ import (
 
 	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
 	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
 )
 
 var etag = "myetag"
@@ -56,6 +57,15 @@ type TransferManagerLoggingClient struct {
 	CompleteMultipartUploadFn func(*TransferManagerLoggingClient, *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error)
 	AbortMultipartUploadFn    func(*TransferManagerLoggingClient, *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error)
 	GetObjectFn               func(*TransferManagerLoggingClient, *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	ListObjectsV2Fn           func(*TransferManagerLoggingClient, *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error)
+	HeadObjectFn              func(*TransferManagerLoggingClient, *s3.HeadObjectInput) (*s3.HeadObjectOutput, error)
+	CopyObjectFn              func(*TransferManagerLoggingClient, *s3.CopyObjectInput) (*s3.CopyObjectOutput, error)
+	UploadPartCopyFn          func(*TransferManagerLoggingClient, *s3.UploadPartCopyInput) (*s3.UploadPartCopyOutput, error)
+	GetObjectTaggingFn        func(*TransferManagerLoggingClient, *s3.GetObjectTaggingInput) (*s3.GetObjectTaggingOutput, error)
+	ListLegalHoldsFn          func(*TransferManagerLoggingClient, *s3.ListLegalHoldsInput) (*s3.ListLegalHoldsOutput, error)
+	ListPartsFn               func(*TransferManagerLoggingClient, *s3.ListPartsInput) (*s3.ListPartsOutput, error)
+	DeleteObjectsFn           func(*TransferManagerLoggingClient, *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error)
+	ListObjectVersionsFn      func(*TransferManagerLoggingClient, *s3.ListObjectVersionsInput) (*s3.ListObjectVersionsOutput, error)
 }
 
 func (c *TransferManagerLoggingClient) simulateHTTPClientOption(optFns ...func(*s3.Options)) error {
@@ -236,6 +246,10 @@ func (c *TransferManagerLoggingClient) HeadObject(ctx context.Context, params *s
 	c.m.Lock()
 	defer c.m.Unlock()
 
+	if c.HeadObjectFn != nil {
+		return c.HeadObjectFn(c, params)
+	}
+
 	return &s3.HeadObjectOutput{
 		PartsCount:    aws.Int32(c.PartsCount),
 		ContentLength: aws.Int64(int64(len(c.Data))),
@@ -243,6 +257,155 @@ func (c *TransferManagerLoggingClient) HeadObject(ctx context.Context, params *s
 	}, nil
 }
 
+// ListObjectsV2 is the S3 ListObjectsV2 API
+func (c *TransferManagerLoggingClient) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.ListObjectsV2Fn != nil {
+		return c.ListObjectsV2Fn(c, params)
+	}
+
+	return &s3.ListObjectsV2Output{}, nil
+}
+
+// CopyObject is the S3 CopyObject API.
+func (c *TransferManagerLoggingClient) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("CopyObject", params)
+
+	if err := c.simulateHTTPClientOption(optFns...); err != nil {
+		return nil, err
+	}
+
+	if c.CopyObjectFn != nil {
+		return c.CopyObjectFn(c, params)
+	}
+
+	return &s3.CopyObjectOutput{
+		CopyObjectResult: &types.CopyObjectResult{ETag: aws.String(etag)},
+		VersionId:        aws.String("VERSION-ID"),
+	}, nil
+}
+
+// UploadPartCopy is the S3 UploadPartCopy API.
+func (c *TransferManagerLoggingClient) UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("UploadPartCopy", params)
+
+	if err := c.simulateHTTPClientOption(optFns...); err != nil {
+		return nil, err
+	}
+
+	if c.UploadPartCopyFn != nil {
+		return c.UploadPartCopyFn(c, params)
+	}
+
+	return &s3.UploadPartCopyOutput{
+		CopyPartResult: &types.CopyPartResult{
+			ETag: aws.String(fmt.Sprintf("ETAG%d", *params.PartNumber)),
+		},
+	}, nil
+}
+
+// GetObjectTagging is the S3 GetObjectTagging API.
+func (c *TransferManagerLoggingClient) GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("GetObjectTagging", params)
+
+	if c.GetObjectTaggingFn != nil {
+		return c.GetObjectTaggingFn(c, params)
+	}
+
+	return &s3.GetObjectTaggingOutput{}, nil
+}
+
+// ListLegalHolds is the S3 ListLegalHolds API.
+func (c *TransferManagerLoggingClient) ListLegalHolds(ctx context.Context, params *s3.ListLegalHoldsInput, optFns ...func(*s3.Options)) (*s3.ListLegalHoldsOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("ListLegalHolds", params)
+
+	if c.ListLegalHoldsFn != nil {
+		return c.ListLegalHoldsFn(c, params)
+	}
+
+	return &s3.ListLegalHoldsOutput{}, nil
+}
+
+// AddLegalHold is the S3 AddLegalHold API.
+func (c *TransferManagerLoggingClient) AddLegalHold(ctx context.Context, params *s3.AddLegalHoldInput, optFns ...func(*s3.Options)) (*s3.AddLegalHoldOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("AddLegalHold", params)
+
+	return &s3.AddLegalHoldOutput{}, nil
+}
+
+// ExtendObjectRetention is the S3 ExtendObjectRetention API.
+func (c *TransferManagerLoggingClient) ExtendObjectRetention(ctx context.Context, params *s3.ExtendObjectRetentionInput, optFns ...func(*s3.Options)) (*s3.ExtendObjectRetentionOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("ExtendObjectRetention", params)
+
+	return &s3.ExtendObjectRetentionOutput{}, nil
+}
+
+// ListParts is the S3 ListParts API.
+func (c *TransferManagerLoggingClient) ListParts(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("ListParts", params)
+
+	if c.ListPartsFn != nil {
+		return c.ListPartsFn(c, params)
+	}
+
+	return &s3.ListPartsOutput{}, nil
+}
+
+// DeleteObjects is the S3 DeleteObjects API.
+func (c *TransferManagerLoggingClient) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("DeleteObjects", params)
+
+	if c.DeleteObjectsFn != nil {
+		return c.DeleteObjectsFn(c, params)
+	}
+
+	out := &s3.DeleteObjectsOutput{}
+	for _, o := range params.Delete.Objects {
+		out.Deleted = append(out.Deleted, types.DeletedObject{Key: o.Key, VersionId: o.VersionId})
+	}
+	return out, nil
+}
+
+// ListObjectVersions is the S3 ListObjectVersions API.
+func (c *TransferManagerLoggingClient) ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.traceOperation("ListObjectVersions", params)
+
+	if c.ListObjectVersionsFn != nil {
+		return c.ListObjectVersionsFn(c, params)
+	}
+
+	return &s3.ListObjectVersionsOutput{}, nil
+}
+
 // NewUploadLoggingClient returns a new TransferManagerLoggingClient for upload testing.
 func NewUploadLoggingClient(ignoredOps []string) (*TransferManagerLoggingClient, *[]string, *[]interface{}) {
 	c := &TransferManagerLoggingClient{
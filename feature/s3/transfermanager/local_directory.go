@@ -0,0 +1,107 @@
+package transfermanager
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+)
+
+// localFile is a regular file found when walking a local directory.
+type localFile struct {
+	// path of the file on the local filesystem
+	path string
+
+	// slash-separated path of the file relative to the walked directory
+	rel string
+
+	size    int64
+	modTime time.Time
+}
+
+// localWalker walks a local directory tree, calling fn for each selected
+// regular file. Paths which cannot be read are passed to fn along with the
+// error. The walk stops at the first error returned by fn.
+type localWalker struct {
+	symlinks types.SymlinkPolicy
+	filter   *pathFilter
+	fn       func(localFile, error) error
+
+	// real paths of the directories walked so far when following symlinks
+	visited map[string]struct{}
+}
+
+func walkLocalDirectory(ctx context.Context, root string, symlinks types.SymlinkPolicy, filter *pathFilter, fn func(localFile, error) error) error {
+	w := &localWalker{
+		symlinks: symlinks,
+		filter:   filter,
+		fn:       fn,
+		visited:  map[string]struct{}{},
+	}
+	return w.walkDir(ctx, root, "")
+}
+
+func (w *localWalker) walkDir(ctx context.Context, dir, rel string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if w.symlinks == types.SymlinkPolicyFollow {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return w.fn(localFile{path: dir, rel: rel}, err)
+		}
+		if _, ok := w.visited[real]; ok {
+			return nil
+		}
+		w.visited[real] = struct{}{}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return w.fn(localFile{path: dir, rel: rel}, err)
+	}
+
+	for _, e := range entries {
+		full := filepath.Join(dir, e.Name())
+		r := path.Join(rel, e.Name())
+		if w.filter.excluded(r) {
+			continue
+		}
+
+		var info fs.FileInfo
+		if e.Type()&fs.ModeSymlink != 0 {
+			if w.symlinks != types.SymlinkPolicyFollow {
+				continue
+			}
+			info, err = os.Stat(full)
+		} else {
+			info, err = e.Info()
+		}
+		if err != nil {
+			if err := w.fn(localFile{path: full, rel: r}, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch {
+		case info.IsDir():
+			if err := w.walkDir(ctx, full, r); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if !w.filter.matchFile(r) {
+				continue
+			}
+			if err := w.fn(localFile{path: full, rel: r, size: info.Size(), modTime: info.ModTime()}, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,652 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+type migrateSourceObject struct {
+	data []byte
+	// size of the parts the object was uploaded in, zero if it was uploaded
+	// in a single request
+	partSize int64
+	etag     string
+}
+
+// objectETag returns the ETag S3 assigns to data uploaded in parts of
+// partSize, or in a single request if partSize is zero.
+func objectETag(data []byte, partSize int64) string {
+	if partSize == 0 {
+		sum := md5.Sum(data)
+		return `"` + hex.EncodeToString(sum[:]) + `"`
+	}
+	sums := md5.New()
+	parts := 0
+	for first := int64(0); first < int64(len(data)); first += partSize {
+		sum := md5.Sum(data[first:min(first+partSize, int64(len(data)))])
+		sums.Write(sum[:])
+		parts++
+	}
+	return `"` + hex.EncodeToString(sums.Sum(nil)) + "-" + strconv.Itoa(parts) + `"`
+}
+
+func newMigrateSourceClient(objects map[string]*migrateSourceObject) *s3testing.TransferManagerLoggingClient {
+	for _, o := range objects {
+		if o.etag == "" {
+			o.etag = objectETag(o.data, o.partSize)
+		}
+	}
+
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		o, ok := objects[aws.ToString(params.Key)]
+		if !ok {
+			return nil, &smithy.GenericAPIError{Code: "NotFound"}
+		}
+		size := int64(len(o.data))
+		if n := aws.ToInt32(params.PartNumber); n > 0 {
+			size = min(o.partSize, size-int64(n-1)*o.partSize)
+		}
+		return &s3.HeadObjectOutput{
+			ContentLength: aws.Int64(size),
+			ContentType:   aws.String("text/plain"),
+			ETag:          aws.String(o.etag),
+			Metadata:      map[string]string{"owner": "team-a"},
+			VersionId:     aws.String("SOURCE-VERSION"),
+		}, nil
+	}
+	c.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		o := objects[aws.ToString(params.Key)]
+		if aws.ToString(params.IfMatch) != o.etag {
+			return nil, &smithy.GenericAPIError{Code: "PreconditionFailed"}
+		}
+		start, end := int64(0), int64(len(o.data))
+		if n := aws.ToInt32(params.PartNumber); n > 0 {
+			start = int64(n-1) * o.partSize
+			end = min(start+o.partSize, end)
+		} else if params.Range != nil {
+			fmt.Sscanf(aws.ToString(params.Range), "bytes=%d-%d", &start, &end)
+			end++
+		}
+		return &s3.GetObjectOutput{
+			Body:          io.NopCloser(bytes.NewReader(o.data[start:end])),
+			ContentLength: aws.Int64(end - start),
+			ETag:          aws.String(o.etag),
+		}, nil
+	}
+	c.GetObjectTaggingFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectTaggingInput) (*s3.GetObjectTaggingOutput, error) {
+		return &s3.GetObjectTaggingOutput{
+			TagSet: []s3types.Tag{{Key: aws.String("project"), Value: aws.String("x")}},
+		}, nil
+	}
+	return c
+}
+
+func newMigrateDestinationClient(existing map[string]string) *s3testing.TransferManagerLoggingClient {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		etag, ok := existing[aws.ToString(params.Key)]
+		if !ok {
+			return nil, &smithy.GenericAPIError{Code: "NotFound"}
+		}
+		return &s3.HeadObjectOutput{
+			ContentLength: aws.Int64(int64(len(etag))),
+			ETag:          aws.String(etag),
+		}, nil
+	}
+	return c
+}
+
+// writtenObject returns the content written to the destination by a single
+// PutObject or the parts of a multipart upload.
+func writtenObject(t *testing.T, c *s3testing.TransferManagerLoggingClient) []byte {
+	t.Helper()
+	parts := map[int32][]byte{}
+	for _, p := range c.Params {
+		switch in := p.(type) {
+		case *s3.PutObjectInput:
+			b, _ := io.ReadAll(in.Body)
+			return b
+		case *s3.UploadPartInput:
+			b, _ := io.ReadAll(in.Body)
+			parts[aws.ToInt32(in.PartNumber)] = b
+		}
+	}
+	var nums []int
+	for n := range parts {
+		nums = append(nums, int(n))
+	}
+	sort.Ints(nums)
+	var data []byte
+	for _, n := range nums {
+		data = append(data, parts[int32(n)]...)
+	}
+	return data
+}
+
+func migrateTestData(n int64) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestMigrateObjectSingle(t *testing.T) {
+	data := migrateTestData(1024)
+	src := newMigrateSourceClient(map[string]*migrateSourceObject{"key": {data: data}})
+	dst := newMigrateDestinationClient(nil)
+
+	mgr := New(dst, Options{})
+	out, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+		SourceClient: src,
+		SourceBucket: "source",
+		SourceKey:    "key",
+		Bucket:       "dest",
+		Key:          "new-key",
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if out.Skipped {
+		t.Error("expect object not to be skipped")
+	}
+	if e, a := int64(len(data)), out.ObjectSize; e != a {
+		t.Errorf("expect size %d, got %d", e, a)
+	}
+	if e, a := "SOURCE-VERSION", out.SourceVersionID; e != a {
+		t.Errorf("expect source version %q, got %q", e, a)
+	}
+
+	if e, a := []string{"PutObject"}, dst.UploadInvocations; cmpDiff(e, a) != "" {
+		t.Errorf("expect %v, got %v", e, a)
+	}
+	put := dst.Params[0].(*s3.PutObjectInput)
+	if e, a := "dest", aws.ToString(put.Bucket); e != a {
+		t.Errorf("expect bucket %q, got %q", e, a)
+	}
+	if e, a := "new-key", aws.ToString(put.Key); e != a {
+		t.Errorf("expect key %q, got %q", e, a)
+	}
+	if e, a := "text/plain", aws.ToString(put.ContentType); e != a {
+		t.Errorf("expect content type %q, got %q", e, a)
+	}
+	if e, a := "team-a", put.Metadata["owner"]; e != a {
+		t.Errorf("expect metadata %q, got %q", e, a)
+	}
+	if e, a := "project=x", aws.ToString(put.Tagging); e != a {
+		t.Errorf("expect tagging %q, got %q", e, a)
+	}
+	if !bytes.Equal(data, writtenObject(t, dst)) {
+		t.Error("expect migrated content to match")
+	}
+}
+
+func TestMigrateObjectMultipartSource(t *testing.T) {
+	data := migrateTestData(2*minPartSizeBytes + 100)
+	src := newMigrateSourceClient(map[string]*migrateSourceObject{
+		"key": {data: data, partSize: minPartSizeBytes},
+	})
+	dst := newMigrateDestinationClient(nil)
+
+	mgr := New(dst, Options{})
+	out, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+		SourceClient: src,
+		SourceBucket: "source",
+		SourceKey:    "key",
+		Bucket:       "dest",
+		IgnoreTags:   true,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := "UPLOAD-ID", out.UploadID; e != a {
+		t.Errorf("expect upload ID %q, got %q", e, a)
+	}
+
+	parts := append([]int32(nil), src.RetrievedParts...)
+	sort.Slice(parts, func(i, j int) bool { return parts[i] < parts[j] })
+	if e, a := []int32{1, 2, 3}, parts; cmpDiff(e, a) != "" {
+		t.Errorf("expect source parts %v, got %v", e, a)
+	}
+	if len(src.RetrievedRanges) != 0 {
+		t.Errorf("expect no ranges, got %v", src.RetrievedRanges)
+	}
+	for _, op := range src.UploadInvocations {
+		if op == "GetObjectTagging" {
+			t.Error("expect tags to be ignored")
+		}
+	}
+	create := dst.Params[0].(*s3.CreateMultipartUploadInput)
+	if e, a := "key", aws.ToString(create.Key); e != a {
+		t.Errorf("expect key %q, got %q", e, a)
+	}
+	if create.Tagging != nil {
+		t.Errorf("expect no tagging, got %q", aws.ToString(create.Tagging))
+	}
+	if !bytes.Equal(data, writtenObject(t, dst)) {
+		t.Error("expect migrated content to match")
+	}
+}
+
+func TestMigrateObjectRanges(t *testing.T) {
+	data := migrateTestData(3*minPartSizeBytes + 5)
+	src := newMigrateSourceClient(map[string]*migrateSourceObject{"key": {data: data}})
+	dst := newMigrateDestinationClient(nil)
+
+	mgr := New(dst, Options{Concurrency: 3})
+	_, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+		SourceClient: src,
+		SourceBucket: "source",
+		SourceKey:    "key",
+		Bucket:       "dest",
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 4, len(src.RetrievedRanges); e != a {
+		t.Errorf("expect %d ranges, got %d", e, a)
+	}
+	if !bytes.Equal(data, writtenObject(t, dst)) {
+		t.Error("expect migrated content to match")
+	}
+}
+
+func TestMigrateObjectChecksumMismatch(t *testing.T) {
+	cases := map[string]struct {
+		size     int64
+		partSize int64
+		expect   string
+	}{
+		"single": {
+			size: 1024,
+		},
+		"parts": {
+			size:     2*minPartSizeBytes + 1,
+			partSize: minPartSizeBytes,
+			expect:   "AbortMultipartUpload",
+		},
+		"ranges": {
+			size:   2*minPartSizeBytes + 1,
+			expect: "AbortMultipartUpload",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			data := migrateTestData(c.size)
+			// the ETag of other content
+			etag := objectETag(data[1:], c.partSize)
+			src := newMigrateSourceClient(map[string]*migrateSourceObject{
+				"key": {data: data, partSize: c.partSize, etag: etag},
+			})
+			dst := newMigrateDestinationClient(nil)
+
+			mgr := New(dst, Options{MultipartUploadThreshold: minPartSizeBytes})
+			_, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+				SourceClient: src,
+				SourceBucket: "source",
+				SourceKey:    "key",
+				Bucket:       "dest",
+			})
+			if !errors.Is(err, ErrChecksumMismatch) {
+				t.Fatalf("expect checksum mismatch, got %v", err)
+			}
+			for _, op := range dst.UploadInvocations {
+				if op == "PutObject" || op == "CompleteMultipartUpload" {
+					t.Errorf("expect object not to be written, got %v", dst.UploadInvocations)
+				}
+			}
+			if c.expect != "" {
+				if e, a := c.expect, dst.UploadInvocations[len(dst.UploadInvocations)-1]; e != a {
+					t.Errorf("expect %s, got %v", e, dst.UploadInvocations)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateObjectSourceChanged(t *testing.T) {
+	data := migrateTestData(1024)
+	objects := map[string]*migrateSourceObject{"key": {data: data}}
+	src := newMigrateSourceClient(objects)
+	head := src.HeadObjectFn
+	src.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		out, err := head(c, params)
+		// the object is overwritten once it was headed
+		objects["key"].etag = `"changed"`
+		return out, err
+	}
+
+	mgr := New(newMigrateDestinationClient(nil), Options{})
+	_, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+		SourceClient: src,
+		SourceBucket: "source",
+		SourceKey:    "key",
+		Bucket:       "dest",
+	})
+	if !errors.Is(err, ErrObjectChanged) {
+		t.Fatalf("expect object changed, got %v", err)
+	}
+}
+
+func TestMigrateObjectSkip(t *testing.T) {
+	data := migrateTestData(1024)
+	etag := objectETag(data, 0)
+	src := newMigrateSourceClient(map[string]*migrateSourceObject{"key": {data: data}})
+	src.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		return &s3.HeadObjectOutput{
+			ContentLength: aws.Int64(1024),
+			ETag:          aws.String(etag),
+			ChecksumCRC32: aws.String("crc"),
+		}, nil
+	}
+
+	cases := map[string]*s3.HeadObjectOutput{
+		"etag":     {ContentLength: aws.Int64(1024), ETag: aws.String(etag)},
+		"checksum": {ContentLength: aws.Int64(1024), ETag: aws.String(`"other"`), ChecksumCRC32: aws.String("crc")},
+	}
+	for name, dest := range cases {
+		t.Run(name, func(t *testing.T) {
+			dst, _, _ := s3testing.NewUploadLoggingClient(nil)
+			dst.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+				return dest, nil
+			}
+
+			mgr := New(dst, Options{})
+			out, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+				SourceClient: src,
+				SourceBucket: "source",
+				SourceKey:    "key",
+				Bucket:       "dest",
+			})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if !out.Skipped {
+				t.Error("expect object to be skipped")
+			}
+			if len(dst.UploadInvocations) != 0 {
+				t.Errorf("expect no writes, got %v", dst.UploadInvocations)
+			}
+			if src.GetObjectInvocations != 0 {
+				t.Errorf("expect no reads, got %d", src.GetObjectInvocations)
+			}
+		})
+	}
+
+	dst := newMigrateDestinationClient(map[string]string{"key": `"other"`})
+	mgr := New(dst, Options{})
+	out, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+		SourceClient: newMigrateSourceClient(map[string]*migrateSourceObject{"key": {data: data}}),
+		SourceBucket: "source",
+		SourceKey:    "key",
+		Bucket:       "dest",
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if out.Skipped {
+		t.Error("expect changed object to be migrated")
+	}
+}
+
+func TestMigrateObjectRetention(t *testing.T) {
+	data := migrateTestData(1024)
+	expiration := time.Now().Add(24 * time.Hour).UTC()
+	src := newMigrateSourceClient(map[string]*migrateSourceObject{"key": {data: data}})
+	head := src.HeadObjectFn
+	src.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		out, err := head(c, params)
+		out.RetentionExpirationDate = aws.Time(expiration)
+		out.RetentionLegalHoldCount = aws.Int64(1)
+		return out, err
+	}
+	src.ListLegalHoldsFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListLegalHoldsInput) (*s3.ListLegalHoldsOutput, error) {
+		return &s3.ListLegalHoldsOutput{
+			RetentionPeriodExpirationDate: aws.Time(expiration),
+			LegalHolds:                    []s3types.LegalHold{{ID: aws.String("hold-1")}},
+		}, nil
+	}
+
+	for _, ignore := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ignore=%v", ignore), func(t *testing.T) {
+			dst := newMigrateDestinationClient(nil)
+			mgr := New(dst, Options{})
+			_, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+				SourceClient:    src,
+				SourceBucket:    "source",
+				SourceKey:       "key",
+				Bucket:          "dest",
+				IgnoreRetention: ignore,
+			})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+
+			e := []string{"PutObject", "ExtendObjectRetention", "AddLegalHold"}
+			if ignore {
+				e = e[:1]
+			}
+			if a := dst.UploadInvocations; cmpDiff(e, a) != "" {
+				t.Fatalf("expect %v, got %v", e, a)
+			}
+			if ignore {
+				return
+			}
+			extend := dst.Params[1].(*s3.ExtendObjectRetentionInput)
+			if a := aws.ToTime(extend.NewRetentionExpirationDate); !a.Equal(expiration) {
+				t.Errorf("expect expiration %v, got %v", expiration, a)
+			}
+			hold := dst.Params[2].(*s3.AddLegalHoldInput)
+			if e, a := "hold-1", aws.ToString(hold.RetentionLegalHoldId); e != a {
+				t.Errorf("expect legal hold %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+func TestMigrateObjectValidation(t *testing.T) {
+	src := newMigrateSourceClient(nil)
+	cases := map[string]*MigrateObjectInput{
+		"source client": {SourceBucket: "source", SourceKey: "key", Bucket: "dest"},
+		"source key":    {SourceClient: src, SourceBucket: "source", Bucket: "dest"},
+		"bucket":        {SourceClient: src, SourceBucket: "source", SourceKey: "key"},
+	}
+	for name, in := range cases {
+		t.Run(name, func(t *testing.T) {
+			mgr := New(newMigrateDestinationClient(nil), Options{})
+			if _, err := mgr.MigrateObject(context.Background(), in); err == nil {
+				t.Error("expect error, got none")
+			}
+		})
+	}
+}
+
+func TestMigrateObjects(t *testing.T) {
+	objects := map[string]*migrateSourceObject{
+		"logs/a.log":     {data: migrateTestData(10)},
+		"logs/b/b.log":   {data: migrateTestData(20)},
+		"logs/c.log":     {data: migrateTestData(30)},
+		"logs/skip.tmp":  {data: migrateTestData(40)},
+		"logs/broken.db": {data: migrateTestData(50)},
+	}
+	src := newMigrateSourceClient(objects)
+	src.ListObjectsV2Fn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+		out := &s3.ListObjectsV2Output{}
+		for key, o := range objects {
+			out.Contents = append(out.Contents, s3types.Object{
+				Key:  aws.String(key),
+				Size: aws.Int64(int64(len(o.data))),
+			})
+		}
+		return out, nil
+	}
+	get := src.GetObjectFn
+	src.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		if aws.ToString(params.Key) == "logs/broken.db" {
+			return nil, fmt.Errorf("connection reset")
+		}
+		return get(c, params)
+	}
+
+	// c.log was migrated already
+	dst := newMigrateDestinationClient(nil)
+	dst.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		if aws.ToString(params.Key) != "archive/c.log" {
+			return nil, &smithy.GenericAPIError{Code: "NotFound"}
+		}
+		return &s3.HeadObjectOutput{
+			ContentLength: aws.Int64(30),
+			ETag:          aws.String(objects["logs/c.log"].etag),
+		}, nil
+	}
+
+	listener := &mockDirectoryListener{}
+	opts := Options{}
+	opts.ProgressListeners.Register(listener)
+	mgr := New(dst, opts)
+	out, err := mgr.MigrateObjects(context.Background(), &MigrateObjectsInput{
+		SourceClient:  src,
+		SourceBucket:  "source",
+		SourcePrefix:  "logs/",
+		Bucket:        "dest",
+		KeyPrefix:     "archive/",
+		Filter:        func(o types.Object) bool { return o.Key != "logs/skip.tmp" },
+		FailurePolicy: types.FailurePolicyContinue,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 2, out.ObjectsMigrated; e != a {
+		t.Errorf("expect %d objects migrated, got %d", e, a)
+	}
+	if e, a := 1, out.ObjectsSkipped; e != a {
+		t.Errorf("expect %d objects skipped, got %d", e, a)
+	}
+	if e, a := int64(30), out.BytesMigrated; e != a {
+		t.Errorf("expect %d bytes migrated, got %d", e, a)
+	}
+	if e, a := 1, out.ObjectsFailed; e != a {
+		t.Fatalf("expect %d objects failed, got %d", e, a)
+	}
+	if e, a := "logs/broken.db", out.Failures[0].Key; e != a {
+		t.Errorf("expect failure of %q, got %q", e, a)
+	}
+
+	var keys []string
+	for _, p := range dst.Params {
+		if in, ok := p.(*s3.PutObjectInput); ok {
+			keys = append(keys, aws.ToString(in.Key))
+		}
+	}
+	sort.Strings(keys)
+	if e, a := []string{"archive/a.log", "archive/b/b.log"}, keys; cmpDiff(e, a) != "" {
+		t.Errorf("expect keys %v, got %v", e, a)
+	}
+
+	if e, a := 1, len(listener.complete); e != a {
+		t.Fatalf("expect %d directory transfer complete event, got %d", e, a)
+	}
+	complete := listener.complete[0]
+	if e, a := int64(60), complete.BytesTransferred; e != a {
+		t.Errorf("expect %d bytes transferred, got %d", e, a)
+	}
+}
+
+func TestSplitETag(t *testing.T) {
+	sum := "d41d8cd98f00b204e9800998ecf8427e"
+	cases := map[string]struct {
+		sum   string
+		parts int
+		ok    bool
+	}{
+		`"` + sum + `"`:    {sum: sum, ok: true},
+		`"` + sum + `-12"`: {sum: sum, parts: 12, ok: true},
+		`"` + sum + `-x"`:  {},
+		`"abc"`:            {},
+		`"abc-3"`:          {parts: 3},
+	}
+	for etag, c := range cases {
+		sum, parts, ok := splitETag(etag)
+		if sum != c.sum || parts != c.parts || ok != c.ok {
+			t.Errorf("%s: expect %q %d %v, got %q %d %v", etag, c.sum, c.parts, c.ok, sum, parts, ok)
+		}
+	}
+}
+
+func TestMigrateObjectsBufferPool(t *testing.T) {
+	objects := map[string]*migrateSourceObject{}
+	for i := 0; i < 4; i++ {
+		// objects uploaded in parts are read by part number, the others by
+		// range
+		o := &migrateSourceObject{data: migrateTestData(2*minPartSizeBytes + 100)}
+		if i%2 == 0 {
+			o.partSize = minPartSizeBytes
+		}
+		objects[fmt.Sprintf("key-%d", i)] = o
+	}
+	src := newMigrateSourceClient(objects)
+	src.ListObjectsV2Fn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+		out := &s3.ListObjectsV2Output{}
+		for key, o := range objects {
+			out.Contents = append(out.Contents, s3types.Object{
+				Key:  aws.String(key),
+				Size: aws.Int64(int64(len(o.data))),
+			})
+		}
+		return out, nil
+	}
+
+	mgr := New(newMigrateDestinationClient(nil), Options{
+		Concurrency:          2,
+		MaxBufferMemoryBytes: 2 * minPartSizeBytes,
+	})
+	type result struct {
+		out *MigrateObjectsOutput
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := mgr.MigrateObjects(context.Background(), &MigrateObjectsInput{
+			SourceClient: src,
+			SourceBucket: "source",
+			Bucket:       "dest",
+			Callback:     func(in *MigrateObjectInput) { in.IgnoreTags = true },
+		})
+		done <- result{out, err}
+	}()
+
+	// requests holding a token of the budget must not wait for memory held
+	// by requests waiting for a token
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatalf("expect no error, got %v", r.err)
+		}
+		if e, a := len(objects), r.out.ObjectsMigrated; e != a {
+			t.Errorf("expect %d objects migrated, got %d", e, a)
+		}
+	case <-time.After(time.Minute):
+		t.Fatalf("migration deadlocked, %+v", mgr.BufferPool().Stats())
+	}
+	if e, a := int64(0), mgr.BufferPool().Stats().ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved after the migration, got %d", e, a)
+	}
+}
@@ -0,0 +1,187 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// newObjectWriterClient returns a client recording the content of the
+// uploaded parts by part number
+func newObjectWriterClient(parts map[int32][]byte) *s3testing.TransferManagerLoggingClient {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.UploadPartFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+		b, err := io.ReadAll(params.Body)
+		if err != nil {
+			return nil, err
+		}
+		parts[aws.ToInt32(params.PartNumber)] = b
+		return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("ETAG%d", aws.ToInt32(params.PartNumber)))}, nil
+	}
+	return c
+}
+
+// writeChunks writes data to w in small writes, as an encoder would
+func writeChunks(w io.Writer, data []byte) error {
+	for len(data) > 0 {
+		n := min(len(data), 100_000)
+		if _, err := w.Write(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func TestObjectWriterMultipart(t *testing.T) {
+	data := checksumTestData(2*minPartSizeBytes + 3)
+	parts := map[int32][]byte{}
+	c := newObjectWriterClient(parts)
+	mgr := New(c, Options{Concurrency: 2})
+
+	w, err := mgr.NewObjectWriter(context.Background(), &PutObjectInput{Bucket: "bucket", Key: "key"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := writeChunks(w, data); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if w.Output() != nil {
+		t.Errorf("expect no output before close")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	sort.Strings(c.UploadInvocations)
+	if diff := cmpDiff([]string{"CompleteMultipartUpload", "CreateMultipartUpload", "UploadPart", "UploadPart", "UploadPart"}, c.UploadInvocations); diff != "" {
+		t.Error(diff)
+	}
+	var uploaded []byte
+	for i := int32(1); i <= int32(len(parts)); i++ {
+		uploaded = append(uploaded, parts[i]...)
+	}
+	if !bytes.Equal(data, uploaded) {
+		t.Errorf("expect uploaded parts to match the written data")
+	}
+	if e, a := "UPLOAD-ID", w.Output().UploadID; e != a {
+		t.Errorf("expect upload ID %s, got %s", e, a)
+	}
+	if _, err := w.Write([]byte("more")); err == nil {
+		t.Errorf("expect error writing to a closed writer, got none")
+	}
+}
+
+func TestObjectWriterSingle(t *testing.T) {
+	c, invocations, args := s3testing.NewUploadLoggingClient(nil)
+	var body []byte
+	c.PutObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+		body, _ = io.ReadAll(params.Body)
+		return &s3.PutObjectOutput{VersionId: aws.String("VERSION-ID")}, nil
+	}
+	mgr := New(c, Options{})
+
+	w, err := mgr.NewObjectWriter(context.Background(), &PutObjectInput{Bucket: "bucket", Key: "key", ContentType: "text/csv"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if _, err := io.WriteString(w, "a,b\n1,2\n"); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if diff := cmpDiff([]string{"PutObject"}, *invocations); diff != "" {
+		t.Error(diff)
+	}
+	if e, a := "a,b\n1,2\n", string(body); e != a {
+		t.Errorf("expect body %q, got %q", e, a)
+	}
+	if e, a := "text/csv", aws.ToString((*args)[0].(*s3.PutObjectInput).ContentType); e != a {
+		t.Errorf("expect content type %s, got %s", e, a)
+	}
+	if e, a := "VERSION-ID", w.Output().VersionID; e != a {
+		t.Errorf("expect version ID %s, got %s", e, a)
+	}
+}
+
+func TestObjectWriterUploadError(t *testing.T) {
+	c, invocations, _ := s3testing.NewUploadLoggingClient(nil)
+	c.UploadPartFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+		return nil, fmt.Errorf("part upload failed")
+	}
+	mgr := New(c, Options{Concurrency: 1})
+
+	w, err := mgr.NewObjectWriter(context.Background(), &PutObjectInput{Bucket: "bucket", Key: "key"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	// writes fail once the upload stopped reading the written data
+	err = writeChunks(w, checksumTestData(5*minPartSizeBytes))
+	if err == nil {
+		t.Fatal("expect write error, got none")
+	}
+	if e, a := err, w.Close(); !errors.Is(a, e) {
+		t.Errorf("expect close error %v, got %v", e, a)
+	}
+	if w.Output() != nil {
+		t.Errorf("expect no output of a failed upload")
+	}
+	if e, a := "AbortMultipartUpload", (*invocations)[len(*invocations)-1]; e != a {
+		t.Errorf("expect last operation %s, got %s", e, a)
+	}
+}
+
+func TestObjectWriterAbort(t *testing.T) {
+	parts := map[int32][]byte{}
+	c := newObjectWriterClient(parts)
+	mgr := New(c, Options{Concurrency: 1})
+
+	w, err := mgr.NewObjectWriter(context.Background(), &PutObjectInput{Bucket: "bucket", Key: "key"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := writeChunks(w, checksumTestData(minPartSizeBytes+10)); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := w.Abort(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if e, a := "AbortMultipartUpload", c.UploadInvocations[len(c.UploadInvocations)-1]; e != a {
+		t.Errorf("expect last operation %s, got %s", e, a)
+	}
+	for _, op := range c.UploadInvocations {
+		if op == "CompleteMultipartUpload" {
+			t.Errorf("expect aborted upload not to be completed")
+		}
+	}
+	if _, err := w.Write([]byte("more")); !errors.Is(err, ErrObjectWriterAborted) {
+		t.Errorf("expect ErrObjectWriterAborted, got %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrObjectWriterAborted) {
+		t.Errorf("expect ErrObjectWriterAborted, got %v", err)
+	}
+}
+
+func TestObjectWriterBody(t *testing.T) {
+	mgr := New(&s3testing.TransferManagerLoggingClient{}, Options{})
+	_, err := mgr.NewObjectWriter(context.Background(), &PutObjectInput{
+		Bucket: "bucket",
+		Key:    "key",
+		Body:   bytes.NewReader(nil),
+	})
+	if err == nil {
+		t.Fatal("expect error, got none")
+	}
+}
@@ -0,0 +1,284 @@
+package transfermanager
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// newOpenObjectClient returns a client serving ranges of data with the ETag
+// etag, whose HeadObject reports the ETag headETag
+func newOpenObjectClient(data []byte, etag, headETag string) *s3testing.TransferManagerLoggingClient {
+	c := newResumableDownloadClient(data, etag)
+	c.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		return &s3.HeadObjectOutput{
+			ContentLength: aws.Int64(int64(len(data))),
+			ETag:          aws.String(headETag),
+			VersionId:     aws.String("version-1"),
+		}, nil
+	}
+	return c
+}
+
+func openTestObject(t *testing.T, c *s3testing.TransferManagerLoggingClient, opts ...func(*Options)) *ObjectReaderAt {
+	t.Helper()
+	r, err := New(c, Options{}).OpenObject(context.Background(), "bucket", "key", opts...)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestObjectReaderAtReadAt(t *testing.T) {
+	data := checksumTestData(10_500)
+	c := newOpenObjectClient(data, "etag", "etag")
+	r := openTestObject(t, c, func(o *Options) {
+		o.ReadAtBlockSizeBytes = 1000
+		o.ReadAtCacheBlocks = 4
+	})
+
+	if e, a := int64(len(data)), r.Size(); e != a {
+		t.Errorf("expect size %d, got %d", e, a)
+	}
+
+	cases := []struct {
+		off, n    int64
+		expectN   int
+		expectErr error
+	}{
+		{off: 0, n: 10, expectN: 10},
+		{off: 995, n: 10, expectN: 10},
+		{off: 2500, n: 3000, expectN: 3000},
+		{off: 10_000, n: 1000, expectN: 500, expectErr: io.EOF},
+		{off: 10_500, n: 10, expectN: 0, expectErr: io.EOF},
+	}
+	for _, c := range cases {
+		p := make([]byte, c.n)
+		n, err := r.ReadAt(p, c.off)
+		if e, a := c.expectErr, err; e != a {
+			t.Errorf("%d: expect error %v, got %v", c.off, e, a)
+		}
+		if e, a := c.expectN, n; e != a {
+			t.Errorf("%d: expect %d bytes, got %d", c.off, e, a)
+		}
+		if !bytes.Equal(data[c.off:c.off+int64(n)], p[:n]) {
+			t.Errorf("%d: expect content to match the object", c.off)
+		}
+	}
+
+	for _, etag := range c.Etags {
+		if e, a := "etag", etag; e != a {
+			t.Errorf("expect reads conditional on ETag %s, got %s", e, a)
+		}
+	}
+	for _, v := range c.Versions {
+		if e, a := "version-1", v; e != a {
+			t.Errorf("expect reads of version %s, got %s", e, a)
+		}
+	}
+}
+
+func TestObjectReaderAtConcurrent(t *testing.T) {
+	data := checksumTestData(64_000)
+	c := newOpenObjectClient(data, "etag", "etag")
+	r := openTestObject(t, c, func(o *Options) {
+		o.ReadAtBlockSizeBytes = 1000
+		o.ReadAtCacheBlocks = 8
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for off := int64(i * 7919); off < int64(len(data)); off += 8 * 1237 {
+				p := make([]byte, 1500)
+				n, err := r.ReadAt(p, off)
+				if err != nil && err != io.EOF {
+					t.Errorf("expect no error, got %v", err)
+					return
+				}
+				if !bytes.Equal(data[off:off+int64(n)], p[:n]) {
+					t.Errorf("%d: expect content to match the object", off)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestObjectReaderAtCache(t *testing.T) {
+	data := checksumTestData(10_000)
+	c := newOpenObjectClient(data, "etag", "etag")
+	r := openTestObject(t, c, func(o *Options) {
+		o.ReadAtBlockSizeBytes = 1000
+		o.ReadAtCacheBlocks = 2
+	})
+
+	p := make([]byte, 10)
+	// reads which are not sequential do not fetch blocks ahead of them
+	for _, off := range []int64{5000, 2000, 5010, 8000, 2000} {
+		if _, err := r.ReadAt(p, off); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+
+	// block 5 is cached, block 2 is fetched again after it was evicted by 8
+	expect := []string{"bytes=5000-5999", "bytes=2000-2999", "bytes=8000-8999", "bytes=2000-2999"}
+	if diff := cmpDiff(expect, c.RetrievedRanges); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestObjectReaderAtReadAhead(t *testing.T) {
+	data := checksumTestData(10_000)
+	c := newOpenObjectClient(data, "etag", "etag")
+	r := openTestObject(t, c, func(o *Options) {
+		o.ReadAtBlockSizeBytes = 1000
+		o.Concurrency = 4
+	})
+
+	if _, err := r.Read(make([]byte, 1000)); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if _, err := r.Read(make([]byte, 1000)); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	r.wg.Wait()
+
+	// the window doubles with each sequential read
+	fetched := map[string]bool{}
+	for _, rng := range c.RetrievedRanges {
+		fetched[rng] = true
+	}
+	for i := 0; i < 4; i++ {
+		rng := fmt.Sprintf("bytes=%d-%d", i*1000, i*1000+999)
+		if !fetched[rng] {
+			t.Errorf("expect %s to be fetched", rng)
+		}
+	}
+	if e, a := 4, len(c.RetrievedRanges); e != a {
+		t.Errorf("expect %d ranges fetched, got %d", e, a)
+	}
+}
+
+func TestObjectReaderAtZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < 3; i++ {
+		f, err := zw.Create(fmt.Sprintf("file-%d.txt", i))
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		f.Write(bytes.Repeat([]byte(fmt.Sprintf("content %d\n", i)), 500))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	c := newOpenObjectClient(buf.Bytes(), "etag", "etag")
+	r := openTestObject(t, c, func(o *Options) {
+		o.ReadAtBlockSizeBytes = 512
+	})
+
+	zr, err := zip.NewReader(r, r.Size())
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	f, err := zr.Open("file-1.txt")
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := bytes.Repeat([]byte("content 1\n"), 500), content; !bytes.Equal(e, a) {
+		t.Errorf("expect file content to match")
+	}
+}
+
+func TestObjectReaderAtSeek(t *testing.T) {
+	data := checksumTestData(5000)
+	c := newOpenObjectClient(data, "etag", "etag")
+	r := openTestObject(t, c, func(o *Options) {
+		o.ReadAtBlockSizeBytes = 1000
+	})
+
+	if pos, err := r.Seek(-100, io.SeekEnd); err != nil || pos != 4900 {
+		t.Fatalf("expect position 4900, got %d, %v", pos, err)
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if !bytes.Equal(data[4900:], rest) {
+		t.Errorf("expect content to match the end of the object")
+	}
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("expect error seeking before the start, got none")
+	}
+}
+
+func TestObjectReaderAtObjectChanged(t *testing.T) {
+	c := newOpenObjectClient(checksumTestData(5000), "etag-2", "etag-1")
+	r := openTestObject(t, c)
+
+	_, err := r.ReadAt(make([]byte, 10), 0)
+	if !errors.Is(err, ErrObjectChanged) {
+		t.Fatalf("expect ErrObjectChanged, got %v", err)
+	}
+}
+
+func TestObjectReaderAtClose(t *testing.T) {
+	c := newOpenObjectClient(checksumTestData(5000), "etag", "etag")
+	r := openTestObject(t, c)
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if _, err := r.ReadAt(make([]byte, 10), 0); err == nil {
+		t.Errorf("expect error reading a closed reader, got none")
+	}
+}
+
+func TestObjectReaderAtRetryBody(t *testing.T) {
+	data := checksumTestData(5000)
+	c := newOpenObjectClient(data, "etag", "etag")
+	getObject := c.GetObjectFn
+	var calls int
+	c.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		out, err := getObject(c, params)
+		if calls++; calls == 1 && err == nil {
+			// the first body fails halfway, the retry succeeds
+			out.Body = io.NopCloser(&s3testing.TestErrReader{Buf: data[:10], Err: fmt.Errorf("connection reset")})
+		}
+		return out, err
+	}
+	r := openTestObject(t, c, func(o *Options) {
+		o.PartBodyMaxRetries = 3
+	})
+
+	p := make([]byte, 100)
+	n, err := r.ReadAt(p, 0)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if !bytes.Equal(data[:n], p[:n]) || n != len(p) {
+		t.Errorf("expect %d bytes of the object, got %d", len(p), n)
+	}
+	if e, a := 2, calls; e != a {
+		t.Errorf("expect %d requests, got %d", e, a)
+	}
+}
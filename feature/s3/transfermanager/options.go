@@ -30,6 +30,22 @@ type Options struct {
 	// The concurrency pool is not shared between calls to Upload.
 	Concurrency int
 
+	// Enables the adaptive tuning of uploads. The part size of an upload of
+	// unknown size doubles every 1000 parts, up to the maximum part size, so
+	// that it is not capped at 10000 parts of PartSizeBytes. The number of
+	// parts sent at once starts at Concurrency, and is tuned from the
+	// observed throughput of the parts and throttling errors, between
+	// MinConcurrency and MaxConcurrency.
+	AdaptiveUpload bool
+
+	// The lower bound of the concurrency of adaptive uploads. If this is set
+	// to zero, the lower bound is 1.
+	MinConcurrency int
+
+	// The upper bound of the concurrency of adaptive uploads. If this is set
+	// to zero, the upper bound is twice Concurrency.
+	MaxConcurrency int
+
 	// The type indicating if object is multi-downloaded in parts or ranges
 	GetObjectType types.GetObjectType
 
@@ -39,11 +55,56 @@ type Options struct {
 	// Max size for the get object buffer
 	GetBufferSize int64
 
+	// The size (in bytes) of the blocks an ObjectReaderAt fetches with ranged
+	// GetObject requests and caches. If this is set to zero, the
+	// defaultReadAtBlockSize value will be used.
+	ReadAtBlockSizeBytes int64
+
+	// The number of blocks an ObjectReaderAt keeps cached, evicting the least
+	// recently used ones. If this is set to zero, the defaultReadAtCacheBlocks
+	// value will be used.
+	ReadAtCacheBlocks int
+
+	// The customer provided key the objects an ObjectReaderAt reads are
+	// encrypted with, if they are encrypted with SSE-C
+	ReadAtCustomerKey *SSECustomerKey
+
 	// Registry of progress listener hooks.
 	//
 	// It is safe to modify the registry in per-operation functional options,
 	// the original client-level registry will not be affected.
 	ProgressListeners ProgressListeners
+
+	// The maximum rate, in bytes per second, at which object bodies are
+	// uploaded or downloaded, shared by all the part goroutines. If this is
+	// set to zero, transfers are not limited.
+	//
+	// Changing it in per-operation functional options gives the operation a
+	// limit of its own, on top of the client-level limit.
+	MaxBytesPerSecond int64
+
+	// The token bucket enforcing MaxBytesPerSecond, which can be used to
+	// change the limit while transfers are running. If nil, New creates one
+	// from MaxBytesPerSecond, shared by all the operations of the client.
+	BandwidthLimiter *BandwidthLimiter
+
+	// The maximum memory, in bytes, of the buffers holding object parts,
+	// shared by all the uploads and downloads of the client. Operations block
+	// until memory is available instead of allocating past the limit. If this
+	// is set to zero, buffer memory is not limited.
+	//
+	// The limit must be at least PartSizeBytes, and a GetObject body holds the
+	// buffers of its parts until they are read.
+	MaxBufferMemoryBytes int64
+
+	// The pool enforcing MaxBufferMemoryBytes, which reports its utilisation.
+	// If nil, New creates one from MaxBufferMemoryBytes, shared by all the
+	// operations of the client. Setting the same pool on several clients
+	// shares the limit between them.
+	BufferPool *BufferPool
+
+	// bounds the in-flight requests of the objects of a directory transfer
+	requestBudget *requestBudget
 }
 
 func (o *Options) init() {
@@ -91,6 +152,40 @@ func resolveGetBufferSize(o *Options) {
 	}
 }
 
+func resolveReadAtBlockSize(o *Options) {
+	if o.ReadAtBlockSizeBytes == 0 {
+		o.ReadAtBlockSizeBytes = defaultReadAtBlockSize
+	}
+}
+
+func resolveReadAtCacheBlocks(o *Options) {
+	if o.ReadAtCacheBlocks == 0 {
+		o.ReadAtCacheBlocks = defaultReadAtCacheBlocks
+	}
+}
+
+func resolveBandwidthLimiter(o *Options) {
+	if o.BandwidthLimiter == nil {
+		o.BandwidthLimiter = NewBandwidthLimiter(o.MaxBytesPerSecond)
+	}
+}
+
+func resolveBufferPool(o *Options) {
+	if o.BufferPool == nil {
+		o.BufferPool = NewBufferPool(o.MaxBufferMemoryBytes)
+	}
+}
+
+// resolveOperationBandwidthLimiter gives an operation which changed
+// MaxBytesPerSecond a limiter of its own, nested in the client's one.
+func resolveOperationBandwidthLimiter(o *Options, client *Options) {
+	if o.BandwidthLimiter == client.BandwidthLimiter && o.MaxBytesPerSecond != client.MaxBytesPerSecond {
+		l := NewBandwidthLimiter(o.MaxBytesPerSecond)
+		l.parent = client.BandwidthLimiter
+		o.BandwidthLimiter = l
+	}
+}
+
 // Copy returns new copy of the Options
 func (o Options) Copy() Options {
 	to := o
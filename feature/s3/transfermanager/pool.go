@@ -3,6 +3,7 @@ package transfermanager
 import (
 	"context"
 	"fmt"
+	"sync"
 )
 
 type bytesBufferPool interface {
@@ -13,6 +14,9 @@ type bytesBufferPool interface {
 
 type defaultSlicePool struct {
 	slices chan []byte
+
+	m      sync.Mutex
+	closed bool
 }
 
 func newDefaultSlicePool(sliceSize int64, capacity int) *defaultSlicePool {
@@ -50,13 +54,273 @@ func (p *defaultSlicePool) Get(ctx context.Context) ([]byte, error) {
 }
 
 func (p *defaultSlicePool) Put(bs []byte) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.closed {
+		// the slice of a closed pool is not reused
+		return
+	}
 	p.slices <- bs
 }
 
 func (p *defaultSlicePool) Close() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.closed = true
 	close(p.slices)
 	for range p.slices {
 		// drain channel
 	}
-	p.slices = nil
+}
+
+// BufferPool bounds the memory of the buffers holding object parts, shared by
+// all the uploads and downloads of the client using it, so that operations
+// block instead of allocating past the limit when many transfers run at once.
+//
+// It is safe to use a BufferPool concurrently across goroutines.
+type BufferPool struct {
+	maxBytes int64
+
+	mu       sync.Mutex
+	reserved int64
+	peak     int64
+	waiting  int
+	waits    int64
+
+	// closed when memory is released, to wake up the waiting goroutines
+	released chan struct{}
+}
+
+// BufferPoolStats is a snapshot of the utilisation of a BufferPool
+type BufferPoolStats struct {
+	// The limit of the pool in bytes, zero meaning unlimited
+	MaxBytes int64
+
+	// The bytes of the buffers currently held by transfers
+	ReservedBytes int64
+
+	// The highest ReservedBytes since the pool was created
+	PeakReservedBytes int64
+
+	// The number of goroutines currently waiting for memory
+	Waiting int
+
+	// The number of times a goroutine had to wait for memory
+	Waits int64
+}
+
+// NewBufferPool returns a BufferPool allowing buffers of up to maxBytes bytes
+// in total. Zero means unlimited, in which case the pool only reports its
+// utilisation.
+func NewBufferPool(maxBytes int64) *BufferPool {
+	return &BufferPool{
+		maxBytes: max(maxBytes, 0),
+		released: make(chan struct{}),
+	}
+}
+
+// Stats returns the current utilisation of the pool
+func (p *BufferPool) Stats() BufferPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return BufferPoolStats{
+		MaxBytes:          p.maxBytes,
+		ReservedBytes:     p.reserved,
+		PeakReservedBytes: p.peak,
+		Waiting:           p.waiting,
+		Waits:             p.waits,
+	}
+}
+
+// limit returns the limit of the pool in bytes, zero meaning unlimited. A nil
+// pool is unlimited.
+func (p *BufferPool) limit() int64 {
+	if p == nil {
+		return 0
+	}
+	return p.maxBytes
+}
+
+// tryReserve reserves n bytes if they are available. Otherwise it returns a
+// channel closed when memory is released.
+func (p *BufferPool) tryReserve(n int64) (bool, <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.maxBytes > 0 && p.reserved+n > p.maxBytes {
+		return false, p.released
+	}
+	p.reserved += n
+	p.peak = max(p.peak, p.reserved)
+	return true, nil
+}
+
+// reserve blocks until n bytes are available and reserves them. A nil pool is
+// unlimited.
+func (p *BufferPool) reserve(ctx context.Context, n int64) error {
+	if p == nil {
+		return nil
+	}
+	if p.maxBytes > 0 && n > p.maxBytes {
+		return fmt.Errorf("buffer of %d bytes exceeds the buffer memory limit of %d bytes", n, p.maxBytes)
+	}
+
+	waited := false
+	for {
+		ok, released := p.tryReserve(n)
+		if ok {
+			if waited {
+				p.setWaiting(-1)
+			}
+			return nil
+		}
+		if !waited {
+			waited = true
+			p.setWaiting(1)
+		}
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			p.setWaiting(-1)
+			return ctx.Err()
+		}
+	}
+}
+
+// setWaiting counts a goroutine starting (+1) or done (-1) waiting for memory
+func (p *BufferPool) setWaiting(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.waiting += delta
+	if delta > 0 {
+		p.waits++
+	}
+}
+
+// release returns n reserved bytes to the pool
+func (p *BufferPool) release(n int64) {
+	if p == nil || n == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reserved -= n
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+// slicePool returns a bytesBufferPool of up to capacity slices of sliceSize
+// bytes for an operation, which reserves the memory of a slice when it is
+// first allocated and releases it when the operation closes the pool.
+func (p *BufferPool) slicePool(sliceSize int64, capacity int) bytesBufferPool {
+	if p == nil {
+		return newDefaultSlicePool(sliceSize, capacity)
+	}
+	return &boundedSlicePool{
+		pool:     p,
+		size:     sliceSize,
+		capacity: capacity,
+		slices:   make(chan []byte, capacity),
+	}
+}
+
+// boundedSlicePool reuses the slices of an operation, allocating them from
+// the memory of a BufferPool.
+type boundedSlicePool struct {
+	pool     *BufferPool
+	size     int64
+	capacity int
+	slices   chan []byte
+
+	m         sync.Mutex
+	allocated int
+	closed    bool
+}
+
+func (p *boundedSlicePool) Get(ctx context.Context) ([]byte, error) {
+	if p.pool.maxBytes > 0 && p.size > p.pool.maxBytes {
+		return nil, fmt.Errorf("buffer of %d bytes exceeds the buffer memory limit of %d bytes", p.size, p.pool.maxBytes)
+	}
+
+	waited := false
+	defer func() {
+		if waited {
+			p.pool.setWaiting(-1)
+		}
+	}()
+	for {
+		select {
+		case bs := <-p.slices:
+			return bs, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		// a slice is allocated while the operation has fewer than its
+		// capacity, otherwise one is put back
+		var released <-chan struct{}
+		p.m.Lock()
+		if p.allocated < p.capacity {
+			var ok bool
+			if ok, released = p.pool.tryReserve(p.size); ok {
+				p.allocated++
+				p.m.Unlock()
+				return make([]byte, p.size), nil
+			}
+		}
+		p.m.Unlock()
+
+		if !waited && released != nil {
+			waited = true
+			p.pool.setWaiting(1)
+		}
+		select {
+		case bs := <-p.slices:
+			return bs, nil
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p *boundedSlicePool) Put(bs []byte) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.closed {
+		// the slice of a closed pool is not reused
+		p.allocated--
+		p.pool.release(p.size)
+		return
+	}
+	// never blocks, as the channel has room for all the allocated slices
+	p.slices <- bs
+}
+
+// Close releases the memory of the slices allocated by the operation. The
+// memory of the slices still in use is released when they are put back.
+func (p *boundedSlicePool) Close() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.closed = true
+	var idle int
+	for {
+		select {
+		case <-p.slices:
+			idle++
+		default:
+			p.allocated -= idle
+			p.pool.release(int64(idle) * p.size)
+			return
+		}
+	}
 }
@@ -1,9 +1,17 @@
 package transfermanager
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"runtime"
 	"sync"
 	"testing"
+	"time"
+
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
 )
 
 func TestDefaultSlicePool(t *testing.T) {
@@ -45,3 +53,291 @@ func TestDefaultSlicePool(t *testing.T) {
 
 	pool.Close()
 }
+
+func TestBufferPoolReserve(t *testing.T) {
+	pool := NewBufferPool(10)
+	ctx := context.Background()
+
+	if err := pool.reserve(ctx, 6); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := pool.reserve(ctx, 11); err == nil {
+		t.Errorf("expect error reserving more than the limit, got none")
+	}
+
+	reserved := make(chan error)
+	go func() {
+		reserved <- pool.reserve(ctx, 6)
+	}()
+	for pool.Stats().Waiting == 0 {
+		runtime.Gosched()
+	}
+	select {
+	case err := <-reserved:
+		t.Fatalf("expect reserve to wait for memory, got %v", err)
+	default:
+	}
+
+	pool.release(6)
+	if err := <-reserved; err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	expect := BufferPoolStats{MaxBytes: 10, ReservedBytes: 6, PeakReservedBytes: 6, Waits: 1}
+	if diff := cmpDiff(expect, pool.Stats()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestBufferPoolReserveCanceled(t *testing.T) {
+	pool := NewBufferPool(10)
+	if err := pool.reserve(context.Background(), 10); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for pool.Stats().Waiting == 0 {
+			runtime.Gosched()
+		}
+		cancel()
+	}()
+	if err := pool.reserve(ctx, 1); err != context.Canceled {
+		t.Fatalf("expect %v, got %v", context.Canceled, err)
+	}
+	if e, a := 0, pool.Stats().Waiting; e != a {
+		t.Errorf("expect %d waiting, got %d", e, a)
+	}
+}
+
+func TestBufferPoolUnlimited(t *testing.T) {
+	var nilPool *BufferPool
+	if err := nilPool.reserve(context.Background(), 1<<40); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+	nilPool.release(1 << 40)
+
+	pool := NewBufferPool(0)
+	if err := pool.reserve(context.Background(), 1<<40); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+	if e, a := int64(1<<40), pool.Stats().PeakReservedBytes; e != a {
+		t.Errorf("expect peak %d, got %d", e, a)
+	}
+}
+
+func TestBoundedSlicePool(t *testing.T) {
+	pool := NewBufferPool(25)
+	ctx := context.Background()
+
+	a := pool.slicePool(10, 3)
+	b := pool.slicePool(10, 3)
+
+	a1, err := a.Get(ctx)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	b1, err := b.Get(ctx)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	// the pool has no memory for a third slice, so a waits for one to be put
+	// back
+	got := make(chan []byte)
+	go func() {
+		bs, _ := a.Get(ctx)
+		got <- bs
+	}()
+	for pool.Stats().Waiting == 0 {
+		runtime.Gosched()
+	}
+	a.Put(a1)
+	reused := <-got
+	if &reused[0] != &a1[0] {
+		t.Errorf("expect the slice put back to be reused")
+	}
+
+	// b waits for the memory released by a
+	go func() {
+		bs, _ := b.Get(ctx)
+		got <- bs
+	}()
+	for pool.Stats().Waiting == 0 {
+		runtime.Gosched()
+	}
+	a.Put(reused)
+	a.Close()
+	b2 := <-got
+	if len(b2) != 10 {
+		t.Errorf("expect a slice of %d bytes, got %d", 10, len(b2))
+	}
+
+	b.Put(b1)
+	b.Put(b2)
+	b.Close()
+	if e, a := int64(0), pool.Stats().ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved, got %d", e, a)
+	}
+	if e, a := int64(20), pool.Stats().PeakReservedBytes; e != a {
+		t.Errorf("expect peak %d, got %d", e, a)
+	}
+}
+
+func TestBoundedSlicePoolTooLarge(t *testing.T) {
+	p := NewBufferPool(5).slicePool(10, 1)
+	defer p.Close()
+	if _, err := p.Get(context.Background()); err == nil {
+		t.Errorf("expect error, got none")
+	}
+}
+
+func TestPutObjectBufferPool(t *testing.T) {
+	c, invocations, _ := s3testing.NewUploadLoggingClient(nil)
+	mgr := New(c, Options{
+		Concurrency:          5,
+		MaxBufferMemoryBytes: 2 * minPartSizeBytes,
+	})
+
+	_, err := mgr.PutObject(context.Background(), &PutObjectInput{
+		Bucket: "bucket",
+		Key:    "key",
+		Body:   bytes.NewReader(make([]byte, 6*minPartSizeBytes)),
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if e, a := 8, len(*invocations); e != a {
+		t.Errorf("expect %d operations, got %d", e, a)
+	}
+	stats := mgr.BufferPool().Stats()
+	if a, e := stats.PeakReservedBytes, int64(2*minPartSizeBytes); a > e {
+		t.Errorf("expect at most %d bytes reserved, got %d", e, a)
+	}
+	if e, a := int64(0), stats.ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved after the upload, got %d", e, a)
+	}
+}
+
+func TestGetObjectBufferPool(t *testing.T) {
+	c, _, _, _, _, _ := s3testing.NewDownloadClient()
+	c.Data = make([]byte, 4*minPartSizeBytes)
+	c.GetObjectFn = s3testing.RangeGetObjectFn
+	mgr := New(c, Options{
+		GetObjectType:        types.GetObjectRanges,
+		Concurrency:          5,
+		MaxBufferMemoryBytes: 2 * minPartSizeBytes,
+	})
+
+	out, err := mgr.GetObject(context.Background(), &GetObjectInput{Bucket: "bucket", Key: "key"})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	p := make([]byte, minPartSizeBytes)
+	for {
+		_, err := out.Body.Read(p)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+
+	stats := mgr.BufferPool().Stats()
+	if a, e := stats.PeakReservedBytes, int64(2*minPartSizeBytes); a > e {
+		t.Errorf("expect at most %d bytes reserved, got %d", e, a)
+	}
+	if e, a := int64(0), stats.ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved after the download, got %d", e, a)
+	}
+}
+
+func TestMigrateObjectBufferPool(t *testing.T) {
+	data := migrateTestData(3*minPartSizeBytes + 5)
+	src := newMigrateSourceClient(map[string]*migrateSourceObject{"key": {data: data}})
+	dst := newMigrateDestinationClient(nil)
+
+	mgr := New(dst, Options{
+		Concurrency:          3,
+		MaxBufferMemoryBytes: minPartSizeBytes,
+	})
+	_, err := mgr.MigrateObject(context.Background(), &MigrateObjectInput{
+		SourceClient: src,
+		SourceBucket: "source",
+		SourceKey:    "key",
+		Bucket:       "dest",
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if !bytes.Equal(data, writtenObject(t, dst)) {
+		t.Error("expect migrated content to match")
+	}
+
+	stats := mgr.BufferPool().Stats()
+	if a, e := stats.PeakReservedBytes, int64(minPartSizeBytes); a > e {
+		t.Errorf("expect at most %d bytes reserved, got %d", e, a)
+	}
+	if e, a := int64(0), stats.ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved after the migration, got %d", e, a)
+	}
+}
+
+func TestGetObjectBufferPoolConcurrentReaders(t *testing.T) {
+	c, _, _, _, _, _ := s3testing.NewDownloadClient()
+	c.Data = make([]byte, 4*minPartSizeBytes+5)
+	c.GetObjectFn = s3testing.RangeGetObjectFn
+	mgr := New(c, Options{
+		GetObjectType:        types.GetObjectRanges,
+		Concurrency:          2,
+		MaxBufferMemoryBytes: 2 * minPartSizeBytes,
+	})
+
+	// readers sharing the pool must not each hold the memory of a part
+	// while waiting for the memory of the part they read first
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			out, err := mgr.GetObject(context.Background(), &GetObjectInput{Bucket: "bucket", Key: "key"})
+			if err != nil {
+				errs <- err
+				return
+			}
+			p := make([]byte, minPartSizeBytes)
+			var read int
+			for {
+				n, err := out.Body.Read(p)
+				read += n
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			if read != len(c.Data) {
+				errs <- fmt.Errorf("expect %d bytes read, got %d", len(c.Data), read)
+				return
+			}
+			errs <- nil
+		}()
+	}
+	timeout := time.After(time.Minute)
+	for i := 0; i < cap(errs); i++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Errorf("expect no error, got %v", err)
+			}
+		case <-timeout:
+			t.Fatalf("readers deadlocked, %+v", mgr.BufferPool().Stats())
+		}
+	}
+
+	if e, a := int64(0), mgr.BufferPool().Stats().ReservedBytes; e != a {
+		t.Errorf("expect %d bytes reserved after the downloads, got %d", e, a)
+	}
+}
@@ -0,0 +1,259 @@
+package transfermanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProgressBarWidth           = 30
+	defaultProgressBarRefreshInterval = 100 * time.Millisecond
+)
+
+// ProgressBar is a progress listener writing a terminal progress bar to an
+// io.Writer, along with the throughput as a moving average and the estimated
+// time remaining.
+//
+// Register it with Options.ProgressListeners. For UploadDirectory and
+// DownloadDirectory the bar shows the aggregate progress of the transfer
+// rather than the progress of each object. A ProgressBar tracks a single
+// operation at a time.
+type ProgressBar struct {
+	// Width of the bar in characters. If this is set to zero, 30 is used.
+	Width int
+
+	// Minimum interval between two renderings, so that frequent events do
+	// not flood the writer. The final state of a transfer is always
+	// rendered. If this is set to zero, 100ms is used.
+	RefreshInterval time.Duration
+
+	w io.Writer
+
+	mu        sync.Mutex
+	directory bool
+	meter     throughputMeter
+	rendered  time.Time
+	lastLen   int
+
+	transferred int64
+	total       int64
+}
+
+var (
+	_ ObjectTransferStartListener         = (*ProgressBar)(nil)
+	_ ObjectBytesTransferredListener      = (*ProgressBar)(nil)
+	_ ObjectTransferCompleteListener      = (*ProgressBar)(nil)
+	_ ObjectTransferFailedListener        = (*ProgressBar)(nil)
+	_ DirectoryTransferStartListener      = (*ProgressBar)(nil)
+	_ DirectoryObjectsTransferredListener = (*ProgressBar)(nil)
+	_ DirectoryTransferCompleteListener   = (*ProgressBar)(nil)
+	_ DirectoryTransferFailedListener     = (*ProgressBar)(nil)
+)
+
+// NewProgressBar returns a ProgressBar writing to w, usually os.Stderr.
+func NewProgressBar(w io.Writer) *ProgressBar {
+	return &ProgressBar{w: w}
+}
+
+// OnObjectTransferStart resets the bar for a new object.
+func (p *ProgressBar) OnObjectTransferStart(ctx context.Context, event *ObjectTransferStartEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.directory {
+		return
+	}
+	p.reset()
+	p.total = event.TotalBytes
+	p.renderObject(time.Now(), false)
+}
+
+// OnObjectBytesTransferred renders the progress of the object.
+func (p *ProgressBar) OnObjectBytesTransferred(ctx context.Context, event *ObjectBytesTransferredEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.directory {
+		return
+	}
+	now := time.Now()
+	// events of concurrent parts may arrive out of order
+	if n := event.BytesTransferred - p.transferred; n > 0 {
+		p.meter.add(now, n)
+		p.transferred = event.BytesTransferred
+	}
+	p.renderObject(now, false)
+}
+
+// OnObjectTransferComplete renders the final state of the object.
+func (p *ProgressBar) OnObjectTransferComplete(ctx context.Context, event *ObjectTransferCompleteEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.directory {
+		return
+	}
+	p.transferred = event.BytesTransferred
+	p.renderObject(time.Now(), true)
+	p.finish("")
+}
+
+// OnObjectTransferFailed renders the final state of the object and the error.
+func (p *ProgressBar) OnObjectTransferFailed(ctx context.Context, event *ObjectTransferFailedEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.directory {
+		return
+	}
+	p.renderObject(time.Now(), true)
+	p.finish(fmt.Sprintf("failed: %v", event.Error))
+}
+
+// OnDirectoryTransferStart resets the bar for a directory transfer, whose
+// object events are ignored until it completes.
+func (p *ProgressBar) OnDirectoryTransferStart(ctx context.Context, event *DirectoryTransferStartEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reset()
+	p.directory = true
+}
+
+// OnDirectoryObjectsTransferred renders the progress of the directory
+// transfer.
+func (p *ProgressBar) OnDirectoryObjectsTransferred(ctx context.Context, event *DirectoryObjectsTransferredEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.renderDirectory(time.Now(), &event.DirectoryTransferProgress, false)
+}
+
+// OnDirectoryTransferComplete renders the final state of the directory
+// transfer.
+func (p *ProgressBar) OnDirectoryTransferComplete(ctx context.Context, event *DirectoryTransferCompleteEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.renderDirectory(time.Now(), &event.DirectoryTransferProgress, true)
+	p.finish("")
+	p.directory = false
+}
+
+// OnDirectoryTransferFailed renders the final state of the directory
+// transfer and the error.
+func (p *ProgressBar) OnDirectoryTransferFailed(ctx context.Context, event *DirectoryTransferFailedEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.renderDirectory(time.Now(), &event.DirectoryTransferProgress, true)
+	p.finish(fmt.Sprintf("failed: %v", event.Error))
+	p.directory = false
+}
+
+func (p *ProgressBar) reset() {
+	p.meter = throughputMeter{}
+	p.rendered = time.Time{}
+	p.lastLen = 0
+	p.transferred = 0
+	p.total = 0
+}
+
+func (p *ProgressBar) renderObject(now time.Time, final bool) {
+	rate := p.meter.rate(now)
+	line := p.format(p.transferred, p.total, rate, estimateRemaining(p.total-p.transferred, rate))
+	p.render(now, line, final)
+}
+
+func (p *ProgressBar) renderDirectory(now time.Time, progress *DirectoryTransferProgress, final bool) {
+	line := p.format(progress.BytesTransferred, progress.TotalBytes, progress.BytesPerSecond, progress.ETA)
+	// a "+" marks totals which may still grow
+	more := ""
+	if !progress.DiscoveryComplete {
+		more = "+"
+	}
+	objects := fmt.Sprintf("%d/%d%s objects", progress.ObjectsTransferred, progress.TotalObjects, more)
+	if progress.ObjectsFailed > 0 {
+		objects += fmt.Sprintf(", %d failed", progress.ObjectsFailed)
+	}
+	p.render(now, line+"  "+objects, final)
+}
+
+// format returns the bar, percentage, bytes, throughput and ETA of a
+// transfer. The bar and percentage are omitted if the total is unknown.
+func (p *ProgressBar) format(transferred, total int64, bytesPerSecond float64, eta time.Duration) string {
+	var b strings.Builder
+	if total > 0 {
+		width := p.Width
+		if width <= 0 {
+			width = defaultProgressBarWidth
+		}
+		ratio := min(float64(transferred)/float64(total), 1)
+		filled := int(ratio * float64(width))
+
+		b.WriteByte('[')
+		b.WriteString(strings.Repeat("=", filled))
+		if filled < width {
+			b.WriteByte('>')
+			b.WriteString(strings.Repeat(" ", width-filled-1))
+		}
+		fmt.Fprintf(&b, "] %3d%%  %s / %s", int(ratio*100), formatBytes(transferred), formatBytes(total))
+	} else {
+		b.WriteString(formatBytes(transferred))
+	}
+
+	fmt.Fprintf(&b, "  %s/s", formatBytes(int64(bytesPerSecond)))
+	if eta >= 0 && total > 0 {
+		fmt.Fprintf(&b, "  ETA %s", eta.Round(time.Second))
+	} else if total > 0 {
+		b.WriteString("  ETA --")
+	}
+	return b.String()
+}
+
+// render writes line over the previous one, at most once per refresh
+// interval unless final.
+func (p *ProgressBar) render(now time.Time, line string, final bool) {
+	interval := p.RefreshInterval
+	if interval <= 0 {
+		interval = defaultProgressBarRefreshInterval
+	}
+	if !final && !p.rendered.IsZero() && now.Sub(p.rendered) < interval {
+		return
+	}
+	p.rendered = now
+
+	pad := ""
+	if n := p.lastLen - len(line); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	p.lastLen = len(line)
+	fmt.Fprintf(p.w, "\r%s%s", line, pad)
+}
+
+// finish ends the line of the transfer, followed by msg if any.
+func (p *ProgressBar) finish(msg string) {
+	if msg != "" {
+		fmt.Fprintf(p.w, "  %s", msg)
+	}
+	fmt.Fprintln(p.w)
+	p.lastLen = 0
+}
+
+// formatBytes formats n with a binary unit prefix.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTP"[exp])
+}
@@ -0,0 +1,121 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressBar_Object(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewProgressBar(&buf)
+	bar.Width = 10
+	ctx := context.Background()
+
+	bar.OnObjectTransferStart(ctx, &ObjectTransferStartEvent{TotalBytes: 2048})
+	bar.OnObjectBytesTransferred(ctx, &ObjectBytesTransferredEvent{BytesTransferred: 1024, TotalBytes: 2048})
+	bar.OnObjectTransferComplete(ctx, &ObjectTransferCompleteEvent{BytesTransferred: 2048, TotalBytes: 2048})
+
+	lines := strings.Split(buf.String(), "\r")
+	last := lines[len(lines)-1]
+	if e := "[==========] 100%  2.0 KiB / 2.0 KiB"; !strings.HasPrefix(last, e) {
+		t.Errorf("expect final line to start with %q, got %q", e, last)
+	}
+	if !strings.HasSuffix(last, "\n") {
+		t.Errorf("expect final line to end with a newline, got %q", last)
+	}
+}
+
+func TestProgressBar_ObjectFailed(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewProgressBar(&buf)
+	ctx := context.Background()
+
+	bar.OnObjectTransferStart(ctx, &ObjectTransferStartEvent{TotalBytes: -1})
+	bar.OnObjectTransferFailed(ctx, &ObjectTransferFailedEvent{Error: errors.New("boom")})
+
+	if e, a := "failed: boom\n", buf.String(); !strings.HasSuffix(a, e) {
+		t.Errorf("expect output to end with %q, got %q", e, a)
+	}
+	if strings.Contains(buf.String(), "[") {
+		t.Errorf("expect no bar for an unknown total, got %q", buf.String())
+	}
+}
+
+func TestProgressBar_Directory(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewProgressBar(&buf)
+	bar.Width = 10
+	ctx := context.Background()
+
+	bar.OnDirectoryTransferStart(ctx, &DirectoryTransferStartEvent{})
+	// object events are ignored during a directory transfer
+	bar.OnObjectTransferStart(ctx, &ObjectTransferStartEvent{TotalBytes: 10})
+	bar.OnDirectoryObjectsTransferred(ctx, &DirectoryObjectsTransferredEvent{
+		DirectoryTransferProgress: DirectoryTransferProgress{
+			ObjectsTransferred: 1,
+			TotalObjects:       2,
+			BytesTransferred:   512,
+			TotalBytes:         1024,
+			BytesPerSecond:     512,
+			ETA:                time.Second,
+		},
+	})
+	bar.OnDirectoryTransferComplete(ctx, &DirectoryTransferCompleteEvent{
+		DirectoryTransferProgress: DirectoryTransferProgress{
+			ObjectsTransferred: 2,
+			TotalObjects:       2,
+			BytesTransferred:   1024,
+			TotalBytes:         1024,
+			DiscoveryComplete:  true,
+			BytesPerSecond:     512,
+		},
+	})
+
+	lines := strings.Split(buf.String(), "\r")
+	if e, a := 3, len(lines); e != a {
+		t.Fatalf("expect %d renderings, got %d: %q", e-1, a-1, buf.String())
+	}
+	if e := "[=====>    ]  50%  512 B / 1.0 KiB  512 B/s  ETA 1s  1/2+ objects"; strings.TrimSpace(lines[1]) != e {
+		t.Errorf("expect %q, got %q", e, lines[1])
+	}
+	if e := "[==========] 100%  1.0 KiB / 1.0 KiB  512 B/s  ETA 0s  2/2 objects\n"; lines[2] != e {
+		t.Errorf("expect %q, got %q", e, lines[2])
+	}
+}
+
+func TestProgressBar_RefreshInterval(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewProgressBar(&buf)
+	bar.RefreshInterval = time.Hour
+	ctx := context.Background()
+
+	bar.OnObjectTransferStart(ctx, &ObjectTransferStartEvent{TotalBytes: 100})
+	for i := int64(1); i < 100; i++ {
+		bar.OnObjectBytesTransferred(ctx, &ObjectBytesTransferredEvent{BytesTransferred: i, TotalBytes: 100})
+	}
+	bar.OnObjectTransferComplete(ctx, &ObjectTransferCompleteEvent{BytesTransferred: 100, TotalBytes: 100})
+
+	if e, a := 2, strings.Count(buf.String(), "\r"); e != a {
+		t.Errorf("expect %d renderings, got %d", e, a)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KiB",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 40:         "3.0 TiB",
+	}
+	for n, e := range cases {
+		if a := formatBytes(n); e != a {
+			t.Errorf("%d: expect %q, got %q", n, e, a)
+		}
+	}
+}
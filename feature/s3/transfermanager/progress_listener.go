@@ -2,7 +2,10 @@ package transfermanager
 
 import (
 	"context"
+	"math"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // ProgressListeners holds various "transfer progress" hooks that a caller can
@@ -17,6 +20,11 @@ type ProgressListeners struct {
 	ObjectBytesTransferred []ObjectBytesTransferredListener
 	ObjectTransferComplete []ObjectTransferCompleteListener
 	ObjectTransferFailed   []ObjectTransferFailedListener
+
+	DirectoryTransferStart      []DirectoryTransferStartListener
+	DirectoryObjectsTransferred []DirectoryObjectsTransferredListener
+	DirectoryTransferComplete   []DirectoryTransferCompleteListener
+	DirectoryTransferFailed     []DirectoryTransferFailedListener
 }
 
 // Register registers the input with all progress listener hooks that it implements.
@@ -37,6 +45,18 @@ func (p *ProgressListeners) Register(v any) {
 	if l, ok := v.(ObjectTransferFailedListener); ok {
 		p.ObjectTransferFailed = append(p.ObjectTransferFailed, l)
 	}
+	if l, ok := v.(DirectoryTransferStartListener); ok {
+		p.DirectoryTransferStart = append(p.DirectoryTransferStart, l)
+	}
+	if l, ok := v.(DirectoryObjectsTransferredListener); ok {
+		p.DirectoryObjectsTransferred = append(p.DirectoryObjectsTransferred, l)
+	}
+	if l, ok := v.(DirectoryTransferCompleteListener); ok {
+		p.DirectoryTransferComplete = append(p.DirectoryTransferComplete, l)
+	}
+	if l, ok := v.(DirectoryTransferFailedListener); ok {
+		p.DirectoryTransferFailed = append(p.DirectoryTransferFailed, l)
+	}
 }
 
 // Copy creates a clone where all hook lists are deep-copied.
@@ -49,11 +69,24 @@ func (p *ProgressListeners) Copy() ProgressListeners {
 	copy(objectBytesTransferred, p.ObjectBytesTransferred)
 	copy(objectTransferComplete, p.ObjectTransferComplete)
 	copy(objectTransferFailed, p.ObjectTransferFailed)
+	directoryTransferStart := make([]DirectoryTransferStartListener, len(p.DirectoryTransferStart))
+	directoryObjectsTransferred := make([]DirectoryObjectsTransferredListener, len(p.DirectoryObjectsTransferred))
+	directoryTransferComplete := make([]DirectoryTransferCompleteListener, len(p.DirectoryTransferComplete))
+	directoryTransferFailed := make([]DirectoryTransferFailedListener, len(p.DirectoryTransferFailed))
+	copy(directoryTransferStart, p.DirectoryTransferStart)
+	copy(directoryObjectsTransferred, p.DirectoryObjectsTransferred)
+	copy(directoryTransferComplete, p.DirectoryTransferComplete)
+	copy(directoryTransferFailed, p.DirectoryTransferFailed)
 	return ProgressListeners{
 		ObjectTransferStart:    objectTransferStart,
 		ObjectBytesTransferred: objectBytesTransferred,
 		ObjectTransferComplete: objectTransferComplete,
 		ObjectTransferFailed:   objectTransferFailed,
+
+		DirectoryTransferStart:      directoryTransferStart,
+		DirectoryObjectsTransferred: directoryObjectsTransferred,
+		DirectoryTransferComplete:   directoryTransferComplete,
+		DirectoryTransferFailed:     directoryTransferFailed,
 	}
 }
 
@@ -116,6 +149,97 @@ type ObjectTransferFailedEvent struct {
 	TotalBytes       int64
 }
 
+// DirectoryTransferProgress is a snapshot of the progress of a multi-object
+// transfer, such as UploadDirectory or DownloadDirectory.
+//
+// Objects are found while the transfer is running, so the totals grow until
+// DiscoveryComplete is set.
+type DirectoryTransferProgress struct {
+	// The number of objects transferred so far
+	ObjectsTransferred int
+
+	// The number of objects which failed to transfer so far
+	ObjectsFailed int
+
+	// The number of objects found so far
+	TotalObjects int
+
+	// The number of bytes transferred so far, across all objects
+	BytesTransferred int64
+
+	// The size of the objects found so far
+	TotalBytes int64
+
+	// Whether every object of the transfer has been found, making the totals
+	// final
+	DiscoveryComplete bool
+
+	// The time since the transfer started
+	Elapsed time.Duration
+
+	// The throughput, as a moving average over the last few seconds
+	BytesPerSecond float64
+
+	// The estimated time to transfer the remaining bytes of the objects found
+	// so far at the current throughput, or -1 if there is no throughput yet
+	ETA time.Duration
+}
+
+// DirectoryTransferStartListener is invoked when a multi-object transfer
+// begins.
+type DirectoryTransferStartListener interface {
+	OnDirectoryTransferStart(context.Context, *DirectoryTransferStartEvent)
+}
+
+// DirectoryTransferStartEvent is the event payload for directory transfer
+// start.
+type DirectoryTransferStartEvent struct {
+	Input any
+}
+
+// DirectoryObjectsTransferredListener is invoked on progress in a
+// multi-object transfer, whenever bytes of one of its objects are transferred
+// or an object completes or fails.
+type DirectoryObjectsTransferredListener interface {
+	OnDirectoryObjectsTransferred(context.Context, *DirectoryObjectsTransferredEvent)
+}
+
+// DirectoryObjectsTransferredEvent is the event payload for directory objects
+// transferred.
+type DirectoryObjectsTransferredEvent struct {
+	Input any
+	DirectoryTransferProgress
+}
+
+// DirectoryTransferCompleteListener is invoked when a multi-object transfer
+// completes without error. Objects may still have failed with
+// types.FailurePolicyContinue.
+type DirectoryTransferCompleteListener interface {
+	OnDirectoryTransferComplete(context.Context, *DirectoryTransferCompleteEvent)
+}
+
+// DirectoryTransferCompleteEvent is the event payload for directory transfer
+// complete.
+type DirectoryTransferCompleteEvent struct {
+	Input  any
+	Output any
+	DirectoryTransferProgress
+}
+
+// DirectoryTransferFailedListener is invoked when a multi-object transfer
+// fails.
+type DirectoryTransferFailedListener interface {
+	OnDirectoryTransferFailed(context.Context, *DirectoryTransferFailedEvent)
+}
+
+// DirectoryTransferFailedEvent is the event payload for directory transfer
+// failure.
+type DirectoryTransferFailedEvent struct {
+	Input any
+	Error error
+	DirectoryTransferProgress
+}
+
 func (p *ProgressListeners) emitObjectTransferStart(ctx context.Context, event *ObjectTransferStartEvent) {
 	for _, l := range p.ObjectTransferStart {
 		l.OnObjectTransferStart(ctx, event)
@@ -188,3 +312,230 @@ func (e *singleObjectProgressEmitter) Failed(ctx context.Context, err error) {
 		Error:            err,
 	})
 }
+
+func (p *ProgressListeners) emitDirectoryTransferStart(ctx context.Context, event *DirectoryTransferStartEvent) {
+	for _, l := range p.DirectoryTransferStart {
+		l.OnDirectoryTransferStart(ctx, event)
+	}
+}
+
+func (p *ProgressListeners) emitDirectoryObjectsTransferred(ctx context.Context, event *DirectoryObjectsTransferredEvent) {
+	for _, l := range p.DirectoryObjectsTransferred {
+		l.OnDirectoryObjectsTransferred(ctx, event)
+	}
+}
+
+func (p *ProgressListeners) emitDirectoryTransferComplete(ctx context.Context, event *DirectoryTransferCompleteEvent) {
+	for _, l := range p.DirectoryTransferComplete {
+		l.OnDirectoryTransferComplete(ctx, event)
+	}
+}
+
+func (p *ProgressListeners) emitDirectoryTransferFailed(ctx context.Context, event *DirectoryTransferFailedEvent) {
+	for _, l := range p.DirectoryTransferFailed {
+		l.OnDirectoryTransferFailed(ctx, event)
+	}
+}
+
+// progress event emitter aggregating the objects of a multi-object transfer
+// used for implementations of:
+//   - UploadDirectory
+//   - DownloadDirectory
+//
+// Events are emitted while holding the emitter lock, so that listeners see
+// them in order.
+type directoryProgressEmitter struct {
+	Listeners ProgressListeners
+
+	mu       sync.Mutex
+	input    any
+	start    time.Time
+	progress DirectoryTransferProgress
+	meter    throughputMeter
+}
+
+func (e *directoryProgressEmitter) Start(ctx context.Context, in any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.input = in
+	e.start = time.Now()
+	e.Listeners.emitDirectoryTransferStart(ctx, &DirectoryTransferStartEvent{
+		Input: in,
+	})
+}
+
+// Discovered records an object found by the transfer.
+func (e *directoryProgressEmitter) Discovered(size int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.progress.TotalObjects++
+	e.progress.TotalBytes += size
+}
+
+// DiscoveryComplete records that every object of the transfer was found.
+func (e *directoryProgressEmitter) DiscoveryComplete() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.progress.DiscoveryComplete = true
+}
+
+func (e *directoryProgressEmitter) BytesTransferred(ctx context.Context, n int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.progress.BytesTransferred += n
+	e.meter.add(time.Now(), n)
+	e.emitObjectsTransferred(ctx)
+}
+
+func (e *directoryProgressEmitter) ObjectTransferred(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.progress.ObjectsTransferred++
+	e.emitObjectsTransferred(ctx)
+}
+
+func (e *directoryProgressEmitter) ObjectFailed(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.progress.ObjectsFailed++
+	e.emitObjectsTransferred(ctx)
+}
+
+func (e *directoryProgressEmitter) Complete(ctx context.Context, out any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.Listeners.emitDirectoryTransferComplete(ctx, &DirectoryTransferCompleteEvent{
+		Input:                     e.input,
+		Output:                    out,
+		DirectoryTransferProgress: e.snapshot(),
+	})
+}
+
+func (e *directoryProgressEmitter) Failed(ctx context.Context, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.Listeners.emitDirectoryTransferFailed(ctx, &DirectoryTransferFailedEvent{
+		Input:                     e.input,
+		Error:                     err,
+		DirectoryTransferProgress: e.snapshot(),
+	})
+}
+
+// emitObjectsTransferred emits the current progress. e.mu must be held.
+func (e *directoryProgressEmitter) emitObjectsTransferred(ctx context.Context) {
+	e.Listeners.emitDirectoryObjectsTransferred(ctx, &DirectoryObjectsTransferredEvent{
+		Input:                     e.input,
+		DirectoryTransferProgress: e.snapshot(),
+	})
+}
+
+// snapshot returns the current progress. e.mu must be held.
+func (e *directoryProgressEmitter) snapshot() DirectoryTransferProgress {
+	now := time.Now()
+	p := e.progress
+	p.Elapsed = now.Sub(e.start)
+	p.BytesPerSecond = e.meter.rate(now)
+	p.ETA = estimateRemaining(p.TotalBytes-p.BytesTransferred, p.BytesPerSecond)
+	return p
+}
+
+// ObjectListeners returns the listeners of an object of the transfer,
+// feeding its bytes transferred into the directory progress.
+func (e *directoryProgressEmitter) ObjectListeners(listeners ProgressListeners) ProgressListeners {
+	listeners = listeners.Copy()
+	listeners.Register(&directoryObjectListener{emitter: e})
+	return listeners
+}
+
+// directoryObjectListener converts the cumulative bytes transferred of a
+// single object into increments of the directory progress. Its events may be
+// emitted out of order by concurrent parts, so only increases are counted.
+type directoryObjectListener struct {
+	emitter *directoryProgressEmitter
+
+	mu          sync.Mutex
+	transferred int64
+}
+
+func (l *directoryObjectListener) OnObjectBytesTransferred(ctx context.Context, event *ObjectBytesTransferredEvent) {
+	l.mu.Lock()
+	n := event.BytesTransferred - l.transferred
+	if n > 0 {
+		l.transferred = event.BytesTransferred
+	}
+	l.mu.Unlock()
+
+	if n > 0 {
+		l.emitter.BytesTransferred(ctx, n)
+	}
+}
+
+// throughputMeter estimates a throughput as an exponentially weighted moving
+// average of the rate measured over each sampling interval.
+type throughputMeter struct {
+	bytesPerSecond float64
+	sampled        bool
+
+	last    time.Time
+	pending int64
+}
+
+const (
+	throughputSampleInterval = 250 * time.Millisecond
+	throughputWindow         = 5 * time.Second
+)
+
+// add records n bytes transferred at now.
+func (m *throughputMeter) add(now time.Time, n int64) {
+	if m.last.IsZero() {
+		m.last = now
+	}
+	m.pending += n
+	m.sample(now)
+}
+
+// rate returns the estimated throughput in bytes per second at now.
+func (m *throughputMeter) rate(now time.Time) float64 {
+	if !m.last.IsZero() {
+		m.sample(now)
+	}
+	return m.bytesPerSecond
+}
+
+func (m *throughputMeter) sample(now time.Time) {
+	dt := now.Sub(m.last)
+	if dt < throughputSampleInterval {
+		return
+	}
+
+	r := float64(m.pending) / dt.Seconds()
+	if !m.sampled {
+		m.bytesPerSecond = r
+		m.sampled = true
+	} else {
+		alpha := 1 - math.Exp(-dt.Seconds()/throughputWindow.Seconds())
+		m.bytesPerSecond += alpha * (r - m.bytesPerSecond)
+	}
+	m.last = now
+	m.pending = 0
+}
+
+// estimateRemaining returns the time to transfer remaining bytes at
+// bytesPerSecond, or -1 if it cannot be estimated.
+func estimateRemaining(remaining int64, bytesPerSecond float64) time.Duration {
+	if remaining <= 0 {
+		return 0
+	}
+	if bytesPerSecond <= 0 {
+		return -1
+	}
+	return time.Duration(float64(remaining) / bytesPerSecond * float64(time.Second))
+}
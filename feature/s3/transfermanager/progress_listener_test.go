@@ -4,6 +4,10 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
+
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
 )
 
 type mockPartialListener struct{}
@@ -176,3 +180,242 @@ func (m *mockListener) expectByteTransfers(t *testing.T, expect ...int64) {
 		}
 	}
 }
+
+type mockDirectoryListener struct {
+	mu sync.Mutex
+
+	start    []*DirectoryTransferStartEvent
+	transfer []*DirectoryObjectsTransferredEvent
+	complete []*DirectoryTransferCompleteEvent
+	failed   []*DirectoryTransferFailedEvent
+}
+
+func (m *mockDirectoryListener) OnDirectoryTransferStart(ctx context.Context, event *DirectoryTransferStartEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.start = append(m.start, event)
+}
+
+func (m *mockDirectoryListener) OnDirectoryObjectsTransferred(ctx context.Context, event *DirectoryObjectsTransferredEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.transfer = append(m.transfer, event)
+}
+
+func (m *mockDirectoryListener) OnDirectoryTransferComplete(ctx context.Context, event *DirectoryTransferCompleteEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.complete = append(m.complete, event)
+}
+
+func (m *mockDirectoryListener) OnDirectoryTransferFailed(ctx context.Context, event *DirectoryTransferFailedEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failed = append(m.failed, event)
+}
+
+// expectMonotonic checks that the progress events never go backwards
+func (m *mockDirectoryListener) expectMonotonic(t *testing.T) {
+	t.Helper()
+
+	for i := 1; i < len(m.transfer); i++ {
+		prev, cur := m.transfer[i-1], m.transfer[i]
+		if cur.BytesTransferred < prev.BytesTransferred {
+			t.Errorf("event %d: bytes transferred went from %d to %d", i, prev.BytesTransferred, cur.BytesTransferred)
+		}
+		if cur.ObjectsTransferred < prev.ObjectsTransferred {
+			t.Errorf("event %d: objects transferred went from %d to %d", i, prev.ObjectsTransferred, cur.ObjectsTransferred)
+		}
+	}
+}
+
+func TestProgressListener_UploadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{"a.txt", "b.txt", "sub/c.txt"}
+	writeTree(t, dir, files...)
+	var total int64
+	for _, f := range files {
+		total += int64(len(f))
+	}
+
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.ConsumeBody = true
+
+	listener := &mockDirectoryListener{}
+	objects := &mockListener{}
+	var opts Options
+	opts.ProgressListeners.Register(listener)
+	opts.ProgressListeners.Register(objects)
+	mgr := New(c, opts)
+
+	in := &UploadDirectoryInput{Bucket: "bucket", Source: dir}
+	out, err := mgr.UploadDirectory(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := 1, len(listener.start); e != a {
+		t.Fatalf("expect %d start events, got %d", e, a)
+	}
+	if in != listener.start[0].Input {
+		t.Errorf("transfer start: input %v != %v", in, listener.start[0].Input)
+	}
+	if e, a := 1, len(listener.complete); e != a {
+		t.Fatalf("expect %d complete events, got %d", e, a)
+	}
+	complete := listener.complete[0]
+	if out != complete.Output {
+		t.Errorf("transfer complete: output %v != %v", out, complete.Output)
+	}
+	if e, a := 3, complete.ObjectsTransferred; e != a {
+		t.Errorf("expect %d objects transferred, got %d", e, a)
+	}
+	if e, a := 3, complete.TotalObjects; e != a {
+		t.Errorf("expect %d total objects, got %d", e, a)
+	}
+	if e, a := total, complete.BytesTransferred; e != a {
+		t.Errorf("expect %d bytes transferred, got %d", e, a)
+	}
+	if e, a := total, complete.TotalBytes; e != a {
+		t.Errorf("expect %d total bytes, got %d", e, a)
+	}
+	if !complete.DiscoveryComplete {
+		t.Error("expect discovery to be complete")
+	}
+	if e, a := time.Duration(0), complete.ETA; e != a {
+		t.Errorf("expect ETA %v, got %v", e, a)
+	}
+	// one event for the bytes and one for the completion of each object
+	if e, a := 6, len(listener.transfer); e != a {
+		t.Errorf("expect %d progress events, got %d", e, a)
+	}
+	listener.expectMonotonic(t)
+
+	// object listeners are still invoked for each object
+	if e, a := 3, len(objects.complete); e != a {
+		t.Errorf("expect %d object complete events, got %d", e, a)
+	}
+}
+
+func TestProgressListener_DownloadDirectoryFailed(t *testing.T) {
+	keys := []string{"a.txt", "b.txt", "c.txt"}
+	c := newDirectoryDownloadClient(keys, "b.txt")
+
+	listener := &mockDirectoryListener{}
+	var opts Options
+	opts.ProgressListeners.Register(listener)
+	mgr := New(c, opts, func(o *Options) {
+		o.Concurrency = 1
+	})
+
+	in := &DownloadDirectoryInput{Bucket: "bucket", Destination: t.TempDir()}
+	_, err := mgr.DownloadDirectory(context.Background(), in)
+	if err == nil {
+		t.Fatal("expect error, got none")
+	}
+
+	if e, a := 0, len(listener.complete); e != a {
+		t.Errorf("expect %d complete events, got %d", e, a)
+	}
+	if e, a := 1, len(listener.failed); e != a {
+		t.Fatalf("expect %d failed events, got %d", e, a)
+	}
+	failed := listener.failed[0]
+	if err != failed.Error {
+		t.Errorf("transfer failed: error %v != %v", err, failed.Error)
+	}
+	if e, a := 1, failed.ObjectsTransferred; e != a {
+		t.Errorf("expect %d objects transferred, got %d", e, a)
+	}
+	if e, a := 1, failed.ObjectsFailed; e != a {
+		t.Errorf("expect %d objects failed, got %d", e, a)
+	}
+	listener.expectMonotonic(t)
+}
+
+func TestProgressListener_DownloadDirectoryContinue(t *testing.T) {
+	keys := []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}
+	c := newDirectoryDownloadClient(keys, "c.txt")
+
+	listener := &mockDirectoryListener{}
+	var opts Options
+	opts.ProgressListeners.Register(listener)
+	mgr := New(c, opts)
+
+	_, err := mgr.DownloadDirectory(context.Background(), &DownloadDirectoryInput{
+		Bucket:        "bucket",
+		Destination:   t.TempDir(),
+		FailurePolicy: types.FailurePolicyContinue,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := 1, len(listener.complete); e != a {
+		t.Fatalf("expect %d complete events, got %d", e, a)
+	}
+	complete := listener.complete[0]
+	if e, a := 4, complete.ObjectsTransferred; e != a {
+		t.Errorf("expect %d objects transferred, got %d", e, a)
+	}
+	if e, a := 1, complete.ObjectsFailed; e != a {
+		t.Errorf("expect %d objects failed, got %d", e, a)
+	}
+	if e, a := 5, complete.TotalObjects; e != a {
+		t.Errorf("expect %d total objects, got %d", e, a)
+	}
+	if e, a := int64(4*len("a.txt")), complete.BytesTransferred; e != a {
+		t.Errorf("expect %d bytes transferred, got %d", e, a)
+	}
+	listener.expectMonotonic(t)
+}
+
+func TestThroughputMeter(t *testing.T) {
+	var m throughputMeter
+	start := time.Unix(0, 0)
+
+	m.add(start, 0)
+	if e, a := 0.0, m.rate(start.Add(100*time.Millisecond)); e != a {
+		t.Errorf("expect no rate before the first sample, got %v", a)
+	}
+
+	m.add(start.Add(500*time.Millisecond), 500)
+	if e, a := 1000.0, m.rate(start.Add(500*time.Millisecond)); e != a {
+		t.Errorf("expect rate %v, got %v", e, a)
+	}
+
+	// the average moves towards the new rate without jumping to it
+	m.add(start.Add(1500*time.Millisecond), 3000)
+	if a := m.rate(start.Add(1500 * time.Millisecond)); a <= 1000 || a >= 3000 {
+		t.Errorf("expect rate between 1000 and 3000, got %v", a)
+	}
+
+	// and decays when nothing is transferred
+	before := m.rate(start.Add(1500 * time.Millisecond))
+	if a := m.rate(start.Add(10 * time.Second)); a >= before {
+		t.Errorf("expect rate below %v, got %v", before, a)
+	}
+}
+
+func TestEstimateRemaining(t *testing.T) {
+	cases := map[string]struct {
+		remaining      int64
+		bytesPerSecond float64
+		expect         time.Duration
+	}{
+		"done":        {0, 0, 0},
+		"no rate":     {100, 0, -1},
+		"ten seconds": {1000, 100, 10 * time.Second},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if a := estimateRemaining(c.remaining, c.bytesPerSecond); c.expect != a {
+				t.Errorf("expect %v, got %v", c.expect, a)
+			}
+		})
+	}
+}
@@ -49,7 +49,7 @@ func TestUploadOrderMulti(t *testing.T) {
 	}
 
 	if diff := cmpDiff([]string{"CreateMultipartUpload", "UploadPart", "UploadPart", "UploadPart", "CompleteMultipartUpload"}, *invocations); len(diff) > 0 {
-		t.Errorf(diff)
+		t.Error(diff)
 	}
 
 	if "UPLOAD-ID" != resp.UploadID {
@@ -311,6 +311,43 @@ func TestUploadOrderMultiFailureOnComplete(t *testing.T) {
 	}
 }
 
+// optionsRecordingClient records the number of client options of the
+// requests it sends
+type optionsRecordingClient struct {
+	*s3testing.TransferManagerLoggingClient
+	completeOptions, abortOptions int
+}
+
+func (c *optionsRecordingClient) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	c.completeOptions = len(optFns)
+	return c.TransferManagerLoggingClient.CompleteMultipartUpload(ctx, params, optFns...)
+}
+
+func (c *optionsRecordingClient) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	c.abortOptions = len(optFns)
+	return c.TransferManagerLoggingClient.AbortMultipartUpload(ctx, params, optFns...)
+}
+
+func TestUploadOrderMultiFailureAbortClientOptions(t *testing.T) {
+	lc, _, _ := s3testing.NewUploadLoggingClient(nil)
+	lc.CompleteMultipartUploadFn = func(*s3testing.TransferManagerLoggingClient, *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+		return nil, fmt.Errorf("complete multipart error")
+	}
+	c := &optionsRecordingClient{TransferManagerLoggingClient: lc}
+
+	_, err := New(c, Options{}).PutObject(context.Background(), &PutObjectInput{
+		Bucket: "Bucket",
+		Key:    "Key",
+		Body:   bytes.NewReader(buf20MB),
+	})
+	if err == nil {
+		t.Fatal("expect error, got nil")
+	}
+	if c.abortOptions == 0 || c.abortOptions != c.completeOptions {
+		t.Errorf("expect the abort to have the %d client options of the upload, got %d", c.completeOptions, c.abortOptions)
+	}
+}
+
 func TestUploadOrderMultiFailureOnCreate(t *testing.T) {
 	c, invocations, _ := s3testing.NewUploadLoggingClient(nil)
 
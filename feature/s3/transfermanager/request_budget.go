@@ -0,0 +1,35 @@
+package transfermanager
+
+import "context"
+
+// requestBudget bounds the number of in-flight PutObject and UploadPart
+// requests shared by the objects of a directory transfer, so that uploading
+// many objects at once does not multiply the configured concurrency.
+type requestBudget struct {
+	tokens chan struct{}
+}
+
+func newRequestBudget(n int) *requestBudget {
+	return &requestBudget{tokens: make(chan struct{}, n)}
+}
+
+// acquire blocks until a request may be sent. A nil budget is unbounded.
+func (b *requestBudget) acquire(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	select {
+	case b.tokens <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release returns a token acquired with acquire.
+func (b *requestBudget) release() {
+	if b == nil {
+		return
+	}
+	<-b.tokens
+}
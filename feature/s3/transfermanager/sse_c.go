@@ -0,0 +1,193 @@
+package transfermanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	smithy "github.com/aws/smithy-go"
+)
+
+// sseCustomerKeySize is the size in bytes of an AES256 customer provided key
+const sseCustomerKeySize = 32
+
+// SSECustomerKey is a key for server-side encryption with a customer
+// provided key (SSE-C). It derives the algorithm, the base64 encoded key and
+// its MD5 digest that every request reading or writing an object encrypted
+// with the key must carry.
+//
+// Setting it on an input, e.g. PutObjectInput.CustomerKey, replaces the
+// SSECustomerAlgorithm, SSECustomerKey and SSECustomerKeyMD5 members of the
+// input, and applies them to every request of the operation, including the
+// HeadObject, UploadPart and ranged GetObject requests.
+type SSECustomerKey struct {
+	key    string
+	keyMD5 string
+}
+
+// NewSSECustomerKey returns the SSECustomerKey of a raw 256-bit key
+func NewSSECustomerKey(key []byte) (*SSECustomerKey, error) {
+	if len(key) != sseCustomerKeySize {
+		return nil, fmt.Errorf("customer provided key must be %d bytes, got %d", sseCustomerKeySize, len(key))
+	}
+	sum := md5.Sum(key)
+	return &SSECustomerKey{
+		key:    base64.StdEncoding.EncodeToString(key),
+		keyMD5: base64.StdEncoding.EncodeToString(sum[:]),
+	}, nil
+}
+
+// ParseSSECustomerKey returns the SSECustomerKey of a base64 encoded 256-bit
+// key, as passed in the SSECustomerKey members of the inputs.
+func ParseSSECustomerKey(key string) (*SSECustomerKey, error) {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customer provided key: %w", err)
+	}
+	return NewSSECustomerKey(b)
+}
+
+// Algorithm returns the encryption algorithm of the key, AES256
+func (k *SSECustomerKey) Algorithm() string {
+	return "AES256"
+}
+
+// Key returns the base64 encoded key
+func (k *SSECustomerKey) Key() string {
+	return k.key
+}
+
+// KeyMD5 returns the base64 encoded MD5 digest of the key
+func (k *SSECustomerKey) KeyMD5() string {
+	return k.keyMD5
+}
+
+// String does not return the key, so that it is not logged by accident
+func (k *SSECustomerKey) String() string {
+	return "SSECustomerKey(" + k.keyMD5 + ")"
+}
+
+// apply sets the algorithm, key and MD5 members of an input to those of the
+// key, if it is not nil
+func (k *SSECustomerKey) apply(algorithm, key, keyMD5 *string) {
+	if k == nil {
+		return
+	}
+	*algorithm, *key, *keyMD5 = k.Algorithm(), k.key, k.keyMD5
+}
+
+// RotateSSECustomerKey re-encrypts an object encrypted with the customer
+// provided key oldKey with newKey, by copying it onto itself with CopyObject.
+// The metadata, tags and retention of the object are kept. In a versioned
+// bucket the copy is a new version, and the previous version remains
+// encrypted with oldKey.
+//
+// Additional functional options can be provided to configure the individual
+// copy. These options are copies of the original Options instance, the client of which RotateSSECustomerKey is called from.
+// Modifying the options will not impact the original Client and Options instance.
+func (c *Client) RotateSSECustomerKey(ctx context.Context, bucket, key string, oldKey, newKey *SSECustomerKey, opts ...func(*Options)) (*CopyObjectOutput, error) {
+	if oldKey == nil || newKey == nil {
+		return nil, fmt.Errorf("old and new customer provided keys are required")
+	}
+	return c.CopyObject(ctx, &CopyObjectInput{
+		Bucket:                bucket,
+		Key:                   key,
+		SourceBucket:          bucket,
+		SourceKey:             key,
+		CustomerKey:           newKey,
+		CopySourceCustomerKey: oldKey,
+	}, opts...)
+}
+
+// SSECustomerKeyMismatchError is returned when an object encrypted with a
+// customer provided key is read with another key, or without one. Err is the
+// error returned by the service.
+type SSECustomerKeyMismatchError struct {
+	// The base64 encoded MD5 digest of the key the object was read with,
+	// empty if it was read without a key
+	KeyMD5 string
+
+	Err error
+}
+
+func (e *SSECustomerKeyMismatchError) Error() string {
+	if e.KeyMD5 == "" {
+		return fmt.Sprintf("object is encrypted with a customer provided key, none was provided: %v", e.Err)
+	}
+	return fmt.Sprintf("object is not encrypted with the customer provided key %s: %v", e.KeyMD5, e.Err)
+}
+
+func (e *SSECustomerKeyMismatchError) Unwrap() error {
+	return e.Err
+}
+
+// sseCustomerKeyError returns err as an SSECustomerKeyMismatchError if it
+// reports that the key of a request, whose MD5 digest is keyMD5, is not the
+// key of the object. The service rejects a request with another key with a
+// 403, and one without a key, or whose key differs from that of a multipart
+// upload, with a 400 mentioning the encryption. HeadObject errors have no
+// message, so a 403 of a HeadObject with a key is taken as another key, which
+// headCustomerKeyError confirms first, and any 400 of a HeadObject without a
+// key is a missing key. Other 403s only signal another key when they mention
+// the encryption, as authorization errors are 403s as well, and are returned
+// unchanged otherwise.
+func sseCustomerKeyError(err error, key, keyMD5 string, head bool) error {
+	if err == nil {
+		return nil
+	}
+	var statusErr interface{ HTTPStatusCode() int }
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+
+	var message string
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		message = strings.ToLower(apiErr.ErrorMessage())
+	}
+	switch statusErr.HTTPStatusCode() {
+	case http.StatusForbidden:
+		if key == "" || !head && !strings.Contains(message, "encrypt") {
+			return err
+		}
+	case http.StatusBadRequest:
+		if !strings.Contains(message, "encrypt") && !(head && key == "") {
+			return err
+		}
+	default:
+		return err
+	}
+	if key == "" {
+		keyMD5 = ""
+	} else if k, err := ParseSSECustomerKey(key); keyMD5 == "" && err == nil {
+		keyMD5 = k.KeyMD5()
+	}
+	return &SSECustomerKeyMismatchError{KeyMD5: keyMD5, Err: err}
+}
+
+// headCustomerKeyError returns the error of a HeadObject with the input in as
+// an SSECustomerKeyMismatchError if it reports another key. As a 403 of a
+// HeadObject has no message to tell another key from an authorization error,
+// the HeadObject is retried without the key, and the key is only taken as
+// another key when the retry succeeds or is rejected for the missing key.
+func headCustomerKeyError(ctx context.Context, client S3APIClient, in *s3.HeadObjectInput, err error, optFns ...func(*s3.Options)) error {
+	key := aws.ToString(in.SSECustomerKey)
+	var statusErr interface{ HTTPStatusCode() int }
+	if key != "" && errors.As(err, &statusErr) && statusErr.HTTPStatusCode() == http.StatusForbidden {
+		probe := *in
+		probe.SSECustomerAlgorithm = nil
+		probe.SSECustomerKey = nil
+		probe.SSECustomerKeyMD5 = nil
+		_, probeErr := client.HeadObject(ctx, &probe, optFns...)
+		if probeErr != nil && !(errors.As(probeErr, &statusErr) && statusErr.HTTPStatusCode() == http.StatusBadRequest) {
+			return err
+		}
+	}
+	return sseCustomerKeyError(err, key, aws.ToString(in.SSECustomerKeyMD5), true)
+}
@@ -0,0 +1,293 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	smithy "github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func testSSECustomerKey(t *testing.T, b byte) *SSECustomerKey {
+	t.Helper()
+	key, err := NewSSECustomerKey(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	return key
+}
+
+// sseCustomerKeyResponseError returns the error of a response with the given
+// status code
+func sseCustomerKeyResponseError(status int, code, message string) error {
+	return &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+		Err:      &smithy.GenericAPIError{Code: code, Message: message},
+	}
+}
+
+func TestSSECustomerKey(t *testing.T) {
+	raw := bytes.Repeat([]byte{7}, 32)
+	key, err := NewSSECustomerKey(raw)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	sum := md5.Sum(raw)
+
+	if e, a := "AES256", key.Algorithm(); e != a {
+		t.Errorf("expect algorithm %s, got %s", e, a)
+	}
+	if e, a := base64.StdEncoding.EncodeToString(raw), key.Key(); e != a {
+		t.Errorf("expect key %s, got %s", e, a)
+	}
+	if e, a := base64.StdEncoding.EncodeToString(sum[:]), key.KeyMD5(); e != a {
+		t.Errorf("expect key MD5 %s, got %s", e, a)
+	}
+	if s := key.String(); strings.Contains(s, key.Key()) {
+		t.Errorf("expect the key not to be formatted, got %s", s)
+	}
+
+	parsed, err := ParseSSECustomerKey(key.Key())
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := key.KeyMD5(), parsed.KeyMD5(); e != a {
+		t.Errorf("expect key MD5 %s, got %s", e, a)
+	}
+
+	if _, err := NewSSECustomerKey(raw[:16]); err == nil {
+		t.Error("expect error for a 128-bit key, got none")
+	}
+	if _, err := ParseSSECustomerKey("not base64!"); err == nil {
+		t.Error("expect error for an invalid key, got none")
+	}
+}
+
+func TestPutObjectCustomerKey(t *testing.T) {
+	c, _, params := s3testing.NewUploadLoggingClient(nil)
+	mgr := New(c, Options{})
+	key := testSSECustomerKey(t, 1)
+
+	input := &PutObjectInput{
+		Bucket:      "bucket",
+		Key:         "key",
+		Body:        bytes.NewReader(make([]byte, 2*minPartSizeBytes)),
+		CustomerKey: key,
+	}
+	if _, err := mgr.PutObject(context.Background(), input); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if input.SSECustomerKey != "" {
+		t.Errorf("expect the input not to be modified")
+	}
+
+	if e, a := 4, len(*params); e != a {
+		t.Fatalf("expect %d requests, got %d", e, a)
+	}
+	for _, p := range *params {
+		var algorithm, k, keyMD5 *string
+		switch in := p.(type) {
+		case *s3.CreateMultipartUploadInput:
+			algorithm, k, keyMD5 = in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5
+		case *s3.UploadPartInput:
+			algorithm, k, keyMD5 = in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5
+		case *s3.CompleteMultipartUploadInput:
+			algorithm, k, keyMD5 = in.SSECustomerAlgorithm, in.SSECustomerKey, in.SSECustomerKeyMD5
+		default:
+			t.Fatalf("unexpected request %T", p)
+		}
+		if diff := cmpDiff([]string{key.Algorithm(), key.Key(), key.KeyMD5()},
+			[]string{aws.ToString(algorithm), aws.ToString(k), aws.ToString(keyMD5)}); diff != "" {
+			t.Errorf("%T: %s", p, diff)
+		}
+	}
+}
+
+func TestGetObjectCustomerKey(t *testing.T) {
+	c, _, _, _, _, _ := s3testing.NewDownloadClient()
+	c.Data = checksumTestData(3 * minPartSizeBytes)
+	key := testSSECustomerKey(t, 2)
+
+	var requests []string
+	c.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+		requests = append(requests, "HeadObject:"+aws.ToString(params.SSECustomerKeyMD5))
+		return &s3.HeadObjectOutput{
+			ContentLength: aws.Int64(int64(len(c.Data))),
+			ETag:          aws.String("etag"),
+		}, nil
+	}
+	c.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		requests = append(requests, "GetObject:"+aws.ToString(params.SSECustomerKeyMD5))
+		return s3testing.RangeGetObjectFn(c, params)
+	}
+	mgr := New(c, Options{PartSizeBytes: minPartSizeBytes, GetObjectType: types.GetObjectRanges})
+
+	out, err := mgr.GetObject(context.Background(), &GetObjectInput{
+		Bucket:      "bucket",
+		Key:         "key",
+		CustomerKey: key,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if !bytes.Equal(c.Data, b) {
+		t.Error("expect the object to be read")
+	}
+
+	expect := []string{"HeadObject:" + key.KeyMD5()}
+	for i := 0; i < 3; i++ {
+		expect = append(expect, "GetObject:"+key.KeyMD5())
+	}
+	if diff := cmpDiff(expect, requests); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSSECustomerKeyMismatch(t *testing.T) {
+	key := testSSECustomerKey(t, 3)
+	cases := map[string]struct {
+		key       *SSECustomerKey
+		head      error
+		probe     error
+		get       error
+		expectMD5 string
+		mismatch  bool
+	}{
+		"other key": {
+			key:       key,
+			head:      sseCustomerKeyResponseError(http.StatusForbidden, "Forbidden", ""),
+			expectMD5: key.KeyMD5(),
+			mismatch:  true,
+		},
+		"other key without key rejected": {
+			key:       key,
+			head:      sseCustomerKeyResponseError(http.StatusForbidden, "Forbidden", ""),
+			probe:     sseCustomerKeyResponseError(http.StatusBadRequest, "BadRequest", ""),
+			expectMD5: key.KeyMD5(),
+			mismatch:  true,
+		},
+		"access denied with key": {
+			key:   key,
+			head:  sseCustomerKeyResponseError(http.StatusForbidden, "Forbidden", ""),
+			probe: sseCustomerKeyResponseError(http.StatusForbidden, "Forbidden", ""),
+		},
+		"missing key": {
+			head:     sseCustomerKeyResponseError(http.StatusBadRequest, "BadRequest", ""),
+			mismatch: true,
+		},
+		"other key on get": {
+			key:       key,
+			get:       sseCustomerKeyResponseError(http.StatusForbidden, "AccessDenied", "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object."),
+			expectMD5: key.KeyMD5(),
+			mismatch:  true,
+		},
+		"access denied on get": {
+			key: key,
+			get: sseCustomerKeyResponseError(http.StatusForbidden, "AccessDenied", "Access Denied"),
+		},
+		"access denied": {
+			head: sseCustomerKeyResponseError(http.StatusForbidden, "Forbidden", ""),
+		},
+		"not found": {
+			key:  key,
+			head: sseCustomerKeyResponseError(http.StatusNotFound, "NotFound", ""),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, _, _, _, _, _ := s3testing.NewDownloadClient()
+			c.Data = make([]byte, 10)
+			c.HeadObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+				if tc.key != nil && params.SSECustomerKey == nil {
+					// the HeadObject retried without the key
+					if tc.probe != nil {
+						return nil, tc.probe
+					}
+				} else if tc.head != nil {
+					return nil, tc.head
+				}
+				return &s3.HeadObjectOutput{ContentLength: aws.Int64(10)}, nil
+			}
+			c.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+				if tc.get != nil {
+					return nil, tc.get
+				}
+				return s3testing.RangeGetObjectFn(c, params)
+			}
+			mgr := New(c, Options{GetObjectType: types.GetObjectRanges})
+
+			out, err := mgr.GetObject(context.Background(), &GetObjectInput{
+				Bucket:      "bucket",
+				Key:         "key",
+				CustomerKey: tc.key,
+			})
+			if err == nil {
+				_, err = io.ReadAll(out.Body)
+			}
+			if err == nil {
+				t.Fatal("expect error, got none")
+			}
+			var mismatch *SSECustomerKeyMismatchError
+			if e, a := tc.mismatch, errors.As(err, &mismatch); e != a {
+				t.Fatalf("expect mismatch %v, got %v", e, err)
+			}
+			if !tc.mismatch {
+				expect := tc.head
+				if expect == nil {
+					expect = tc.get
+				}
+				if !errors.Is(err, expect) {
+					t.Errorf("expect the original error, got %v", err)
+				}
+				return
+			}
+			if e, a := tc.expectMD5, mismatch.KeyMD5; e != a {
+				t.Errorf("expect key MD5 %q, got %q", e, a)
+			}
+			var responseErr *smithyhttp.ResponseError
+			if !errors.As(err, &responseErr) {
+				t.Errorf("expect the response error to be wrapped, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRotateSSECustomerKey(t *testing.T) {
+	c := newCopyClient(1024)
+	mgr := New(c, Options{})
+	oldKey, newKey := testSSECustomerKey(t, 4), testSSECustomerKey(t, 5)
+
+	if _, err := mgr.RotateSSECustomerKey(context.Background(), "bucket", "key", oldKey, newKey); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	params := c.Params[0].(*s3.CopyObjectInput)
+	if e, a := "bucket/key", aws.ToString(params.CopySource); e != a {
+		t.Errorf("expect copy source %s, got %s", e, a)
+	}
+	if diff := cmpDiff([]string{oldKey.Key(), oldKey.KeyMD5(), newKey.Key(), newKey.KeyMD5()}, []string{
+		aws.ToString(params.CopySourceSSECustomerKey), aws.ToString(params.CopySourceSSECustomerKeyMD5),
+		aws.ToString(params.SSECustomerKey), aws.ToString(params.SSECustomerKeyMD5),
+	}); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := mgr.RotateSSECustomerKey(context.Background(), "bucket", "key", oldKey, nil); err == nil {
+		t.Error("expect error without a new key, got none")
+	}
+}
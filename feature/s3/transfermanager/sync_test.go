@@ -0,0 +1,398 @@
+package transfermanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+	s3types "github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
+)
+
+var (
+	syncOldTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	syncNewTime = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type syncObject struct {
+	data         string
+	lastModified time.Time
+}
+
+func md5ETag(data string) string {
+	sum := md5.Sum([]byte(data))
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// newSyncClient returns a client listing and serving the objects.
+func newSyncClient(objects map[string]syncObject) *s3testing.TransferManagerLoggingClient {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.ConsumeBody = true
+	c.ListObjectsV2Fn = func(c *s3testing.TransferManagerLoggingClient, params *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+		var keys []string
+		for k := range objects {
+			if strings.HasPrefix(k, aws.ToString(params.Prefix)) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		out := &s3.ListObjectsV2Output{}
+		for _, k := range keys {
+			o := objects[k]
+			out.Contents = append(out.Contents, s3types.Object{
+				Key:          aws.String(k),
+				Size:         aws.Int64(int64(len(o.data))),
+				LastModified: aws.Time(o.lastModified),
+				ETag:         aws.String(md5ETag(o.data)),
+			})
+		}
+		return out, nil
+	}
+	c.GetObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		o := objects[aws.ToString(params.Key)]
+		return &s3.GetObjectOutput{
+			Body:          io.NopCloser(strings.NewReader(o.data)),
+			ContentLength: aws.Int64(int64(len(o.data))),
+			PartsCount:    aws.Int32(1),
+			LastModified:  aws.Time(o.lastModified),
+		}, nil
+	}
+	return c
+}
+
+// writeSyncFile writes a file of the local tree with the given content and
+// modification time.
+func writeSyncFile(t *testing.T, dir, rel, data string, modTime time.Time) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// actionSummary returns "TYPE name (REASON)" for each action.
+func actionSummary(actions []SyncAction) []string {
+	var s []string
+	for _, a := range actions {
+		s = append(s, fmt.Sprintf("%s %s (%s)", a.Type, a.Name, a.Reason))
+	}
+	return s
+}
+
+func deletedKeys(c *s3testing.TransferManagerLoggingClient) []string {
+	var keys []string
+	for _, p := range c.Params {
+		if in, ok := p.(*s3.DeleteObjectsInput); ok {
+			for _, o := range in.Delete.Objects {
+				keys = append(keys, aws.ToString(o.Key))
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestSyncUpload(t *testing.T) {
+	dir := t.TempDir()
+	writeSyncFile(t, dir, "new.txt", "new", syncOldTime)
+	writeSyncFile(t, dir, "same.txt", "same", syncOldTime)
+	writeSyncFile(t, dir, "resized.txt", "resized", syncOldTime)
+	writeSyncFile(t, dir, "sub/touched.txt", "touched", syncNewTime)
+	writeSyncFile(t, dir, "tmp/skipped.txt", "skipped", syncOldTime)
+
+	c := newSyncClient(map[string]syncObject{
+		"backup/same.txt":        {"same", syncNewTime},
+		"backup/resized.txt":     {"old", syncNewTime},
+		"backup/sub/touched.txt": {"touched", syncOldTime},
+		"backup/extra.txt":       {"extra", syncOldTime},
+		"backup/tmp/kept.txt":    {"kept", syncOldTime},
+	})
+	mgr := New(c, Options{})
+
+	input := &SyncInput{
+		Bucket:         "bucket",
+		LocalDirectory: dir,
+		KeyPrefix:      "backup",
+		Direction:      types.SyncDirectionUpload,
+		Exclude:        []string{"tmp"},
+		Delete:         true,
+		DryRun:         true,
+	}
+	expect := []string{
+		"DELETE extra.txt (EXTRANEOUS)",
+		"UPLOAD new.txt (MISSING)",
+		"UPLOAD resized.txt (SIZE_CHANGED)",
+		"UPLOAD sub/touched.txt (MODIFIED)",
+	}
+
+	out, err := mgr.Sync(context.Background(), input)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if diff := cmpDiff(expect, actionSummary(out.Actions)); len(diff) != 0 {
+		t.Errorf("unexpected actions: %s", diff)
+	}
+	if e, a := 0, len(uploadedKeys(c))+len(deletedKeys(c)); e != a {
+		t.Errorf("expect no changes on dry run, got %d", a)
+	}
+
+	input.DryRun = false
+	out, err = mgr.Sync(context.Background(), input)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if diff := cmpDiff(expect, actionSummary(out.Actions)); len(diff) != 0 {
+		t.Errorf("unexpected actions: %s", diff)
+	}
+	if diff := cmpDiff([]string{"backup/new.txt", "backup/resized.txt", "backup/sub/touched.txt"}, uploadedKeys(c)); len(diff) != 0 {
+		t.Errorf("unexpected uploads: %s", diff)
+	}
+	if diff := cmpDiff([]string{"backup/extra.txt"}, deletedKeys(c)); len(diff) != 0 {
+		t.Errorf("unexpected deletions: %s", diff)
+	}
+	if e, a := 3, out.ObjectsUploaded; e != a {
+		t.Errorf("expect %d objects uploaded, got %d", e, a)
+	}
+	if e, a := 1, out.Deleted; e != a {
+		t.Errorf("expect %d deleted, got %d", e, a)
+	}
+	if e, a := int64(len("new")+len("resized")+len("touched")), out.BytesTransferred; e != a {
+		t.Errorf("expect %d bytes transferred, got %d", e, a)
+	}
+}
+
+func TestSyncDownload(t *testing.T) {
+	dir := t.TempDir()
+	writeSyncFile(t, dir, "same.txt", "same", syncOldTime)
+	writeSyncFile(t, dir, "edited.txt", "edited", syncNewTime)
+	writeSyncFile(t, dir, "extra.txt", "extra", syncOldTime)
+
+	c := newSyncClient(map[string]syncObject{
+		"data/same.txt":    {"same", syncOldTime},
+		"data/edited.txt":  {"remote", syncOldTime},
+		"data/sub/new.txt": {"new", syncOldTime},
+	})
+	mgr := New(c, Options{})
+
+	out, err := mgr.Sync(context.Background(), &SyncInput{
+		Bucket:         "bucket",
+		LocalDirectory: dir,
+		KeyPrefix:      "data/",
+		Direction:      types.SyncDirectionDownload,
+		Delete:         true,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	expect := []string{
+		"DOWNLOAD edited.txt (MODIFIED)",
+		"DELETE extra.txt (EXTRANEOUS)",
+		"DOWNLOAD sub/new.txt (MISSING)",
+	}
+	if diff := cmpDiff(expect, actionSummary(out.Actions)); len(diff) != 0 {
+		t.Errorf("unexpected actions: %s", diff)
+	}
+	if diff := cmpDiff([]string{"edited.txt", "same.txt", "sub/new.txt"}, readTree(t, dir)); len(diff) != 0 {
+		t.Errorf("unexpected files: %s", diff)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "edited.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, a := "remote", string(b); e != a {
+		t.Errorf("expect content %q, got %q", e, a)
+	}
+	if e, a := 2, out.ObjectsDownloaded; e != a {
+		t.Errorf("expect %d objects downloaded, got %d", e, a)
+	}
+
+	// a second sync finds nothing to do
+	out, err = mgr.Sync(context.Background(), &SyncInput{
+		Bucket:         "bucket",
+		LocalDirectory: dir,
+		KeyPrefix:      "data/",
+		Direction:      types.SyncDirectionDownload,
+		Delete:         true,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 0, len(out.Actions); e != a {
+		t.Errorf("expect %d actions, got %v", e, actionSummary(out.Actions))
+	}
+}
+
+func TestSyncCompareChecksum(t *testing.T) {
+	dir := t.TempDir()
+	writeSyncFile(t, dir, "same.txt", "same", syncNewTime)
+	writeSyncFile(t, dir, "changed.txt", "abcd", syncOldTime)
+
+	c := newSyncClient(map[string]syncObject{
+		"same.txt":    {"same", syncOldTime},
+		"changed.txt": {"wxyz", syncNewTime},
+	})
+	mgr := New(c, Options{})
+
+	out, err := mgr.Sync(context.Background(), &SyncInput{
+		Bucket:          "bucket",
+		LocalDirectory:  dir,
+		Direction:       types.SyncDirectionUpload,
+		CompareChecksum: true,
+		DryRun:          true,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if diff := cmpDiff([]string{"UPLOAD changed.txt (CHECKSUM_CHANGED)"}, actionSummary(out.Actions)); len(diff) != 0 {
+		t.Errorf("unexpected actions: %s", diff)
+	}
+}
+
+func TestSyncSkipsDeletesOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	writeSyncFile(t, dir, "a.txt", "a", syncOldTime)
+	writeSyncFile(t, dir, "b.txt", "b", syncOldTime)
+
+	c := newSyncClient(map[string]syncObject{
+		"extra.txt": {"extra", syncOldTime},
+	})
+	c.PutObjectFn = func(c *s3testing.TransferManagerLoggingClient, params *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+		if aws.ToString(params.Key) == "b.txt" {
+			return nil, fmt.Errorf("put failed")
+		}
+		return &s3.PutObjectOutput{}, nil
+	}
+	mgr := New(c, Options{})
+
+	out, err := mgr.Sync(context.Background(), &SyncInput{
+		Bucket:         "bucket",
+		LocalDirectory: dir,
+		Direction:      types.SyncDirectionUpload,
+		Delete:         true,
+		FailurePolicy:  types.FailurePolicyContinue,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 1, len(out.Failures); e != a {
+		t.Fatalf("expect %d failures, got %d", e, a)
+	}
+	if e, a := "b.txt", out.Failures[0].Action.Name; e != a {
+		t.Errorf("expect failure of %s, got %s", e, a)
+	}
+	if e, a := 0, len(deletedKeys(c)); e != a {
+		t.Errorf("expect no deletions, got %d", a)
+	}
+	if e, a := 0, out.Deleted; e != a {
+		t.Errorf("expect %d deleted, got %d", e, a)
+	}
+}
+
+func TestSyncDeleteBatches(t *testing.T) {
+	objects := map[string]syncObject{}
+	for i := 0; i < maxDeleteObjects+5; i++ {
+		objects[fmt.Sprintf("old/%04d", i)] = syncObject{"x", syncOldTime}
+	}
+	c := newSyncClient(objects)
+	mgr := New(c, Options{})
+
+	out, err := mgr.Sync(context.Background(), &SyncInput{
+		Bucket:         "bucket",
+		LocalDirectory: t.TempDir(),
+		Direction:      types.SyncDirectionUpload,
+		Delete:         true,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	var batches []int
+	for _, p := range c.Params {
+		if in, ok := p.(*s3.DeleteObjectsInput); ok {
+			batches = append(batches, len(in.Delete.Objects))
+		}
+	}
+	if diff := cmpDiff([]int{maxDeleteObjects, 5}, batches); len(diff) != 0 {
+		t.Errorf("unexpected batches: %s", diff)
+	}
+	if e, a := maxDeleteObjects+5, out.Deleted; e != a {
+		t.Errorf("expect %d deleted, got %d", e, a)
+	}
+}
+
+func TestSyncValidation(t *testing.T) {
+	mgr := New(newSyncClient(nil), Options{})
+
+	cases := map[string]*SyncInput{
+		"no bucket":    {LocalDirectory: t.TempDir(), Direction: types.SyncDirectionUpload},
+		"no directory": {Bucket: "bucket", Direction: types.SyncDirectionUpload},
+		"no direction": {Bucket: "bucket", LocalDirectory: t.TempDir()},
+		"missing":      {Bucket: "bucket", LocalDirectory: filepath.Join(t.TempDir(), "missing"), Direction: types.SyncDirectionUpload},
+	}
+	for name, in := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := mgr.Sync(context.Background(), in); err == nil {
+				t.Error("expect error, got none")
+			}
+		})
+	}
+}
+
+func TestFileMatchesETag(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("0123456789"), 25)
+	path := filepath.Join(dir, "file")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// multipart ETag of 100 byte parts
+	sums := md5.New()
+	for i := 0; i < len(data); i += 100 {
+		sum := md5.Sum(data[i:min(i+100, len(data))])
+		sums.Write(sum[:])
+	}
+	multipart := `"` + hex.EncodeToString(sums.Sum(nil)) + `-3"`
+
+	cases := map[string]struct {
+		etag   string
+		expect bool
+	}{
+		"single":          {md5ETag(string(data)), true},
+		"single mismatch": {md5ETag("other"), false},
+		"multipart":       {multipart, true},
+		"part count":      {strings.Replace(multipart, "-3", "-4", 1), false},
+		"not md5":         {`"abc-xyz"`, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			match, err := fileMatchesETag(path, c.etag, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.expect != match {
+				t.Errorf("expect %v, got %v", c.expect, match)
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package types
 import (
 	"io"
 	"sync"
+	"time"
 
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
 	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
 	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3/types"
 )
@@ -140,10 +142,26 @@ type ChecksumAlgorithm string
 
 // Enum values for ChecksumAlgorithm
 const (
-	ChecksumAlgorithmCrc32  ChecksumAlgorithm = "CRC32"
-	ChecksumAlgorithmCrc32c                   = "CRC32C"
-	ChecksumAlgorithmSha1                     = "SHA1"
-	ChecksumAlgorithmSha256                   = "SHA256"
+	ChecksumAlgorithmCrc32     ChecksumAlgorithm = "CRC32"
+	ChecksumAlgorithmCrc32c                      = "CRC32C"
+	ChecksumAlgorithmSha1                        = "SHA1"
+	ChecksumAlgorithmSha256                      = "SHA256"
+	ChecksumAlgorithmCrc64nvme                   = "CRC64NVME"
+)
+
+// ChecksumType indicates how the checksum of an object uploaded in parts is
+// computed from its content.
+type ChecksumType string
+
+// Enum values for ChecksumType
+const (
+	// ChecksumTypeComposite is the checksum of the concatenated checksums of
+	// the parts, followed by the part count
+	ChecksumTypeComposite ChecksumType = "COMPOSITE"
+
+	// ChecksumTypeFullObject is the checksum of the whole content, which is
+	// only supported by the CRC algorithms
+	ChecksumTypeFullObject = "FULL_OBJECT"
 )
 
 // ObjectCannedACL defines the canned ACL to apply to the object, see [Canned ACL] in the
@@ -240,6 +258,121 @@ const (
 	StorageClassExpressOnezone                  = "EXPRESS_ONEZONE"
 )
 
+// MetadataDirective specifies whether the metadata of a copied object is copied
+// from the source object or replaced with the metadata of the request.
+type MetadataDirective string
+
+// Enum values for MetadataDirective
+const (
+	MetadataDirectiveCopy    MetadataDirective = "COPY"
+	MetadataDirectiveReplace MetadataDirective = "REPLACE"
+)
+
+// TaggingDirective specifies whether the tag set of a copied object is copied
+// from the source object or replaced with the tag set of the request.
+type TaggingDirective string
+
+// Enum values for TaggingDirective
+const (
+	TaggingDirectiveCopy    TaggingDirective = "COPY"
+	TaggingDirectiveReplace TaggingDirective = "REPLACE"
+)
+
+// RetentionDirective specifies whether the retention period and legal holds of
+// a copied object are copied from the source object or replaced with the
+// retention of the request.
+type RetentionDirective string
+
+// Enum values for RetentionDirective
+const (
+	RetentionDirectiveCopy    RetentionDirective = "COPY"
+	RetentionDirectiveReplace RetentionDirective = "REPLACE"
+)
+
+// Object describes an object listed by a directory transfer
+type Object struct {
+	// The key of the object
+	Key string
+
+	// The size of the object in bytes
+	Size int64
+
+	// The date the object was last modified
+	LastModified time.Time
+
+	// The entity tag of the object
+	ETag string
+
+	// The storage class of the object
+	StorageClass StorageClass
+}
+
+// MapFrom sets the fields of an Object from a ListObjectsV2 entry
+func (o *Object) MapFrom(obj types.Object) {
+	o.Key = aws.ToString(obj.Key)
+	o.Size = aws.ToInt64(obj.Size)
+	o.LastModified = aws.ToTime(obj.LastModified)
+	o.ETag = aws.ToString(obj.ETag)
+	o.StorageClass = StorageClass(obj.StorageClass)
+}
+
+// ObjectVersion describes an object, or a version of an object, deleted by a
+// batch delete
+type ObjectVersion struct {
+	// The key of the object
+	Key string
+
+	// The version of the object. If empty, the current version is deleted,
+	// which adds a delete marker in a versioned bucket.
+	VersionID string
+
+	// The size of the object in bytes
+	Size int64
+
+	// The date the object was last modified
+	LastModified time.Time
+
+	// The entity tag of the object
+	ETag string
+
+	// Whether the version is the current version of the object
+	IsLatest bool
+
+	// Whether the version is a delete marker
+	IsDeleteMarker bool
+}
+
+// MapFromObject sets the fields of an ObjectVersion from a ListObjectsV2
+// entry
+func (o *ObjectVersion) MapFromObject(obj types.Object) {
+	o.Key = aws.ToString(obj.Key)
+	o.Size = aws.ToInt64(obj.Size)
+	o.LastModified = aws.ToTime(obj.LastModified)
+	o.ETag = aws.ToString(obj.ETag)
+	o.IsLatest = true
+}
+
+// MapFromVersion sets the fields of an ObjectVersion from a
+// ListObjectVersions entry
+func (o *ObjectVersion) MapFromVersion(v types.ObjectVersion) {
+	o.Key = aws.ToString(v.Key)
+	o.VersionID = aws.ToString(v.VersionId)
+	o.Size = aws.ToInt64(v.Size)
+	o.LastModified = aws.ToTime(v.LastModified)
+	o.ETag = aws.ToString(v.ETag)
+	o.IsLatest = aws.ToBool(v.IsLatest)
+}
+
+// MapFromDeleteMarker sets the fields of an ObjectVersion from a delete
+// marker listed by ListObjectVersions
+func (o *ObjectVersion) MapFromDeleteMarker(m types.DeleteMarkerEntry) {
+	o.Key = aws.ToString(m.Key)
+	o.VersionID = aws.ToString(m.VersionId)
+	o.LastModified = aws.ToTime(m.LastModified)
+	o.IsLatest = aws.ToBool(m.IsLatest)
+	o.IsDeleteMarker = true
+}
+
 // CompletedPart includes details of the parts that were uploaded.
 type CompletedPart struct {
 
@@ -265,6 +398,10 @@ type CompletedPart struct {
 	// [Checking object integrity]: https://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html#large-object-checksums
 	ChecksumCRC32C *string
 
+	// The base64-encoded, 64-bit CRC64NVME checksum of the part. This will only be
+	// present if the part was uploaded with the CRC64NVME checksum algorithm.
+	ChecksumCRC64NVME *string
+
 	// The base64-encoded, 160-bit SHA-1 digest of the object. This will only be
 	// present if it was uploaded with the object. When you use the API operation on an
 	// object that was uploaded using multipart uploads, this value may not be a direct
@@ -308,12 +445,13 @@ type CompletedPart struct {
 // MapCompletedPart maps CompletedPart to s3 types
 func (cp CompletedPart) MapCompletedPart() types.CompletedPart {
 	return types.CompletedPart{
-		ChecksumCRC32:  cp.ChecksumCRC32,
-		ChecksumCRC32C: cp.ChecksumCRC32C,
-		ChecksumSHA1:   cp.ChecksumSHA1,
-		ChecksumSHA256: cp.ChecksumSHA256,
-		ETag:           cp.ETag,
-		PartNumber:     cp.PartNumber,
+		ChecksumCRC32:     cp.ChecksumCRC32,
+		ChecksumCRC32C:    cp.ChecksumCRC32C,
+		ChecksumCRC64NVME: cp.ChecksumCRC64NVME,
+		ChecksumSHA1:      cp.ChecksumSHA1,
+		ChecksumSHA256:    cp.ChecksumSHA256,
+		ETag:              cp.ETag,
+		PartNumber:        cp.PartNumber,
 	}
 }
 
@@ -321,12 +459,26 @@ func (cp CompletedPart) MapCompletedPart() types.CompletedPart {
 func (cp *CompletedPart) MapFrom(resp *s3.UploadPartOutput, partNum *int32) {
 	cp.ChecksumCRC32 = resp.ChecksumCRC32
 	cp.ChecksumCRC32C = resp.ChecksumCRC32C
+	cp.ChecksumCRC64NVME = resp.ChecksumCRC64NVME
 	cp.ChecksumSHA1 = resp.ChecksumSHA1
 	cp.ChecksumSHA256 = resp.ChecksumSHA256
 	cp.ETag = resp.ETag
 	cp.PartNumber = partNum
 }
 
+// MapFromCopyPart set CompletedPart fields from s3 UploadPartCopyOutput
+func (cp *CompletedPart) MapFromCopyPart(resp *s3.UploadPartCopyOutput, partNum *int32) {
+	if r := resp.CopyPartResult; r != nil {
+		cp.ChecksumCRC32 = r.ChecksumCRC32
+		cp.ChecksumCRC32C = r.ChecksumCRC32C
+		cp.ChecksumCRC64NVME = r.ChecksumCRC64NVME
+		cp.ChecksumSHA1 = r.ChecksumSHA1
+		cp.ChecksumSHA256 = r.ChecksumSHA256
+		cp.ETag = r.ETag
+	}
+	cp.PartNumber = partNum
+}
+
 // RequestCharged indicates that the requester was successfully charged for the request.
 type RequestCharged string
 
@@ -376,6 +528,106 @@ const (
 	ReplicationStatusCompleted ReplicationStatus = "COMPLETED"
 )
 
+// SymlinkPolicy specifies how symbolic links are handled when walking a local
+// directory
+type SymlinkPolicy string
+
+// Enum values for SymlinkPolicy
+const (
+	// SymlinkPolicySkip ignores symbolic links
+	SymlinkPolicySkip SymlinkPolicy = "SKIP"
+
+	// SymlinkPolicyFollow transfers the files and directories symbolic links
+	// point to, visiting each directory at most once
+	SymlinkPolicyFollow SymlinkPolicy = "FOLLOW"
+)
+
+// FailurePolicy specifies how a directory transfer reacts to the failure of a
+// single object
+type FailurePolicy string
+
+// Enum values for FailurePolicy
+const (
+	// FailurePolicyAbort cancels the remaining transfers and returns the
+	// error of the first failed object
+	FailurePolicyAbort FailurePolicy = "ABORT"
+
+	// FailurePolicyContinue records the failure in the output and carries on
+	// with the remaining objects
+	FailurePolicyContinue FailurePolicy = "CONTINUE"
+)
+
+// SyncDirection specifies which side of a Sync is mirrored to the other
+type SyncDirection string
+
+// Enum values for SyncDirection
+const (
+	// SyncDirectionUpload makes the bucket prefix mirror the local directory
+	SyncDirectionUpload SyncDirection = "UPLOAD"
+
+	// SyncDirectionDownload makes the local directory mirror the bucket
+	// prefix
+	SyncDirectionDownload SyncDirection = "DOWNLOAD"
+)
+
+// SyncActionType specifies what a Sync does to a file or object
+type SyncActionType string
+
+// Enum values for SyncActionType
+const (
+	// SyncActionUpload uploads a local file
+	SyncActionUpload SyncActionType = "UPLOAD"
+
+	// SyncActionDownload downloads an object
+	SyncActionDownload SyncActionType = "DOWNLOAD"
+
+	// SyncActionDelete deletes an object or a local file which does not
+	// exist on the mirrored side
+	SyncActionDelete SyncActionType = "DELETE"
+)
+
+// SyncReason specifies why a Sync planned an action
+type SyncReason string
+
+// Enum values for SyncReason
+const (
+	// SyncReasonMissing means the file or object does not exist on the
+	// destination side
+	SyncReasonMissing SyncReason = "MISSING"
+
+	// SyncReasonSizeChanged means the sizes of the file and the object
+	// differ
+	SyncReasonSizeChanged SyncReason = "SIZE_CHANGED"
+
+	// SyncReasonModified means the modification times of the file and the
+	// object show a change
+	SyncReasonModified SyncReason = "MODIFIED"
+
+	// SyncReasonChecksumChanged means the content of the file does not match
+	// the ETag of the object
+	SyncReasonChecksumChanged SyncReason = "CHECKSUM_CHANGED"
+
+	// SyncReasonExtraneous means the file or object does not exist on the
+	// mirrored side
+	SyncReasonExtraneous SyncReason = "EXTRANEOUS"
+)
+
+// ArchiveFormat specifies the format of an archive object
+type ArchiveFormat string
+
+// Enum values for ArchiveFormat
+const (
+	// ArchiveFormatTar is an uncompressed tar archive
+	ArchiveFormatTar ArchiveFormat = "TAR"
+
+	// ArchiveFormatTarGzip is a tar archive compressed with gzip
+	ArchiveFormatTarGzip ArchiveFormat = "TAR_GZIP"
+
+	// ArchiveFormatZip is a zip archive whose members are compressed with
+	// deflate
+	ArchiveFormatZip ArchiveFormat = "ZIP"
+)
+
 // A WriteAtBuffer provides a in memory buffer supporting the io.WriterAt interface
 // Can be used with the s3manager.Downloader to download content to a buffer
 // in memory. Safe to use concurrently.
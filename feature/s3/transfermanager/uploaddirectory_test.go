@@ -0,0 +1,284 @@
+package transfermanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	s3testing "github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/internal/testing"
+	"github.com/IBM/ibm-cos-sdk-go-v2/feature/s3/transfermanager/types"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/s3"
+)
+
+// writeTree creates the files in dir, each containing its own relative path.
+func writeTree(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// uploadedKeys returns the sorted keys of the PutObject calls logged by c.
+func uploadedKeys(c *s3testing.TransferManagerLoggingClient) []string {
+	var keys []string
+	for _, p := range c.Params {
+		if in, ok := p.(*s3.PutObjectInput); ok {
+			keys = append(keys, aws.ToString(in.Key))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestUploadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir,
+		"a.txt",
+		"b.log",
+		"sub/c.txt",
+		"sub/deeper/d.txt",
+		"sub/skip.txt",
+		"tmp/e.txt",
+	)
+
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c.ConsumeBody = true
+	mgr := New(c, Options{})
+
+	out, err := mgr.UploadDirectory(context.Background(), &UploadDirectoryInput{
+		Bucket:    "bucket",
+		Source:    dir,
+		KeyPrefix: "backup",
+		Include:   []string{"*.txt"},
+		Exclude:   []string{"tmp"},
+		KeyFunc: func(path string) string {
+			if path == "sub/skip.txt" {
+				return ""
+			}
+			return path
+		},
+		Callback: func(in *PutObjectInput) {
+			in.ContentType = "text/plain"
+		},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	expect := []string{"backup/a.txt", "backup/sub/c.txt", "backup/sub/deeper/d.txt"}
+	if diff := cmpDiff(expect, uploadedKeys(c)); len(diff) > 0 {
+		t.Error(diff)
+	}
+	if e, a := 3, out.ObjectsUploaded; e != a {
+		t.Errorf("expect %d objects uploaded, got %d", e, a)
+	}
+	if e, a := int64(len("a.txt")+len("sub/c.txt")+len("sub/deeper/d.txt")), out.BytesUploaded; e != a {
+		t.Errorf("expect %d bytes uploaded, got %d", e, a)
+	}
+	for _, p := range c.Params {
+		if e, a := "text/plain", aws.ToString(p.(*s3.PutObjectInput).ContentType); e != a {
+			t.Errorf("expect content type %q, got %q", e, a)
+		}
+	}
+}
+
+func TestUploadDirectory_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "data/a.txt")
+	other := t.TempDir()
+	writeTree(t, other, "b.txt")
+
+	if err := os.Symlink(other, filepath.Join(dir, "linked")); err != nil {
+		t.Skipf("symlinks not supported, %v", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "data", "a.txt"), filepath.Join(dir, "alias.txt")); err != nil {
+		t.Fatal(err)
+	}
+	// a cycle back to the root must not be walked forever
+	if err := os.Symlink(dir, filepath.Join(dir, "data", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		policy types.SymlinkPolicy
+		expect []string
+	}{
+		"skip": {
+			expect: []string{"data/a.txt"},
+		},
+		"follow": {
+			policy: types.SymlinkPolicyFollow,
+			expect: []string{"alias.txt", "data/a.txt", "linked/b.txt"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, _, _ := s3testing.NewUploadLoggingClient(nil)
+			mgr := New(c, Options{})
+
+			_, err := mgr.UploadDirectory(context.Background(), &UploadDirectoryInput{
+				Bucket:        "bucket",
+				Source:        dir,
+				SymlinkPolicy: tc.policy,
+			})
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if diff := cmpDiff(tc.expect, uploadedKeys(c)); len(diff) > 0 {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestUploadDirectory_FailurePolicy(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.txt", "bad1.txt", "bad2.txt", "c.txt")
+
+	newClient := func() *s3testing.TransferManagerLoggingClient {
+		c, _, _ := s3testing.NewUploadLoggingClient(nil)
+		c.PutObjectFn = func(c *s3testing.TransferManagerLoggingClient, in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+			if strings.HasPrefix(aws.ToString(in.Key), "bad") {
+				return nil, fmt.Errorf("put failed")
+			}
+			return &s3.PutObjectOutput{}, nil
+		}
+		return c
+	}
+
+	t.Run("continue", func(t *testing.T) {
+		mgr := New(newClient(), Options{})
+		out, err := mgr.UploadDirectory(context.Background(), &UploadDirectoryInput{
+			Bucket:        "bucket",
+			Source:        dir,
+			FailurePolicy: types.FailurePolicyContinue,
+		})
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if e, a := 2, out.ObjectsUploaded; e != a {
+			t.Errorf("expect %d objects uploaded, got %d", e, a)
+		}
+		if e, a := 2, out.ObjectsFailed; e != a {
+			t.Fatalf("expect %d objects failed, got %d", e, a)
+		}
+		if e, a := "bad1.txt", out.Failures[0].Key; e != a {
+			t.Errorf("expect failed key %q, got %q", e, a)
+		}
+		if e, a := filepath.Join(dir, "bad2.txt"), out.Failures[1].Path; e != a {
+			t.Errorf("expect failed path %q, got %q", e, a)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		mgr := New(newClient(), Options{Concurrency: 1})
+		out, err := mgr.UploadDirectory(context.Background(), &UploadDirectoryInput{
+			Bucket: "bucket",
+			Source: dir,
+		})
+		if err == nil || !strings.Contains(err.Error(), "put failed") {
+			t.Fatalf("expect put failed error, got %v", err)
+		}
+		if e, a := 1, len(out.Failures); e != a {
+			t.Errorf("expect %d failure, got %d", e, a)
+		}
+		if out.ObjectsUploaded > 1 {
+			t.Errorf("expect upload to stop after the failure, got %d uploaded", out.ObjectsUploaded)
+		}
+	})
+}
+
+// inFlightClient tracks the maximum number of concurrent PutObject and
+// UploadPart requests.
+type inFlightClient struct {
+	*s3testing.TransferManagerLoggingClient
+
+	inFlight, max atomic.Int32
+}
+
+func (c *inFlightClient) track() func() {
+	n := c.inFlight.Add(1)
+	for {
+		m := c.max.Load()
+		if n <= m || c.max.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return func() { c.inFlight.Add(-1) }
+}
+
+func (c *inFlightClient) PutObject(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	defer c.track()()
+	return c.TransferManagerLoggingClient.PutObject(ctx, in, optFns...)
+}
+
+func (c *inFlightClient) UploadPart(ctx context.Context, in *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	defer c.track()()
+	return c.TransferManagerLoggingClient.UploadPart(ctx, in, optFns...)
+}
+
+func TestUploadDirectory_SharedConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	large := make([]byte, minPartSizeBytes*2+1)
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("large%d", i)), large, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTree(t, dir, "small1", "small2", "small3")
+
+	logging, _, _ := s3testing.NewUploadLoggingClient(nil)
+	c := &inFlightClient{TransferManagerLoggingClient: logging}
+	mgr := New(c, Options{Concurrency: 2, MultipartUploadThreshold: minPartSizeBytes})
+
+	out, err := mgr.UploadDirectory(context.Background(), &UploadDirectoryInput{
+		Bucket: "bucket",
+		Source: dir,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 6, out.ObjectsUploaded; e != a {
+		t.Errorf("expect %d objects uploaded, got %d", e, a)
+	}
+	if m := c.max.Load(); m > 2 {
+		t.Errorf("expect at most 2 requests in flight, got %d", m)
+	}
+}
+
+func TestUploadDirectory_InvalidInput(t *testing.T) {
+	c, _, _ := s3testing.NewUploadLoggingClient(nil)
+	mgr := New(c, Options{})
+
+	file := filepath.Join(t.TempDir(), "file")
+	writeTree(t, filepath.Dir(file), "file")
+
+	cases := map[string]*UploadDirectoryInput{
+		"no bucket":    {Source: filepath.Dir(file)},
+		"missing":      {Bucket: "bucket", Source: filepath.Join(file, "missing")},
+		"not a dir":    {Bucket: "bucket", Source: file},
+		"invalid glob": {Bucket: "bucket", Source: filepath.Dir(file), Include: []string{"[a-"}},
+	}
+	for name, in := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := mgr.UploadDirectory(context.Background(), in); err == nil {
+				t.Errorf("expect error")
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package keyprotect
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/credentials/ibmiam/token"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	smithy "github.com/aws/smithy-go"
+)
+
+const testInstanceID = "2ac04c5b-4f7d-4c3a-b2cb-1c9a6d4a5e8d"
+
+// stubServer is a minimal in-memory stand-in for the Key Protect API.
+type stubServer struct {
+	t *testing.T
+
+	m        sync.Mutex
+	keys     []map[string]interface{}
+	policies map[string]interface{}
+	failures int
+}
+
+func (s *stubServer) writeCollection(w http.ResponseWriter, status int, mediaType string, resources ...interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Correlation-Id", "correlation-"+strconv.Itoa(status))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(collection(mediaType, resources...))
+}
+
+func (s *stubServer) writeError(w http.ResponseWriter, status int, code, message string) {
+	s.writeCollection(w, status, "application/vnd.ibm.kms.error+json", map[string]interface{}{
+		"errorMsg": http.StatusText(status),
+		"reasons": []interface{}{
+			map[string]interface{}{"code": code, "message": message, "status": status},
+		},
+	})
+}
+
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if e, a := "Bearer test-token", r.Header.Get("Authorization"); e != a {
+		s.t.Errorf("expect authorization %q, got %q", e, a)
+	}
+	if e, a := testInstanceID, r.Header.Get("Bluemix-Instance"); e != a {
+		s.t.Errorf("expect instance %q, got %q", e, a)
+	}
+	if s.failures > 0 {
+		s.failures--
+		s.writeError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE_ERR", "try again")
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v2/keys")
+	switch {
+	case r.Method == "POST" && path == "":
+		if e, a := mediaTypeKey, r.Header.Get("Content-Type"); e != a {
+			s.t.Errorf("expect content type %q, got %q", e, a)
+		}
+		key := body["resources"].([]interface{})[0].(map[string]interface{})
+		key["id"] = fmt.Sprintf("key-%d", len(s.keys))
+		key["crn"] = "crn:v1:bluemix:public:kms:us-south:a/acct:" + testInstanceID + ":key:" + key["id"].(string)
+		key["state"] = 1
+		key["creationDate"] = "2024-05-01T10:20:30Z"
+		s.keys = append(s.keys, key)
+		s.writeCollection(w, http.StatusCreated, mediaTypeKey, key)
+
+	case r.Method == "GET" && path == "":
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var page []interface{}
+		for i := offset; i < len(s.keys) && len(page) < limit; i++ {
+			page = append(page, s.keys[i])
+		}
+		s.writeCollection(w, http.StatusOK, mediaTypeKey, page...)
+
+	case r.Method == "GET" && path == "/missing":
+		s.writeError(w, http.StatusNotFound, "KEY_NOT_FOUND_ERR", "Key does not exist")
+
+	case r.Method == "GET" && strings.HasSuffix(path, "/policies"):
+		s.writeCollection(w, http.StatusOK, mediaTypePolicy, s.policies)
+
+	case r.Method == "PUT" && strings.HasSuffix(path, "/policies"):
+		if e, a := "rotation", r.URL.Query().Get("policy"); e != a {
+			s.t.Errorf("expect policy query %q, got %q", e, a)
+		}
+		s.policies = body["resources"].([]interface{})[0].(map[string]interface{})
+		s.policies["lastUpdateDate"] = "2024-05-02T10:20:30+0000"
+		s.writeCollection(w, http.StatusOK, mediaTypePolicy, s.policies)
+
+	case r.Method == "GET":
+		s.writeCollection(w, http.StatusOK, mediaTypeKey, map[string]interface{}{
+			"id":          strings.TrimPrefix(path, "/"),
+			"extractable": true,
+			"payload":     base64.StdEncoding.EncodeToString([]byte("standard key")),
+			"keyVersion":  map[string]interface{}{"id": "v1"},
+		})
+
+	case r.Method == "POST" && strings.HasSuffix(path, "/actions/rotate"):
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == "POST" && strings.HasSuffix(path, "/actions/wrap"):
+		plaintext, _ := body["plaintext"].(string)
+		resp := map[string]interface{}{"keyVersion": map[string]interface{}{"id": "v1"}}
+		if plaintext == "" {
+			plaintext = base64.StdEncoding.EncodeToString([]byte("generated dek"))
+			resp["plaintext"] = plaintext
+		}
+		resp["ciphertext"] = "wrapped:" + plaintext
+		json.NewEncoder(w).Encode(resp)
+
+	case r.Method == "POST" && strings.HasSuffix(path, "/actions/unwrap"):
+		ciphertext := body["ciphertext"].(string)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"plaintext":  strings.TrimPrefix(ciphertext, "wrapped:"),
+			"keyVersion": map[string]interface{}{"id": "v1"},
+		})
+
+	default:
+		s.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newTestClient(t *testing.T) (*Client, *stubServer) {
+	stub := &stubServer{t: t}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	client := New(Options{
+		BaseEndpoint: aws.String(server.URL),
+		InstanceID:   testInstanceID,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{
+				Token:             token.Token{AccessToken: "test-token", TokenType: "Bearer"},
+				ServiceInstanceID: "cos-instance",
+			}, nil
+		}),
+	})
+	return client, stub
+}
+
+func TestClient_Keys(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		out, err := client.CreateKey(ctx, &CreateKeyInput{
+			Name:        aws.String(fmt.Sprintf("root-%d", i)),
+			Description: aws.String("bucket root key"),
+		})
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if e, a := fmt.Sprintf("key-%d", i), aws.ToString(out.Key.ID); e != a {
+			t.Errorf("expect key id %v, got %v", e, a)
+		}
+		if e, a := types.KeyStateActive, out.Key.State; e != a {
+			t.Errorf("expect state %v, got %v", e, a)
+		}
+		if out.Key.Extractable {
+			t.Errorf("expect root key to not be extractable")
+		}
+		if out.Key.CreationDate == nil {
+			t.Errorf("expect creation date")
+		}
+	}
+
+	p := NewListKeysPaginator(client, &ListKeysInput{}, func(o *ListKeysPaginatorOptions) {
+		o.Limit = 2
+	})
+	var names []string
+	var pages int
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		pages++
+		for _, k := range page.Keys {
+			names = append(names, aws.ToString(k.Name))
+		}
+	}
+	if e, a := 2, pages; e != a {
+		t.Errorf("expect %d pages, got %d", e, a)
+	}
+	if e, a := "[root-0 root-1 root-2]", fmt.Sprint(names); e != a {
+		t.Errorf("expect keys %v, got %v", e, a)
+	}
+
+	get, err := client.GetKey(ctx, &GetKeyInput{ID: aws.String("standard")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := "standard key", string(get.Key.Payload); e != a {
+		t.Errorf("expect payload %q, got %q", e, a)
+	}
+	if e, a := "v1", aws.ToString(get.Key.KeyVersion.ID); e != a {
+		t.Errorf("expect key version %v, got %v", e, a)
+	}
+
+	if _, err := client.RotateKey(ctx, &RotateKeyInput{ID: aws.String("key-0")}); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+}
+
+func TestClient_WrapUnwrap(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	wrap, err := client.WrapKey(ctx, &WrapKeyInput{ID: aws.String("key-0")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := "generated dek", string(wrap.Plaintext); e != a {
+		t.Errorf("expect generated key %q, got %q", e, a)
+	}
+
+	unwrap, err := client.UnwrapKey(ctx, &UnwrapKeyInput{
+		ID:         aws.String("key-0"),
+		Ciphertext: wrap.Ciphertext,
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := "generated dek", string(unwrap.Plaintext); e != a {
+		t.Errorf("expect unwrapped key %q, got %q", e, a)
+	}
+	if e, a := "v1", aws.ToString(unwrap.KeyVersion.ID); e != a {
+		t.Errorf("expect key version %v, got %v", e, a)
+	}
+}
+
+func TestClient_Policies(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.PutKeyPolicies(ctx, &PutKeyPoliciesInput{
+		ID: aws.String("key-0"),
+		Rotation: &types.RotationPolicy{
+			Enabled:       aws.Bool(true),
+			IntervalMonth: aws.Int32(3),
+		},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	out, err := client.GetKeyPolicies(ctx, &GetKeyPoliciesInput{ID: aws.String("key-0")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if out.Rotation == nil {
+		t.Fatalf("expect rotation policy")
+	}
+	if e, a := int32(3), aws.ToInt32(out.Rotation.IntervalMonth); e != a {
+		t.Errorf("expect interval %v, got %v", e, a)
+	}
+	if out.DualAuthDelete != nil {
+		t.Errorf("expect no dual auth delete policy, got %v", out.DualAuthDelete)
+	}
+	if e, a := 1, len(out.Policies); e != a {
+		t.Fatalf("expect %d policies, got %d", e, a)
+	}
+	if out.Policies[0].LastUpdateDate == nil {
+		t.Errorf("expect last update date")
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	client, stub := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.GetKey(ctx, &GetKeyInput{ID: aws.String("missing")})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expect API error, got %v", err)
+	}
+	if e, a := "KEY_NOT_FOUND_ERR", apiErr.ErrorCode(); e != a {
+		t.Errorf("expect error code %v, got %v", e, a)
+	}
+	if e, a := "Key does not exist", apiErr.ErrorMessage(); e != a {
+		t.Errorf("expect error message %v, got %v", e, a)
+	}
+	var opErr *smithy.OperationError
+	if !errors.As(err, &opErr) || opErr.Service() != ServiceID {
+		t.Errorf("expect operation error for %v, got %v", ServiceID, err)
+	}
+
+	stub.failures = 2
+	if _, err := client.RotateKey(ctx, &RotateKeyInput{ID: aws.String("key-0")}); err != nil {
+		t.Fatalf("expect retried request to succeed, got %v", err)
+	}
+
+	_, err = client.UnwrapKey(ctx, &UnwrapKeyInput{})
+	var invalidParams smithy.InvalidParamsError
+	if !errors.As(err, &invalidParams) {
+		t.Fatalf("expect invalid params error, got %v", err)
+	}
+	if e, a := 2, invalidParams.Len(); e != a {
+		t.Errorf("expect %d invalid params, got %d", e, a)
+	}
+
+	instanceCRN := "crn:v1:bluemix:public:kms:us-south:a/acct:" + testInstanceID + "::"
+	if _, err := client.ListKeys(ctx, &ListKeysInput{}, WithInstanceID(instanceCRN)); err != nil {
+		t.Errorf("expect instance CRN to be accepted, got %v", err)
+	}
+
+	_, err = client.ListKeys(ctx, &ListKeysInput{}, WithInstanceID(""))
+	if err == nil || !strings.Contains(err.Error(), "instance ID is required") {
+		t.Errorf("expect missing instance error, got %v", err)
+	}
+}
+
+func TestResolveEndpoint(t *testing.T) {
+	cases := map[string]struct {
+		Region       string
+		EndpointType EndpointType
+		Expect       string
+		ExpectErr    bool
+	}{
+		"public":  {Region: "us-south", Expect: "https://us-south.kms.cloud.ibm.com"},
+		"private": {Region: "eu-de", EndpointType: EndpointTypePrivate, Expect: "https://private.eu-de.kms.cloud.ibm.com"},
+		"no region": {
+			ExpectErr: true,
+		},
+		"unknown type": {Region: "us-south", EndpointType: "direct", ExpectErr: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ResolveEndpoint(c.Region, c.EndpointType)
+			if c.ExpectErr {
+				if err == nil {
+					t.Fatalf("expect error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if e, a := c.Expect, actual; e != a {
+				t.Errorf("expect %v, got %v", e, a)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package keyprotect
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// CreateKey creates a new root or standard key. Root keys, which have
+// Extractable set to false, can be used to protect COS buckets with SSE-KP and
+// to wrap data encryption keys. If Payload is set, the key material is
+// imported instead of generated by the service.
+func (c *Client) CreateKey(ctx context.Context, params *CreateKeyInput, optFns ...func(*Options)) (*CreateKeyOutput, error) {
+	if params == nil {
+		params = &CreateKeyInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "CreateKey", params, optFns, c.addOperationCreateKeyMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*CreateKeyOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type CreateKeyInput struct {
+
+	// The human-readable name of the key.
+	//
+	// This member is required.
+	Name *string
+
+	// A description of the key.
+	Description *string
+
+	// Whether the key material can leave the service. Set to false to create a
+	// root key.
+	Extractable bool
+
+	// Key material to import. If nil, the service generates the key material.
+	Payload []byte
+
+	// Aliases to assign to the key.
+	Aliases []string
+
+	// The key ring to create the key in. If nil, the default key ring is used.
+	KeyRingID *string
+
+	noSmithyDocumentSerde
+}
+
+type CreateKeyOutput struct {
+
+	// The created key.
+	Key *types.Key
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationCreateKeyMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpCreateKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpCreateKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addValidationMiddleware(stack, validateOpCreateKeyInput); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
@@ -0,0 +1,59 @@
+package keyprotect
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// GetKey retrieves a key and its metadata by ID or alias. The key material is
+// included for standard keys.
+func (c *Client) GetKey(ctx context.Context, params *GetKeyInput, optFns ...func(*Options)) (*GetKeyOutput, error) {
+	if params == nil {
+		params = &GetKeyInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "GetKey", params, optFns, c.addOperationGetKeyMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*GetKeyOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type GetKeyInput struct {
+
+	// The ID or alias of the key.
+	//
+	// This member is required.
+	ID *string
+
+	noSmithyDocumentSerde
+}
+
+type GetKeyOutput struct {
+
+	// The requested key.
+	Key *types.Key
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationGetKeyMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpGetKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpGetKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addValidationMiddleware(stack, validateOpGetKeyInput); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
@@ -0,0 +1,65 @@
+package keyprotect
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// GetKeyPolicies retrieves the rotation and dual authorization delete
+// policies of a key.
+func (c *Client) GetKeyPolicies(ctx context.Context, params *GetKeyPoliciesInput, optFns ...func(*Options)) (*GetKeyPoliciesOutput, error) {
+	if params == nil {
+		params = &GetKeyPoliciesInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "GetKeyPolicies", params, optFns, c.addOperationGetKeyPoliciesMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*GetKeyPoliciesOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type GetKeyPoliciesInput struct {
+
+	// The ID or alias of the key.
+	//
+	// This member is required.
+	ID *string
+
+	noSmithyDocumentSerde
+}
+
+type GetKeyPoliciesOutput struct {
+
+	// The rotation policy, if one is set.
+	Rotation *types.RotationPolicy
+
+	// The dual authorization delete policy, if one is set.
+	DualAuthDelete *types.DualAuthDeletePolicy
+
+	// The bookkeeping fields of each returned policy.
+	Policies []types.PolicyMetadata
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationGetKeyPoliciesMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpGetKeyPolicies{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpGetKeyPolicies{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addValidationMiddleware(stack, validateOpGetKeyPoliciesInput); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
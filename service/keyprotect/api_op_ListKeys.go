@@ -0,0 +1,161 @@
+package keyprotect
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ListKeys lists the keys in the instance. Key material is never returned by
+// ListKeys, use GetKey to retrieve a standard key's payload.
+func (c *Client) ListKeys(ctx context.Context, params *ListKeysInput, optFns ...func(*Options)) (*ListKeysOutput, error) {
+	if params == nil {
+		params = &ListKeysInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "ListKeys", params, optFns, c.addOperationListKeysMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*ListKeysOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type ListKeysInput struct {
+
+	// The maximum number of keys to return, up to 5000. If nil, the service
+	// default of 200 is used.
+	Limit *int32
+
+	// The number of keys to skip.
+	Offset *int32
+
+	// Only return keys in one of these states.
+	State []types.KeyState
+
+	// Only return root keys (false) or standard keys (true).
+	Extractable *bool
+
+	// Only return keys in this key ring.
+	KeyRingID *string
+
+	noSmithyDocumentSerde
+}
+
+type ListKeysOutput struct {
+
+	// The keys in the requested page.
+	Keys []types.Key
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationListKeysMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpListKeys{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpListKeys{}, middleware.After); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
+
+// ListKeysPaginatorOptions is the paginator options for ListKeys
+type ListKeysPaginatorOptions struct {
+	// The maximum number of keys to return per page. If zero, the service
+	// default of 200 is used.
+	Limit int32
+}
+
+// ListKeysPaginator is a paginator for ListKeys
+type ListKeysPaginator struct {
+	options   ListKeysPaginatorOptions
+	client    ListKeysAPIClient
+	params    *ListKeysInput
+	offset    int32
+	firstPage bool
+	lastPage  bool
+}
+
+// NewListKeysPaginator returns a new ListKeysPaginator
+func NewListKeysPaginator(client ListKeysAPIClient, params *ListKeysInput, optFns ...func(*ListKeysPaginatorOptions)) *ListKeysPaginator {
+	if params == nil {
+		params = &ListKeysInput{}
+	}
+
+	options := ListKeysPaginatorOptions{}
+	if params.Limit != nil {
+		options.Limit = *params.Limit
+	}
+
+	for _, fn := range optFns {
+		fn(&options)
+	}
+
+	var offset int32
+	if params.Offset != nil {
+		offset = *params.Offset
+	}
+
+	return &ListKeysPaginator{
+		options:   options,
+		client:    client,
+		params:    params,
+		offset:    offset,
+		firstPage: true,
+	}
+}
+
+// HasMorePages returns a boolean indicating whether more pages are available
+func (p *ListKeysPaginator) HasMorePages() bool {
+	return p.firstPage || !p.lastPage
+}
+
+// NextPage retrieves the next ListKeys page.
+func (p *ListKeysPaginator) NextPage(ctx context.Context, optFns ...func(*Options)) (*ListKeysOutput, error) {
+	if !p.HasMorePages() {
+		return nil, fmt.Errorf("no more pages available")
+	}
+
+	params := *p.params
+	offset := p.offset
+	params.Offset = &offset
+
+	var limit *int32
+	if p.options.Limit > 0 {
+		limit = &p.options.Limit
+	}
+	params.Limit = limit
+
+	result, err := p.client.ListKeys(ctx, &params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	p.firstPage = false
+
+	n := int32(len(result.Keys))
+	p.offset += n
+	// the service does not return a continuation token, a short page is the
+	// last one
+	p.lastPage = n == 0 || (limit != nil && n < *limit) || (limit == nil && n < defaultListKeysLimit)
+
+	return result, nil
+}
+
+// defaultListKeysLimit is the page size used by the service when no limit is
+// given.
+const defaultListKeysLimit = 200
+
+// ListKeysAPIClient is a client that implements the ListKeys operation.
+type ListKeysAPIClient interface {
+	ListKeys(context.Context, *ListKeysInput, ...func(*Options)) (*ListKeysOutput, error)
+}
+
+var _ ListKeysAPIClient = (*Client)(nil)
@@ -0,0 +1,71 @@
+package keyprotect
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// PutKeyPolicies sets the rotation and/or dual authorization delete policies
+// of a key. Policies which are nil are left unchanged.
+func (c *Client) PutKeyPolicies(ctx context.Context, params *PutKeyPoliciesInput, optFns ...func(*Options)) (*PutKeyPoliciesOutput, error) {
+	if params == nil {
+		params = &PutKeyPoliciesInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "PutKeyPolicies", params, optFns, c.addOperationPutKeyPoliciesMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*PutKeyPoliciesOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type PutKeyPoliciesInput struct {
+
+	// The ID or alias of the key.
+	//
+	// This member is required.
+	ID *string
+
+	// The rotation policy to set.
+	Rotation *types.RotationPolicy
+
+	// The dual authorization delete policy to set.
+	DualAuthDelete *types.DualAuthDeletePolicy
+
+	noSmithyDocumentSerde
+}
+
+type PutKeyPoliciesOutput struct {
+
+	// The rotation policy, if one is set.
+	Rotation *types.RotationPolicy
+
+	// The dual authorization delete policy, if one is set.
+	DualAuthDelete *types.DualAuthDeletePolicy
+
+	// The bookkeeping fields of each returned policy.
+	Policies []types.PolicyMetadata
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationPutKeyPoliciesMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpPutKeyPolicies{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpPutKeyPolicies{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addValidationMiddleware(stack, validateOpPutKeyPoliciesInput); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
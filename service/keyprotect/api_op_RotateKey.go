@@ -0,0 +1,59 @@
+package keyprotect
+
+import (
+	"context"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+// RotateKey creates a new version of a root key's material. Data encryption
+// keys wrapped by previous versions can still be unwrapped, and are rewrapped
+// with the latest version when unwrapped.
+func (c *Client) RotateKey(ctx context.Context, params *RotateKeyInput, optFns ...func(*Options)) (*RotateKeyOutput, error) {
+	if params == nil {
+		params = &RotateKeyInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "RotateKey", params, optFns, c.addOperationRotateKeyMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*RotateKeyOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type RotateKeyInput struct {
+
+	// The ID or alias of the root key.
+	//
+	// This member is required.
+	ID *string
+
+	// New key material, required if the key material was originally imported.
+	Payload []byte
+
+	noSmithyDocumentSerde
+}
+
+type RotateKeyOutput struct {
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationRotateKeyMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpRotateKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpRotateKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addValidationMiddleware(stack, validateOpRotateKeyInput); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
@@ -0,0 +1,76 @@
+package keyprotect
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// UnwrapKey decrypts a data encryption key which was wrapped with WrapKey.
+func (c *Client) UnwrapKey(ctx context.Context, params *UnwrapKeyInput, optFns ...func(*Options)) (*UnwrapKeyOutput, error) {
+	if params == nil {
+		params = &UnwrapKeyInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "UnwrapKey", params, optFns, c.addOperationUnwrapKeyMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*UnwrapKeyOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type UnwrapKeyInput struct {
+
+	// The ID or alias of the root key.
+	//
+	// This member is required.
+	ID *string
+
+	// The wrapped data encryption key returned by WrapKey.
+	//
+	// This member is required.
+	Ciphertext *string
+
+	// The additional authenticated data given when the key was wrapped.
+	AAD []string
+
+	noSmithyDocumentSerde
+}
+
+type UnwrapKeyOutput struct {
+
+	// The unwrapped data encryption key.
+	Plaintext []byte
+
+	// The data encryption key wrapped with the latest root key version, set
+	// if the root key was rotated since the key was wrapped.
+	Ciphertext *string
+
+	// The version of the root key that wrapped the given ciphertext.
+	KeyVersion *types.KeyVersion
+
+	// The version of the root key that wrapped the returned ciphertext.
+	RewrappedKeyVersion *types.KeyVersion
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationUnwrapKeyMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpUnwrapKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpUnwrapKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addValidationMiddleware(stack, validateOpUnwrapKeyInput); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
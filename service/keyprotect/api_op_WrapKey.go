@@ -0,0 +1,74 @@
+package keyprotect
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// WrapKey encrypts a data encryption key with a root key. If Plaintext is nil
+// the service generates a new 256-bit data encryption key and returns it in
+// the output alongside its wrapped form.
+func (c *Client) WrapKey(ctx context.Context, params *WrapKeyInput, optFns ...func(*Options)) (*WrapKeyOutput, error) {
+	if params == nil {
+		params = &WrapKeyInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "WrapKey", params, optFns, c.addOperationWrapKeyMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*WrapKeyOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type WrapKeyInput struct {
+
+	// The ID or alias of the root key.
+	//
+	// This member is required.
+	ID *string
+
+	// The data encryption key to wrap. If nil, a new key is generated.
+	Plaintext []byte
+
+	// Additional authenticated data, which must be provided again to unwrap
+	// the key.
+	AAD []string
+
+	noSmithyDocumentSerde
+}
+
+type WrapKeyOutput struct {
+
+	// The wrapped data encryption key. The value is opaque and must be passed
+	// unmodified to UnwrapKey.
+	Ciphertext *string
+
+	// The generated data encryption key, only set if no Plaintext was given.
+	Plaintext []byte
+
+	// The version of the root key used to wrap the key.
+	KeyVersion *types.KeyVersion
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationWrapKeyMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpWrapKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpWrapKey{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addValidationMiddleware(stack, validateOpWrapKeyInput); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
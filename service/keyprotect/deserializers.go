@@ -0,0 +1,454 @@
+package keyprotect
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	awsmiddleware "github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/keyprotect/types"
+	smithy "github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+type keyVersionDocument struct {
+	ID           string `json:"id"`
+	CreationDate string `json:"creationDate"`
+}
+
+type keyDocument struct {
+	ID             string              `json:"id"`
+	CRN            string              `json:"crn"`
+	Name           string              `json:"name"`
+	Description    string              `json:"description"`
+	Aliases        []string            `json:"aliases"`
+	State          int32               `json:"state"`
+	Extractable    bool                `json:"extractable"`
+	Imported       bool                `json:"imported"`
+	Deleted        bool                `json:"deleted"`
+	KeyRingID      string              `json:"keyRingID"`
+	AlgorithmType  string              `json:"algorithmType"`
+	KeyVersion     *keyVersionDocument `json:"keyVersion"`
+	Payload        string              `json:"payload"`
+	CreatedBy      string              `json:"createdBy"`
+	CreationDate   string              `json:"creationDate"`
+	LastUpdateDate string              `json:"lastUpdateDate"`
+	LastRotateDate string              `json:"lastRotateDate"`
+}
+
+type keyActionResultDocument struct {
+	Plaintext           string              `json:"plaintext"`
+	Ciphertext          string              `json:"ciphertext"`
+	KeyVersion          *keyVersionDocument `json:"keyVersion"`
+	RewrappedKeyVersion *keyVersionDocument `json:"rewrappedKeyVersion"`
+}
+
+type errorReasonDocument struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Status   int    `json:"status"`
+	MoreInfo string `json:"moreInfo"`
+}
+
+type errorDocument struct {
+	ErrorMsg string                `json:"errorMsg"`
+	Reasons  []errorReasonDocument `json:"reasons"`
+}
+
+// handleResponse invokes the next handler, returning the raw response if
+// the request succeeded or the deserialized service error if it failed.
+func handleResponse(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	response *smithyhttp.Response, out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	out, metadata, err = next.HandleDeserialize(ctx, in)
+	if err != nil {
+		return nil, out, metadata, err
+	}
+
+	response, ok := out.RawResponse.(*smithyhttp.Response)
+	if !ok {
+		return nil, out, metadata, &smithy.DeserializationError{Err: fmt.Errorf("unknown transport type %T", out.RawResponse)}
+	}
+
+	if id := response.Header.Get("Correlation-Id"); len(id) != 0 {
+		awsmiddleware.SetRequestIDMetadata(&metadata, id)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, out, metadata, restjson_deserializeOpError(response)
+	}
+	return response, out, metadata, nil
+}
+
+func restjson_deserializeOpError(response *smithyhttp.Response) error {
+	var errorBuffer bytes.Buffer
+	if _, err := io.Copy(&errorBuffer, response.Body); err != nil {
+		return &smithy.DeserializationError{Err: fmt.Errorf("failed to copy error response body, %w", err)}
+	}
+
+	fault := smithy.FaultClient
+	if response.StatusCode >= 500 {
+		fault = smithy.FaultServer
+	}
+	genericError := &smithy.GenericAPIError{
+		Code:    http.StatusText(response.StatusCode),
+		Message: http.StatusText(response.StatusCode),
+		Fault:   fault,
+	}
+
+	var doc collectionDocument
+	if err := json.Unmarshal(errorBuffer.Bytes(), &doc); err != nil {
+		return genericError
+	}
+	var resources []errorDocument
+	if err := json.Unmarshal(doc.Resources, &resources); err != nil || len(resources) == 0 {
+		return genericError
+	}
+
+	if len(resources[0].ErrorMsg) != 0 {
+		genericError.Message = resources[0].ErrorMsg
+	}
+	if reasons := resources[0].Reasons; len(reasons) != 0 {
+		if len(reasons[0].Code) != 0 {
+			genericError.Code = reasons[0].Code
+		}
+		if len(reasons[0].Message) != 0 {
+			genericError.Message = reasons[0].Message
+		}
+	}
+	return genericError
+}
+
+// decodeResources decodes the resources of a collection response into v,
+// which must be a pointer to a slice.
+func decodeResources(body io.Reader, v interface{}) error {
+	var doc collectionDocument
+	if err := json.NewDecoder(body).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return &smithy.DeserializationError{Err: fmt.Errorf("failed to decode response body, %w", err)}
+	}
+	if len(doc.Resources) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(doc.Resources, v); err != nil {
+		return &smithy.DeserializationError{Err: fmt.Errorf("failed to decode resources, %w", err)}
+	}
+	return nil
+}
+
+func decodeBytes(s string) ([]byte, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, &smithy.DeserializationError{Err: fmt.Errorf("failed to decode base64 value, %w", err)}
+	}
+	return b, nil
+}
+
+// parseTime parses the timestamps returned by the service, which are either
+// RFC 3339 or use a numeric zone offset without a colon.
+func parseTime(s string) *time.Time {
+	if len(s) == 0 {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05Z0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func ptrString(s string) *string {
+	if len(s) == 0 {
+		return nil
+	}
+	return &s
+}
+
+func keyVersionFromDocument(doc *keyVersionDocument) *types.KeyVersion {
+	if doc == nil || len(doc.ID) == 0 {
+		return nil
+	}
+	return &types.KeyVersion{
+		ID:           ptrString(doc.ID),
+		CreationDate: parseTime(doc.CreationDate),
+	}
+}
+
+func keyFromDocument(doc keyDocument) (types.Key, error) {
+	payload, err := decodeBytes(doc.Payload)
+	if err != nil {
+		return types.Key{}, err
+	}
+	return types.Key{
+		ID:             ptrString(doc.ID),
+		CRN:            ptrString(doc.CRN),
+		Name:           ptrString(doc.Name),
+		Description:    ptrString(doc.Description),
+		Aliases:        doc.Aliases,
+		State:          types.KeyState(doc.State),
+		Extractable:    doc.Extractable,
+		Imported:       doc.Imported,
+		Deleted:        doc.Deleted,
+		KeyRingID:      ptrString(doc.KeyRingID),
+		AlgorithmType:  ptrString(doc.AlgorithmType),
+		KeyVersion:     keyVersionFromDocument(doc.KeyVersion),
+		Payload:        payload,
+		CreatedBy:      ptrString(doc.CreatedBy),
+		CreationDate:   parseTime(doc.CreationDate),
+		LastUpdateDate: parseTime(doc.LastUpdateDate),
+		LastRotateDate: parseTime(doc.LastRotateDate),
+	}, nil
+}
+
+func policiesFromDocuments(docs []policyDocument) (rotation *types.RotationPolicy, dualAuthDelete *types.DualAuthDeletePolicy, meta []types.PolicyMetadata) {
+	for _, doc := range docs {
+		if doc.Rotation != nil {
+			rotation = &types.RotationPolicy{Enabled: doc.Rotation.Enabled, IntervalMonth: doc.Rotation.IntervalMonth}
+		}
+		if doc.DualAuthDelete != nil {
+			dualAuthDelete = &types.DualAuthDeletePolicy{Enabled: doc.DualAuthDelete.Enabled}
+		}
+		meta = append(meta, types.PolicyMetadata{
+			ID:             ptrString(doc.ID),
+			CRN:            ptrString(doc.CRN),
+			CreatedBy:      ptrString(doc.CreatedBy),
+			UpdatedBy:      ptrString(doc.UpdatedBy),
+			CreationDate:   parseTime(doc.CreationDate),
+			LastUpdateDate: parseTime(doc.LastUpdateDate),
+		})
+	}
+	return rotation, dualAuthDelete, meta
+}
+
+type restjson_deserializeOpCreateKey struct{}
+
+func (*restjson_deserializeOpCreateKey) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpCreateKey) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &CreateKeyOutput{}
+	out.Result = output
+
+	var docs []keyDocument
+	if err := decodeResources(response.Body, &docs); err != nil {
+		return out, metadata, err
+	}
+	if len(docs) != 0 {
+		key, err := keyFromDocument(docs[0])
+		if err != nil {
+			return out, metadata, err
+		}
+		output.Key = &key
+	}
+	return out, metadata, nil
+}
+
+type restjson_deserializeOpListKeys struct{}
+
+func (*restjson_deserializeOpListKeys) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpListKeys) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &ListKeysOutput{}
+	out.Result = output
+
+	var docs []keyDocument
+	if err := decodeResources(response.Body, &docs); err != nil {
+		return out, metadata, err
+	}
+	for _, doc := range docs {
+		key, err := keyFromDocument(doc)
+		if err != nil {
+			return out, metadata, err
+		}
+		output.Keys = append(output.Keys, key)
+	}
+	return out, metadata, nil
+}
+
+type restjson_deserializeOpGetKey struct{}
+
+func (*restjson_deserializeOpGetKey) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpGetKey) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &GetKeyOutput{}
+	out.Result = output
+
+	var docs []keyDocument
+	if err := decodeResources(response.Body, &docs); err != nil {
+		return out, metadata, err
+	}
+	if len(docs) != 0 {
+		key, err := keyFromDocument(docs[0])
+		if err != nil {
+			return out, metadata, err
+		}
+		output.Key = &key
+	}
+	return out, metadata, nil
+}
+
+type restjson_deserializeOpRotateKey struct{}
+
+func (*restjson_deserializeOpRotateKey) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpRotateKey) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	out.Result = &RotateKeyOutput{}
+	io.Copy(io.Discard, response.Body)
+	return out, metadata, nil
+}
+
+type restjson_deserializeOpWrapKey struct{}
+
+func (*restjson_deserializeOpWrapKey) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpWrapKey) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &WrapKeyOutput{}
+	out.Result = output
+
+	var doc keyActionResultDocument
+	if err := json.NewDecoder(response.Body).Decode(&doc); err != nil {
+		return out, metadata, &smithy.DeserializationError{Err: fmt.Errorf("failed to decode response body, %w", err)}
+	}
+	if output.Plaintext, err = decodeBytes(doc.Plaintext); err != nil {
+		return out, metadata, err
+	}
+	output.Ciphertext = ptrString(doc.Ciphertext)
+	output.KeyVersion = keyVersionFromDocument(doc.KeyVersion)
+	return out, metadata, nil
+}
+
+type restjson_deserializeOpUnwrapKey struct{}
+
+func (*restjson_deserializeOpUnwrapKey) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpUnwrapKey) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &UnwrapKeyOutput{}
+	out.Result = output
+
+	var doc keyActionResultDocument
+	if err := json.NewDecoder(response.Body).Decode(&doc); err != nil {
+		return out, metadata, &smithy.DeserializationError{Err: fmt.Errorf("failed to decode response body, %w", err)}
+	}
+	if output.Plaintext, err = decodeBytes(doc.Plaintext); err != nil {
+		return out, metadata, err
+	}
+	output.Ciphertext = ptrString(doc.Ciphertext)
+	output.KeyVersion = keyVersionFromDocument(doc.KeyVersion)
+	output.RewrappedKeyVersion = keyVersionFromDocument(doc.RewrappedKeyVersion)
+	return out, metadata, nil
+}
+
+type restjson_deserializeOpGetKeyPolicies struct{}
+
+func (*restjson_deserializeOpGetKeyPolicies) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpGetKeyPolicies) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &GetKeyPoliciesOutput{}
+	out.Result = output
+
+	var docs []policyDocument
+	if err := decodeResources(response.Body, &docs); err != nil {
+		return out, metadata, err
+	}
+	output.Rotation, output.DualAuthDelete, output.Policies = policiesFromDocuments(docs)
+	return out, metadata, nil
+}
+
+type restjson_deserializeOpPutKeyPolicies struct{}
+
+func (*restjson_deserializeOpPutKeyPolicies) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpPutKeyPolicies) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &PutKeyPoliciesOutput{}
+	out.Result = output
+
+	var docs []policyDocument
+	if err := decodeResources(response.Body, &docs); err != nil {
+		return out, metadata, err
+	}
+	output.Rotation, output.DualAuthDelete, output.Policies = policiesFromDocuments(docs)
+	return out, metadata, nil
+}
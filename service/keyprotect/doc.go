@@ -0,0 +1,26 @@
+// Package keyprotect provides the API client, operations, and parameter types
+// for IBM Key Protect and IBM Hyper Protect Crypto Services.
+//
+// Key Protect root keys protect Cloud Object Storage buckets encrypted with
+// SSE-KP: the CRN of a root key is the value of
+// CreateBucketInput.IBMSSEKPCustomerRootKeyCrn in the s3 package. The client
+// can create and list keys, rotate root keys, wrap and unwrap data encryption
+// keys, and manage key policies.
+//
+// Requests are authorized with an IBM IAM bearer token, so the client should
+// be configured with credentials from the credentials/ibmiam providers. Every
+// request is made against a single instance, identified by its GUID in
+// Options.InstanceID.
+//
+//	client := keyprotect.NewFromConfig(cfg, func(o *keyprotect.Options) {
+//		o.Region = "us-south"
+//		o.InstanceID = "2ac04c5b-4f7d-4c3a-b2cb-1c9a6d4a5e8d"
+//	})
+//
+//	out, err := client.CreateKey(ctx, &keyprotect.CreateKeyInput{
+//		Name: aws.String("bucket-root-key"),
+//	})
+//
+// Hyper Protect Crypto Services instances implement the same API on an
+// instance specific endpoint, which is configured with Options.BaseEndpoint.
+package keyprotect
@@ -0,0 +1,76 @@
+package keyprotect
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// EndpointType selects between the public and private Key Protect endpoints.
+type EndpointType string
+
+// Enumeration of Key Protect endpoint types.
+const (
+	EndpointTypePublic  EndpointType = "public"
+	EndpointTypePrivate EndpointType = "private"
+)
+
+// ResolveEndpoint returns the Key Protect endpoint for the region and
+// endpoint type, such as https://us-south.kms.cloud.ibm.com.
+func ResolveEndpoint(region string, endpointType EndpointType) (string, error) {
+	if len(region) == 0 {
+		return "", fmt.Errorf("region is required to resolve the Key Protect endpoint")
+	}
+
+	switch endpointType {
+	case "", EndpointTypePublic:
+		return fmt.Sprintf("https://%s.kms.cloud.ibm.com", region), nil
+	case EndpointTypePrivate:
+		return fmt.Sprintf("https://private.%s.kms.cloud.ibm.com", region), nil
+	default:
+		return "", fmt.Errorf("unknown endpoint type %q", endpointType)
+	}
+}
+
+type resolveEndpointMiddleware struct {
+	options Options
+}
+
+func (*resolveEndpointMiddleware) ID() string {
+	return "ResolveEndpoint"
+}
+
+func (m *resolveEndpointMiddleware) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	req, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return out, metadata, fmt.Errorf("unknown transport type %T", in.Request)
+	}
+
+	var endpoint string
+	if m.options.BaseEndpoint != nil {
+		endpoint = *m.options.BaseEndpoint
+	} else if endpoint, err = ResolveEndpoint(m.options.Region, m.options.EndpointType); err != nil {
+		return out, metadata, err
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return out, metadata, fmt.Errorf("failed to parse endpoint %q: %w", endpoint, err)
+	}
+	req.URL.Scheme = u.Scheme
+	req.URL.Host = u.Host
+	req.URL.Path = strings.TrimSuffix(u.Path, "/")
+	req.URL.RawPath = ""
+
+	return next.HandleSerialize(ctx, in)
+}
+
+func addResolveEndpointMiddleware(stack *middleware.Stack, o Options) error {
+	return stack.Serialize.Add(&resolveEndpointMiddleware{options: o}, middleware.Before)
+}
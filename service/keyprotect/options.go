@@ -0,0 +1,97 @@
+package keyprotect
+
+import (
+	"net/http"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/aws/smithy-go/logging"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// HTTPClient is the interface the client uses to send HTTP requests.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Options configures the Key Protect client.
+type Options struct {
+	// Set of options to modify how an operation is invoked. These apply to all
+	// operations invoked for this client. Use functional options on operation call to
+	// modify this list for per operation behavior.
+	APIOptions []func(*middleware.Stack) error
+
+	// The optional application specific identifier appended to the User-Agent header.
+	AppID string
+
+	// A custom base endpoint, such as the instance specific endpoint of a Hyper
+	// Protect Crypto Services instance. If nil, the endpoint is derived from
+	// Region and EndpointType.
+	BaseEndpoint *string
+
+	// Configures the events that will be sent to the configured logger.
+	ClientLogMode aws.ClientLogMode
+
+	// The credentials object to use when signing requests. The credentials must
+	// carry an IBM IAM bearer token, such as those returned by the
+	// credentials/ibmiam providers.
+	Credentials aws.CredentialsProvider
+
+	// Whether the public or private service endpoint is used when BaseEndpoint
+	// is not set.
+	EndpointType EndpointType
+
+	// The HTTP client to invoke API calls with. Defaults to client's default HTTP
+	// implementation if nil.
+	HTTPClient HTTPClient
+
+	// The GUID or CRN of the Key Protect or Hyper Protect Crypto Services
+	// instance, sent as the bluemix-instance header on every request. A CRN
+	// is reduced to the instance GUID. (Required)
+	InstanceID string
+
+	// The logger writer interface to write logging messages to.
+	Logger logging.Logger
+
+	// The region to send requests to, such as us-south. Required unless
+	// BaseEndpoint is set.
+	Region string
+
+	// RetryMaxAttempts specifies the maximum number attempts an API client will call
+	// an operation that fails with a retryable error. A value of 0 is ignored, and
+	// will not be used to configure the API client created default retryer, or modify
+	// per operation call's retry max attempts.
+	RetryMaxAttempts int
+
+	// RetryMode specifies the retry mode the API client will be created with, if
+	// Retryer option is not also specified.
+	RetryMode aws.RetryMode
+
+	// Retryer guides how HTTP requests should be retried in case of recoverable
+	// failures. When nil the API client will use a default retryer.
+	Retryer aws.Retryer
+}
+
+// Copy creates a clone where the APIOptions list is deep copied.
+func (o Options) Copy() Options {
+	to := o
+	to.APIOptions = make([]func(*middleware.Stack) error, len(o.APIOptions))
+	copy(to.APIOptions, o.APIOptions)
+
+	return to
+}
+
+// WithAPIOptions returns a functional option for setting the Client's APIOptions
+// option.
+func WithAPIOptions(optFns ...func(*middleware.Stack) error) func(*Options) {
+	return func(o *Options) {
+		o.APIOptions = append(o.APIOptions, optFns...)
+	}
+}
+
+// WithInstanceID returns a functional option for setting the instance the
+// request is made against.
+func WithInstanceID(instanceID string) func(*Options) {
+	return func(o *Options) {
+		o.InstanceID = instanceID
+	}
+}
@@ -0,0 +1,362 @@
+package keyprotect
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	smithy "github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Media types of the Key Protect API.
+const (
+	mediaTypeKey       = "application/vnd.ibm.kms.key+json"
+	mediaTypeKeyAction = "application/vnd.ibm.kms.key_action+json"
+	mediaTypePolicy    = "application/vnd.ibm.kms.policy+json"
+)
+
+type collectionMetadata struct {
+	CollectionType  string `json:"collectionType"`
+	CollectionTotal int32  `json:"collectionTotal"`
+}
+
+type collectionDocument struct {
+	Metadata  collectionMetadata `json:"metadata"`
+	Resources json.RawMessage    `json:"resources"`
+}
+
+type createKeyDocument struct {
+	Type        string   `json:"type"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Extractable bool     `json:"extractable"`
+	Payload     string   `json:"payload,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+type keyActionDocument struct {
+	Plaintext  string   `json:"plaintext,omitempty"`
+	Ciphertext string   `json:"ciphertext,omitempty"`
+	AAD        []string `json:"aad,omitempty"`
+	Payload    string   `json:"payload,omitempty"`
+}
+
+type rotationDocument struct {
+	Enabled       *bool  `json:"enabled,omitempty"`
+	IntervalMonth *int32 `json:"interval_month,omitempty"`
+}
+
+type dualAuthDeleteDocument struct {
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+type policyDocument struct {
+	Type           string                  `json:"type"`
+	ID             string                  `json:"id,omitempty"`
+	CRN            string                  `json:"crn,omitempty"`
+	CreatedBy      string                  `json:"createdBy,omitempty"`
+	UpdatedBy      string                  `json:"updatedBy,omitempty"`
+	CreationDate   string                  `json:"creationDate,omitempty"`
+	LastUpdateDate string                  `json:"lastUpdateDate,omitempty"`
+	Rotation       *rotationDocument       `json:"rotation,omitempty"`
+	DualAuthDelete *dualAuthDeleteDocument `json:"dualAuthDelete,omitempty"`
+}
+
+// setRequest sets the method, path, query and JSON body of the request.
+// body may be nil for requests without a payload.
+func setRequest(in middleware.SerializeInput, method, path string, query url.Values, contentType string, body interface{}) (*smithyhttp.Request, error) {
+	request, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return nil, &smithy.SerializationError{Err: fmt.Errorf("unknown transport type %T", in.Request)}
+	}
+
+	request.Method = method
+	request.URL.Path = request.URL.Path + path
+	request.URL.RawQuery = query.Encode()
+	request.Header.Set("Accept", "application/json")
+
+	if body == nil {
+		return request, nil
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, &smithy.SerializationError{Err: err}
+	}
+	request.Header.Set("Content-Type", contentType)
+	if request, err = request.SetStream(bytes.NewReader(payload)); err != nil {
+		return nil, &smithy.SerializationError{Err: err}
+	}
+	return request, nil
+}
+
+// keyPath returns the escaped path of a key or one of its sub-resources.
+func keyPath(id *string, elems ...string) string {
+	path := "/api/v2/keys/" + url.PathEscape(*id)
+	if len(elems) > 0 {
+		path += "/" + strings.Join(elems, "/")
+	}
+	return path
+}
+
+func collection(mediaType string, resources ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": collectionMetadata{
+			CollectionType:  mediaType,
+			CollectionTotal: int32(len(resources)),
+		},
+		"resources": resources,
+	}
+}
+
+func encodeBytes(b []byte) string {
+	if b == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+type restjson_serializeOpCreateKey struct{}
+
+func (*restjson_serializeOpCreateKey) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpCreateKey) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*CreateKeyInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	request, err := setRequest(in, "POST", "/api/v2/keys", nil, mediaTypeKey, collection(mediaTypeKey, createKeyDocument{
+		Type:        mediaTypeKey,
+		Name:        *input.Name,
+		Description: stringValue(input.Description),
+		Extractable: input.Extractable,
+		Payload:     encodeBytes(input.Payload),
+		Aliases:     input.Aliases,
+	}))
+	if err != nil {
+		return out, metadata, err
+	}
+	request.Header.Set("Prefer", "return=representation")
+	if input.KeyRingID != nil {
+		request.Header.Set("X-Kms-Key-Ring", *input.KeyRingID)
+	}
+	in.Request = request
+
+	return next.HandleSerialize(ctx, in)
+}
+
+type restjson_serializeOpListKeys struct{}
+
+func (*restjson_serializeOpListKeys) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpListKeys) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*ListKeysInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	query := url.Values{}
+	if input.Limit != nil {
+		query.Set("limit", strconv.FormatInt(int64(*input.Limit), 10))
+	}
+	if input.Offset != nil {
+		query.Set("offset", strconv.FormatInt(int64(*input.Offset), 10))
+	}
+	if len(input.State) > 0 {
+		states := make([]string, len(input.State))
+		for i, s := range input.State {
+			states[i] = strconv.Itoa(int(s))
+		}
+		query.Set("state", strings.Join(states, ","))
+	}
+	if input.Extractable != nil {
+		query.Set("extractable", strconv.FormatBool(*input.Extractable))
+	}
+
+	request, err := setRequest(in, "GET", "/api/v2/keys", query, "", nil)
+	if err != nil {
+		return out, metadata, err
+	}
+	if input.KeyRingID != nil {
+		request.Header.Set("X-Kms-Key-Ring", *input.KeyRingID)
+	}
+	in.Request = request
+
+	return next.HandleSerialize(ctx, in)
+}
+
+type restjson_serializeOpGetKey struct{}
+
+func (*restjson_serializeOpGetKey) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpGetKey) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*GetKeyInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	if in.Request, err = setRequest(in, "GET", keyPath(input.ID), nil, "", nil); err != nil {
+		return out, metadata, err
+	}
+
+	return next.HandleSerialize(ctx, in)
+}
+
+type restjson_serializeOpRotateKey struct{}
+
+func (*restjson_serializeOpRotateKey) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpRotateKey) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*RotateKeyInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	var body interface{}
+	if input.Payload != nil {
+		body = keyActionDocument{Payload: encodeBytes(input.Payload)}
+	}
+	if in.Request, err = setRequest(in, "POST", keyPath(input.ID, "actions", "rotate"), nil, mediaTypeKeyAction, body); err != nil {
+		return out, metadata, err
+	}
+
+	return next.HandleSerialize(ctx, in)
+}
+
+type restjson_serializeOpWrapKey struct{}
+
+func (*restjson_serializeOpWrapKey) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpWrapKey) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*WrapKeyInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	body := keyActionDocument{
+		Plaintext: encodeBytes(input.Plaintext),
+		AAD:       input.AAD,
+	}
+	if in.Request, err = setRequest(in, "POST", keyPath(input.ID, "actions", "wrap"), nil, mediaTypeKeyAction, body); err != nil {
+		return out, metadata, err
+	}
+
+	return next.HandleSerialize(ctx, in)
+}
+
+type restjson_serializeOpUnwrapKey struct{}
+
+func (*restjson_serializeOpUnwrapKey) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpUnwrapKey) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*UnwrapKeyInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	body := keyActionDocument{
+		Ciphertext: *input.Ciphertext,
+		AAD:        input.AAD,
+	}
+	if in.Request, err = setRequest(in, "POST", keyPath(input.ID, "actions", "unwrap"), nil, mediaTypeKeyAction, body); err != nil {
+		return out, metadata, err
+	}
+
+	return next.HandleSerialize(ctx, in)
+}
+
+type restjson_serializeOpGetKeyPolicies struct{}
+
+func (*restjson_serializeOpGetKeyPolicies) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpGetKeyPolicies) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*GetKeyPoliciesInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	if in.Request, err = setRequest(in, "GET", keyPath(input.ID, "policies"), nil, "", nil); err != nil {
+		return out, metadata, err
+	}
+
+	return next.HandleSerialize(ctx, in)
+}
+
+type restjson_serializeOpPutKeyPolicies struct{}
+
+func (*restjson_serializeOpPutKeyPolicies) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpPutKeyPolicies) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*PutKeyPoliciesInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	query := url.Values{}
+	doc := policyDocument{Type: mediaTypePolicy}
+	if v := input.Rotation; v != nil {
+		doc.Rotation = &rotationDocument{Enabled: v.Enabled, IntervalMonth: v.IntervalMonth}
+	}
+	if v := input.DualAuthDelete; v != nil {
+		doc.DualAuthDelete = &dualAuthDeleteDocument{Enabled: v.Enabled}
+	}
+	switch {
+	case doc.Rotation != nil && doc.DualAuthDelete == nil:
+		query.Set("policy", "rotation")
+	case doc.Rotation == nil && doc.DualAuthDelete != nil:
+		query.Set("policy", "dualAuthDelete")
+	}
+
+	if in.Request, err = setRequest(in, "PUT", keyPath(input.ID, "policies"), query, mediaTypePolicy, collection(mediaTypePolicy, doc)); err != nil {
+		return out, metadata, err
+	}
+
+	return next.HandleSerialize(ctx, in)
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
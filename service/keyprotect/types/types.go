@@ -0,0 +1,137 @@
+package types
+
+import (
+	"time"
+)
+
+// KeyState is the lifecycle state of a key.
+type KeyState int32
+
+// Enumeration of key states, following the NIST SP 800-57 key lifecycle.
+const (
+	KeyStatePreActivation KeyState = 0
+	KeyStateActive        KeyState = 1
+	KeyStateSuspended     KeyState = 2
+	KeyStateDeactivated   KeyState = 3
+	KeyStateDestroyed     KeyState = 5
+)
+
+// String returns the name of the key state.
+func (s KeyState) String() string {
+	switch s {
+	case KeyStatePreActivation:
+		return "PreActivation"
+	case KeyStateActive:
+		return "Active"
+	case KeyStateSuspended:
+		return "Suspended"
+	case KeyStateDeactivated:
+		return "Deactivated"
+	case KeyStateDestroyed:
+		return "Destroyed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Key describes a root or standard key stored in a Key Protect or Hyper
+// Protect Crypto Services instance.
+type Key struct {
+	// The GUID of the key.
+	ID *string
+
+	// The Cloud Resource Name of the key. This is the value passed as
+	// IBMSSEKPCustomerRootKeyCrn when creating an SSE-KP bucket.
+	CRN *string
+
+	// The human-readable name of the key.
+	Name *string
+
+	// A description of the key.
+	Description *string
+
+	// The aliases of the key.
+	Aliases []string
+
+	// The lifecycle state of the key.
+	State KeyState
+
+	// Whether the key material can leave the service. Root keys are not
+	// extractable, standard keys are.
+	Extractable bool
+
+	// Whether the key material was imported rather than generated.
+	Imported bool
+
+	// Whether the key has been deleted.
+	Deleted bool
+
+	// The key ring the key belongs to.
+	KeyRingID *string
+
+	// The algorithm of the key material.
+	AlgorithmType *string
+
+	// The current version of the key material.
+	KeyVersion *KeyVersion
+
+	// The key material, only returned for standard keys.
+	Payload []byte
+
+	// The identity which created the key.
+	CreatedBy *string
+
+	// The date the key was created.
+	CreationDate *time.Time
+
+	// The date the key was last updated.
+	LastUpdateDate *time.Time
+
+	// The date the key material was last rotated.
+	LastRotateDate *time.Time
+}
+
+// KeyVersion identifies a version of a key's material.
+type KeyVersion struct {
+	// The identifier of the key version.
+	ID *string
+
+	// The date the key version was created.
+	CreationDate *time.Time
+}
+
+// RotationPolicy configures automatic rotation of a root key.
+type RotationPolicy struct {
+	// Whether automatic rotation is enabled.
+	Enabled *bool
+
+	// The rotation interval, in months, between 1 and 12.
+	IntervalMonth *int32
+}
+
+// DualAuthDeletePolicy requires two authorizations to delete a key.
+type DualAuthDeletePolicy struct {
+	// Whether dual authorization is required to delete the key.
+	Enabled *bool
+}
+
+// PolicyMetadata holds the bookkeeping fields of a key policy.
+type PolicyMetadata struct {
+	// The identifier of the policy.
+	ID *string
+
+	// The Cloud Resource Name of the key the policy applies to.
+	CRN *string
+
+	// The identity which created the policy.
+	CreatedBy *string
+
+	// The identity which last updated the policy.
+	UpdatedBy *string
+
+	// The date the policy was created.
+	CreationDate *time.Time
+
+	// The date the policy was last updated.
+	LastUpdateDate *time.Time
+}
@@ -0,0 +1,91 @@
+package keyprotect
+
+import (
+	"context"
+
+	smithy "github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// validateOpInput validates the operation input before it is serialized.
+type validateOpInput struct {
+	validate func(interface{}) error
+}
+
+func (*validateOpInput) ID() string {
+	return "OperationInputValidation"
+}
+
+func (m *validateOpInput) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+	out middleware.InitializeOutput, metadata middleware.Metadata, err error,
+) {
+	if err := m.validate(in.Parameters); err != nil {
+		return out, metadata, err
+	}
+	return next.HandleInitialize(ctx, in)
+}
+
+func addValidationMiddleware(stack *middleware.Stack, validate func(interface{}) error) error {
+	return stack.Initialize.Add(&validateOpInput{validate: validate}, middleware.After)
+}
+
+// validateKeyID checks the key identifier shared by the key operations.
+func validateKeyID(context string, id *string, extra ...func(*smithy.InvalidParamsError)) error {
+	invalidParams := smithy.InvalidParamsError{Context: context}
+	if id == nil || len(*id) == 0 {
+		invalidParams.Add(smithy.NewErrParamRequired("ID"))
+	}
+	for _, fn := range extra {
+		fn(&invalidParams)
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+func validateOpCreateKeyInput(v interface{}) error {
+	input := v.(*CreateKeyInput)
+	invalidParams := smithy.InvalidParamsError{Context: "CreateKeyInput"}
+	if input.Name == nil || len(*input.Name) == 0 {
+		invalidParams.Add(smithy.NewErrParamRequired("Name"))
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+func validateOpGetKeyInput(v interface{}) error {
+	return validateKeyID("GetKeyInput", v.(*GetKeyInput).ID)
+}
+
+func validateOpRotateKeyInput(v interface{}) error {
+	return validateKeyID("RotateKeyInput", v.(*RotateKeyInput).ID)
+}
+
+func validateOpWrapKeyInput(v interface{}) error {
+	return validateKeyID("WrapKeyInput", v.(*WrapKeyInput).ID)
+}
+
+func validateOpUnwrapKeyInput(v interface{}) error {
+	input := v.(*UnwrapKeyInput)
+	return validateKeyID("UnwrapKeyInput", input.ID, func(p *smithy.InvalidParamsError) {
+		if input.Ciphertext == nil {
+			p.Add(smithy.NewErrParamRequired("Ciphertext"))
+		}
+	})
+}
+
+func validateOpGetKeyPoliciesInput(v interface{}) error {
+	return validateKeyID("GetKeyPoliciesInput", v.(*GetKeyPoliciesInput).ID)
+}
+
+func validateOpPutKeyPoliciesInput(v interface{}) error {
+	input := v.(*PutKeyPoliciesInput)
+	return validateKeyID("PutKeyPoliciesInput", input.ID, func(p *smithy.InvalidParamsError) {
+		if input.Rotation == nil && input.DualAuthDelete == nil {
+			p.Add(smithy.NewErrParamRequired("Rotation or DualAuthDelete"))
+		}
+	})
+}
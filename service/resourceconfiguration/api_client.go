@@ -0,0 +1,304 @@
+package resourceconfiguration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	awsmiddleware "github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/retry"
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/signer/ibmiam"
+	awshttp "github.com/IBM/ibm-cos-sdk-go-v2/aws/transport/http"
+	smithy "github.com/aws/smithy-go"
+	smithydocument "github.com/aws/smithy-go/document"
+	"github.com/aws/smithy-go/logging"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// ServiceID is the identifier of the service used in operation errors and
+// user agent metadata.
+const ServiceID = "ResourceConfiguration"
+
+// Client provides the API client to make operations call for the IBM Cloud
+// Object Storage Resource Configuration API.
+type Client struct {
+	options Options
+}
+
+// New returns an initialized Client based on the functional options. Provide
+// additional functional options to further configure the behavior of the client,
+// such as changing the client's endpoint or adding custom middleware behavior.
+func New(options Options, optFns ...func(*Options)) *Client {
+	options = options.Copy()
+
+	resolveDefaultLogger(&options)
+
+	resolveRetryer(&options)
+
+	resolveHTTPClient(&options)
+
+	for _, fn := range optFns {
+		fn(&options)
+	}
+
+	finalizeRetryMaxAttempts(&options)
+
+	return &Client{
+		options: options,
+	}
+}
+
+// NewFromConfig returns a new client from the provided config.
+//
+// The config's BaseEndpoint is not used, as it usually refers to the S3
+// endpoint of Cloud Object Storage. Set Options.BaseEndpoint to override the
+// Resource Configuration endpoint.
+func NewFromConfig(cfg aws.Config, optFns ...func(*Options)) *Client {
+	opts := Options{
+		Region:        cfg.Region,
+		HTTPClient:    cfg.HTTPClient,
+		Credentials:   cfg.Credentials,
+		APIOptions:    cfg.APIOptions,
+		Logger:        cfg.Logger,
+		ClientLogMode: cfg.ClientLogMode,
+		AppID:         cfg.AppID,
+	}
+	if cfg.Retryer != nil {
+		opts.Retryer = cfg.Retryer()
+	}
+	opts.RetryMaxAttempts = cfg.RetryMaxAttempts
+	opts.RetryMode = cfg.RetryMode
+	return New(opts, optFns...)
+}
+
+// Options returns a copy of the client configuration.
+//
+// Callers SHOULD NOT perform mutations on any inner structures within client
+// config. Config overrides should instead be made on a per-operation basis through
+// functional options.
+func (c *Client) Options() Options {
+	return c.options.Copy()
+}
+
+func (c *Client) invokeOperation(
+	ctx context.Context, opID string, params interface{}, optFns []func(*Options), stackFns ...func(*middleware.Stack, Options) error,
+) (
+	result interface{}, metadata middleware.Metadata, err error,
+) {
+	ctx = middleware.ClearStackValues(ctx)
+	ctx = middleware.WithServiceID(ctx, ServiceID)
+	ctx = middleware.WithOperationName(ctx, opID)
+
+	stack := middleware.NewStack(opID, smithyhttp.NewStackRequest)
+	options := c.options.Copy()
+
+	for _, fn := range optFns {
+		fn(&options)
+	}
+
+	finalizeOperationRetryMaxAttempts(&options, *c)
+
+	for _, fn := range stackFns {
+		if err := fn(stack, options); err != nil {
+			return nil, metadata, err
+		}
+	}
+
+	for _, fn := range options.APIOptions {
+		if err := fn(stack); err != nil {
+			return nil, metadata, err
+		}
+	}
+
+	handler := smithyhttp.NewClientHandler(options.HTTPClient)
+	decorated := middleware.DecorateHandler(handler, stack)
+	result, metadata, err = decorated.Handle(ctx, params)
+	if err != nil {
+		err = &smithy.OperationError{
+			ServiceID:     ServiceID,
+			OperationName: opID,
+			Err:           err,
+		}
+	}
+	return result, metadata, err
+}
+
+// addClientMiddlewares adds the middleware shared by every operation.
+func addClientMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := middleware.AddSetLoggerMiddleware(stack, options.Logger); err != nil {
+		return err
+	}
+	if err := awsmiddleware.AddClientRequestIDMiddleware(stack); err != nil {
+		return err
+	}
+	if err := smithyhttp.AddComputeContentLengthMiddleware(stack); err != nil {
+		return err
+	}
+	if err := addResolveEndpointMiddleware(stack, options); err != nil {
+		return err
+	}
+	if err := addSigning(stack, options); err != nil {
+		return err
+	}
+	if err := addRetry(stack, options); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&awsmiddleware.AddRawResponse{}, middleware.Before); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&awsmiddleware.RecordResponseTiming{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addClientUserAgent(stack, options); err != nil {
+		return err
+	}
+	if err := smithyhttp.AddErrorCloseResponseBodyMiddleware(stack); err != nil {
+		return err
+	}
+	if err := smithyhttp.AddCloseResponseBodyMiddleware(stack); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&awshttp.ResponseErrorWrapper{}, middleware.Before); err != nil {
+		return err
+	}
+	return stack.Deserialize.Add(&smithyhttp.RequestResponseLogger{
+		LogRequest:          options.ClientLogMode.IsRequest(),
+		LogRequestWithBody:  options.ClientLogMode.IsRequestWithBody(),
+		LogResponse:         options.ClientLogMode.IsResponse(),
+		LogResponseWithBody: options.ClientLogMode.IsResponseWithBody(),
+	}, middleware.After)
+}
+
+func resolveDefaultLogger(o *Options) {
+	if o.Logger != nil {
+		return
+	}
+	o.Logger = logging.Nop{}
+}
+
+func resolveHTTPClient(o *Options) {
+	if o.HTTPClient != nil {
+		return
+	}
+	o.HTTPClient = awshttp.NewBuildableClient()
+}
+
+func resolveRetryer(o *Options) {
+	if o.Retryer != nil {
+		return
+	}
+
+	var standardOptions []func(*retry.StandardOptions)
+	if v := o.RetryMaxAttempts; v != 0 {
+		standardOptions = append(standardOptions, func(so *retry.StandardOptions) {
+			so.MaxAttempts = v
+		})
+	}
+
+	switch o.RetryMode {
+	case aws.RetryModeAdaptive:
+		var adaptiveOptions []func(*retry.AdaptiveModeOptions)
+		if len(standardOptions) != 0 {
+			adaptiveOptions = append(adaptiveOptions, func(ao *retry.AdaptiveModeOptions) {
+				ao.StandardOptions = append(ao.StandardOptions, standardOptions...)
+			})
+		}
+		o.Retryer = retry.NewAdaptiveMode(adaptiveOptions...)
+
+	default:
+		o.Retryer = retry.NewStandard(standardOptions...)
+	}
+}
+
+func finalizeRetryMaxAttempts(o *Options) {
+	if o.RetryMaxAttempts == 0 {
+		return
+	}
+
+	o.Retryer = retry.AddWithMaxAttempts(o.Retryer, o.RetryMaxAttempts)
+}
+
+func finalizeOperationRetryMaxAttempts(o *Options, client Client) {
+	if v := o.RetryMaxAttempts; v == 0 || v == client.options.RetryMaxAttempts {
+		return
+	}
+
+	o.Retryer = retry.AddWithMaxAttempts(o.Retryer, o.RetryMaxAttempts)
+}
+
+func addRetry(stack *middleware.Stack, o Options) error {
+	return retry.AddRetryMiddlewares(stack, retry.AddRetryMiddlewaresOptions{
+		Retryer:          o.Retryer,
+		LogRetryAttempts: o.ClientLogMode.IsRetries(),
+	})
+}
+
+func addClientUserAgent(stack *middleware.Stack, options Options) error {
+	id := (*awsmiddleware.RequestUserAgent)(nil).ID()
+	mw, ok := stack.Build.Get(id)
+	if !ok {
+		mw = awsmiddleware.NewRequestUserAgent()
+		if err := stack.Build.Add(mw, middleware.After); err != nil {
+			return err
+		}
+	}
+
+	ua, ok := mw.(*awsmiddleware.RequestUserAgent)
+	if !ok {
+		return fmt.Errorf("%T for %s middleware did not match expected type", mw, id)
+	}
+
+	ua.AddSDKAgentKeyValue(awsmiddleware.APIMetadata, "resourceconfiguration", goModuleVersion)
+	if len(options.AppID) > 0 {
+		ua.AddSDKAgentKey(awsmiddleware.ApplicationIdentifier, options.AppID)
+	}
+	return nil
+}
+
+// signRequestMiddleware authorizes requests with the IBM IAM bearer token of
+// the client's credentials.
+type signRequestMiddleware struct {
+	options Options
+	signer  *ibmiam.IBMCOSSigner
+}
+
+func (*signRequestMiddleware) ID() string {
+	return "Signing"
+}
+
+func (m *signRequestMiddleware) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+	out middleware.FinalizeOutput, metadata middleware.Metadata, err error,
+) {
+	req, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return out, metadata, fmt.Errorf("unknown transport type %T", in.Request)
+	}
+	if m.options.Credentials == nil {
+		return out, metadata, fmt.Errorf("credentials are required to sign Resource Configuration requests")
+	}
+
+	creds, err := m.options.Credentials.Retrieve(ctx)
+	if err != nil {
+		return out, metadata, fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+
+	if err := m.signer.SignHTTP(ctx, creds, req.Request, "", "resource-configuration", m.options.Region, time.Now()); err != nil {
+		return out, metadata, fmt.Errorf("failed to sign request: %w", err)
+	}
+	// buckets are addressed by name, the service instance of the credentials
+	// is not meaningful to the API
+	req.Header.Del("Ibm-Service-Instance-Id")
+
+	return next.HandleFinalize(ctx, in)
+}
+
+func addSigning(stack *middleware.Stack, o Options) error {
+	return stack.Finalize.Add(&signRequestMiddleware{
+		options: o,
+		signer:  ibmiam.NewIBMCOSSigner(ibmiam.WithLogger(o.Logger)),
+	}, middleware.After)
+}
+
+type noSmithyDocumentSerde = smithydocument.NoSerde
@@ -0,0 +1,327 @@
+package resourceconfiguration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/credentials/ibmiam/token"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/resourceconfiguration/types"
+	smithy "github.com/aws/smithy-go"
+)
+
+// stubServer is a minimal in-memory stand-in for the Resource Configuration
+// API holding the configuration of a single bucket.
+type stubServer struct {
+	t *testing.T
+
+	m        sync.Mutex
+	bucket   map[string]interface{}
+	version  int
+	patches  []map[string]interface{}
+	failures int
+
+	// conflicts is the number of PATCH requests to reject with a precondition
+	// failure after changing the configuration behind the caller's back.
+	conflicts int
+
+	// noETag omits the ETag of GET responses.
+	noETag bool
+}
+
+func (s *stubServer) etag() string {
+	return `"v` + strconv.Itoa(s.version) + `"`
+}
+
+func (s *stubServer) writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []interface{}{
+			map[string]interface{}{"code": code, "message": message},
+		},
+		"trace": "trace-id",
+	})
+}
+
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if e, a := "Bearer test-token", r.Header.Get("Authorization"); e != a {
+		s.t.Errorf("expect authorization %q, got %q", e, a)
+	}
+	if a := r.Header.Get("Ibm-Service-Instance-Id"); len(a) != 0 {
+		s.t.Errorf("expect no service instance header, got %q", a)
+	}
+	if s.failures > 0 {
+		s.failures--
+		s.writeError(w, http.StatusServiceUnavailable, "ServiceUnavailable", "try again")
+		return
+	}
+	if e, a := "/v1/b/"+s.bucket["name"].(string), r.URL.Path; e != a {
+		s.writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+		return
+	}
+
+	w.Header().Set("X-Correlation-Id", "correlation-"+strconv.Itoa(s.version))
+	switch r.Method {
+	case "GET":
+		if !s.noETag {
+			w.Header().Set("ETag", s.etag())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.bucket)
+
+	case "PATCH":
+		if e, a := mediaTypeMergePatch, r.Header.Get("Content-Type"); e != a {
+			s.t.Errorf("expect content type %q, got %q", e, a)
+		}
+		if s.conflicts > 0 {
+			s.conflicts--
+			s.version++
+		}
+		if match := r.Header.Get("If-Match"); len(match) != 0 && match != s.etag() {
+			s.writeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "The bucket configuration was modified.")
+			return
+		}
+		var patch map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			s.t.Errorf("expect valid patch, got %v", err)
+		}
+		s.patches = append(s.patches, patch)
+		for k, v := range patch {
+			s.bucket[k] = v
+		}
+		s.version++
+		w.Header().Set("ETag", s.etag())
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func newTestClient(t *testing.T) (*Client, *stubServer) {
+	stub := &stubServer{
+		t: t,
+		bucket: map[string]interface{}{
+			"name":         "bucket",
+			"crn":          "crn:v1:bluemix:public:cloud-object-storage:global:a/acct:instance:bucket:bucket",
+			"time_created": "2024-01-02T03:04:05.000Z",
+			"object_count": 12,
+			"bytes_used":   4096,
+			"firewall": map[string]interface{}{
+				"allowed_ip": []string{"10.0.0.0/8"},
+			},
+		},
+	}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	client := New(Options{
+		BaseEndpoint: aws.String(server.URL),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{
+				Token:             token.Token{AccessToken: "test-token", TokenType: "Bearer"},
+				ServiceInstanceID: "cos-instance",
+			}, nil
+		}),
+	})
+	return client, stub
+}
+
+func TestClient_GetBucketConfig(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	out, err := client.GetBucketConfig(context.Background(), &GetBucketConfigInput{Bucket: aws.String("bucket")})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := `"v0"`, aws.ToString(out.ETag); e != a {
+		t.Errorf("expect etag %v, got %v", e, a)
+	}
+	config := out.BucketConfig
+	if e, a := "bucket", aws.ToString(config.Name); e != a {
+		t.Errorf("expect name %v, got %v", e, a)
+	}
+	if e, a := int64(4096), aws.ToInt64(config.BytesUsed); e != a {
+		t.Errorf("expect bytes used %v, got %v", e, a)
+	}
+	if config.TimeCreated == nil || config.TimeCreated.Year() != 2024 {
+		t.Errorf("expect time created, got %v", config.TimeCreated)
+	}
+	if config.Firewall == nil || len(config.Firewall.AllowedIP) != 1 {
+		t.Errorf("expect firewall, got %v", config.Firewall)
+	}
+	if config.ActivityTracking != nil {
+		t.Errorf("expect no activity tracking, got %v", config.ActivityTracking)
+	}
+}
+
+func TestClient_UpdateBucketConfig(t *testing.T) {
+	client, stub := newTestClient(t)
+	ctx := context.Background()
+
+	out, err := client.UpdateBucketConfig(ctx, &UpdateBucketConfigInput{
+		Bucket:    aws.String("bucket"),
+		IfMatch:   aws.String(`"v0"`),
+		Firewall:  &types.Firewall{AllowedIP: []string{}},
+		HardQuota: aws.Int64(1 << 30),
+		ActivityTracking: &types.ActivityTracking{
+			ManagementEvents: aws.Bool(true),
+		},
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := `"v1"`, aws.ToString(out.ETag); e != a {
+		t.Errorf("expect etag %v, got %v", e, a)
+	}
+
+	patch := stub.patches[0]
+	firewall := patch["firewall"].(map[string]interface{})
+	if v, ok := firewall["allowed_ip"].([]interface{}); !ok || len(v) != 0 {
+		t.Errorf("expect empty allowed IP list, got %v", firewall["allowed_ip"])
+	}
+	if _, ok := firewall["denied_ip"]; ok {
+		t.Errorf("expect unset denied IP list to be omitted")
+	}
+	if e, a := float64(1<<30), patch["hard_quota"]; e != a {
+		t.Errorf("expect hard quota %v, got %v", e, a)
+	}
+	tracking := patch["activity_tracking"].(map[string]interface{})
+	if e, a := 1, len(tracking); e != a || tracking["management_events"] != true {
+		t.Errorf("expect only management events to be set, got %v", tracking)
+	}
+	if _, ok := patch["metrics_monitoring"]; ok {
+		t.Errorf("expect unset metrics monitoring to be omitted")
+	}
+
+	_, err = client.UpdateBucketConfig(ctx, &UpdateBucketConfigInput{
+		Bucket:    aws.String("bucket"),
+		IfMatch:   aws.String(`"v0"`),
+		HardQuota: aws.Int64(0),
+	})
+	var pf *types.PreconditionFailedException
+	if !errors.As(err, &pf) {
+		t.Fatalf("expect precondition failed, got %v", err)
+	}
+	if e, a := "PreconditionFailed", pf.ErrorCode(); e != a {
+		t.Errorf("expect error code %v, got %v", e, a)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	client, stub := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.GetBucketConfig(ctx, &GetBucketConfigInput{Bucket: aws.String("missing")})
+	var nf *types.BucketNotFoundException
+	if !errors.As(err, &nf) {
+		t.Fatalf("expect bucket not found, got %v", err)
+	}
+	var opErr *smithy.OperationError
+	if !errors.As(err, &opErr) || opErr.Service() != ServiceID {
+		t.Errorf("expect operation error for %v, got %v", ServiceID, err)
+	}
+
+	stub.failures = 2
+	if _, err := client.GetBucketConfig(ctx, &GetBucketConfigInput{Bucket: aws.String("bucket")}); err != nil {
+		t.Fatalf("expect retried request to succeed, got %v", err)
+	}
+
+	_, err = client.UpdateBucketConfig(ctx, &UpdateBucketConfigInput{
+		ProtectionManagement: &types.ProtectionManagementUpdate{},
+	})
+	var invalidParams smithy.InvalidParamsError
+	if !errors.As(err, &invalidParams) {
+		t.Fatalf("expect invalid params error, got %v", err)
+	}
+	if e, a := 2, invalidParams.Len(); e != a {
+		t.Errorf("expect %d invalid params, got %d", e, a)
+	}
+}
+
+func TestModifyBucketConfig(t *testing.T) {
+	client, stub := newTestClient(t)
+	ctx := context.Background()
+
+	stub.conflicts = 1
+	var calls int
+	_, err := ModifyBucketConfig(ctx, client, "bucket", func(c *types.BucketConfig) (*UpdateBucketConfigInput, error) {
+		calls++
+		return &UpdateBucketConfigInput{
+			Firewall: &types.Firewall{AllowedIP: append(c.Firewall.AllowedIP, "192.168.0.0/16")},
+		}, nil
+	})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if e, a := 2, calls; e != a {
+		t.Errorf("expect %d calls, got %d", e, a)
+	}
+	if e, a := 1, len(stub.patches); e != a {
+		t.Fatalf("expect %d applied patches, got %d", e, a)
+	}
+
+	stub.conflicts = 5
+	_, err = ModifyBucketConfig(ctx, client, "bucket", func(c *types.BucketConfig) (*UpdateBucketConfigInput, error) {
+		return &UpdateBucketConfigInput{HardQuota: aws.Int64(1)}, nil
+	}, func(o *ModifyBucketConfigOptions) {
+		o.MaxAttempts = 2
+	})
+	var pf *types.PreconditionFailedException
+	if !errors.As(err, &pf) {
+		t.Fatalf("expect precondition failed after retries, got %v", err)
+	}
+
+	stub.noETag = true
+	_, err = ModifyBucketConfig(ctx, client, "bucket", func(c *types.BucketConfig) (*UpdateBucketConfigInput, error) {
+		return &UpdateBucketConfigInput{HardQuota: aws.Int64(2)}, nil
+	})
+	if err == nil {
+		t.Fatal("expect error without an ETag, got none")
+	}
+	if e, a := 1, len(stub.patches); e != a {
+		t.Errorf("expect %d applied patches, got %d", e, a)
+	}
+}
+
+func TestResolveEndpoint(t *testing.T) {
+	cases := map[string]struct {
+		EndpointType EndpointType
+		Expect       string
+		ExpectErr    bool
+	}{
+		"default": {Expect: "https://config.cloud-object-storage.cloud.ibm.com"},
+		"public":  {EndpointType: EndpointTypePublic, Expect: "https://config.cloud-object-storage.cloud.ibm.com"},
+		"private": {EndpointType: EndpointTypePrivate, Expect: "https://config.private.cloud-object-storage.cloud.ibm.com"},
+		"direct":  {EndpointType: EndpointTypeDirect, Expect: "https://config.direct.cloud-object-storage.cloud.ibm.com"},
+		"unknown": {EndpointType: "other", ExpectErr: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ResolveEndpoint(c.EndpointType)
+			if c.ExpectErr {
+				if err == nil {
+					t.Fatalf("expect error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if e, a := c.Expect, actual; e != a {
+				t.Errorf("expect %v, got %v", e, a)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package resourceconfiguration
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/resourceconfiguration/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// GetBucketConfig returns the configuration of a bucket, such as its IP
+// firewall, Activity Tracker and Metrics Monitoring routing, hard quota and
+// usage. The returned ETag can be passed to UpdateBucketConfig as IfMatch so
+// that the update fails if the configuration changed in the meantime.
+func (c *Client) GetBucketConfig(ctx context.Context, params *GetBucketConfigInput, optFns ...func(*Options)) (*GetBucketConfigOutput, error) {
+	if params == nil {
+		params = &GetBucketConfigInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "GetBucketConfig", params, optFns, c.addOperationGetBucketConfigMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*GetBucketConfigOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type GetBucketConfigInput struct {
+
+	// The name of the bucket.
+	//
+	// This member is required.
+	Bucket *string
+
+	noSmithyDocumentSerde
+}
+
+type GetBucketConfigOutput struct {
+
+	// The configuration of the bucket.
+	BucketConfig *types.BucketConfig
+
+	// The entity tag of the configuration.
+	ETag *string
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationGetBucketConfigMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpGetBucketConfig{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpGetBucketConfig{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addOpGetBucketConfigValidationMiddleware(stack); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
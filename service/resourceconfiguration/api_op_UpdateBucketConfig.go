@@ -0,0 +1,82 @@
+package resourceconfiguration
+
+import (
+	"context"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/resourceconfiguration/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// UpdateBucketConfig applies a partial update to the configuration of a
+// bucket. Only the members that are set are changed.
+//
+// If IfMatch is set and the configuration was modified since that ETag was
+// read, the update is rejected with a types.PreconditionFailedException.
+func (c *Client) UpdateBucketConfig(ctx context.Context, params *UpdateBucketConfigInput, optFns ...func(*Options)) (*UpdateBucketConfigOutput, error) {
+	if params == nil {
+		params = &UpdateBucketConfigInput{}
+	}
+
+	result, metadata, err := c.invokeOperation(ctx, "UpdateBucketConfig", params, optFns, c.addOperationUpdateBucketConfigMiddlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := result.(*UpdateBucketConfigOutput)
+	out.ResultMetadata = metadata
+	return out, nil
+}
+
+type UpdateBucketConfigInput struct {
+
+	// The name of the bucket.
+	//
+	// This member is required.
+	Bucket *string
+
+	// The ETag of the configuration the update is based on, as returned by
+	// GetBucketConfig. If nil, the update is applied unconditionally.
+	IfMatch *string
+
+	// The IP firewall to set. Lists that are nil are left unchanged, empty
+	// lists are cleared.
+	Firewall *types.Firewall
+
+	// The Activity Tracker routing to set.
+	ActivityTracking *types.ActivityTracking
+
+	// The Metrics Monitoring routing to set.
+	MetricsMonitoring *types.MetricsMonitoring
+
+	// The maximum size of the bucket, in bytes. Set to 0 to remove the quota.
+	HardQuota *int64
+
+	// A change of the protection management state.
+	ProtectionManagement *types.ProtectionManagementUpdate
+
+	noSmithyDocumentSerde
+}
+
+type UpdateBucketConfigOutput struct {
+
+	// The entity tag of the updated configuration, if returned by the service.
+	ETag *string
+
+	// Metadata pertaining to the operation's result.
+	ResultMetadata middleware.Metadata
+
+	noSmithyDocumentSerde
+}
+
+func (c *Client) addOperationUpdateBucketConfigMiddlewares(stack *middleware.Stack, options Options) error {
+	if err := stack.Serialize.Add(&restjson_serializeOpUpdateBucketConfig{}, middleware.After); err != nil {
+		return err
+	}
+	if err := stack.Deserialize.Add(&restjson_deserializeOpUpdateBucketConfig{}, middleware.After); err != nil {
+		return err
+	}
+	if err := addOpUpdateBucketConfigValidationMiddleware(stack); err != nil {
+		return err
+	}
+	return addClientMiddlewares(stack, options)
+}
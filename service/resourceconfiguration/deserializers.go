@@ -0,0 +1,249 @@
+package resourceconfiguration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	awsmiddleware "github.com/IBM/ibm-cos-sdk-go-v2/aws/middleware"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/resourceconfiguration/types"
+	smithy "github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+type firewallDocument struct {
+	AllowedIP          []string            `json:"allowed_ip"`
+	DeniedIP           []string            `json:"denied_ip"`
+	AllowedNetworkType []types.NetworkType `json:"allowed_network_type"`
+}
+
+type activityTrackingDocument struct {
+	ReadDataEvents     *bool   `json:"read_data_events"`
+	WriteDataEvents    *bool   `json:"write_data_events"`
+	ManagementEvents   *bool   `json:"management_events"`
+	ActivityTrackerCRN *string `json:"activity_tracker_crn"`
+}
+
+type metricsMonitoringDocument struct {
+	UsageMetricsEnabled   *bool   `json:"usage_metrics_enabled"`
+	RequestMetricsEnabled *bool   `json:"request_metrics_enabled"`
+	MetricsMonitoringCRN  *string `json:"metrics_monitoring_crn"`
+}
+
+type protectionManagementTokenDocument struct {
+	TokenID             *string `json:"token_id"`
+	TokenReferenceID    *string `json:"token_reference_id"`
+	TokenExpirationTime *string `json:"token_expiration_time"`
+	AppliedTime         *string `json:"applied_time"`
+	InvalidatedTime     *string `json:"invalidated_time"`
+	ExpirationTime      *string `json:"expiration_time"`
+	ShortenRetention    *bool   `json:"shorten_retention_flag"`
+}
+
+type protectionManagementDocument struct {
+	TokenApplied *string                             `json:"token_applied"`
+	TokenEntries []protectionManagementTokenDocument `json:"token_entries"`
+}
+
+type bucketDocument struct {
+	Name                  *string                       `json:"name"`
+	CRN                   *string                       `json:"crn"`
+	ServiceInstanceID     *string                       `json:"service_instance_id"`
+	ServiceInstanceCRN    *string                       `json:"service_instance_crn"`
+	TimeCreated           *time.Time                    `json:"time_created"`
+	TimeUpdated           *time.Time                    `json:"time_updated"`
+	ObjectCount           *int64                        `json:"object_count"`
+	BytesUsed             *int64                        `json:"bytes_used"`
+	NoncurrentObjectCount *int64                        `json:"noncurrent_object_count"`
+	NoncurrentBytesUsed   *int64                        `json:"noncurrent_bytes_used"`
+	DeleteMarkerCount     *int64                        `json:"delete_marker_count"`
+	HardQuota             *int64                        `json:"hard_quota"`
+	Firewall              *firewallDocument             `json:"firewall"`
+	ActivityTracking      *activityTrackingDocument     `json:"activity_tracking"`
+	MetricsMonitoring     *metricsMonitoringDocument    `json:"metrics_monitoring"`
+	ProtectionManagement  *protectionManagementDocument `json:"protection_management"`
+}
+
+type errorDocument struct {
+	Errors []struct {
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		MoreInfo string `json:"more_info"`
+	} `json:"errors"`
+	Trace string `json:"trace"`
+}
+
+// handleResponse invokes the next handler, returning the raw response if
+// the request succeeded or the deserialized service error if it failed.
+func handleResponse(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	response *smithyhttp.Response, out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	out, metadata, err = next.HandleDeserialize(ctx, in)
+	if err != nil {
+		return nil, out, metadata, err
+	}
+
+	response, ok := out.RawResponse.(*smithyhttp.Response)
+	if !ok {
+		return nil, out, metadata, &smithy.DeserializationError{Err: fmt.Errorf("unknown transport type %T", out.RawResponse)}
+	}
+
+	if id := response.Header.Get("X-Correlation-Id"); len(id) != 0 {
+		awsmiddleware.SetRequestIDMetadata(&metadata, id)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, out, metadata, restjson_deserializeOpError(response)
+	}
+	return response, out, metadata, nil
+}
+
+func restjson_deserializeOpError(response *smithyhttp.Response) error {
+	var errorBuffer bytes.Buffer
+	if _, err := io.Copy(&errorBuffer, response.Body); err != nil {
+		return &smithy.DeserializationError{Err: fmt.Errorf("failed to copy error response body, %w", err)}
+	}
+
+	errorCode := http.StatusText(response.StatusCode)
+	errorMessage := errorCode
+
+	var doc errorDocument
+	if err := json.Unmarshal(errorBuffer.Bytes(), &doc); err == nil && len(doc.Errors) != 0 {
+		if len(doc.Errors[0].Code) != 0 {
+			errorCode = doc.Errors[0].Code
+		}
+		if len(doc.Errors[0].Message) != 0 {
+			errorMessage = doc.Errors[0].Message
+		}
+	}
+
+	switch response.StatusCode {
+	case http.StatusPreconditionFailed:
+		return &types.PreconditionFailedException{Message: &errorMessage}
+	case http.StatusNotFound:
+		return &types.BucketNotFoundException{Message: &errorMessage}
+	}
+
+	fault := smithy.FaultClient
+	if response.StatusCode >= 500 {
+		fault = smithy.FaultServer
+	}
+	return &smithy.GenericAPIError{
+		Code:    errorCode,
+		Message: errorMessage,
+		Fault:   fault,
+	}
+}
+
+func restjson_deserializeDocumentBucketConfig(doc *bucketDocument) *types.BucketConfig {
+	v := &types.BucketConfig{
+		Name:                  doc.Name,
+		CRN:                   doc.CRN,
+		ServiceInstanceID:     doc.ServiceInstanceID,
+		ServiceInstanceCRN:    doc.ServiceInstanceCRN,
+		TimeCreated:           doc.TimeCreated,
+		TimeUpdated:           doc.TimeUpdated,
+		ObjectCount:           doc.ObjectCount,
+		BytesUsed:             doc.BytesUsed,
+		NoncurrentObjectCount: doc.NoncurrentObjectCount,
+		NoncurrentBytesUsed:   doc.NoncurrentBytesUsed,
+		DeleteMarkerCount:     doc.DeleteMarkerCount,
+		HardQuota:             doc.HardQuota,
+	}
+	if f := doc.Firewall; f != nil {
+		v.Firewall = &types.Firewall{
+			AllowedIP:          f.AllowedIP,
+			DeniedIP:           f.DeniedIP,
+			AllowedNetworkType: f.AllowedNetworkType,
+		}
+	}
+	if a := doc.ActivityTracking; a != nil {
+		v.ActivityTracking = &types.ActivityTracking{
+			ReadDataEvents:     a.ReadDataEvents,
+			WriteDataEvents:    a.WriteDataEvents,
+			ManagementEvents:   a.ManagementEvents,
+			ActivityTrackerCRN: a.ActivityTrackerCRN,
+		}
+	}
+	if m := doc.MetricsMonitoring; m != nil {
+		v.MetricsMonitoring = &types.MetricsMonitoring{
+			UsageMetricsEnabled:   m.UsageMetricsEnabled,
+			RequestMetricsEnabled: m.RequestMetricsEnabled,
+			MetricsMonitoringCRN:  m.MetricsMonitoringCRN,
+		}
+	}
+	if p := doc.ProtectionManagement; p != nil {
+		v.ProtectionManagement = &types.ProtectionManagement{TokenApplied: p.TokenApplied}
+		for _, e := range p.TokenEntries {
+			v.ProtectionManagement.TokenEntries = append(v.ProtectionManagement.TokenEntries, types.ProtectionManagementTokenEntry{
+				TokenID:             e.TokenID,
+				TokenReferenceID:    e.TokenReferenceID,
+				TokenExpirationTime: e.TokenExpirationTime,
+				AppliedTime:         e.AppliedTime,
+				InvalidatedTime:     e.InvalidatedTime,
+				ExpirationTime:      e.ExpirationTime,
+				ShortenRetention:    e.ShortenRetention,
+			})
+		}
+	}
+	return v
+}
+
+func headerString(response *smithyhttp.Response, name string) *string {
+	if v := response.Header.Get(name); len(v) != 0 {
+		return &v
+	}
+	return nil
+}
+
+type restjson_deserializeOpGetBucketConfig struct{}
+
+func (*restjson_deserializeOpGetBucketConfig) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpGetBucketConfig) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &GetBucketConfigOutput{}
+	out.Result = output
+	output.ETag = headerString(response, "ETag")
+
+	var doc bucketDocument
+	if err := json.NewDecoder(response.Body).Decode(&doc); err != nil {
+		return out, metadata, &smithy.DeserializationError{Err: fmt.Errorf("failed to decode response body, %w", err)}
+	}
+	output.BucketConfig = restjson_deserializeDocumentBucketConfig(&doc)
+	return out, metadata, nil
+}
+
+type restjson_deserializeOpUpdateBucketConfig struct{}
+
+func (*restjson_deserializeOpUpdateBucketConfig) ID() string {
+	return "OperationDeserializer"
+}
+
+func (m *restjson_deserializeOpUpdateBucketConfig) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	response, out, metadata, err := handleResponse(ctx, in, next)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	output := &UpdateBucketConfigOutput{}
+	out.Result = output
+	output.ETag = headerString(response, "ETag")
+	io.Copy(io.Discard, response.Body)
+	return out, metadata, nil
+}
@@ -0,0 +1,26 @@
+// Package resourceconfiguration provides the API client, operations, and
+// parameter types for the IBM Cloud Object Storage Resource Configuration
+// API.
+//
+// Bucket settings that are not part of the S3 API, such as the IP firewall,
+// Activity Tracker and Metrics Monitoring routing, hard quotas and the
+// protection management state, are read and updated through this API.
+// Requests are authorized with an IBM IAM bearer token, so the client should
+// be configured with credentials from the credentials/ibmiam providers.
+//
+// Updates are partial, and can be made conditional on the ETag returned by
+// GetBucketConfig to avoid overwriting concurrent changes:
+//
+//	client := resourceconfiguration.NewFromConfig(cfg)
+//
+//	_, err := resourceconfiguration.ModifyBucketConfig(ctx, client, "bucket",
+//		func(c *types.BucketConfig) (*resourceconfiguration.UpdateBucketConfigInput, error) {
+//			allowed := []string{"10.0.0.0/8"}
+//			if c.Firewall != nil {
+//				allowed = append(allowed, c.Firewall.AllowedIP...)
+//			}
+//			return &resourceconfiguration.UpdateBucketConfigInput{
+//				Firewall: &types.Firewall{AllowedIP: allowed},
+//			}, nil
+//		})
+package resourceconfiguration
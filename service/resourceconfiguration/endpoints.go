@@ -0,0 +1,74 @@
+package resourceconfiguration
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// EndpointType selects between the public, private and direct Resource
+// Configuration endpoints.
+type EndpointType string
+
+// Enumeration of Resource Configuration endpoint types.
+const (
+	EndpointTypePublic  EndpointType = "public"
+	EndpointTypePrivate EndpointType = "private"
+	EndpointTypeDirect  EndpointType = "direct"
+)
+
+// ResolveEndpoint returns the Resource Configuration endpoint for the
+// endpoint type, such as https://config.cloud-object-storage.cloud.ibm.com.
+func ResolveEndpoint(endpointType EndpointType) (string, error) {
+	switch endpointType {
+	case "", EndpointTypePublic:
+		return "https://config.cloud-object-storage.cloud.ibm.com", nil
+	case EndpointTypePrivate, EndpointTypeDirect:
+		return fmt.Sprintf("https://config.%s.cloud-object-storage.cloud.ibm.com", endpointType), nil
+	default:
+		return "", fmt.Errorf("unknown endpoint type %q", endpointType)
+	}
+}
+
+type resolveEndpointMiddleware struct {
+	options Options
+}
+
+func (*resolveEndpointMiddleware) ID() string {
+	return "ResolveEndpoint"
+}
+
+func (m *resolveEndpointMiddleware) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	req, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return out, metadata, fmt.Errorf("unknown transport type %T", in.Request)
+	}
+
+	var endpoint string
+	if m.options.BaseEndpoint != nil {
+		endpoint = *m.options.BaseEndpoint
+	} else if endpoint, err = ResolveEndpoint(m.options.EndpointType); err != nil {
+		return out, metadata, err
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return out, metadata, fmt.Errorf("failed to parse endpoint %q: %w", endpoint, err)
+	}
+	req.URL.Scheme = u.Scheme
+	req.URL.Host = u.Host
+	req.URL.Path = strings.TrimSuffix(u.Path, "/")
+	req.URL.RawPath = ""
+
+	return next.HandleSerialize(ctx, in)
+}
+
+func addResolveEndpointMiddleware(stack *middleware.Stack, o Options) error {
+	return stack.Serialize.Add(&resolveEndpointMiddleware{options: o}, middleware.Before)
+}
@@ -0,0 +1,90 @@
+package resourceconfiguration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/service/resourceconfiguration/types"
+)
+
+// DefaultModifyMaxAttempts is the default number of read-modify-write cycles
+// ModifyBucketConfig makes before giving up.
+const DefaultModifyMaxAttempts = 3
+
+// ModifyBucketConfigAPIClient is a client that implements the GetBucketConfig
+// and UpdateBucketConfig operations.
+type ModifyBucketConfigAPIClient interface {
+	GetBucketConfig(context.Context, *GetBucketConfigInput, ...func(*Options)) (*GetBucketConfigOutput, error)
+	UpdateBucketConfig(context.Context, *UpdateBucketConfigInput, ...func(*Options)) (*UpdateBucketConfigOutput, error)
+}
+
+var _ ModifyBucketConfigAPIClient = (*Client)(nil)
+
+// ModifyBucketConfigOptions are the options for ModifyBucketConfig.
+type ModifyBucketConfigOptions struct {
+	// The maximum number of read-modify-write cycles. If zero,
+	// DefaultModifyMaxAttempts is used.
+	MaxAttempts int
+
+	// Options applied to each API call.
+	ClientOptions []func(*Options)
+}
+
+// ModifyBucketConfig reads the configuration of a bucket, passes it to fn and
+// applies the returned update conditionally on the ETag that was read. If the
+// configuration was changed concurrently, the cycle is repeated with the new
+// configuration. If fn returns a nil update, no update is made.
+//
+// The Bucket and IfMatch members of the update are set by ModifyBucketConfig.
+// If the configuration that was read has no ETag, an error is returned rather
+// than updating it unconditionally.
+func ModifyBucketConfig(ctx context.Context, client ModifyBucketConfigAPIClient, bucket string,
+	fn func(*types.BucketConfig) (*UpdateBucketConfigInput, error), optFns ...func(*ModifyBucketConfigOptions),
+) (*UpdateBucketConfigOutput, error) {
+	options := ModifyBucketConfigOptions{
+		MaxAttempts: DefaultModifyMaxAttempts,
+	}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultModifyMaxAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < options.MaxAttempts; attempt++ {
+		var get *GetBucketConfigOutput
+		get, err = client.GetBucketConfig(ctx, &GetBucketConfigInput{Bucket: &bucket}, options.ClientOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		update, fnErr := fn(get.BucketConfig)
+		if fnErr != nil {
+			return nil, fnErr
+		}
+		if update == nil {
+			return &UpdateBucketConfigOutput{ETag: get.ETag}, nil
+		}
+		if aws.ToString(get.ETag) == "" {
+			// an update without If-Match would overwrite concurrent changes
+			return nil, fmt.Errorf("bucket %s configuration has no ETag to update it conditionally", bucket)
+		}
+		update.Bucket = &bucket
+		update.IfMatch = get.ETag
+
+		var out *UpdateBucketConfigOutput
+		out, err = client.UpdateBucketConfig(ctx, update, options.ClientOptions...)
+		if err == nil {
+			return out, nil
+		}
+
+		var pf *types.PreconditionFailedException
+		if !errors.As(err, &pf) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("bucket %s configuration kept changing after %d attempts: %w", bucket, options.MaxAttempts, err)
+}
@@ -0,0 +1,148 @@
+package resourceconfiguration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	smithy "github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// mediaTypeMergePatch is the content type of bucket configuration updates.
+const mediaTypeMergePatch = "application/merge-patch+json"
+
+// bucketPath returns the escaped path of a bucket's configuration.
+func bucketPath(bucket *string) string {
+	return "/v1/b/" + url.PathEscape(*bucket)
+}
+
+type restjson_serializeOpGetBucketConfig struct{}
+
+func (*restjson_serializeOpGetBucketConfig) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpGetBucketConfig) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	request, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown transport type %T", in.Request)}
+	}
+	input, ok := in.Parameters.(*GetBucketConfigInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	request.Method = "GET"
+	request.URL.Path = request.URL.Path + bucketPath(input.Bucket)
+	request.Header.Set("Accept", "application/json")
+
+	return next.HandleSerialize(ctx, in)
+}
+
+type restjson_serializeOpUpdateBucketConfig struct{}
+
+func (*restjson_serializeOpUpdateBucketConfig) ID() string {
+	return "OperationSerializer"
+}
+
+func (m *restjson_serializeOpUpdateBucketConfig) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	request, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown transport type %T", in.Request)}
+	}
+	input, ok := in.Parameters.(*UpdateBucketConfigInput)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown input parameters type %T", in.Parameters)}
+	}
+
+	request.Method = "PATCH"
+	request.URL.Path = request.URL.Path + bucketPath(input.Bucket)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", mediaTypeMergePatch)
+	if input.IfMatch != nil {
+		request.Header.Set("If-Match", *input.IfMatch)
+	}
+
+	payload, err := json.Marshal(restjson_serializeDocumentBucketPatch(input))
+	if err != nil {
+		return out, metadata, &smithy.SerializationError{Err: err}
+	}
+	if request, err = request.SetStream(bytes.NewReader(payload)); err != nil {
+		return out, metadata, &smithy.SerializationError{Err: err}
+	}
+	in.Request = request
+
+	return next.HandleSerialize(ctx, in)
+}
+
+// restjson_serializeDocumentBucketPatch builds the JSON merge patch of an
+// update. Members which are nil are omitted so that they are left unchanged,
+// while empty lists are sent so that they are cleared.
+func restjson_serializeDocumentBucketPatch(v *UpdateBucketConfigInput) map[string]interface{} {
+	doc := map[string]interface{}{}
+
+	if f := v.Firewall; f != nil {
+		firewall := map[string]interface{}{}
+		if f.AllowedIP != nil {
+			firewall["allowed_ip"] = f.AllowedIP
+		}
+		if f.DeniedIP != nil {
+			firewall["denied_ip"] = f.DeniedIP
+		}
+		if f.AllowedNetworkType != nil {
+			firewall["allowed_network_type"] = f.AllowedNetworkType
+		}
+		doc["firewall"] = firewall
+	}
+
+	if a := v.ActivityTracking; a != nil {
+		tracking := map[string]interface{}{}
+		setBool(tracking, "read_data_events", a.ReadDataEvents)
+		setBool(tracking, "write_data_events", a.WriteDataEvents)
+		setBool(tracking, "management_events", a.ManagementEvents)
+		setString(tracking, "activity_tracker_crn", a.ActivityTrackerCRN)
+		doc["activity_tracking"] = tracking
+	}
+
+	if m := v.MetricsMonitoring; m != nil {
+		monitoring := map[string]interface{}{}
+		setBool(monitoring, "usage_metrics_enabled", m.UsageMetricsEnabled)
+		setBool(monitoring, "request_metrics_enabled", m.RequestMetricsEnabled)
+		setString(monitoring, "metrics_monitoring_crn", m.MetricsMonitoringCRN)
+		doc["metrics_monitoring"] = monitoring
+	}
+
+	if v.HardQuota != nil {
+		doc["hard_quota"] = *v.HardQuota
+	}
+
+	if p := v.ProtectionManagement; p != nil {
+		protection := map[string]interface{}{
+			"requested_state": p.RequestedState,
+		}
+		setString(protection, "protection_management_token", p.ProtectionManagementToken)
+		doc["protection_management"] = protection
+	}
+
+	return doc
+}
+
+func setBool(doc map[string]interface{}, name string, v *bool) {
+	if v != nil {
+		doc[name] = *v
+	}
+}
+
+func setString(doc map[string]interface{}, name string, v *string) {
+	if v != nil {
+		doc[name] = *v
+	}
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"fmt"
+
+	smithy "github.com/aws/smithy-go"
+)
+
+// The bucket configuration was modified since the ETag given in If-Match was
+// read. Get the configuration again and retry the update.
+type PreconditionFailedException struct {
+	Message *string
+
+	ErrorCodeOverride *string
+}
+
+func (e *PreconditionFailedException) Error() string {
+	return fmt.Sprintf("%s: %s", e.ErrorCode(), e.ErrorMessage())
+}
+func (e *PreconditionFailedException) ErrorMessage() string {
+	if e.Message == nil {
+		return ""
+	}
+	return *e.Message
+}
+func (e *PreconditionFailedException) ErrorCode() string {
+	if e == nil || e.ErrorCodeOverride == nil {
+		return "PreconditionFailed"
+	}
+	return *e.ErrorCodeOverride
+}
+func (e *PreconditionFailedException) ErrorFault() smithy.ErrorFault { return smithy.FaultClient }
+
+// The bucket does not exist, or is not visible to the caller.
+type BucketNotFoundException struct {
+	Message *string
+
+	ErrorCodeOverride *string
+}
+
+func (e *BucketNotFoundException) Error() string {
+	return fmt.Sprintf("%s: %s", e.ErrorCode(), e.ErrorMessage())
+}
+func (e *BucketNotFoundException) ErrorMessage() string {
+	if e.Message == nil {
+		return ""
+	}
+	return *e.Message
+}
+func (e *BucketNotFoundException) ErrorCode() string {
+	if e == nil || e.ErrorCodeOverride == nil {
+		return "BucketNotFound"
+	}
+	return *e.ErrorCodeOverride
+}
+func (e *BucketNotFoundException) ErrorFault() smithy.ErrorFault { return smithy.FaultClient }
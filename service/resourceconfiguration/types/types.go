@@ -0,0 +1,165 @@
+package types
+
+import (
+	"time"
+)
+
+// NetworkType is a type of network a bucket can be accessed from.
+type NetworkType string
+
+// Enumeration values for NetworkType
+const (
+	NetworkTypePublic  NetworkType = "public"
+	NetworkTypePrivate NetworkType = "private"
+	NetworkTypeDirect  NetworkType = "direct"
+)
+
+// ProtectionManagementState is the requested state of protection management.
+type ProtectionManagementState string
+
+// Enumeration values for ProtectionManagementState
+const (
+	ProtectionManagementStateActivate   ProtectionManagementState = "activate"
+	ProtectionManagementStateDeactivate ProtectionManagementState = "deactivate"
+)
+
+// BucketConfig is the configuration of a bucket held by the Resource
+// Configuration API.
+type BucketConfig struct {
+	// The name of the bucket.
+	Name *string
+
+	// The Cloud Resource Name of the bucket.
+	CRN *string
+
+	// The GUID of the service instance that owns the bucket.
+	ServiceInstanceID *string
+
+	// The Cloud Resource Name of the service instance that owns the bucket.
+	ServiceInstanceCRN *string
+
+	// The time the bucket was created.
+	TimeCreated *time.Time
+
+	// The time the bucket configuration was last updated.
+	TimeUpdated *time.Time
+
+	// The number of objects in the bucket.
+	ObjectCount *int64
+
+	// The total size of the objects in the bucket, in bytes.
+	BytesUsed *int64
+
+	// The number of non-current object versions in the bucket.
+	NoncurrentObjectCount *int64
+
+	// The total size of the non-current object versions, in bytes.
+	NoncurrentBytesUsed *int64
+
+	// The number of delete markers in the bucket.
+	DeleteMarkerCount *int64
+
+	// The maximum size of the bucket, in bytes. Writes that would exceed the
+	// quota are rejected.
+	HardQuota *int64
+
+	// The IP firewall of the bucket.
+	Firewall *Firewall
+
+	// The Activity Tracker routing of the bucket.
+	ActivityTracking *ActivityTracking
+
+	// The Metrics Monitoring routing of the bucket.
+	MetricsMonitoring *MetricsMonitoring
+
+	// The protection management state of the bucket.
+	ProtectionManagement *ProtectionManagement
+}
+
+// Firewall restricts the addresses and networks a bucket can be accessed
+// from.
+type Firewall struct {
+	// The IP addresses or CIDR blocks allowed to access the bucket. An empty,
+	// non-nil list removes the allowlist when updating a bucket.
+	AllowedIP []string
+
+	// The IP addresses or CIDR blocks denied access to the bucket.
+	DeniedIP []string
+
+	// The network types allowed to access the bucket.
+	AllowedNetworkType []NetworkType
+}
+
+// ActivityTracking configures the events of a bucket sent to Activity
+// Tracker.
+type ActivityTracking struct {
+	// Whether object read events are tracked.
+	ReadDataEvents *bool
+
+	// Whether object write events are tracked.
+	WriteDataEvents *bool
+
+	// Whether bucket management events are tracked.
+	ManagementEvents *bool
+
+	// The Cloud Resource Name of the Activity Tracker instance receiving the
+	// events.
+	ActivityTrackerCRN *string
+}
+
+// MetricsMonitoring configures the metrics of a bucket sent to IBM Cloud
+// Monitoring.
+type MetricsMonitoring struct {
+	// Whether usage metrics are sent.
+	UsageMetricsEnabled *bool
+
+	// Whether request metrics are sent.
+	RequestMetricsEnabled *bool
+
+	// The Cloud Resource Name of the monitoring instance receiving the
+	// metrics.
+	MetricsMonitoringCRN *string
+}
+
+// ProtectionManagement is the protection management state of a bucket.
+type ProtectionManagement struct {
+	// The token currently applied to the bucket.
+	TokenApplied *string
+
+	// The tokens which have been applied to the bucket.
+	TokenEntries []ProtectionManagementTokenEntry
+}
+
+// ProtectionManagementTokenEntry describes a protection management token.
+type ProtectionManagementTokenEntry struct {
+	// The identifier of the token.
+	TokenID *string
+
+	// The reference identifier given when the token was issued.
+	TokenReferenceID *string
+
+	// The time the token itself expires.
+	TokenExpirationTime *string
+
+	// The time the token was applied to the bucket.
+	AppliedTime *string
+
+	// The time the token was invalidated, if it was.
+	InvalidatedTime *string
+
+	// The time protection management granted by the token expires.
+	ExpirationTime *string
+
+	// Whether the token allows retention periods to be shortened.
+	ShortenRetention *bool
+}
+
+// ProtectionManagementUpdate requests a change of the protection management
+// state of a bucket.
+type ProtectionManagementUpdate struct {
+	// The requested state.
+	RequestedState ProtectionManagementState
+
+	// The protection management token authorizing the change.
+	ProtectionManagementToken *string
+}
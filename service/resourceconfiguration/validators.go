@@ -0,0 +1,88 @@
+package resourceconfiguration
+
+import (
+	"context"
+	"fmt"
+
+	smithy "github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+type validateOpGetBucketConfig struct {
+}
+
+func (*validateOpGetBucketConfig) ID() string {
+	return "OperationInputValidation"
+}
+
+func (m *validateOpGetBucketConfig) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+	out middleware.InitializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*GetBucketConfigInput)
+	if !ok {
+		return out, metadata, fmt.Errorf("unknown input parameters type %T", in.Parameters)
+	}
+	if err := validateOpGetBucketConfigInput(input); err != nil {
+		return out, metadata, err
+	}
+	return next.HandleInitialize(ctx, in)
+}
+
+type validateOpUpdateBucketConfig struct {
+}
+
+func (*validateOpUpdateBucketConfig) ID() string {
+	return "OperationInputValidation"
+}
+
+func (m *validateOpUpdateBucketConfig) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+	out middleware.InitializeOutput, metadata middleware.Metadata, err error,
+) {
+	input, ok := in.Parameters.(*UpdateBucketConfigInput)
+	if !ok {
+		return out, metadata, fmt.Errorf("unknown input parameters type %T", in.Parameters)
+	}
+	if err := validateOpUpdateBucketConfigInput(input); err != nil {
+		return out, metadata, err
+	}
+	return next.HandleInitialize(ctx, in)
+}
+
+func addOpGetBucketConfigValidationMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(&validateOpGetBucketConfig{}, middleware.After)
+}
+
+func addOpUpdateBucketConfigValidationMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(&validateOpUpdateBucketConfig{}, middleware.After)
+}
+
+func validateOpGetBucketConfigInput(v *GetBucketConfigInput) error {
+	if v == nil {
+		return nil
+	}
+	invalidParams := smithy.InvalidParamsError{Context: "GetBucketConfigInput"}
+	if v.Bucket == nil || len(*v.Bucket) == 0 {
+		invalidParams.Add(smithy.NewErrParamRequired("Bucket"))
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+func validateOpUpdateBucketConfigInput(v *UpdateBucketConfigInput) error {
+	if v == nil {
+		return nil
+	}
+	invalidParams := smithy.InvalidParamsError{Context: "UpdateBucketConfigInput"}
+	if v.Bucket == nil || len(*v.Bucket) == 0 {
+		invalidParams.Add(smithy.NewErrParamRequired("Bucket"))
+	}
+	if p := v.ProtectionManagement; p != nil && len(p.RequestedState) == 0 {
+		invalidParams.Add(smithy.NewErrParamRequired("ProtectionManagement.RequestedState"))
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws/crn"
+	smithy "github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const serviceInstanceIDHeader = "ibm-service-instance-id"
+
+// serviceInstanceID reduces the service instance of a request, which may be
+// given as a CRN or as a bare GUID, to the GUID. It runs once the request is
+// serialized and before it is signed, whatever the credentials.
+type serviceInstanceID struct{}
+
+func (*serviceInstanceID) ID() string {
+	return "serviceInstanceID"
+}
+
+func (m *serviceInstanceID) HandleSerialize(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (
+	out middleware.SerializeOutput, metadata middleware.Metadata, err error,
+) {
+	req, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("unknown transport type %T", in.Request)}
+	}
+
+	if v := req.Header.Get(serviceInstanceIDHeader); v != "" {
+		id, err := crn.ServiceInstanceID(v)
+		if err != nil {
+			return out, metadata, &smithy.SerializationError{Err: fmt.Errorf("invalid service instance ID %q: %w", v, err)}
+		}
+		req.Header.Set(serviceInstanceIDHeader, id)
+	}
+	return next.HandleSerialize(ctx, in)
+}
+
+func addServiceInstanceID(stack *middleware.Stack) error {
+	return stack.Serialize.Insert(&serviceInstanceID{}, "OperationSerializer", middleware.After)
+}
@@ -0,0 +1,112 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go-v2/aws"
+	"github.com/IBM/ibm-cos-sdk-go-v2/credentials/ibmiam/token"
+)
+
+func TestServiceInstanceIDHeader(t *testing.T) {
+	const guid = "1a0ec336-f391-4091-a6fb-5e084a4c56f4"
+	const instanceCRN = "crn:v1:bluemix:public:cloud-object-storage:global:a/59bcbfa6ea2f006b4ed7094c1a08dcdd:" + guid + "::"
+
+	hmac := aws.Credentials{
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+	}
+	iam := aws.Credentials{
+		Source: "StaticProviderIBM",
+		Token:  token.Token{AccessToken: "token", TokenType: "Bearer"},
+	}
+	iamInstance := iam
+	iamInstance.ServiceInstanceID = instanceCRN
+
+	cases := map[string]struct {
+		credentials  aws.Credentials
+		instanceID   *string
+		expectAuth   string
+		expectErr    string
+		expectHeader string
+		expectSigned bool
+	}{
+		"hmac crn": {
+			credentials:  hmac,
+			instanceID:   aws.String(instanceCRN),
+			expectAuth:   "AWS4-HMAC-SHA256 ",
+			expectHeader: guid,
+			expectSigned: true,
+		},
+		"hmac guid": {
+			credentials:  hmac,
+			instanceID:   aws.String(guid),
+			expectAuth:   "AWS4-HMAC-SHA256 ",
+			expectHeader: guid,
+			expectSigned: true,
+		},
+		"hmac invalid": {
+			credentials: hmac,
+			instanceID:  aws.String("crn:v1:bluemix"),
+			expectErr:   "invalid service instance ID",
+		},
+		"iam crn": {
+			credentials:  iam,
+			instanceID:   aws.String(instanceCRN),
+			expectAuth:   "Bearer token",
+			expectHeader: guid,
+		},
+		"iam credentials crn": {
+			credentials:  iamInstance,
+			expectAuth:   "Bearer token",
+			expectHeader: guid,
+		},
+		"iam invalid": {
+			credentials: iam,
+			instanceID:  aws.String("crn:v1:bluemix"),
+			expectErr:   "invalid service instance ID",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var captured capturedRequest
+			svc := New(Options{
+				Region:     "us-south",
+				HTTPClient: &captured,
+				Credentials: aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+					return c.credentials, nil
+				})),
+			})
+
+			_, err := svc.ListBuckets(context.Background(), &ListBucketsInput{
+				IBMServiceInstanceId: c.instanceID,
+			})
+			if c.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.expectErr) {
+					t.Fatalf("expect error containing %q, got %v", c.expectErr, err)
+				}
+				if captured.r != nil {
+					t.Error("expect no request sent")
+				}
+				return
+			}
+			if captured.r == nil {
+				t.Fatalf("expect request sent, got %v", err)
+			}
+
+			header := captured.r.Header
+			if e, a := c.expectHeader, header.Get("ibm-service-instance-id"); e != a {
+				t.Errorf("expect service instance %q, got %q", e, a)
+			}
+			auth := header.Get("Authorization")
+			if !strings.HasPrefix(auth, c.expectAuth) {
+				t.Errorf("expect authorization %q, got %q", c.expectAuth, auth)
+			}
+			if e, a := c.expectSigned, strings.Contains(auth, "ibm-service-instance-id"); e != a {
+				t.Errorf("expect service instance signed %v, got authorization %q", e, auth)
+			}
+		})
+	}
+}